@@ -0,0 +1,339 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/betasve/mstd/atomicfile"
+	"github.com/betasve/mstd/backup"
+	tm "github.com/betasve/mstd/ext/time"
+	api "github.com/betasve/mstd/todoapi"
+	"io"
+	"os"
+	"strings"
+)
+
+// The policies for restoring a task into a list that already has one with
+// the same title: keep the existing task (and skip the archived one), replace
+// it with the archived one, or restore the archived one next to it.
+const (
+	ConflictSkip      string = "skip"
+	ConflictOverwrite string = "overwrite"
+	ConflictDuplicate string = "duplicate"
+)
+
+// The conflict policies a restore accepts.
+var ConflictPolicies map[string]bool = map[string]bool{
+	ConflictSkip:      true,
+	ConflictOverwrite: true,
+	ConflictDuplicate: true,
+}
+
+// How the ids of the archived lists and tasks map to the ids they were
+// restored with.
+type restoreIdMap struct {
+	Lists map[string]string `json:"lists"`
+	Tasks map[string]string `json:"tasks"`
+}
+
+// Restores an archive, keeping track of what it did (or, on a dry run, what
+// it would do).
+type restorer struct {
+	policy string
+	dryRun bool
+	ids    restoreIdMap
+	// The ids of the existing lists already restored into, so two archived
+	// lists (e.g. of the same name) aren't restored into the same one.
+	matched      map[string]bool
+	listsCreated int
+	created      int
+	overwritten  int
+	skipped      int
+}
+
+// Writes all the lists of the account, with their tasks and the checklist
+// items and linked resources of those, into a single (versioned) JSON
+// archive at `path` (or the standard output).
+func Backup(path string) error {
+	apiClient.SetToken(config.ClientAccessToken())
+
+	lists, err := apiClient.ListsIndex(ctx)
+	if err != nil {
+		return err
+	}
+
+	archive := &backup.Archive{
+		CreatedDateTime: tm.Client.Now().UTC(),
+		Profile:         config.Profile(),
+	}
+
+	for _, l := range *lists {
+		list, err := backupList(l)
+		if err != nil {
+			return err
+		}
+
+		archive.Lists = append(archive.Lists, list)
+	}
+
+	if path == "" || path == "-" {
+		return backup.Write(os.Stdout, archive)
+	}
+
+	err = atomicfile.Write(path, func(out io.Writer) error {
+		return backup.Write(out, archive)
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(
+		os.Stdout,
+		"Backed up %d list(s) and %d task(s) to %s\n",
+		len(archive.Lists),
+		archive.TasksCount(),
+		path,
+	)
+
+	return nil
+}
+
+// Recreates the lists and tasks of the archive at `path` in the account
+// (of the current profile). The archived lists are matched to the existing
+// ones by their well-known name (e.g. the default "Tasks" list) or their
+// name, and created when missing. A task conflicts with one of the same
+// title in its list, and the `policy` decides what happens then. The ids
+// the archived lists and tasks got are written to `idMapPath`, when set.
+// With `dryRun` nothing is changed, only what would be is reported.
+func Restore(path, policy, idMapPath string, dryRun bool) error {
+	if !ConflictPolicies[policy] {
+		return invalidf(
+			"Invalid conflict policy %q, use one of: %s, %s or %s",
+			policy,
+			ConflictSkip,
+			ConflictOverwrite,
+			ConflictDuplicate,
+		)
+	}
+
+	data, err := readImportFile(path)
+	if err != nil {
+		return err
+	}
+
+	archive, err := backup.Read(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	apiClient.SetToken(config.ClientAccessToken())
+
+	existing, err := apiClient.ListsIndex(ctx)
+	if err != nil {
+		return err
+	}
+
+	r := &restorer{
+		policy:  policy,
+		dryRun:  dryRun,
+		ids:     restoreIdMap{Lists: map[string]string{}, Tasks: map[string]string{}},
+		matched: map[string]bool{},
+	}
+
+	for _, list := range archive.Lists {
+		if err := r.restoreList(list, *existing); err != nil {
+			return partialf(
+				"Restored %d of %d task(s), then failed: %w",
+				r.created+r.overwritten+r.skipped,
+				archive.TasksCount(),
+				err,
+			)
+		}
+	}
+
+	if idMapPath != "" && !dryRun {
+		if err := writeRestoreIdMap(idMapPath, r.ids); err != nil {
+			return err
+		}
+	}
+
+	prefix := "Restored"
+	if dryRun {
+		prefix = "Dry run: would restore"
+	}
+
+	fmt.Fprintf(
+		os.Stdout,
+		"%s %d list(s) (%d new) and %d task(s): %d created, %d overwritten, %d skipped\n",
+		prefix,
+		len(archive.Lists),
+		r.listsCreated,
+		archive.TasksCount(),
+		r.created,
+		r.overwritten,
+		r.skipped,
+	)
+
+	return nil
+}
+
+// Retrieves a list's tasks, along with their checklist items and linked
+// resources.
+func backupList(l api.ListsItem) (backup.List, error) {
+	list := backup.List{List: l, Tasks: []backup.Task{}}
+
+	tasks, err := apiClient.TasksIndex(ctx, l.Id)
+	if err != nil {
+		return list, err
+	}
+
+	for _, t := range *tasks {
+		items, err := apiClient.ChecklistItemsIndex(ctx, l.Id, t.Id)
+		if err != nil {
+			return list, err
+		}
+
+		resources, err := apiClient.LinkedResourcesIndex(ctx, l.Id, t.Id)
+		if err != nil {
+			return list, err
+		}
+
+		list.Tasks = append(list.Tasks, backup.Task{
+			Task:            t,
+			ChecklistItems:  *items,
+			LinkedResources: *resources,
+		})
+	}
+
+	return list, nil
+}
+
+// Restores an archived list into the matching existing one (or a new one)
+// along with its tasks.
+func (r *restorer) restoreList(list backup.List, existing []api.ListsItem) error {
+	target := matchingList(list.List, existing, r.matched)
+	titles := map[string]api.TaskItem{}
+
+	if target != nil {
+		r.matched[target.Id] = true
+
+		tasks, err := apiClient.TasksIndex(ctx, target.Id)
+		if err != nil {
+			return err
+		}
+
+		for _, t := range *tasks {
+			key := strings.ToLower(t.Title)
+			if _, ok := titles[key]; !ok {
+				titles[key] = t
+			}
+		}
+	} else {
+		r.listsCreated++
+		target = &api.ListsItem{Name: list.List.Name}
+
+		if !r.dryRun {
+			created, err := apiClient.ListsCreate(ctx, list.List.Name)
+			if err != nil {
+				return fmt.Errorf("Could not create list %q: %s", list.List.Name, err)
+			}
+
+			target = created
+		}
+	}
+
+	r.ids.Lists[list.List.Id] = target.Id
+
+	for _, t := range list.Tasks {
+		conflict, ok := titles[strings.ToLower(t.Task.Title)]
+		if !ok || r.policy == ConflictDuplicate {
+			r.created++
+		} else if r.policy == ConflictSkip {
+			r.skipped++
+			r.ids.Tasks[t.Task.Id] = conflict.Id
+			continue
+		} else {
+			r.overwritten++
+		}
+
+		if r.dryRun {
+			continue
+		}
+
+		// The task being overwritten is only removed once its replacement is
+		// fully restored, so a failure leaves it in place.
+		id, err := restoreTask(target.Id, t)
+		if err != nil {
+			if id != "" {
+				_ = apiClient.TasksDelete(ctx, target.Id, id)
+			}
+
+			return fmt.Errorf("Could not restore %q: %s", t.Task.Title, err)
+		}
+
+		if ok && r.policy == ConflictOverwrite {
+			if err := apiClient.TasksDelete(ctx, target.Id, conflict.Id); err != nil {
+				return fmt.Errorf("Restored %q, but could not remove the task it overwrites: %s", t.Task.Title, err)
+			}
+		}
+
+		r.ids.Tasks[t.Task.Id] = id
+	}
+
+	return nil
+}
+
+// Creates an archived task, with its checklist items and linked resources,
+// in a list and returns its new id.
+func restoreTask(listId string, t backup.Task) (string, error) {
+	task, err := apiClient.TasksCreate(ctx, listId, copyableTask(t.Task))
+	if err != nil {
+		return "", err
+	}
+
+	return task.Id, addTaskParts(listId, task.Id, t.ChecklistItems, t.LinkedResources)
+}
+
+// Finds the existing list an archived one is restored into: the one with the
+// same well-known name (as their names may differ between accounts) or else
+// the same (case insensitive) name, among the ones not `matched` yet.
+func matchingList(list api.ListsItem, existing []api.ListsItem, matched map[string]bool) *api.ListsItem {
+	for i, l := range existing {
+		if !matched[l.Id] && list.System != "" && list.System != "none" && l.System == list.System {
+			return &existing[i]
+		}
+	}
+
+	for i, l := range existing {
+		if !matched[l.Id] && strings.EqualFold(l.Name, list.Name) {
+			return &existing[i]
+		}
+	}
+
+	return nil
+}
+
+// Writes how the archived ids map to the restored ones as JSON.
+func writeRestoreIdMap(path string, ids restoreIdMap) error {
+	data, err := json.MarshalIndent(ids, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return atomicfile.WriteFile(path, append(data, '\n'))
+}
@@ -0,0 +1,280 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/betasve/mstd/backup"
+	"github.com/betasve/mstd/conf"
+	api "github.com/betasve/mstd/todoapi"
+	apiTest "github.com/betasve/mstd/todoapi/todoapitest"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBackup(test *testing.T) {
+	config = &conf.Config{}
+	apiClient = &apiTest.TodoApiMock{}
+	stubLists()
+
+	apiTest.TasksIndexMockFn = func(l string) (*[]api.TaskItem, error) {
+		return &[]api.TaskItem{{Id: "t1", Title: "Buy milk"}}, nil
+	}
+
+	apiTest.ChecklistItemsIndexMockFn = func(l, t string) (*[]api.ChecklistItem, error) {
+		return &[]api.ChecklistItem{{Id: "c1", DisplayName: "Whole"}}, nil
+	}
+
+	apiTest.LinkedResourcesIndexMockFn = func(l, t string) (*[]api.LinkedResource, error) {
+		return &[]api.LinkedResource{{Id: "r1", WebUrl: "https://example.com"}}, nil
+	}
+
+	path := filepath.Join(test.TempDir(), "backup.json")
+	if err := Backup(path); err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	file, _ := os.Open(path)
+	defer file.Close()
+
+	archive, err := backup.Read(file)
+	if err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	if len(archive.Lists) != 1 ||
+		archive.Profile != conf.DefaultProfile ||
+		archive.Lists[0].List.Name != "Groceries" ||
+		archive.Lists[0].Tasks[0].ChecklistItems[0].DisplayName != "Whole" ||
+		archive.Lists[0].Tasks[0].LinkedResources[0].WebUrl != "https://example.com" {
+		test.Errorf("\nExpected the whole account to be archived\nbut was\n%+v", archive)
+	}
+}
+
+// Writes an archive of a default list (named differently than the one of
+// the account) and a list the account doesn't have, each with a task.
+func writeTestArchive(test *testing.T) string {
+	return writeArchive(test, &backup.Archive{Lists: []backup.List{
+		{
+			List: api.ListsItem{Id: "old-default", Name: "Aufgaben", System: "defaultList"},
+			Tasks: []backup.Task{{
+				Task:            api.TaskItem{Id: "old-t1", Title: "Buy milk", CreatedDateTime: "2021-05-01T00:00:00Z"},
+				ChecklistItems:  []api.ChecklistItem{{Id: "old-c1", DisplayName: "Whole", IsChecked: true}},
+				LinkedResources: []api.LinkedResource{{Id: "old-r1", WebUrl: "https://example.com"}},
+			}},
+		},
+		{
+			List:  api.ListsItem{Id: "old-work", Name: "Work", System: "none"},
+			Tasks: []backup.Task{{Task: api.TaskItem{Id: "old-t2", Title: "Report"}}},
+		},
+	}})
+}
+
+// Writes an archive to a temporary file and returns its path.
+func writeArchive(test *testing.T, archive *backup.Archive) string {
+	path := filepath.Join(test.TempDir(), "backup.json")
+	file, _ := os.Create(path)
+	defer file.Close()
+
+	if err := backup.Write(file, archive); err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	return path
+}
+
+func stubRestore() map[string]*api.TaskItem {
+	config = &conf.Config{}
+	apiClient = &apiTest.TodoApiMock{}
+
+	apiTest.ListsIndexMockFn = func() (*[]api.ListsItem, error) {
+		return &[]api.ListsItem{{Id: "tasks-id", Name: "Tasks", System: "defaultList"}}, nil
+	}
+
+	apiTest.ListsCreateMockFn = func(n string) (*api.ListsItem, error) {
+		return &api.ListsItem{Id: "work-id", Name: n}, nil
+	}
+
+	apiTest.TasksIndexMockFn = func(l string) (*[]api.TaskItem, error) {
+		return &[]api.TaskItem{{Id: "existing", Title: "buy milk"}}, nil
+	}
+
+	created := map[string]*api.TaskItem{}
+	apiTest.TasksCreateMockFn = func(l string, t *api.TaskItem) (*api.TaskItem, error) {
+		created[l+"/"+t.Title] = t
+		return &api.TaskItem{Id: "new-" + t.Title, Title: t.Title}, nil
+	}
+
+	return created
+}
+
+func TestRestoreSkipsConflicts(test *testing.T) {
+	path := writeTestArchive(test)
+	created := stubRestore()
+	idMapPath := filepath.Join(test.TempDir(), "ids.json")
+
+	if err := Restore(path, ConflictSkip, idMapPath, false); err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	if len(created) != 1 || created["work-id/Report"] == nil {
+		test.Errorf("\nExpected only the task of the new list to be created\nbut were\n%v", created)
+	}
+
+	data, _ := ioutil.ReadFile(idMapPath)
+	ids := restoreIdMap{}
+	_ = json.Unmarshal(data, &ids)
+
+	if ids.Lists["old-default"] != "tasks-id" ||
+		ids.Lists["old-work"] != "work-id" ||
+		ids.Tasks["old-t1"] != "existing" ||
+		ids.Tasks["old-t2"] != "new-Report" {
+		test.Errorf("\nExpected the ids to be mapped\nbut were\n%+v", ids)
+	}
+}
+
+func TestRestoreOverwritesConflicts(test *testing.T) {
+	path := writeTestArchive(test)
+	stubRestore()
+
+	var created *api.TaskItem
+	apiTest.TasksCreateMockFn = func(l string, t *api.TaskItem) (*api.TaskItem, error) {
+		if l == "tasks-id" {
+			created = t
+		}
+		return &api.TaskItem{Id: "new"}, nil
+	}
+
+	deleted := []string{}
+	apiTest.TasksDeleteMockFn = func(l, i string) error {
+		deleted = append(deleted, l+"/"+i)
+		return nil
+	}
+
+	var item *api.ChecklistItem
+	apiTest.ChecklistItemsCreateMockFn = func(l, t string, c *api.ChecklistItem) (*api.ChecklistItem, error) {
+		item = c
+		return c, nil
+	}
+
+	var resource *api.LinkedResource
+	apiTest.LinkedResourcesCreateMockFn = func(l, t string, r *api.LinkedResource) (*api.LinkedResource, error) {
+		resource = r
+		return r, nil
+	}
+
+	if err := Restore(path, ConflictOverwrite, "", false); err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	if len(deleted) != 1 || deleted[0] != "tasks-id/existing" {
+		test.Errorf("\nExpected the conflicting task to be deleted\nbut were\n%v", deleted)
+	}
+
+	if created == nil || created.Id != "" || created.CreatedDateTime != "" {
+		test.Errorf("\nExpected the task to be created without its old id\nbut was\n%+v", created)
+	}
+
+	if item == nil || item.Id != "" || !item.IsChecked || resource == nil || resource.Id != "" {
+		test.Errorf("\nExpected the item and the resource to be restored\nbut were\n%+v, %+v", item, resource)
+	}
+}
+
+func TestRestoreListsOfTheSameName(test *testing.T) {
+	path := writeArchive(test, &backup.Archive{Lists: []backup.List{
+		{
+			List:  api.ListsItem{Id: "old-1", Name: "Tasks", System: "none"},
+			Tasks: []backup.Task{{Task: api.TaskItem{Id: "old-t1", Title: "Buy milk"}}},
+		},
+		{
+			List:  api.ListsItem{Id: "old-2", Name: "Tasks", System: "none"},
+			Tasks: []backup.Task{{Task: api.TaskItem{Id: "old-t2", Title: "Buy milk"}}},
+		},
+	}})
+	stubRestore()
+
+	deleted := []string{}
+	apiTest.TasksDeleteMockFn = func(l, i string) error {
+		deleted = append(deleted, l+"/"+i)
+		return nil
+	}
+
+	if err := Restore(path, ConflictOverwrite, "", false); err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	if !reflect.DeepEqual(deleted, []string{"tasks-id/existing"}) {
+		test.Errorf("\nExpected the conflicting task to be deleted once\nbut were\n%v", deleted)
+	}
+}
+
+func TestRestoreOverwriteKeepsTheTaskOnFailure(test *testing.T) {
+	path := writeTestArchive(test)
+	stubRestore()
+
+	deleted := []string{}
+	apiTest.TasksDeleteMockFn = func(l, i string) error {
+		deleted = append(deleted, l+"/"+i)
+		return nil
+	}
+	apiTest.ChecklistItemsCreateMockFn = func(l, t string, c *api.ChecklistItem) (*api.ChecklistItem, error) {
+		return nil, errors.New("Service unavailable")
+	}
+	defer func() {
+		apiTest.ChecklistItemsCreateMockFn = func(l, t string, c *api.ChecklistItem) (*api.ChecklistItem, error) {
+			return c, nil
+		}
+	}()
+
+	err := Restore(path, ConflictOverwrite, "", false)
+	if err == nil || !strings.Contains(err.Error(), "Could not restore") {
+		test.Fatalf("\nExpected the restore to fail\nbut was\n%v", err)
+	}
+
+	if len(deleted) != 1 || deleted[0] != "tasks-id/new-Buy milk" {
+		test.Errorf("\nExpected only the partial replacement to be deleted\nbut were\n%v", deleted)
+	}
+}
+
+func TestRestoreDuplicatesDryRun(test *testing.T) {
+	path := writeTestArchive(test)
+	created := stubRestore()
+
+	listsCreated := 0
+	apiTest.ListsCreateMockFn = func(n string) (*api.ListsItem, error) {
+		listsCreated++
+		return &api.ListsItem{}, nil
+	}
+
+	if err := Restore(path, ConflictDuplicate, "", true); err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	if len(created) != 0 || listsCreated != 0 {
+		test.Errorf("\nExpected nothing to be created on a dry run\nbut were\n%v", created)
+	}
+}
+
+func TestRestoreInvalidPolicy(test *testing.T) {
+	if err := Restore("backup.json", "merge", "", false); err == nil {
+		test.Error("\nExpected to return error\nbut it was\nnil")
+	}
+}
@@ -0,0 +1,517 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"github.com/betasve/mstd/cache"
+	"github.com/betasve/mstd/dateparse"
+	"github.com/betasve/mstd/ext/term"
+	"github.com/betasve/mstd/filter"
+	api "github.com/betasve/mstd/todoapi"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// The actions that can be taken on all the tasks matching a filter.
+const (
+	BulkComplete string = "complete"
+	BulkDelete   string = "delete"
+	BulkUpdate   string = "update"
+)
+
+// The list name that stands for all the lists (e.g. `-l '*'`).
+const AllLists string = "*"
+
+// The number of batches of changes sent (or of lists searched) at the same
+// time, unless set otherwise.
+const DefaultBulkConcurrency int = 4
+
+// The most batches (or lists) that can be worked on at the same time.
+const MaxBulkConcurrency int = 16
+
+// The past tense of the bulk actions, used in the summary.
+var bulkActionsDone map[string]string = map[string]string{
+	BulkComplete: "completed",
+	BulkDelete:   "deleted",
+	BulkUpdate:   "updated",
+}
+
+// Holds how a bulk action is carried out.
+type BulkOptions struct {
+	// The filter the tasks are picked by (see the `filter` package).
+	Where string
+	// Only prints the tasks that would be changed.
+	DryRun bool
+	// Skips the confirmation.
+	Yes bool
+	// The number of batches of changes (of up to MaxBatchSize tasks) sent at
+	// the same time.
+	Concurrency int
+}
+
+// A task picked by a bulk action, along with the list it's in.
+type bulkItem struct {
+	ListId   string
+	ListName string
+	Task     api.TaskItem
+}
+
+// The outcome of a bulk action on a single task.
+type bulkResult struct {
+	List  string `json:"list"`
+	Id    string `json:"id"`
+	Title string `json:"title"`
+	Ok    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+	err   error
+}
+
+// A flattened, printable representation of a bulkItem.
+type bulkRow struct {
+	List       string
+	Title      string
+	Status     string
+	Importance string
+	Due        string
+	Id         string
+}
+
+// Maps the columns of the preview of a bulk action to the attributes of a
+// bulkRow.
+var bulkColumnsToKeysMap map[string]string = map[string]string{
+	"list":       "List",
+	"title":      "Title",
+	"status":     "Status",
+	"importance": "Importance",
+	"due":        "Due",
+	"id":         "Id",
+}
+
+// The headers of the preview of a bulk action (in the order they are
+// displayed).
+var bulkHeaders []string = []string{"list", "title", "status", "importance", "due", "id"}
+
+// Asks the user to confirm a bulk action, reading the answer from `in`.
+// Swapped in the tests.
+var confirmBulk func(prompt string, in io.Reader) (bool, error) = askConfirmation
+
+// Completes a single task (found by its id in a list) and prints it back to
+// output.
+func TasksComplete(list, taskId string, columns []string) error {
+	return TasksUpdate(list, taskId, TaskOptions{Status: "completed"}, columns)
+}
+
+// Deletes a single task, found by its id in a list.
+func TasksDelete(list, taskId string) error {
+	apiClient.SetToken(config.ClientAccessToken())
+
+	listId, err := resolveListId(list)
+	if err != nil {
+		return err
+	}
+
+	if err := apiClient.TasksDelete(ctx, listId, taskId); err != nil {
+		return err
+	}
+
+	if Output == OutputJson {
+		printJson(os.Stdout, map[string]string{"deleted": taskId})
+		return nil
+	}
+
+	fmt.Fprintf(os.Stdout, "Deleted the task %s\n", taskId)
+	return nil
+}
+
+// Takes an `action` (complete, delete or update with `changes`) on all the
+// tasks in a list (or in all of them, see AllLists) matching the filter in
+// `opts`. The tasks are previewed and, unless it's a dry run, changed once
+// the user confirms, in batches. The outcome of each change is printed
+// in a summary, and the error tells whether some (or all) of them failed.
+func TasksBulk(list, action string, changes TaskOptions, opts BulkOptions) error {
+	apiClient.SetToken(config.ClientAccessToken())
+
+	if _, ok := bulkActionsDone[action]; !ok {
+		return invalidf("Unknown bulk action %q", action)
+	}
+
+	loc, err := appLocation()
+	if err != nil {
+		return err
+	}
+
+	where, err := filter.Parse(opts.Where, dateparse.Parser{Location: loc})
+	if err != nil {
+		return &ValidationError{Message: err.Error()}
+	}
+
+	var task *api.TaskItem
+	switch action {
+	case BulkComplete:
+		task = &api.TaskItem{Status: "completed"}
+	case BulkUpdate:
+		if task, err = buildTask(changes); err != nil {
+			return err
+		}
+
+		if isEmptyTask(task) {
+			return invalidf("Nothing to update, set at least one attribute of the tasks")
+		}
+	}
+
+	items, err := findBulkItems(list, where)
+	if err != nil {
+		return err
+	}
+
+	if len(items) == 0 {
+		fmt.Fprintf(os.Stderr, "No tasks match %q\n", opts.Where)
+		return nil
+	}
+
+	if Output != OutputJson || opts.DryRun {
+		printBulkPreview(items)
+	}
+
+	if opts.DryRun {
+		if Output != OutputJson {
+			fmt.Fprintf(
+				os.Stdout,
+				"Dry run: %d task(s) would be %s, nothing was changed\n",
+				len(items),
+				bulkActionsDone[action],
+			)
+		}
+
+		return nil
+	}
+
+	if !opts.Yes {
+		if !term.Client.IsTerminal(int(os.Stdin.Fd())) {
+			return invalidf("Not running in a terminal, pass --yes to %s the %d task(s)", action, len(items))
+		}
+
+		ok, err := confirmBulk(fmt.Sprintf("About to %s %d task(s), continue?", action, len(items)), os.Stdin)
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			fmt.Fprintln(os.Stderr, "Nothing was changed")
+			return nil
+		}
+	}
+
+	change := bulkChange{
+		request: func(id string, item bulkItem) api.BatchRequest {
+			return api.NewTaskUpdateRequest(id, item.ListId, item.Task.Id, task)
+		},
+		status: http.StatusOK,
+		single: func(item bulkItem) error {
+			changed := *task
+			_, err := apiClient.TasksUpdate(ctx, item.ListId, item.Task.Id, &changed)
+			return err
+		},
+	}
+
+	if action == BulkDelete {
+		change = bulkChange{
+			request: func(id string, item bulkItem) api.BatchRequest {
+				return api.NewTaskDeleteRequest(id, item.ListId, item.Task.Id)
+			},
+			status: http.StatusNoContent,
+			single: func(item bulkItem) error {
+				return apiClient.TasksDelete(ctx, item.ListId, item.Task.Id)
+			},
+		}
+	}
+
+	results := runBulk(items, opts.Concurrency, change)
+
+	printBulkSummary(results, bulkActionsDone[action])
+
+	return bulkError(results, action)
+}
+
+// Returns the tasks in a list (or in all of them) matching a filter.
+func findBulkItems(list string, where *filter.Filter) ([]bulkItem, error) {
+	lists, err := apiClient.ListsIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	picked := []api.ListsItem{}
+	for _, l := range *lists {
+		if list == AllLists || l.Id == list || strings.EqualFold(l.Name, list) {
+			picked = append(picked, l)
+		}
+	}
+
+	if len(picked) == 0 {
+		return nil, &NotFoundError{Kind: "List", Name: list}
+	}
+
+	items := []bulkItem{}
+	for _, l := range picked {
+		tasks, err := apiClient.TasksIndex(ctx, l.Id)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, t := range *tasks {
+			if where.Match(l.Name, t) {
+				items = append(items, bulkItem{ListId: l.Id, ListName: l.Name, Task: t})
+			}
+		}
+	}
+
+	return items, nil
+}
+
+// How a bulk action changes a task: the request making the change in a batch
+// (with the given id), the status its response has when it's made, and the
+// single request making it instead.
+type bulkChange struct {
+	request func(id string, item bulkItem) api.BatchRequest
+	status  int
+	single  func(item bulkItem) error
+}
+
+// Makes the change to each of the items, in batches of up to MaxBatchSize
+// (`concurrency` of them sent at a time), and returns the outcome of each
+// change (in the order of the items).
+func runBulk(items []bulkItem, concurrency int, change bulkChange) []bulkResult {
+	chunks := (len(items) + api.MaxBatchSize - 1) / api.MaxBatchSize
+	errs := make([]error, len(items))
+
+	chunkErrs := forEachConcurrently(chunks, concurrency, func(c int) error {
+		start := c * api.MaxBatchSize
+		end := start + api.MaxBatchSize
+		if end > len(items) {
+			end = len(items)
+		}
+
+		copy(errs[start:end], runBulkBatch(items[start:end], change))
+		return nil
+	})
+
+	results := make([]bulkResult, len(items))
+	for i, item := range items {
+		if err := chunkErrs[i/api.MaxBatchSize]; err != nil {
+			errs[i] = err
+		}
+
+		results[i] = bulkResult{List: item.ListName, Id: item.Task.Id, Title: item.Task.Title, Ok: errs[i] == nil}
+		if errs[i] != nil {
+			results[i].Error, results[i].err = errs[i].Error(), errs[i]
+		}
+	}
+
+	return results
+}
+
+// Makes the change to the items in a single batch and returns the errors
+// of the changes at the same indexes. The tasks the API doesn't know yet
+// (made while offline), and the ones the batch couldn't reach the API for,
+// are changed one by one, so that the changes get queued.
+func runBulkBatch(items []bulkItem, change bulkChange) []error {
+	errs := make([]error, len(items))
+	requests := []api.BatchRequest{}
+	for i, item := range items {
+		if !cache.IsLocalId(item.ListId) && !cache.IsLocalId(item.Task.Id) {
+			requests = append(requests, change.request(strconv.Itoa(i), item))
+		}
+	}
+
+	var responses map[string]*api.BatchResponse
+	var err error
+	if len(requests) > 0 {
+		responses, err = apiClient.Batch(ctx, requests)
+	}
+
+	for i, item := range items {
+		response, ok := responses[strconv.Itoa(i)]
+
+		switch {
+		case ok:
+			errs[i] = response.Check(change.status)
+		case cache.IsLocalId(item.ListId) || cache.IsLocalId(item.Task.Id) || cache.IsOffline(err):
+			errs[i] = change.single(item)
+		case err != nil:
+			errs[i] = err
+		default:
+			errs[i] = fmt.Errorf("No response for the task")
+		}
+	}
+
+	return errs
+}
+
+// Calls `fn` with the indexes up to `n`, `concurrency` of them at a time
+// (DefaultBulkConcurrency when not set, MaxBulkConcurrency at most), and
+// returns the errors of the calls at the same indexes. The calls not started
+// by the time the app is cancelled fail with its error.
+func forEachConcurrently(n, concurrency int, fn func(i int) error) []error {
+	if concurrency <= 0 {
+		concurrency = DefaultBulkConcurrency
+	}
+
+	if concurrency > MaxBulkConcurrency {
+		concurrency = MaxBulkConcurrency
+	}
+
+	errs := make([]error, n)
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i := 0; i < n; i++ {
+		if err := ctx.Err(); err != nil {
+			errs[i] = err
+			continue
+		}
+
+		slots <- struct{}{}
+		wg.Add(1)
+
+		go func(i int) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+
+			errs[i] = fn(i)
+		}(i)
+	}
+
+	wg.Wait()
+
+	return errs
+}
+
+// Tells whether a TaskItem (built out of the TaskOptions) sets no attribute.
+func isEmptyTask(task *api.TaskItem) bool {
+	return task.Title == "" &&
+		task.Status == "" &&
+		task.Importance == "" &&
+		task.DueDateTime == nil &&
+		task.ReminderDateTime == nil &&
+		task.Recurrence == nil
+}
+
+// Returns an error telling how many of the changes failed, if any. It's a
+// PartialError when some of them were made.
+func bulkError(results []bulkResult, action string) error {
+	failed := 0
+	var first error
+	for _, r := range results {
+		if !r.Ok {
+			if failed == 0 {
+				first = r.err
+			}
+			failed++
+		}
+	}
+
+	if failed == 0 {
+		return nil
+	}
+
+	if failed == len(results) {
+		return fmt.Errorf("Could not %s any of the %d task(s): %w", action, failed, first)
+	}
+
+	return partialf("Could not %s %d of the %d task(s)", action, failed, len(results))
+}
+
+// Prints the tasks a bulk action is about to change (or with `--output
+// json`, prints them as JSON).
+func printBulkPreview(items []bulkItem) {
+	if Output == OutputJson {
+		tasks := []api.TaskItem{}
+		for _, item := range items {
+			tasks = append(tasks, item.Task)
+		}
+
+		printJson(os.Stdout, tasks)
+		return
+	}
+
+	rows := []interface{}{}
+	for _, item := range items {
+		task := newTaskRow(item.Task)
+
+		rows = append(rows, bulkRow{
+			List:       item.ListName,
+			Title:      task.Title,
+			Status:     task.Status,
+			Importance: task.Importance,
+			Due:        task.Due,
+			Id:         task.Id,
+		})
+	}
+
+	printTable(rows, []string{"all"}, bulkHeaders, bulkColumnsToKeysMap)
+}
+
+// Prints the outcome of each change of a bulk action, followed by the
+// totals (or with `--output json`, prints the outcomes as JSON).
+func printBulkSummary(results []bulkResult, done string) {
+	if Output == OutputJson {
+		printJson(os.Stdout, results)
+		return
+	}
+
+	failed := 0
+	for _, r := range results {
+		if r.Ok {
+			fmt.Fprintf(os.Stdout, "ok      %s / %s\n", r.List, r.Title)
+			continue
+		}
+
+		failed++
+		fmt.Fprintf(os.Stdout, "failed  %s / %s: %s\n", r.List, r.Title, r.Error)
+	}
+
+	fmt.Fprintf(
+		os.Stdout,
+		"%d of %d task(s) %s, %d failed\n",
+		len(results)-failed,
+		len(results),
+		done,
+		failed,
+	)
+}
+
+// Prints the `prompt` and reads a yes/no answer from `in` (no, unless it
+// starts with a `y`).
+func askConfirmation(prompt string, in io.Reader) (bool, error) {
+	fmt.Fprintf(os.Stderr, "%s (y/N) ", prompt)
+
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return false, err
+	}
+
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(answer)), "y"), nil
+}
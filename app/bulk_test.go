@@ -0,0 +1,305 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package app
+
+import (
+	"errors"
+	"github.com/betasve/mstd/conf"
+	"github.com/betasve/mstd/ext/term"
+	"github.com/betasve/mstd/ext/term/termtest"
+	api "github.com/betasve/mstd/todoapi"
+	apiTest "github.com/betasve/mstd/todoapi/todoapitest"
+	"io"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// An in-memory account for the bulk tests, recording the changes (as
+// `list/task`) made to its tasks.
+type bulkAccount struct {
+	mu      sync.Mutex
+	updated []string
+	deleted []string
+}
+
+func (a *bulkAccount) changed(changes []string) []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	sorted := append([]string{}, changes...)
+	sort.Strings(sorted)
+
+	return sorted
+}
+
+func stubBulkAccount(test *testing.T) *bulkAccount {
+	config = &conf.Config{}
+	apiClient = &apiTest.TodoApiMock{}
+	a := &bulkAccount{}
+
+	listsIndex, tasksIndex := apiTest.ListsIndexMockFn, apiTest.TasksIndexMockFn
+	tasksUpdate, tasksDelete := apiTest.TasksUpdateMockFn, apiTest.TasksDeleteMockFn
+	batch := apiTest.BatchMockFn
+	test.Cleanup(func() {
+		apiTest.ListsIndexMockFn, apiTest.TasksIndexMockFn = listsIndex, tasksIndex
+		apiTest.TasksUpdateMockFn, apiTest.TasksDeleteMockFn = tasksUpdate, tasksDelete
+		apiTest.BatchMockFn = batch
+	})
+
+	apiTest.ListsIndexMockFn = func() (*[]api.ListsItem, error) {
+		return &[]api.ListsItem{{Id: "work", Name: "Work"}, {Id: "home", Name: "Home"}}, nil
+	}
+	apiTest.TasksIndexMockFn = func(l string) (*[]api.TaskItem, error) {
+		tasks := map[string][]api.TaskItem{
+			"work": {
+				{Id: "report", Title: "Write report", Status: "notStarted", Importance: "high"},
+				{Id: "slides", Title: "Make slides", Status: "completed", Importance: "high"},
+				{Id: "mail", Title: "Answer mail", Status: "notStarted", Importance: "normal"},
+			},
+			"home": {
+				{Id: "taxes", Title: "Pay taxes", Status: "inProgress", Importance: "high"},
+			},
+		}[l]
+		return &tasks, nil
+	}
+	apiTest.TasksUpdateMockFn = func(l, i string, t *api.TaskItem) (*api.TaskItem, error) {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+
+		a.updated = append(a.updated, l+"/"+i+"/"+t.Status+t.Importance)
+		return t, nil
+	}
+	apiTest.TasksDeleteMockFn = func(l, i string) error {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+
+		a.deleted = append(a.deleted, l+"/"+i)
+		return nil
+	}
+
+	return a
+}
+
+func TestTasksBulkCompletesTheMatchingTasks(test *testing.T) {
+	a := stubBulkAccount(test)
+
+	opts := BulkOptions{Where: "status != completed and importance = high", Yes: true}
+	if err := TasksBulk(AllLists, BulkComplete, TaskOptions{}, opts); err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	expected := []string{"home/taxes/completed", "work/report/completed"}
+	if updated := a.changed(a.updated); !reflect.DeepEqual(updated, expected) {
+		test.Errorf("\nExpected the tasks\n%v\nto be completed\nbut were\n%v", expected, updated)
+	}
+}
+
+func TestTasksBulkUpdatesTheTasksOfAList(test *testing.T) {
+	a := stubBulkAccount(test)
+
+	opts := BulkOptions{Where: "title ~ (?i)^answer", Yes: true}
+	if err := TasksBulk("work", BulkUpdate, TaskOptions{Importance: "low"}, opts); err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	if updated := a.changed(a.updated); !reflect.DeepEqual(updated, []string{"work/mail/low"}) {
+		test.Errorf("\nExpected only the matching task to be updated\nbut were\n%v", updated)
+	}
+}
+
+func TestTasksBulkUpdateWithoutChanges(test *testing.T) {
+	stubBulkAccount(test)
+
+	err := TasksBulk("work", BulkUpdate, TaskOptions{}, BulkOptions{Where: "status = completed", Yes: true})
+	if ExitCode(err) != ExitValidation {
+		test.Errorf("\nExpected a validation error\nbut was\n%v", err)
+	}
+}
+
+func TestTasksBulkInvalidFilter(test *testing.T) {
+	stubBulkAccount(test)
+
+	err := TasksBulk("work", BulkDelete, TaskOptions{}, BulkOptions{Where: "colour = red", Yes: true})
+	if ExitCode(err) != ExitValidation {
+		test.Errorf("\nExpected a validation error\nbut was\n%v", err)
+	}
+}
+
+func TestTasksBulkDryRun(test *testing.T) {
+	a := stubBulkAccount(test)
+
+	opts := BulkOptions{Where: "list = work", DryRun: true}
+	if err := TasksBulk(AllLists, BulkDelete, TaskOptions{}, opts); err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	if len(a.deleted) != 0 {
+		test.Errorf("\nExpected nothing to be deleted\nbut were\n%v", a.deleted)
+	}
+}
+
+func TestTasksBulkConfirmation(test *testing.T) {
+	a := stubBulkAccount(test)
+
+	term.Client = termtest.TermMock{}
+	defer func() {
+		term.Client = term.Term{}
+		termtest.IsTerminalMockFn = func(fd int) bool { return true }
+		confirmBulk = askConfirmation
+	}()
+
+	termtest.IsTerminalMockFn = func(fd int) bool { return false }
+	err := TasksBulk("home", BulkDelete, TaskOptions{}, BulkOptions{Where: "status != completed"})
+	if ExitCode(err) != ExitValidation || !strings.Contains(err.Error(), "--yes") {
+		test.Errorf("\nExpected --yes to be asked for outside of a terminal\nbut was\n%v", err)
+	}
+
+	termtest.IsTerminalMockFn = func(fd int) bool { return true }
+	confirmBulk = func(prompt string, in io.Reader) (bool, error) { return false, nil }
+	if err := TasksBulk("home", BulkDelete, TaskOptions{}, BulkOptions{Where: "status != completed"}); err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	if len(a.deleted) != 0 {
+		test.Errorf("\nExpected nothing to be deleted without a confirmation\nbut were\n%v", a.deleted)
+	}
+
+	confirmBulk = func(prompt string, in io.Reader) (bool, error) { return true, nil }
+	if err := TasksBulk("home", BulkDelete, TaskOptions{}, BulkOptions{Where: "status != completed"}); err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	if !reflect.DeepEqual(a.deleted, []string{"home/taxes"}) {
+		test.Errorf("\nExpected the task to be deleted once confirmed\nbut were\n%v", a.deleted)
+	}
+}
+
+func TestTasksBulkPartialFailure(test *testing.T) {
+	stubBulkAccount(test)
+	apiTest.TasksDeleteMockFn = func(l, i string) error {
+		if i == "mail" {
+			return errors.New("Forbidden")
+		}
+		return nil
+	}
+
+	err := TasksBulk("work", BulkDelete, TaskOptions{}, BulkOptions{Where: "title ~ .", Yes: true})
+	if ExitCode(err) != ExitPartialFailure || !strings.Contains(err.Error(), "1 of the 3 task(s)") {
+		test.Errorf("\nExpected a partial failure\nbut was\n%v", err)
+	}
+}
+
+func TestTasksBulkFailure(test *testing.T) {
+	stubBulkAccount(test)
+	apiTest.BatchMockFn = func(r []api.BatchRequest) (map[string]*api.BatchResponse, error) {
+		responses := map[string]*api.BatchResponse{}
+		for _, request := range r {
+			responses[request.Id] = &api.BatchResponse{Id: request.Id, Status: 404}
+		}
+		return responses, nil
+	}
+
+	err := TasksBulk("work", BulkDelete, TaskOptions{}, BulkOptions{Where: "title ~ .", Yes: true})
+	if ExitCode(err) != ExitNotFound {
+		test.Errorf("\nExpected the error of the failed changes\nbut was\n%v", err)
+	}
+}
+
+func TestTasksBulkSendsTheChangesInBatches(test *testing.T) {
+	a := stubBulkAccount(test)
+	apiTest.TasksIndexMockFn = func(l string) (*[]api.TaskItem, error) {
+		tasks := []api.TaskItem{}
+		for i := 0; i < 45; i++ {
+			tasks = append(tasks, api.TaskItem{Id: l + strconv.Itoa(i), Title: "Task", Status: "notStarted"})
+		}
+		if l == "home" {
+			tasks = append(tasks, api.TaskItem{Id: "local-1", Title: "Made offline"})
+		}
+		return &tasks, nil
+	}
+
+	var mu sync.Mutex
+	batches, running, most := []int{}, 0, 0
+	apiTest.BatchMockFn = func(r []api.BatchRequest) (map[string]*api.BatchResponse, error) {
+		mu.Lock()
+		batches = append(batches, len(r))
+		if running++; running > most {
+			most = running
+		}
+		mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+
+		responses := map[string]*api.BatchResponse{}
+		for _, request := range r {
+			if request.Method != "DELETE" {
+				test.Errorf("\nExpected the tasks to be deleted\nbut got\n%+v", request)
+			}
+			responses[request.Id] = &api.BatchResponse{Id: request.Id, Status: 204}
+		}
+		return responses, nil
+	}
+
+	opts := BulkOptions{Where: "title ~ .", Yes: true, Concurrency: 2}
+	if err := TasksBulk(AllLists, BulkDelete, TaskOptions{}, opts); err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	sort.Ints(batches)
+	if !reflect.DeepEqual(batches, []int{10, 20, 20, 20, 20}) || most > 2 {
+		test.Errorf("\nExpected 5 batches, 2 at a time at most\nbut were\n%v (%d at a time)", batches, most)
+	}
+
+	if !reflect.DeepEqual(a.deleted, []string{"home/local-1"}) {
+		test.Errorf("\nExpected the task made offline to be deleted on its own\nbut were\n%v", a.deleted)
+	}
+}
+
+func TestTasksBulkFallsBackToSingleChangesWhenOffline(test *testing.T) {
+	a := stubBulkAccount(test)
+	apiTest.BatchMockFn = func(r []api.BatchRequest) (map[string]*api.BatchResponse, error) {
+		return nil, &url.Error{Op: "Post", URL: "https://graph", Err: errors.New("no route to host")}
+	}
+
+	opts := BulkOptions{Where: "importance = high", Yes: true}
+	if err := TasksBulk(AllLists, BulkComplete, TaskOptions{}, opts); err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	expected := []string{"home/taxes/completed", "work/report/completed", "work/slides/completed"}
+	if updated := a.changed(a.updated); !reflect.DeepEqual(updated, expected) {
+		test.Errorf("\nExpected the tasks\n%v\nto be completed one by one\nbut were\n%v", expected, updated)
+	}
+}
+
+func TestAskConfirmation(test *testing.T) {
+	for answer, expected := range map[string]bool{"y\n": true, "Yes\n": true, "n\n": false, "\n": false, "": false} {
+		ok, err := askConfirmation("Delete?", strings.NewReader(answer))
+		if err != nil || ok != expected {
+			test.Errorf("\nExpected the answer %q to be\n%v\nbut was\n%v (%v)", answer, expected, ok, err)
+		}
+	}
+}
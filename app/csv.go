@@ -0,0 +1,433 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package app
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"github.com/betasve/mstd/dateparse"
+	api "github.com/betasve/mstd/todoapi"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The attributes of a task the columns of a CSV file can be mapped to.
+const (
+	csvTitle      string = "title"
+	csvDueDate    string = "dueDate"
+	csvReminder   string = "reminderDate"
+	csvImportance string = "importance"
+	csvStatus     string = "status"
+	csvCategories string = "categories"
+	csvNote       string = "note"
+	csvList       string = "list"
+)
+
+// Maps the (normalized) headers of a CSV file to the attributes of a task,
+// for the columns that aren't mapped explicitly.
+var csvHeaderAliases map[string]string = map[string]string{
+	"title":        csvTitle,
+	"task":         csvTitle,
+	"name":         csvTitle,
+	"subject":      csvTitle,
+	"due":          csvDueDate,
+	"duedate":      csvDueDate,
+	"deadline":     csvDueDate,
+	"reminder":     csvReminder,
+	"reminderdate": csvReminder,
+	"remind":       csvReminder,
+	"importance":   csvImportance,
+	"priority":     csvImportance,
+	"status":       csvStatus,
+	"state":        csvStatus,
+	"categories":   csvCategories,
+	"category":     csvCategories,
+	"tags":         csvCategories,
+	"labels":       csvCategories,
+	"note":         csvNote,
+	"notes":        csvNote,
+	"description":  csvNote,
+	"body":         csvNote,
+	"list":         csvList,
+}
+
+// Maps the (normalized) values of the importance column to the importance
+// of a task.
+var csvImportances map[string]string = map[string]string{
+	"high":   "high",
+	"normal": "normal",
+	"medium": "normal",
+	"low":    "low",
+}
+
+// Maps the (normalized) values of the status column to the status of a task.
+var csvStatuses map[string]string = map[string]string{
+	"notstarted":      "notStarted",
+	"todo":            "notStarted",
+	"open":            "notStarted",
+	"inprogress":      "inProgress",
+	"completed":       "completed",
+	"done":            "completed",
+	"waitingonothers": "waitingOnOthers",
+	"deferred":        "deferred",
+}
+
+// Maps the tokens of the date formats (e.g. `DD/MM/YYYY`) to the ones of
+// the Go layouts, longest first.
+var dateFormatTokens *strings.Replacer = strings.NewReplacer(
+	"YYYY", "2006",
+	"YY", "06",
+	"MM", "01",
+	"DD", "02",
+	"HH", "15",
+	"mm", "04",
+	"ss", "05",
+	"M", "1",
+	"D", "2",
+)
+
+// Holds the settings of a CSV import.
+type CsvOptions struct {
+	// Maps the columns (by their header or their 1-based position) to the
+	// attributes of a task, e.g. `Title=title,Due=dueDate`.
+	Mapping string
+	// The format of the dates, e.g. `DD/MM/YYYY` (or a Go layout). When not
+	// set, the dates are read like the ones typed in the CLI.
+	DateFormat string
+	// Reads the first row as data, rather than detecting if it's a header.
+	NoHeader bool
+	// The separator of the cells, a comma when not set.
+	Delimiter rune
+	Batch     ImportBatch
+}
+
+// A column of a CSV file, mapped to an attribute of a task.
+type csvColumn struct {
+	Index int
+	Field string
+}
+
+// Imports the rows of a CSV file as tasks, into the list of their `list`
+// column or else into `list`. The columns are mapped to the attributes of
+// the tasks through the mapping of `opts`, or by their headers. The rows
+// that can't be read are reported (by their number) and skipped, while the
+// rest are imported.
+func ImportCsv(path, list string, opts CsvOptions, dryRun bool) error {
+	data, err := readImportFile(path)
+	if err != nil {
+		return err
+	}
+
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\uFEFF"))))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	if opts.Delimiter != 0 {
+		reader.Comma = opts.Delimiter
+	}
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return err
+	}
+
+	if len(rows) == 0 {
+		return invalidf("The file has no rows")
+	}
+
+	mapping, err := parseCsvMapping(opts.Mapping)
+	if err != nil {
+		return err
+	}
+
+	header := !opts.NoHeader && isCsvHeader(rows[0], mapping)
+
+	columns, err := csvColumns(rows[0], header, mapping)
+	if err != nil {
+		return err
+	}
+
+	loc, err := appLocation()
+	if err != nil {
+		return err
+	}
+
+	first := 1
+	if header {
+		rows = rows[1:]
+		first = 2
+	}
+
+	items := []importItem{}
+	failed := 0
+	for i, row := range rows {
+		item, err := csvRowToItem(row, columns, list, opts.DateFormat, loc)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Row %d: %s\n", first+i, err)
+			failed++
+			continue
+		}
+
+		items = append(items, item)
+	}
+
+	if len(items) > 0 {
+		if err := importTasks(items, dryRun, opts.Batch); err != nil {
+			return err
+		}
+	}
+
+	if failed > 0 && len(items) > 0 {
+		return partialf("%d row(s) could not be imported", failed)
+	}
+
+	if failed > 0 {
+		return invalidf("%d row(s) could not be imported", failed)
+	}
+
+	return nil
+}
+
+// Parses a mapping like `Title=title,3=dueDate` into the attributes each
+// column (by its lowercased header or its 1-based position) is mapped to.
+func parseCsvMapping(mapping string) (map[string]string, error) {
+	parsed := map[string]string{}
+	if strings.TrimSpace(mapping) == "" {
+		return parsed, nil
+	}
+
+	for _, pair := range strings.Split(mapping, ",") {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, invalidf("Invalid column mapping %q, expected COLUMN=attribute", pair)
+		}
+
+		field, ok := csvHeaderAliases[normalizeCsvValue(parts[1])]
+		if !ok {
+			return nil, invalidf(
+				"Unknown attribute %q, use one of: %s",
+				strings.TrimSpace(parts[1]),
+				strings.Join([]string{
+					csvTitle, csvDueDate, csvReminder, csvImportance,
+					csvStatus, csvCategories, csvNote, csvList,
+				}, ", "),
+			)
+		}
+
+		parsed[strings.ToLower(strings.TrimSpace(parts[0]))] = field
+	}
+
+	return parsed, nil
+}
+
+// Checks if the first row of a file is a header: if any of its cells is a
+// column of the mapping, or a known header (when there's no mapping by
+// header).
+func isCsvHeader(row []string, mapping map[string]string) bool {
+	byHeader := false
+	for column := range mapping {
+		if _, err := strconv.Atoi(column); err != nil {
+			byHeader = true
+		}
+	}
+
+	for _, cell := range row {
+		if _, ok := mapping[strings.ToLower(strings.TrimSpace(cell))]; ok {
+			return true
+		}
+
+		if _, ok := csvHeaderAliases[normalizeCsvValue(cell)]; ok && !byHeader {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Works out the attribute each column is mapped to. Without a mapping, the
+// columns are mapped by their headers or, in a file without a header, the
+// first one is the title.
+func csvColumns(first []string, header bool, mapping map[string]string) ([]csvColumn, error) {
+	columns := []csvColumn{}
+
+	if len(mapping) == 0 {
+		if !header {
+			return []csvColumn{{Index: 0, Field: csvTitle}}, nil
+		}
+
+		for i, cell := range first {
+			if field, ok := csvHeaderAliases[normalizeCsvValue(cell)]; ok {
+				columns = append(columns, csvColumn{Index: i, Field: field})
+			}
+		}
+
+		return columns, nil
+	}
+
+	for column, field := range mapping {
+		index, err := strconv.Atoi(column)
+		if err == nil {
+			if index < 1 {
+				return nil, invalidf("Invalid column number %d, they start from 1", index)
+			}
+			columns = append(columns, csvColumn{Index: index - 1, Field: field})
+			continue
+		}
+
+		if !header {
+			return nil, invalidf("Column %q is mapped, but the file has no header", column)
+		}
+
+		index = -1
+		for i, cell := range first {
+			if strings.EqualFold(strings.TrimSpace(cell), column) {
+				index = i
+			}
+		}
+
+		if index == -1 {
+			return nil, invalidf("Column %q is mapped, but it's not in the header", column)
+		}
+
+		columns = append(columns, csvColumn{Index: index, Field: field})
+	}
+
+	// Keep the values joined from several columns (e.g. notes) in order.
+	sort.Slice(columns, func(i, j int) bool { return columns[i].Index < columns[j].Index })
+
+	return columns, nil
+}
+
+// Converts a row of a CSV file to a task, along with the list it goes into.
+func csvRowToItem(
+	row []string,
+	columns []csvColumn,
+	list, dateFormat string,
+	loc *time.Location,
+) (importItem, error) {
+	task := &api.TaskItem{}
+	item := importItem{List: list, Task: task}
+	notes := []string{}
+
+	for _, column := range columns {
+		if column.Index >= len(row) {
+			continue
+		}
+
+		value := strings.TrimSpace(row[column.Index])
+		if value == "" {
+			continue
+		}
+
+		switch column.Field {
+		case csvTitle:
+			task.Title = value
+		case csvDueDate:
+			due, err := parseCsvDate(value, dateFormat, loc, 0)
+			if err != nil {
+				return item, fmt.Errorf("Invalid due date %q: %s", value, err)
+			}
+			task.DueDateTime = api.NewDateTimeTimeZone(due)
+		case csvReminder:
+			reminder, err := parseCsvDate(value, dateFormat, loc, defaultReminderTimeOfDay)
+			if err != nil {
+				return item, fmt.Errorf("Invalid reminder date %q: %s", value, err)
+			}
+			task.IsReminderOn = true
+			task.ReminderDateTime = api.NewDateTimeTimeZone(reminder)
+		case csvImportance:
+			importance, ok := csvImportances[normalizeCsvValue(value)]
+			if !ok {
+				return item, fmt.Errorf("Invalid importance %q, use high, normal or low", value)
+			}
+			task.Importance = importance
+		case csvStatus:
+			status, ok := csvStatuses[normalizeCsvValue(value)]
+			if !ok {
+				return item, fmt.Errorf("Invalid status %q", value)
+			}
+			task.Status = status
+		case csvCategories:
+			task.Categories = append(task.Categories, splitCsvCategories(value)...)
+		case csvNote:
+			notes = append(notes, value)
+		case csvList:
+			item.List = value
+		}
+	}
+
+	if task.Title == "" {
+		return item, fmt.Errorf("The title is empty")
+	}
+
+	if item.List == "" {
+		return item, fmt.Errorf("Task %q has no list to go into, set one with --list", task.Title)
+	}
+
+	if len(notes) > 0 {
+		task.Body = &api.ItemBody{Content: strings.Join(notes, "\n"), ContentType: "text"}
+	}
+
+	return item, nil
+}
+
+// Parses a date of a CSV file in the `format` (see [dateFormatTokens]) or,
+// when it's not set, like a date typed in the CLI. The dates without a time
+// get `timeOfDay`.
+func parseCsvDate(value, format string, loc *time.Location, timeOfDay time.Duration) (time.Time, error) {
+	if format == "" {
+		return dateparse.Parser{Location: loc, DefaultTimeOfDay: timeOfDay}.Parse(value)
+	}
+
+	layout := dateFormatTokens.Replace(format)
+
+	date, err := time.ParseInLocation(layout, value, loc)
+	if err != nil {
+		return date, fmt.Errorf("Expected the format %s", format)
+	}
+
+	if date.Hour() == 0 && date.Minute() == 0 && date.Second() == 0 {
+		date = date.Add(timeOfDay)
+	}
+
+	return date, nil
+}
+
+// Splits the categories of a cell, separated by commas or semicolons.
+func splitCsvCategories(value string) []string {
+	categories := []string{}
+	for _, c := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ';' }) {
+		if c = strings.TrimSpace(c); c != "" {
+			categories = append(categories, c)
+		}
+	}
+
+	return categories
+}
+
+// Lowercases a value and drops its spaces, dashes and underscores, so that
+// e.g. `Due Date`, `due_date` and `dueDate` are the same.
+func normalizeCsvValue(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' || r == '_' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(strings.TrimSpace(value)))
+}
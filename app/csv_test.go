@@ -0,0 +1,111 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package app
+
+import (
+	"github.com/betasve/mstd/conf"
+	api "github.com/betasve/mstd/todoapi"
+	apiTest "github.com/betasve/mstd/todoapi/todoapitest"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeCsv(test *testing.T, content string) string {
+	path := filepath.Join(test.TempDir(), "tasks.csv")
+	_ = ioutil.WriteFile(path, []byte(content), 0600)
+	return path
+}
+
+func stubCsvImport() *[]*api.TaskItem {
+	config = &conf.Config{}
+	apiClient = &apiTest.TodoApiMock{}
+	stubLists()
+
+	created := []*api.TaskItem{}
+	apiTest.TasksCreateMockFn = func(l string, t *api.TaskItem) (*api.TaskItem, error) {
+		created = append(created, t)
+		return t, nil
+	}
+
+	return &created
+}
+
+func TestImportCsvWithMapping(test *testing.T) {
+	created := stubCsvImport()
+	path := writeCsv(test, "\uFEFFTitle,Due,Owner,Notes,Tags,Priority\n"+
+		"Buy milk,03/05/2021,Ann,Whole,\"home; errands\",High\n"+
+		",04/05/2021,Bob,,,\n"+
+		"Call Bob,2021-05-04,Bob,,,urgent\n"+
+		"Pay rent,31/05/2021,,Monthly,,low\n")
+
+	err := ImportCsv(path, "Groceries", CsvOptions{
+		Mapping:    "Title=title, Due=dueDate, Owner=categories, Tags=categories, Notes=note, Priority=importance",
+		DateFormat: "DD/MM/YYYY",
+	}, false)
+
+	if err == nil || err.Error() != "2 row(s) could not be imported" {
+		test.Errorf("\nExpected the failed rows to be reported\nbut was\n%v", err)
+	}
+
+	if len(*created) != 2 {
+		test.Fatalf("\nExpected the 2 valid rows to be imported\nbut were\n%d", len(*created))
+	}
+
+	task := (*created)[0]
+	if task.Title != "Buy milk" ||
+		task.Importance != "high" ||
+		task.DueDateTime.DateTime != "2021-05-03T00:00:00.0000000" ||
+		task.Body.Content != "Whole" ||
+		!reflect.DeepEqual(task.Categories, []string{"Ann", "home", "errands"}) {
+		test.Errorf("\nExpected the columns to be mapped\nbut the task was\n%+v", task)
+	}
+}
+
+func TestImportCsvDetectsHeaders(test *testing.T) {
+	created := stubCsvImport()
+	path := writeCsv(test, "Task;Due Date;Status;List\nBuy milk;2021-05-03;Done;groceries\n")
+
+	if err := ImportCsv(path, "", CsvOptions{Delimiter: ';'}, false); err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	if len(*created) != 1 || (*created)[0].Title != "Buy milk" || (*created)[0].Status != "completed" {
+		test.Errorf("\nExpected the columns to be mapped by their headers\nbut were\n%+v", *created)
+	}
+}
+
+func TestImportCsvWithoutHeader(test *testing.T) {
+	created := stubCsvImport()
+	path := writeCsv(test, "Buy milk,2021-05-03\nEggs,2021-05-04\n")
+
+	if err := ImportCsv(path, "Groceries", CsvOptions{Mapping: "1=title,2=due"}, false); err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	if len(*created) != 2 || (*created)[0].Title != "Buy milk" || (*created)[1].DueDateTime == nil {
+		test.Errorf("\nExpected both rows to be imported\nbut were\n%+v", *created)
+	}
+
+	if err := ImportCsv(path, "Groceries", CsvOptions{Mapping: "Title=title"}, false); err == nil {
+		test.Error("\nExpected a mapped header missing to return error\nbut it was\nnil")
+	}
+
+	if err := ImportCsv(path, "Groceries", CsvOptions{Mapping: "1=owner"}, false); err == nil {
+		test.Error("\nExpected an unknown attribute to return error\nbut it was\nnil")
+	}
+}
@@ -0,0 +1,187 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/betasve/mstd/cache"
+	"github.com/betasve/mstd/login"
+	api "github.com/betasve/mstd/todoapi"
+	"io"
+	"net/http"
+)
+
+// The exit codes of the app, telling scripts what went wrong.
+const (
+	ExitOk             int = 0
+	ExitFailure        int = 1 // anything not listed below
+	ExitValidation     int = 2 // invalid flags, arguments or input
+	ExitAuthRequired   int = 3 // not logged in, or the session can't be refreshed
+	ExitNotFound       int = 4
+	ExitConflict       int = 5
+	ExitNetwork        int = 6 // To Do could not be reached (or didn't respond in time)
+	ExitThrottled      int = 7
+	ExitPartialFailure int = 8   // some of the changes were made, the rest were not
+	ExitCancelled      int = 130 // interrupted with Ctrl+C
+)
+
+// The names of the exit codes, used in the JSON form of the errors.
+var exitCodeNames = map[int]string{
+	ExitFailure:        "failure",
+	ExitValidation:     "validation",
+	ExitAuthRequired:   "auth_required",
+	ExitNotFound:       "not_found",
+	ExitConflict:       "conflict",
+	ExitNetwork:        "network",
+	ExitThrottled:      "throttled",
+	ExitPartialFailure: "partial_failure",
+	ExitCancelled:      "cancelled",
+}
+
+// The formats the results and the errors can be printed in.
+const (
+	OutputText string = "text"
+	OutputJson string = "json"
+)
+
+// The error returned for invalid flags, arguments or input.
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// The error returned when a list or a task can't be found.
+type NotFoundError struct {
+	Kind string
+	Name string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("%s %q not found", e.Kind, e.Name)
+}
+
+// The error returned when a command made only some of its changes, wrapping
+// the error that stopped it.
+type PartialError struct {
+	Err error
+}
+
+func (e *PartialError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PartialError) Unwrap() error {
+	return e.Err
+}
+
+// Formats a ValidationError.
+func invalidf(format string, a ...interface{}) error {
+	return &ValidationError{Message: fmt.Sprintf(format, a...)}
+}
+
+// Formats a PartialError (`%w` keeps the cause).
+func partialf(format string, a ...interface{}) error {
+	return &PartialError{Err: fmt.Errorf(format, a...)}
+}
+
+// Returns the exit code an error should end the app with.
+func ExitCode(err error) int {
+	var partialErr *PartialError
+	var validationErr *ValidationError
+	var notFoundErr *NotFoundError
+	var authErr *login.AuthError
+	var apiErr *api.ApiError
+
+	switch {
+	case err == nil:
+		return ExitOk
+	case errors.As(err, &partialErr):
+		return ExitPartialFailure
+	case errors.Is(err, context.Canceled):
+		return ExitCancelled
+	case errors.As(err, &validationErr):
+		return ExitValidation
+	case errors.As(err, &notFoundErr):
+		return ExitNotFound
+	case errors.As(err, &authErr):
+		return ExitAuthRequired
+	case errors.As(err, &apiErr):
+		return apiExitCode(apiErr)
+	case cache.IsOffline(err) || errors.Is(err, context.DeadlineExceeded):
+		return ExitNetwork
+	}
+
+	return ExitFailure
+}
+
+// Returns the exit code for the status the API responded with.
+func apiExitCode(err *api.ApiError) int {
+	switch {
+	case err.Throttled():
+		return ExitThrottled
+	case err.StatusCode == http.StatusUnauthorized, err.StatusCode == http.StatusForbidden:
+		return ExitAuthRequired
+	case err.StatusCode == http.StatusNotFound:
+		return ExitNotFound
+	case err.StatusCode == http.StatusConflict, err.StatusCode == http.StatusPreconditionFailed:
+		return ExitConflict
+	case err.StatusCode == http.StatusBadRequest, err.StatusCode == http.StatusUnprocessableEntity:
+		return ExitValidation
+	}
+
+	return ExitFailure
+}
+
+// Prints an error to `out` (meant to be the standard error) in the
+// `Output` format. The JSON form is an object like
+// `{"error":{"code":"not_found","exitCode":4,"message":"..."}}`.
+func PrintError(out io.Writer, err error) {
+	code := ExitCode(err)
+
+	if Output != OutputJson {
+		fmt.Fprintf(out, "Error: %s\n", err)
+		return
+	}
+
+	body := map[string]interface{}{
+		"code":     exitCodeNames[code],
+		"exitCode": code,
+		"message":  err.Error(),
+	}
+
+	var apiErr *api.ApiError
+	if errors.As(err, &apiErr) {
+		body["status"] = apiErr.StatusCode
+	}
+
+	data, _ := json.Marshal(map[string]interface{}{"error": body})
+	fmt.Fprintln(out, string(data))
+}
+
+// Prints a result as indented JSON, for `--output json`.
+func printJson(out io.Writer, v interface{}) {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+
+	_ = encoder.Encode(v)
+}
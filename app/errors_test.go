@@ -0,0 +1,78 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/betasve/mstd/login"
+	api "github.com/betasve/mstd/todoapi"
+	"net/url"
+	"testing"
+)
+
+func TestExitCode(test *testing.T) {
+	offline := &url.Error{Op: "Get", URL: "https://graph", Err: errors.New("no route to host")}
+
+	expectations := []struct {
+		err  error
+		code int
+	}{
+		{nil, ExitOk},
+		{errors.New("boom"), ExitFailure},
+		{invalidf("Invalid"), ExitValidation},
+		{&login.AuthError{Code: "invalid_grant"}, ExitAuthRequired},
+		{&api.ApiError{StatusCode: 401}, ExitAuthRequired},
+		{&NotFoundError{Kind: "List", Name: "Groceries"}, ExitNotFound},
+		{&api.ApiError{StatusCode: 404}, ExitNotFound},
+		{&api.ApiError{StatusCode: 409}, ExitConflict},
+		{&api.ApiError{StatusCode: 400}, ExitValidation},
+		{offline, ExitNetwork},
+		{&api.ApiError{StatusCode: 429}, ExitThrottled},
+		{partialf("Imported 1 of 2 task(s), but %w", &api.ApiError{StatusCode: 429}), ExitPartialFailure},
+		{fmt.Errorf("Sending: %w", context.Canceled), ExitCancelled},
+	}
+
+	for _, e := range expectations {
+		if code := ExitCode(e.err); code != e.code {
+			test.Errorf("\nExpected the exit code of %v to be:\n%d\nbut was\n%d", e.err, e.code, code)
+		}
+	}
+}
+
+func TestPrintError(test *testing.T) {
+	defer func() { Output = OutputText }()
+
+	out := &bytes.Buffer{}
+	err := &NotFoundError{Kind: "List", Name: "Groceries"}
+
+	PrintError(out, err)
+	if out.String() != "Error: List \"Groceries\" not found\n" {
+		test.Errorf("\nExpected the error to be printed as text\nbut got\n%s", out)
+	}
+
+	Output = OutputJson
+	out.Reset()
+
+	PrintError(out, err)
+	expected := `{"error":{"code":"not_found","exitCode":4,"message":"List \"Groceries\" not found"}}` + "\n"
+	if out.String() != expected {
+		test.Errorf("\nExpected the error to be:\n%s\nbut was\n%s", expected, out)
+	}
+}
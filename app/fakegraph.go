@@ -0,0 +1,50 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	httpService "github.com/betasve/mstd/ext/http"
+	"github.com/betasve/mstd/fakegraph"
+	"os"
+)
+
+// Serves a fake Microsoft Graph (see the `fakegraph` package) on `addr`
+// until it's interrupted, printing how to point the app at it.
+func FakeGraph(addr string) error {
+	fmt.Fprintf(
+		os.Stdout,
+		"Serving a fake Microsoft Graph on http://%s (Ctrl+C to stop)\n"+
+			"Point mstd at it with:\n"+
+			"  export %s=http://%s%s\n"+
+			"  export %s=http://%s%s\n"+
+			"or by setting graph_url and authority_host to http://%s in the config file\n",
+		addr,
+		GraphUrlEnv, addr, fakegraph.ApiPath,
+		LoginUrlEnv, addr, fakegraph.LoginPath,
+		addr,
+	)
+
+	err := (&httpService.Client{}).ListenAndServe(ctx, addr, fakegraph.New())
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,151 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"fmt"
+	"github.com/betasve/mstd/dateparse"
+	"github.com/betasve/mstd/filter"
+	api "github.com/betasve/mstd/todoapi"
+	"os"
+)
+
+// Maps the columns of the tasks found (in any list) to the attributes of a
+// foundRow.
+var foundColumnsToKeysMap map[string]string = func() map[string]string {
+	m := map[string]string{"list": "List"}
+	for column, key := range TaskColumnsToKeysMap {
+		m[column] = key
+	}
+
+	return m
+}()
+
+// The headers of the table of the tasks found (in the order they are
+// displayed).
+var foundHeaders []string = append([]string{"list"}, TaskItemHeaders...)
+
+// A task found in any of the lists, along with the list it's in.
+type foundTask struct {
+	List   string `json:"list"`
+	ListId string `json:"listId"`
+	api.TaskItem
+}
+
+// A flattened, printable representation of a foundTask.
+type foundRow struct {
+	List       string
+	Title      string
+	Status     string
+	Importance string
+	Due        string
+	Reminder   string
+	Repeat     string
+	Id         string
+}
+
+// Prints the tasks in all the lists matching the filter `expr` (see the
+// `filter` package), with the name of their list as a column. As the API
+// can't search across the lists, the tasks of `concurrency` lists at a time
+// are retrieved and the filter is applied to them here.
+func TasksFind(expr string, concurrency int, columns []string) error {
+	apiClient.SetToken(config.ClientAccessToken())
+
+	loc, err := appLocation()
+	if err != nil {
+		return err
+	}
+
+	where, err := filter.Parse(expr, dateparse.Parser{Location: loc})
+	if err != nil {
+		return &ValidationError{Message: err.Error()}
+	}
+
+	results, err := findTasks(where, concurrency)
+	if err != nil {
+		return err
+	}
+
+	printFoundTasks(results, columns)
+	return nil
+}
+
+// Returns the tasks in all the lists matching a filter, searching
+// `concurrency` lists at a time. They're in the order of the lists, and of
+// the tasks in them.
+func findTasks(where *filter.Filter, concurrency int) ([]foundTask, error) {
+	lists, err := apiClient.ListsIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	found := make([][]foundTask, len(*lists))
+	errs := forEachConcurrently(len(*lists), concurrency, func(i int) error {
+		l := (*lists)[i]
+
+		tasks, err := apiClient.TasksIndex(ctx, l.Id)
+		if err != nil {
+			return err
+		}
+
+		for _, t := range *tasks {
+			if where.Match(l.Name, t) {
+				found[i] = append(found[i], foundTask{List: l.Name, ListId: l.Id, TaskItem: t})
+			}
+		}
+
+		return nil
+	})
+
+	results := []foundTask{}
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("Could not search the list %q: %w", (*lists)[i].Name, err)
+		}
+
+		results = append(results, found[i]...)
+	}
+
+	return results, nil
+}
+
+// Renders a table of the tasks found, showing only the requested `columns`
+// (or with `--output json`, prints them as JSON).
+func printFoundTasks(tasks []foundTask, columns []string) {
+	if Output == OutputJson {
+		printJson(os.Stdout, tasks)
+		return
+	}
+
+	rows := []interface{}{}
+	for _, task := range tasks {
+		row := newTaskRow(task.TaskItem)
+
+		rows = append(rows, foundRow{
+			List:       task.List,
+			Title:      row.Title,
+			Status:     row.Status,
+			Importance: row.Importance,
+			Due:        row.Due,
+			Reminder:   row.Reminder,
+			Repeat:     row.Repeat,
+			Id:         row.Id,
+		})
+	}
+
+	printTable(rows, columns, foundHeaders, foundColumnsToKeysMap)
+}
@@ -0,0 +1,78 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package app
+
+import (
+	"errors"
+	"github.com/betasve/mstd/dateparse"
+	"github.com/betasve/mstd/filter"
+	api "github.com/betasve/mstd/todoapi"
+	apiTest "github.com/betasve/mstd/todoapi/todoapitest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func mustParseFilter(test *testing.T, expr string) *filter.Filter {
+	f, err := filter.Parse(expr, dateparse.Parser{Location: time.UTC})
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	return f
+}
+
+func TestFindTasksAcrossLists(test *testing.T) {
+	stubBulkAccount(test)
+
+	found, err := findTasks(mustParseFilter(test, "importance = high and not completed"), 2)
+	if err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	results := []string{}
+	for _, task := range found {
+		results = append(results, task.List+"/"+task.Title)
+	}
+
+	if strings.Join(results, ", ") != "Work/Write report, Home/Pay taxes" {
+		test.Errorf("\nExpected the matching tasks of both lists (in their order)\nbut were\n%v", results)
+	}
+}
+
+func TestFindTasksFailedList(test *testing.T) {
+	stubBulkAccount(test)
+	failure := errors.New("Service unavailable")
+	apiTest.TasksIndexMockFn = func(l string) (*[]api.TaskItem, error) {
+		if l == "home" {
+			return nil, failure
+		}
+		return &[]api.TaskItem{}, nil
+	}
+
+	_, err := findTasks(mustParseFilter(test, "completed"), 2)
+	if err == nil || !strings.Contains(err.Error(), `"Home"`) || !errors.Is(err, failure) {
+		test.Errorf("\nExpected the failed list to be reported\nbut was\n%v", err)
+	}
+}
+
+func TestTasksFindInvalidExpression(test *testing.T) {
+	stubBulkAccount(test)
+
+	if err := TasksFind("due < someday", 2, []string{"all"}); ExitCode(err) != ExitValidation {
+		test.Errorf("\nExpected a validation error\nbut was\n%v", err)
+	}
+}
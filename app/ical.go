@@ -0,0 +1,576 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"bytes"
+	"fmt"
+	tm "github.com/betasve/mstd/ext/time"
+	"github.com/betasve/mstd/ical"
+	api "github.com/betasve/mstd/todoapi"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The non-standard property holding the name of the list of a VTODO, so the
+// tasks of all the lists can be exported to (and imported from) one file.
+const icalListProperty string = "X-MSTD-LIST"
+
+// Maps the statuses of the tasks to the ones of the VTODOs. The statuses
+// without a match there are exported as NEEDS-ACTION.
+var statusToIcal map[string]string = map[string]string{
+	"notStarted": "NEEDS-ACTION",
+	"inProgress": "IN-PROCESS",
+	"completed":  "COMPLETED",
+}
+
+// Maps the statuses of the VTODOs to the ones of the tasks. A cancelled VTODO
+// is done with, so it's imported as completed.
+var icalToStatus map[string]string = map[string]string{
+	"NEEDS-ACTION": "notStarted",
+	"IN-PROCESS":   "inProgress",
+	"COMPLETED":    "completed",
+	"CANCELLED":    "completed",
+}
+
+// Maps the importance of the tasks to the PRIORITY of the VTODOs (1 is the
+// highest and 9 the lowest).
+var importanceToIcal map[string]string = map[string]string{
+	"high":   "1",
+	"normal": "5",
+	"low":    "9",
+}
+
+// Maps the week days of the API to the ones of the RRULEs.
+var weekDayToIcal map[string]string = map[string]string{
+	"sunday":    "SU",
+	"monday":    "MO",
+	"tuesday":   "TU",
+	"wednesday": "WE",
+	"thursday":  "TH",
+	"friday":    "FR",
+	"saturday":  "SA",
+}
+
+// Maps the week indexes of the API to the BYSETPOS of the RRULEs.
+var weekIndexToIcal map[string]int = map[string]int{
+	"first":  1,
+	"second": 2,
+	"third":  3,
+	"fourth": 4,
+	"last":   -1,
+}
+
+// Imports the VTODOs of an iCalendar file. They go into `list` (an id or
+// a name) when it's set, otherwise into the list they were exported from
+// (or the name of their calendar).
+func ImportIcs(path, list string, dryRun bool) error {
+	data, err := readImportFile(path)
+	if err != nil {
+		return err
+	}
+
+	calendars, err := ical.Parse(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	loc, err := appLocation()
+	if err != nil {
+		return err
+	}
+
+	items := []importItem{}
+	for _, calendar := range calendars {
+		for _, todo := range calendar.Children("VTODO") {
+			task, err := fromVTodo(todo, loc)
+			if err != nil {
+				return err
+			}
+
+			item := importItem{List: list, Task: task}
+			for _, name := range []string{todo.Text(icalListProperty), calendar.Text("X-WR-CALNAME")} {
+				if item.List == "" {
+					item.List = name
+				}
+			}
+
+			if item.List == "" {
+				return invalidf("Task %q has no list to go into, set one with --list", task.Title)
+			}
+
+			items = append(items, item)
+		}
+	}
+
+	return importTasks(items, dryRun, DefaultImportBatch)
+}
+
+// Exports the tasks of all the lists (or only `list`, when set) as the
+// VTODOs of an iCalendar file at `path` (or the standard output).
+func ExportIcs(list, path string) error {
+	lists, tasks, err := exportedLists(list)
+	if err != nil {
+		return err
+	}
+
+	calendar := ical.NewComponent("VCALENDAR")
+	calendar.Add("VERSION", "2.0", nil)
+	calendar.Add("PRODID", "-//mstd//mstd//EN", nil)
+
+	if list != "" {
+		calendar.AddText("X-WR-CALNAME", lists[0].Name)
+	}
+
+	for _, l := range lists {
+		for _, t := range tasks[l.Id] {
+			todo, err := toVTodo(t, l.Name)
+			if err != nil {
+				return err
+			}
+
+			calendar.Components = append(calendar.Components, todo)
+		}
+	}
+
+	out, err := exportWriter(path)
+	if err != nil {
+		return err
+	}
+
+	defer out.Close()
+
+	return calendar.Encode(out)
+}
+
+// Converts a task (of the list named `listName`) to a VTODO.
+func toVTodo(t api.TaskItem, listName string) (*ical.Component, error) {
+	todo := ical.NewComponent("VTODO")
+
+	todo.Add("UID", t.Id+"@mstd", nil)
+	todo.Add("DTSTAMP", ical.FormatUTC(tm.Client.Now()), nil)
+	todo.AddText("SUMMARY", t.Title)
+
+	if content := taskNote(&t); content != "" {
+		todo.AddText("DESCRIPTION", content)
+	}
+
+	status, ok := statusToIcal[t.Status]
+	if !ok {
+		status = "NEEDS-ACTION"
+	}
+	todo.Add("STATUS", status, nil)
+
+	if priority, ok := importanceToIcal[t.Importance]; ok {
+		todo.Add("PRIORITY", priority, nil)
+	}
+
+	if len(t.Categories) > 0 {
+		categories := []string{}
+		for _, c := range t.Categories {
+			categories = append(categories, ical.EscapeText(c))
+		}
+		todo.Add("CATEGORIES", strings.Join(categories, ","), nil)
+	}
+
+	var due time.Time
+	dateOnly := false
+	if t.DueDateTime != nil {
+		var err error
+		if due, err = t.DueDateTime.Time(); err != nil {
+			return nil, err
+		}
+
+		dateOnly = due.Hour() == 0 && due.Minute() == 0
+		addIcalTime(todo, "DUE", due, dateOnly)
+	}
+
+	if t.Recurrence != nil {
+		rrule, err := recurrenceToRRule(t.Recurrence)
+		if err != nil {
+			return nil, err
+		}
+
+		start, err := time.Parse(api.RecurrenceDateLayout, t.Recurrence.Range.StartDate)
+		if err == nil {
+			start = time.Date(start.Year(), start.Month(), start.Day(), due.Hour(), due.Minute(), 0, 0, due.Location())
+			addIcalTime(todo, "DTSTART", start, dateOnly)
+		}
+
+		todo.Add("RRULE", rrule, nil)
+	}
+
+	if t.CompletedDateTime != nil {
+		if completed, err := t.CompletedDateTime.Time(); err == nil {
+			todo.Add("COMPLETED", ical.FormatUTC(completed), nil)
+		}
+	}
+
+	if created, err := time.Parse(time.RFC3339, t.CreatedDateTime); err == nil {
+		todo.Add("CREATED", ical.FormatUTC(created), nil)
+	}
+
+	if modified, err := time.Parse(time.RFC3339, t.LastModifiedDateTime); err == nil {
+		todo.Add("LAST-MODIFIED", ical.FormatUTC(modified), nil)
+	}
+
+	todo.AddText(icalListProperty, listName)
+
+	if t.IsReminderOn && t.ReminderDateTime != nil {
+		reminder, err := t.ReminderDateTime.Time()
+		if err != nil {
+			return nil, err
+		}
+
+		alarm := ical.NewComponent("VALARM")
+		alarm.Add("ACTION", "DISPLAY", nil)
+		alarm.AddText("DESCRIPTION", t.Title)
+		alarm.Add("TRIGGER", ical.FormatUTC(reminder), map[string]string{"VALUE": "DATE-TIME"})
+		todo.Components = append(todo.Components, alarm)
+	}
+
+	return todo, nil
+}
+
+// Adds a DATE (when `dateOnly`) or a (UTC) DATE-TIME property.
+func addIcalTime(c *ical.Component, name string, t time.Time, dateOnly bool) {
+	if dateOnly {
+		c.Add(name, t.Format(ical.DateLayout), map[string]string{"VALUE": "DATE"})
+		return
+	}
+
+	c.Add(name, ical.FormatUTC(t), nil)
+}
+
+// Converts a VTODO to a task. Its floating times (and dates) are in `loc`.
+func fromVTodo(todo *ical.Component, loc *time.Location) (*api.TaskItem, error) {
+	task := &api.TaskItem{
+		Title:  strings.TrimSpace(todo.Text("SUMMARY")),
+		Status: icalToStatus[strings.ToUpper(todo.Value("STATUS"))],
+	}
+
+	if task.Title == "" {
+		return nil, fmt.Errorf("VTODO %s has no SUMMARY", todo.Value("UID"))
+	}
+
+	if description := todo.Text("DESCRIPTION"); description != "" {
+		task.Body = &api.ItemBody{Content: description, ContentType: "text"}
+	}
+
+	if priority, err := strconv.Atoi(todo.Value("PRIORITY")); err == nil {
+		switch {
+		case priority >= 1 && priority <= 4:
+			task.Importance = "high"
+		case priority == 5:
+			task.Importance = "normal"
+		case priority >= 6:
+			task.Importance = "low"
+		}
+	}
+
+	for _, p := range todo.Properties {
+		if p.Name == "CATEGORIES" {
+			task.Categories = append(task.Categories, ical.SplitText(p.Value)...)
+		}
+	}
+
+	var due time.Time
+	if p, ok := todo.Get("DUE"); ok {
+		var err error
+		if due, _, err = ical.ParseTime(p, loc); err != nil {
+			return nil, fmt.Errorf("Task %q: %s", task.Title, err)
+		}
+
+		task.DueDateTime = api.NewDateTimeTimeZone(due)
+	}
+
+	start := due
+	if p, ok := todo.Get("DTSTART"); ok {
+		var err error
+		if start, _, err = ical.ParseTime(p, loc); err != nil {
+			return nil, fmt.Errorf("Task %q: %s", task.Title, err)
+		}
+	}
+
+	if rrule := todo.Value("RRULE"); rrule != "" {
+		// Without a DUE or a DTSTART, the task repeats (and is due) from
+		// today.
+		if start.IsZero() {
+			now := tm.Client.Now().In(loc)
+			start = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+		}
+
+		recurrence, err := rruleToRecurrence(rrule, start)
+		if err != nil {
+			return nil, fmt.Errorf("Task %q: %s", task.Title, err)
+		}
+
+		task.Recurrence = recurrence
+		if task.DueDateTime == nil {
+			task.DueDateTime = api.NewDateTimeTimeZone(start)
+		}
+	}
+
+	for _, alarm := range todo.Children("VALARM") {
+		reminder, ok, err := alarmTime(alarm, start, due, loc)
+		if err != nil {
+			return nil, fmt.Errorf("Task %q: %s", task.Title, err)
+		}
+
+		if ok {
+			task.IsReminderOn = true
+			task.ReminderDateTime = api.NewDateTimeTimeZone(reminder)
+			break
+		}
+	}
+
+	return task, nil
+}
+
+// Returns the time a VALARM goes off at: either its absolute TRIGGER, or its
+// relative one added to the start (or, with RELATED=END, to the due time)
+// of the VTODO. It's not `ok` when the time can't be known.
+func alarmTime(alarm *ical.Component, start, due time.Time, loc *time.Location) (time.Time, bool, error) {
+	trigger, ok := alarm.Get("TRIGGER")
+	if !ok {
+		return time.Time{}, false, nil
+	}
+
+	if trigger.Params["VALUE"] == "DATE-TIME" {
+		t, _, err := ical.ParseTime(trigger, loc)
+		return t, err == nil, err
+	}
+
+	offset, err := ical.ParseDuration(trigger.Value)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	base := start
+	if trigger.Params["RELATED"] == "END" || base.IsZero() {
+		base = due
+	}
+
+	if base.IsZero() {
+		return time.Time{}, false, nil
+	}
+
+	return base.Add(offset), true, nil
+}
+
+// Converts a recurrence to an RRULE value, e.g. `FREQ=WEEKLY;BYDAY=MO,WE`.
+func recurrenceToRRule(r *api.PatternedRecurrence) (string, error) {
+	p := r.Pattern
+	parts := []string{}
+
+	days := []string{}
+	for _, d := range p.DaysOfWeek {
+		days = append(days, weekDayToIcal[strings.ToLower(d)])
+	}
+
+	switch p.Type {
+	case api.RecurrenceDaily:
+		parts = append(parts, "FREQ=DAILY")
+	case api.RecurrenceWeekly:
+		parts = append(parts, "FREQ=WEEKLY", "BYDAY="+strings.Join(days, ","))
+	case api.RecurrenceAbsoluteMonthly:
+		parts = append(parts, "FREQ=MONTHLY", fmt.Sprintf("BYMONTHDAY=%d", p.DayOfMonth))
+	case api.RecurrenceRelativeMonthly:
+		parts = append(parts, "FREQ=MONTHLY", "BYDAY="+strings.Join(days, ","))
+		parts = append(parts, fmt.Sprintf("BYSETPOS=%d", weekIndexToIcal[p.Index]))
+	case api.RecurrenceAbsoluteYearly:
+		parts = append(parts, "FREQ=YEARLY", fmt.Sprintf("BYMONTH=%d", p.Month))
+		parts = append(parts, fmt.Sprintf("BYMONTHDAY=%d", p.DayOfMonth))
+	case api.RecurrenceRelativeYearly:
+		parts = append(parts, "FREQ=YEARLY", fmt.Sprintf("BYMONTH=%d", p.Month))
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+		parts = append(parts, fmt.Sprintf("BYSETPOS=%d", weekIndexToIcal[p.Index]))
+	default:
+		return "", fmt.Errorf("Unknown recurrence type %q", p.Type)
+	}
+
+	if p.Interval > 1 {
+		parts = append(parts, fmt.Sprintf("INTERVAL=%d", p.Interval))
+	}
+
+	if day, ok := weekDayToIcal[strings.ToLower(p.FirstDayOfWeek)]; ok && p.Type == api.RecurrenceWeekly {
+		parts = append(parts, "WKST="+day)
+	}
+
+	switch r.Range.Type {
+	case api.RangeEndDate:
+		until, err := time.Parse(api.RecurrenceDateLayout, r.Range.EndDate)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, "UNTIL="+until.Format(ical.DateLayout))
+	case api.RangeNumbered:
+		parts = append(parts, fmt.Sprintf("COUNT=%d", r.Range.NumberOfOccurrences))
+	}
+
+	return strings.Join(parts, ";"), nil
+}
+
+// Converts an RRULE value to a recurrence starting on `start`. Only the
+// rules the API can express are supported.
+func rruleToRecurrence(rrule string, start time.Time) (*api.PatternedRecurrence, error) {
+	rule := map[string]string{}
+	for _, part := range strings.Split(rrule, ";") {
+		pair := append(strings.SplitN(part, "=", 2), "")
+		rule[strings.ToUpper(pair[0])] = strings.ToUpper(pair[1])
+	}
+
+	if start.IsZero() {
+		start = tm.Client.Now()
+	}
+
+	r := &api.PatternedRecurrence{}
+	p := &r.Pattern
+	p.Interval = 1
+
+	if interval, ok := rule["INTERVAL"]; ok {
+		n, err := strconv.Atoi(interval)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid RRULE interval %q", interval)
+		}
+		p.Interval = n
+	}
+
+	index := rule["BYSETPOS"]
+	days := []string{}
+	for _, d := range strings.Split(rule["BYDAY"], ",") {
+		if d == "" {
+			continue
+		}
+
+		// A day can carry its index, e.g. `-1FR` for the last friday.
+		if len(d) > 2 {
+			index = d[:len(d)-2]
+			d = d[len(d)-2:]
+		}
+
+		day, ok := reverseLookup(weekDayToIcal, d)
+		if !ok {
+			return nil, fmt.Errorf("Invalid RRULE day %q", d)
+		}
+		days = append(days, day)
+	}
+
+	byMonthDay, hasMonthDay := rule["BYMONTHDAY"]
+	weekIndex := ""
+	if index != "" {
+		n, err := strconv.Atoi(strings.TrimPrefix(index, "+"))
+		for name, position := range weekIndexToIcal {
+			if err == nil && position == n {
+				weekIndex = name
+			}
+		}
+
+		if weekIndex == "" {
+			return nil, fmt.Errorf("Unsupported RRULE day position %q", index)
+		}
+	}
+
+	switch rule["FREQ"] {
+	case "DAILY":
+		p.Type = api.RecurrenceDaily
+	case "WEEKLY":
+		p.Type = api.RecurrenceWeekly
+		p.DaysOfWeek = days
+		if len(days) == 0 {
+			p.DaysOfWeek = []string{strings.ToLower(start.Weekday().String())}
+		}
+		if day, ok := reverseLookup(weekDayToIcal, rule["WKST"]); ok {
+			p.FirstDayOfWeek = day
+		}
+	case "MONTHLY", "YEARLY":
+		monthly := rule["FREQ"] == "MONTHLY"
+
+		if len(days) > 0 && !hasMonthDay {
+			p.Type = api.RecurrenceRelativeYearly
+			if monthly {
+				p.Type = api.RecurrenceRelativeMonthly
+			}
+			p.DaysOfWeek = days
+			p.Index = weekIndex
+			if p.Index == "" {
+				p.Index = "first"
+			}
+		} else {
+			p.Type = api.RecurrenceAbsoluteYearly
+			if monthly {
+				p.Type = api.RecurrenceAbsoluteMonthly
+			}
+			p.DayOfMonth = start.Day()
+			if hasMonthDay {
+				day, err := strconv.Atoi(byMonthDay)
+				if err != nil || day < 1 {
+					return nil, fmt.Errorf("Unsupported RRULE month day %q", byMonthDay)
+				}
+				p.DayOfMonth = day
+			}
+		}
+
+		if !monthly {
+			p.Month = int(start.Month())
+			if month, ok := rule["BYMONTH"]; ok {
+				m, err := strconv.Atoi(month)
+				if err != nil {
+					return nil, fmt.Errorf("Unsupported RRULE month %q", month)
+				}
+				p.Month = m
+			}
+		}
+	default:
+		return nil, fmt.Errorf("Unsupported RRULE frequency %q", rule["FREQ"])
+	}
+
+	r.Range.Type = api.RangeNoEnd
+	r.Range.StartDate = start.Format(api.RecurrenceDateLayout)
+
+	if until, ok := rule["UNTIL"]; ok && len(until) >= len(ical.DateLayout) {
+		end, err := time.Parse(ical.DateLayout, until[:len(ical.DateLayout)])
+		if err != nil {
+			return nil, fmt.Errorf("Invalid RRULE until %q", until)
+		}
+		r.Range.Type = api.RangeEndDate
+		r.Range.EndDate = end.Format(api.RecurrenceDateLayout)
+	}
+
+	if count, ok := rule["COUNT"]; ok {
+		n, err := strconv.Atoi(count)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid RRULE count %q", count)
+		}
+		r.Range.Type = api.RangeNumbered
+		r.Range.NumberOfOccurrences = n
+	}
+
+	return r, r.Validate()
+}
+
+// Finds the key of a value in a map.
+func reverseLookup(m map[string]string, value string) (string, bool) {
+	for k, v := range m {
+		if v == value {
+			return k, true
+		}
+	}
+
+	return "", false
+}
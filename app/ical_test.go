@@ -0,0 +1,206 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package app
+
+import (
+	"github.com/betasve/mstd/conf"
+	tm "github.com/betasve/mstd/ext/time"
+	"github.com/betasve/mstd/ext/time/timetest"
+	"github.com/betasve/mstd/ical"
+	api "github.com/betasve/mstd/todoapi"
+	apiTest "github.com/betasve/mstd/todoapi/todoapitest"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRRuleConversions(test *testing.T) {
+	start := time.Date(2021, 5, 3, 0, 0, 0, 0, time.UTC)
+
+	cases := map[string]string{
+		"daily;every=2":               "FREQ=DAILY;INTERVAL=2",
+		"weekly:mon,wed;count=5":      "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=5",
+		"monthly:15;until=2021-12-31": "FREQ=MONTHLY;BYMONTHDAY=15;UNTIL=20211231",
+		"monthly:last-fri":            "FREQ=MONTHLY;BYDAY=FR;BYSETPOS=-1",
+		"yearly:12-25":                "FREQ=YEARLY;BYMONTH=12;BYMONTHDAY=25",
+		"yearly:first-mon-sep":        "FREQ=YEARLY;BYMONTH=9;BYDAY=MO;BYSETPOS=1",
+	}
+
+	for shorthand, expected := range cases {
+		recurrence, err := api.ParseRecurrence(shorthand)
+		if err != nil {
+			test.Fatalf("\nExpected %q to parse\nbut got\n%s", shorthand, err)
+		}
+		recurrence.Range.StartDate = "2021-05-03"
+
+		rrule, err := recurrenceToRRule(recurrence)
+		if err != nil || rrule != expected {
+			test.Errorf("\nExpected %q to be:\n%s\nbut was\n%s, %v", shorthand, expected, rrule, err)
+			continue
+		}
+
+		back, err := rruleToRecurrence(rrule, start)
+		if err != nil {
+			test.Errorf("\nExpected %q to convert back\nbut got\n%s", rrule, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(back.Pattern, recurrence.Pattern) || back.Range != recurrence.Range {
+			test.Errorf("\nExpected %q to convert back to:\n%+v\nbut was\n%+v", rrule, recurrence, back)
+		}
+	}
+
+	if r, err := rruleToRecurrence("FREQ=MONTHLY;BYDAY=-1FR", start); err != nil || r.Pattern.Index != "last" {
+		test.Errorf("\nExpected an indexed day to be read\nbut got\n%+v, %v", r, err)
+	}
+
+	for _, rrule := range []string{"FREQ=HOURLY", "FREQ=MONTHLY;BYDAY=2XX", "FREQ=MONTHLY;BYDAY=MO;BYSETPOS=5"} {
+		if _, err := rruleToRecurrence(rrule, start); err == nil {
+			test.Errorf("\nExpected %q to return error\nbut it was\nnil", rrule)
+		}
+	}
+}
+
+func TestVTodoRoundTrip(test *testing.T) {
+	task := api.TaskItem{
+		Id:           "t1",
+		Title:        "Water plants",
+		Status:       "completed",
+		Importance:   "high",
+		Body:         &api.ItemBody{Content: "The ones, on the balcony", ContentType: "text"},
+		Categories:   []string{"home", "garden"},
+		DueDateTime:  &api.DateTimeTimeZone{DateTime: "2021-05-03T00:00:00.0000000", TimeZone: "UTC"},
+		IsReminderOn: true,
+		ReminderDateTime: &api.DateTimeTimeZone{
+			DateTime: "2021-05-03T09:00:00.0000000",
+			TimeZone: "UTC",
+		},
+	}
+	task.Recurrence, _ = api.ParseRecurrence("weekly:mon")
+	task.Recurrence.Range.StartDate = "2021-05-03"
+
+	todo, err := toVTodo(task, "Home")
+	if err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	for name, expected := range map[string]string{
+		"STATUS":     "COMPLETED",
+		"PRIORITY":   "1",
+		"DUE":        "20210503",
+		"DTSTART":    "20210503",
+		"RRULE":      "FREQ=WEEKLY;BYDAY=MO",
+		"CATEGORIES": "home,garden",
+	} {
+		if todo.Value(name) != expected {
+			test.Errorf("\nExpected %s to be:\n%s\nbut was\n%s", name, expected, todo.Value(name))
+		}
+	}
+
+	back, err := fromVTodo(todo, time.UTC)
+	if err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	if back.Title != task.Title ||
+		back.Status != task.Status ||
+		back.Importance != task.Importance ||
+		back.Body.Content != task.Body.Content ||
+		!reflect.DeepEqual(back.Categories, task.Categories) ||
+		*back.DueDateTime != *task.DueDateTime ||
+		*back.ReminderDateTime != *task.ReminderDateTime ||
+		back.Recurrence.Pattern.DaysOfWeek[0] != "monday" {
+		test.Errorf("\nExpected the task to be read back as:\n%+v\nbut was\n%+v", task, back)
+	}
+}
+
+func TestRRuleWithoutDueStartsToday(test *testing.T) {
+	tm.Client = timetest.TimeMock{}
+	timetest.TimeNowMockFunc = func() time.Time { return time.Date(2021, 5, 3, 15, 4, 5, 0, time.UTC) }
+	defer func() {
+		tm.Client = tm.Time{}
+		timetest.TimeNowMockFunc = time.Now
+	}()
+
+	roots, _ := ical.Parse(strings.NewReader(
+		"BEGIN:VTODO\r\nSUMMARY:Water plants\r\nRRULE:FREQ=WEEKLY;BYDAY=MO\r\nEND:VTODO\r\n",
+	))
+
+	task, err := fromVTodo(roots[0], time.UTC)
+	if err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	if task.Recurrence.Range.StartDate != "2021-05-03" ||
+		task.DueDateTime == nil || task.DueDateTime.DateTime != "2021-05-03T00:00:00.0000000" {
+		test.Errorf("\nExpected the task to repeat and be due from today\nbut was\n%+v, %+v", task.Recurrence.Range, task.DueDateTime)
+	}
+}
+
+func TestRelativeAlarm(test *testing.T) {
+	roots, _ := ical.Parse(strings.NewReader(
+		"BEGIN:VTODO\r\nSUMMARY:Call\r\nDUE:20210503T100000Z\r\n" +
+			"BEGIN:VALARM\r\nTRIGGER;RELATED=END:-PT30M\r\nEND:VALARM\r\nEND:VTODO\r\n",
+	))
+
+	task, err := fromVTodo(roots[0], time.UTC)
+	if err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	if task.ReminderDateTime == nil || task.ReminderDateTime.DateTime != "2021-05-03T09:30:00.0000000" {
+		test.Errorf("\nExpected the reminder 30 minutes before due\nbut was\n%+v", task.ReminderDateTime)
+	}
+}
+
+func TestExportAndImportIcs(test *testing.T) {
+	config = &conf.Config{}
+	apiClient = &apiTest.TodoApiMock{}
+	stubLists()
+
+	apiTest.TasksIndexMockFn = func(l string) (*[]api.TaskItem, error) {
+		return &[]api.TaskItem{{Id: "t1", Title: "Buy milk", Importance: "low"}}, nil
+	}
+
+	path := filepath.Join(test.TempDir(), "tasks.ics")
+	if err := ExportIcs("", path); err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	data, _ := ioutil.ReadFile(path)
+	if !strings.Contains(string(data), "X-MSTD-LIST:Groceries\r\n") {
+		test.Errorf("\nExpected the list of the task to be exported\nbut was\n%s", data)
+	}
+
+	var createdIn string
+	var created *api.TaskItem
+	apiTest.TasksCreateMockFn = func(l string, t *api.TaskItem) (*api.TaskItem, error) {
+		createdIn = l
+		created = t
+		return t, nil
+	}
+
+	if err := ImportIcs(path, "", false); err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	if createdIn != "list-id" || created.Title != "Buy milk" || created.Importance != "low" {
+		test.Errorf("\nExpected the task to be imported into its list\nbut got\n%+v in %s", created, createdIn)
+	}
+}
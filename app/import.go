@@ -0,0 +1,337 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"fmt"
+	"github.com/betasve/mstd/cache"
+	tm "github.com/betasve/mstd/ext/time"
+	api "github.com/betasve/mstd/todoapi"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Maps the columns of the import preview to the attributes of an importRow.
+var importColumnsToKeysMap map[string]string = map[string]string{
+	"list":       "List",
+	"title":      "Title",
+	"status":     "Status",
+	"importance": "Importance",
+	"due":        "Due",
+	"categories": "Categories",
+}
+
+// The headers of the import preview (in the order they are displayed).
+var importHeaders []string = []string{
+	"list", "title", "status", "importance", "due", "categories",
+}
+
+// How an import paces its requests to the API, so that large imports don't
+// get throttled: the tasks are created in batches of `Size` (up to
+// [todoapi.MaxBatchSize]), pausing for `Pause` in between.
+type ImportBatch struct {
+	Size  int
+	Pause time.Duration
+}
+
+// The pacing of the imports that don't set their own.
+var DefaultImportBatch ImportBatch = ImportBatch{Size: 20, Pause: time.Second}
+
+// A task to import, along with the name (or id) of the list it goes into.
+type importItem struct {
+	List string
+	Task *api.TaskItem
+}
+
+// A flattened, printable representation of an importItem.
+type importRow struct {
+	List       string
+	Title      string
+	Status     string
+	Importance string
+	Due        string
+	Categories string
+}
+
+// Creates the tasks of `items` in their lists (paced by `batch`), creating
+// the lists that don't exist yet. With `dryRun` nothing is created, the
+// tasks (and lists) that would be are printed instead.
+func importTasks(items []importItem, dryRun bool, batch ImportBatch) error {
+	names := []string{}
+	for _, item := range items {
+		names = append(names, item.List)
+	}
+
+	ids, missing, err := importListIds(names)
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		printImportPreview(items, missing, 0)
+		return nil
+	}
+
+	if err := createImportLists(ids, missing); err != nil {
+		return err
+	}
+
+	size := batch.Size
+	if size <= 0 || size > api.MaxBatchSize {
+		size = api.MaxBatchSize
+	}
+
+	imported := 0
+	for start := 0; start < len(items); start += size {
+		if start > 0 {
+			if err := tm.Client.Sleep(ctx, batch.Pause); err != nil {
+				return partialf("Imported %d of %d task(s), but %w", imported, len(items), err)
+			}
+		}
+
+		end := start + size
+		if end > len(items) {
+			end = len(items)
+		}
+
+		created, err := createImportBatch(ids, items[start:end])
+		imported += created
+
+		if err != nil {
+			return partialf("Imported %d of %d task(s), but %w", imported, len(items), err)
+		}
+	}
+
+	fmt.Fprintf(
+		os.Stdout,
+		"Imported %d task(s), creating %d list(s)\n",
+		len(items),
+		len(missing),
+	)
+
+	return nil
+}
+
+// Creates a batch of imported tasks in their lists (by `ids`) and returns
+// how many were created, the ones the batch created before failing included.
+// When offline, the rest are created one by one, so that they get queued.
+func createImportBatch(ids map[string]string, items []importItem) (int, error) {
+	requests := []api.BatchRequest{}
+	for i, item := range items {
+		listId := ids[strings.ToLower(item.List)]
+		requests = append(requests, api.NewTaskCreateRequest(strconv.Itoa(i), listId, item.Task))
+	}
+
+	responses, batchErr := apiClient.Batch(ctx, requests)
+
+	created := 0
+	var failure error
+	unsent := []importItem{}
+	for i, item := range items {
+		response, ok := responses[strconv.Itoa(i)]
+
+		var err error
+		switch {
+		case ok:
+			err = response.Check(201)
+		case batchErr != nil:
+			unsent = append(unsent, item)
+			continue
+		default:
+			err = fmt.Errorf("No response")
+		}
+
+		if err == nil {
+			created++
+		} else if failure == nil {
+			failure = fmt.Errorf("could not import %q: %s", item.Task.Title, err)
+		}
+	}
+
+	if len(unsent) == 0 {
+		return created, failure
+	}
+
+	// The rest are created again only when the batch didn't reach the API,
+	// as one that timed out may have created them.
+	if !cache.IsOffline(batchErr) || cache.IsTimeout(batchErr) {
+		return created, fmt.Errorf("could not send them: %s", batchErr)
+	}
+
+	sent, err := createImportedTasks(ids, unsent)
+	if failure == nil {
+		failure = err
+	}
+
+	return created + sent, failure
+}
+
+// Creates imported tasks one at a time and returns how many were created.
+func createImportedTasks(ids map[string]string, items []importItem) (int, error) {
+	for i, item := range items {
+		listId := ids[strings.ToLower(item.List)]
+
+		if _, err := apiClient.TasksCreate(ctx, listId, item.Task); err != nil {
+			return i, fmt.Errorf("could not import %q: %s", item.Task.Title, err)
+		}
+	}
+
+	return len(items), nil
+}
+
+// Maps the (lowercased) names and the ids of the existing lists to their
+// ids and returns the `names` among them that don't exist yet. These map to
+// an empty id, until created by [createImportLists].
+func importListIds(names []string) (map[string]string, []string, error) {
+	apiClient.SetToken(config.ClientAccessToken())
+
+	lists, err := apiClient.ListsIndex(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ids := map[string]string{}
+	for _, l := range *lists {
+		ids[l.Id] = l.Id
+		ids[strings.ToLower(l.Name)] = l.Id
+	}
+
+	missing := []string{}
+	for _, name := range names {
+		key := strings.ToLower(name)
+		if _, ok := ids[key]; !ok {
+			ids[key] = ""
+			missing = append(missing, name)
+		}
+	}
+
+	return ids, missing, nil
+}
+
+// Creates the `missing` lists of an import, adding their ids to `ids`.
+func createImportLists(ids map[string]string, missing []string) error {
+	for _, name := range missing {
+		list, err := apiClient.ListsCreate(ctx, name)
+		if err != nil {
+			return fmt.Errorf("Could not create list %q: %s", name, err)
+		}
+
+		ids[strings.ToLower(name)] = list.Id
+	}
+
+	return nil
+}
+
+// Prints the tasks an import would create, the lists it would create for
+// them and how many of the existing tasks it would update.
+func printImportPreview(items []importItem, missing []string, updated int) {
+	rows := []interface{}{}
+	for _, item := range items {
+		task := newTaskRow(*item.Task)
+
+		rows = append(rows, importRow{
+			List:       item.List,
+			Title:      task.Title,
+			Status:     task.Status,
+			Importance: task.Importance,
+			Due:        task.Due,
+			Categories: strings.Join(item.Task.Categories, ", "),
+		})
+	}
+
+	printTable(rows, []string{"all"}, importHeaders, importColumnsToKeysMap)
+
+	if len(missing) > 0 {
+		fmt.Fprintf(os.Stdout, "Would create the list(s): %s\n", strings.Join(missing, ", "))
+	}
+
+	if updated > 0 {
+		fmt.Fprintf(os.Stdout, "Would update %d existing task(s)\n", updated)
+	}
+
+	fmt.Fprintf(
+		os.Stdout,
+		"Dry run: %d task(s) would be imported, nothing was changed\n",
+		len(items),
+	)
+}
+
+// Reads the file to import. A `-` path reads the standard input.
+func readImportFile(path string) ([]byte, error) {
+	if path == "-" {
+		return ioutil.ReadAll(os.Stdin)
+	}
+
+	return ioutil.ReadFile(path)
+}
+
+// Opens where an export is written: the file at `path`, or the standard
+// output when it's empty or `-`.
+func exportWriter(path string) (io.WriteCloser, error) {
+	if path == "" || path == "-" {
+		return nopCloser{os.Stdout}, nil
+	}
+
+	return os.Create(path)
+}
+
+// A writer that isn't closed (e.g. the standard output).
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
+
+// Retrieves the lists to export (all of them, or only `list` when set) and
+// the tasks of each, by the list id.
+func exportedLists(list string) ([]api.ListsItem, map[string][]api.TaskItem, error) {
+	apiClient.SetToken(config.ClientAccessToken())
+
+	all, err := apiClient.ListsIndex(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	lists := []api.ListsItem{}
+	for _, l := range *all {
+		if list == "" || l.Id == list || strings.EqualFold(l.Name, list) {
+			lists = append(lists, l)
+		}
+	}
+
+	if len(lists) == 0 {
+		return nil, nil, &NotFoundError{Kind: "List", Name: list}
+	}
+
+	tasks := map[string][]api.TaskItem{}
+	for _, l := range lists {
+		listTasks, err := apiClient.TasksIndex(ctx, l.Id)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		tasks[l.Id] = *listTasks
+	}
+
+	return lists, tasks, nil
+}
@@ -0,0 +1,175 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package app
+
+import (
+	"github.com/betasve/mstd/conf"
+	tm "github.com/betasve/mstd/ext/time"
+	"github.com/betasve/mstd/ext/time/timetest"
+	api "github.com/betasve/mstd/todoapi"
+	apiTest "github.com/betasve/mstd/todoapi/todoapitest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func importTestItems(count int) []importItem {
+	items := []importItem{}
+	for i := 0; i < count; i++ {
+		items = append(items, importItem{List: "Groceries", Task: &api.TaskItem{Title: "Task"}})
+	}
+
+	return items
+}
+
+func TestImportTasksInBatches(test *testing.T) {
+	config = &conf.Config{}
+	apiClient = &apiTest.TodoApiMock{}
+	stubLists()
+
+	tm.Client = timetest.TimeMock{}
+	defer func() { tm.Client = tm.Time{} }()
+
+	sleeps := []time.Duration{}
+	timetest.TimeSleepMockFunc = func(d time.Duration) {
+		sleeps = append(sleeps, d)
+	}
+
+	sizes := []int{}
+	apiTest.BatchMockFn = func(r []api.BatchRequest) (map[string]*api.BatchResponse, error) {
+		sizes = append(sizes, len(r))
+
+		responses := map[string]*api.BatchResponse{}
+		for _, request := range r {
+			if request.Method != "POST" || request.Url != "/me/todo/lists/list-id/tasks/" {
+				test.Errorf("\nExpected a task to be created in the list\nbut was\n%s %s", request.Method, request.Url)
+			}
+			responses[request.Id] = &api.BatchResponse{Id: request.Id, Status: 201, Body: []byte(`{}`)}
+		}
+		return responses, nil
+	}
+
+	defer func() { apiTest.BatchMockFn = defaultBatchMockFn }()
+
+	if err := importTasks(importTestItems(45), false, ImportBatch{Size: 50, Pause: time.Second}); err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	if !reflect.DeepEqual(sizes, []int{20, 20, 5}) || len(sleeps) != 2 {
+		test.Errorf("\nExpected batches of up to 20 with a pause in between\nbut were\n%v, %v", sizes, sleeps)
+	}
+}
+
+func TestImportTasksReportsFailures(test *testing.T) {
+	config = &conf.Config{}
+	apiClient = &apiTest.TodoApiMock{}
+	stubLists()
+
+	apiTest.BatchMockFn = func(r []api.BatchRequest) (map[string]*api.BatchResponse, error) {
+		return map[string]*api.BatchResponse{
+			"0": {Id: "0", Status: 201},
+			"1": {Id: "1", Status: 400, Body: []byte(`{"error": {}}`)},
+			"2": {Id: "2", Status: 201},
+		}, nil
+	}
+
+	defer func() { apiTest.BatchMockFn = defaultBatchMockFn }()
+
+	err := importTasks(importTestItems(3), false, DefaultImportBatch)
+
+	if err == nil || !strings.HasPrefix(err.Error(), "Imported 2 of 3 task(s), but could not import \"Task\"") {
+		test.Errorf("\nExpected the failed task to be reported\nbut was\n%v", err)
+	}
+}
+
+func TestImportTasksOffline(test *testing.T) {
+	config = &conf.Config{}
+	apiClient = &apiTest.TodoApiMock{}
+	stubLists()
+
+	apiTest.BatchMockFn = func(r []api.BatchRequest) (map[string]*api.BatchResponse, error) {
+		return nil, &url.Error{Op: "Post", URL: "https://graph.microsoft.com/v1.0/$batch"}
+	}
+
+	defer func() { apiTest.BatchMockFn = defaultBatchMockFn }()
+
+	created := 0
+	apiTest.TasksCreateMockFn = func(l string, t *api.TaskItem) (*api.TaskItem, error) {
+		created++
+		return t, nil
+	}
+
+	if err := importTasks(importTestItems(3), false, DefaultImportBatch); err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	if created != 3 {
+		test.Errorf("\nExpected the tasks to be created one by one\nbut were\n%d", created)
+	}
+}
+
+func TestImportTasksCountsWhatAFailedBatchCreated(test *testing.T) {
+	config = &conf.Config{}
+	apiClient = &apiTest.TodoApiMock{}
+	stubLists()
+
+	apiTest.BatchMockFn = func(r []api.BatchRequest) (map[string]*api.BatchResponse, error) {
+		return map[string]*api.BatchResponse{
+			"0": {Id: "0", Status: 201},
+			"1": {Id: "1", Status: 201},
+		}, &api.ApiError{StatusCode: 500}
+	}
+
+	defer func() { apiTest.BatchMockFn = defaultBatchMockFn }()
+
+	err := importTasks(importTestItems(3), false, DefaultImportBatch)
+
+	if err == nil || !strings.HasPrefix(err.Error(), "Imported 2 of 3 task(s), but could not send them") {
+		test.Errorf("\nExpected the tasks created before the failure to be counted\nbut was\n%v", err)
+	}
+}
+
+func TestImportTasksOfflineHalfway(test *testing.T) {
+	config = &conf.Config{}
+	apiClient = &apiTest.TodoApiMock{}
+	stubLists()
+
+	apiTest.BatchMockFn = func(r []api.BatchRequest) (map[string]*api.BatchResponse, error) {
+		return map[string]*api.BatchResponse{"0": {Id: "0", Status: 201}},
+			&url.Error{Op: "Post", URL: "https://graph.microsoft.com/v1.0/$batch"}
+	}
+
+	defer func() { apiTest.BatchMockFn = defaultBatchMockFn }()
+
+	created := 0
+	apiTest.TasksCreateMockFn = func(l string, t *api.TaskItem) (*api.TaskItem, error) {
+		created++
+		return t, nil
+	}
+
+	if err := importTasks(importTestItems(3), false, DefaultImportBatch); err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	if created != 2 {
+		test.Errorf("\nExpected only the tasks the batch didn't create to be created one by one\nbut were\n%d", created)
+	}
+}
+
+// The batch mock the rest of the tests rely on.
+var defaultBatchMockFn = apiTest.BatchMockFn
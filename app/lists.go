@@ -47,17 +47,33 @@ var ListItemHeaders []string = func(m map[string]string) []string {
 }(ColumnsToKeysMap)
 
 // Prints a formatted table with all the lists, contains only the columns,
-// listed in the `columns []string`.
-func ListsIndex(columns []string) error {
+// listed in the `columns []string`. When any of the `query` options are set,
+// only the lists (and the columns) they ask for are retrieved.
+func ListsIndex(query QueryOptions, columns []string) error {
 	apiClient.SetToken(config.ClientAccessToken())
 
-	lists, err := apiClient.ListsIndex()
+	if !query.isSet() {
+		lists, err := apiClient.ListsIndex(ctx)
 
+		if err != nil {
+			return err
+		}
+
+		printResults(lists, columns)
+		return nil
+	}
+
+	q, err := buildQuery(query, listColumnsToFields)
+	if err != nil {
+		return err
+	}
+
+	result, err := apiClient.ListsQuery(ctx, q)
 	if err != nil {
 		return err
 	}
 
-	printResults(lists, columns)
+	printResults(&result.Lists, query.columns(columns))
 	return nil
 }
 
@@ -65,7 +81,7 @@ func ListsIndex(columns []string) error {
 // list of columns mentioned in the `columns []string`.
 func ListsCreate(name string, columns []string) error {
 	apiClient.SetToken(config.ClientAccessToken())
-	newList, err := apiClient.ListsCreate(name)
+	newList, err := apiClient.ListsCreate(ctx, name)
 
 	if err != nil {
 		return err
@@ -82,7 +98,7 @@ func ListsCreate(name string, columns []string) error {
 // default list)
 func ListsUpdate(id, name string, columns []string) error {
 	apiClient.SetToken(config.ClientAccessToken())
-	list, err := apiClient.ListsUpdate(id, name)
+	list, err := apiClient.ListsUpdate(ctx, id, name)
 
 	if err != nil {
 		return err
@@ -95,16 +111,61 @@ func ListsUpdate(id, name string, columns []string) error {
 
 // With the received params [ListItem]s and columns it renders a table with
 // the `columns` as headers of the table, and each ListItem's attributes for
-// that column.
+// that column. With `--output json` the lists are printed as JSON instead.
 func printResults(lists *[]api.ListsItem, columns []string) {
-	columnsToHeaders := columnsHeadersIntersection(columns)
-	keys := columnsToKeys(columnsToHeaders, ColumnsToKeysMap)
+	if Output == OutputJson {
+		printJson(os.Stdout, lists)
+		return
+	}
+
+	rows := []interface{}{}
+	for _, item := range *lists {
+		rows = append(rows, item)
+	}
+
+	printTable(rows, columns, ListItemHeaders, ColumnsToKeysMap)
+}
+
+// Renders a table with the intersection of `columns` and `headers` as its
+// headers, and each row's attributes (found through `columnsKeysMap`) as its
+// cells.
+func printTable(
+	rows []interface{},
+	columns, headers []string,
+	columnsKeysMap map[string]string,
+) {
+	printHighlightedTable(rows, nil, columns, headers, columnsKeysMap)
+}
+
+// Does the same as `printTable`, but prints each row in the (tablewriter)
+// color at the same index in `colors`. Rows without a color (`0`) are
+// printed as usual.
+func printHighlightedTable(
+	rows []interface{},
+	colors []int,
+	columns, headers []string,
+	columnsKeysMap map[string]string,
+) {
+	columnsToHeaders := columnsHeadersIntersection(columns, headers)
+	keys := columnsToKeys(columnsToHeaders, columnsKeysMap)
 
 	table := tablewriter.NewWriter(os.Stdout)
 	table.SetHeader(columnsToHeaders)
 
-	for _, item := range *lists {
-		table.Append(listStrValuesForKeys(item, keys))
+	for i, item := range rows {
+		values := listStrValuesForKeys(item, keys)
+
+		if i >= len(colors) || colors[i] == 0 {
+			table.Append(values)
+			continue
+		}
+
+		rowColors := make([]tablewriter.Colors, len(values))
+		for j := range rowColors {
+			rowColors[j] = tablewriter.Colors{colors[i]}
+		}
+
+		table.Rich(values, rowColors)
 	}
 
 	table.Render()
@@ -119,20 +180,20 @@ func boolToStr(b bool) string {
 	}
 }
 
-// Filters the original list of [ListItemHeaders] columns with the ones passed
-// in `columns`. Or returns all of them if `columns` is `[]string{"all"}`.
-func columnsHeadersIntersection(columns []string) []string {
+// Filters the original list of `headers` with the ones passed in `columns`.
+// Or returns all of them if `columns` is `[]string{"all"}`.
+func columnsHeadersIntersection(columns, headers []string) []string {
 	if len(columns) == 1 &&
 		columns[0] == "all" {
-		return ListItemHeaders
+		return headers
 	}
 
 	result := []string{}
 
-	headers := strings.Join(ListItemHeaders, ",")
+	joinedHeaders := strings.Join(headers, ",")
 
 	for _, c := range columns {
-		if strings.Contains(headers, c) {
+		if strings.Contains(joinedHeaders, c) {
 			result = append(result, c)
 		}
 	}
@@ -155,13 +216,13 @@ func columnsToKeys(columns []string, columnsKeysMap map[string]string) []string
 	return resKeys
 }
 
-// Composes a `[]string` of the values for a particular `ListItem` base on it
-// and a list of its keys that are requested.
-func listStrValuesForKeys(listItem api.ListsItem, keys []string) []string {
+// Composes a `[]string` of the values for a particular item (e.g. `ListItem`)
+// base on it and a list of its keys that are requested.
+func listStrValuesForKeys(item interface{}, keys []string) []string {
 	row := []string{}
 
 	for _, k := range keys {
-		val := reflect.ValueOf(listItem).FieldByName(k)
+		val := reflect.ValueOf(item).FieldByName(k)
 		switch val.Kind() {
 		case reflect.Bool:
 			row = append(row, boolToStr(val.Interface().(bool)))
@@ -38,7 +38,7 @@ func TestListIndex(test *testing.T) {
 		}, nil
 	}
 	apiClient = &apiTest.TodoApiMock{}
-	err := ListsIndex([]string{"display name"})
+	err := ListsIndex(QueryOptions{}, []string{"display name"})
 	test.Log(err)
 	if err != nil {
 		test.Error(err)
@@ -28,7 +28,7 @@ var creds login.Creds
 
 // Facilitates the login procedure for the app. Mainly - reads credentials from
 // the config file and saves them in a place they can be easily accessed.
-func Login() {
+func Login() error {
 	creds = login.Creds{}
 
 	creds.SetAuthCallbackHost(config.AuthCallbackHost())
@@ -43,9 +43,7 @@ func Login() {
 	creds.SetLoginDataCallbackFn(writeDataToConfigFile)
 	creds.SetLoginUrlHandlerFn(openLoginUrl)
 
-	if err := creds.PerformLogin(); err != nil {
-		log.Client.Fatal(err)
-	}
+	return creds.PerformLogin(ctx)
 }
 
 // Checks if the user needs to be logged in (again) or his current session is
@@ -54,6 +52,22 @@ func LoginNeeded() bool {
 	return creds.LoginNeeded()
 }
 
+// Logs the user in again once the session expires, for the long running
+// commands (e.g. `watch` and `shell`) that outlive it.
+func refreshLogin() error {
+	if LoginNeeded() {
+		log.Client.Debug("The session expired, logging in again")
+
+		if err := Login(); err != nil {
+			return err
+		}
+	}
+
+	apiClient.SetToken(config.ClientAccessToken())
+
+	return nil
+}
+
 // Writes data to the config file for the app.
 // TODO: Update tests by covering the api token setup
 func writeDataToConfigFile(a *login.AuthData) error {
@@ -78,7 +92,7 @@ func writeDataToConfigFile(a *login.AuthData) error {
 		return err
 	}
 
-	log.Client.Println("Logged in successfully.\nPlease Ctr+C to exit.")
+	log.Client.Info("Logged in successfully")
 	return nil
 }
 
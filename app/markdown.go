@@ -0,0 +1,323 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/betasve/mstd/markdown"
+	api "github.com/betasve/mstd/todoapi"
+	"os"
+	"strings"
+)
+
+// A change a Markdown import makes: a task to create, or an existing task
+// whose status and checklist items to bring in line with the file.
+type markdownChange struct {
+	List     string
+	Task     markdown.Task
+	Existing *api.TaskItem
+	// The status to set on the existing task, empty when it's unchanged.
+	Status string
+	// The checklist items to add to the (new or existing) task.
+	Items []markdown.Item
+}
+
+// Imports the checklists of a Markdown file. The tasks under a `## Heading`
+// go into the list named after it, the ones outside of a heading into
+// `list`. Importing is idempotent: a task already in its list (matched by its
+// case insensitive title) isn't created again, only its status is updated
+// and the checklist items it lacks are added to it.
+func ImportMarkdown(path, list string, dryRun bool) error {
+	data, err := readImportFile(path)
+	if err != nil {
+		return err
+	}
+
+	lists, err := markdown.Parse(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	names := []string{}
+	for i, l := range lists {
+		if l.Name == "" {
+			if list == "" {
+				return invalidf(
+					"Task %q is not under a heading, set the list it goes into with --list",
+					l.Tasks[0].Title,
+				)
+			}
+
+			lists[i].Name = list
+		}
+
+		names = append(names, lists[i].Name)
+	}
+
+	ids, missing, err := importListIds(names)
+	if err != nil {
+		return err
+	}
+
+	changes, err := planMarkdownImport(lists, ids)
+	if err != nil {
+		return err
+	}
+
+	created := []importItem{}
+	updated := 0
+	for _, change := range changes {
+		if change.Existing == nil {
+			created = append(created, importItem{List: change.List, Task: newMarkdownTask(change.Task)})
+		} else if change.Status != "" || len(change.Items) > 0 {
+			updated++
+		}
+	}
+
+	if dryRun {
+		printImportPreview(created, missing, updated)
+		return nil
+	}
+
+	if err := createImportLists(ids, missing); err != nil {
+		return err
+	}
+
+	for i, change := range changes {
+		if err := applyMarkdownChange(change, ids[strings.ToLower(change.List)]); err != nil {
+			return partialf(
+				"Imported %d of %d task(s), then could not import %q: %w",
+				i,
+				len(changes),
+				change.Task.Title,
+				err,
+			)
+		}
+	}
+
+	fmt.Fprintf(
+		os.Stdout,
+		"Imported %d task(s), updated %d, skipped %d, creating %d list(s)\n",
+		len(created),
+		updated,
+		len(changes)-len(created)-updated,
+		len(missing),
+	)
+
+	return nil
+}
+
+// Exports the tasks of all the lists (or only `list`, when set) as Markdown
+// checklists to the file at `path` (or the standard output), with the
+// checklist items of each task nested under it.
+func ExportMarkdown(list, path string) error {
+	lists, tasks, err := exportedLists(list)
+	if err != nil {
+		return err
+	}
+
+	checklists := []markdown.List{}
+	for _, l := range lists {
+		checklist := markdown.List{Name: l.Name}
+
+		for _, t := range tasks[l.Id] {
+			items, err := apiClient.ChecklistItemsIndex(ctx, l.Id, t.Id)
+			if err != nil {
+				return err
+			}
+
+			checklist.Tasks = append(checklist.Tasks, toMarkdownTask(t, *items))
+		}
+
+		checklists = append(checklists, checklist)
+	}
+
+	out, err := exportWriter(path)
+	if err != nil {
+		return err
+	}
+
+	defer out.Close()
+
+	return markdown.Write(out, checklists)
+}
+
+// Works out the changes importing the checklists makes, matching their tasks
+// to the ones already in the (existing) lists. A task appearing more than
+// once in a list is imported once, with the items of all its appearances.
+func planMarkdownImport(lists []markdown.List, ids map[string]string) ([]markdownChange, error) {
+	changes := []markdownChange{}
+	planned := map[string]int{}
+
+	for _, l := range lists {
+		listId := ids[strings.ToLower(l.Name)]
+
+		existing := map[string]api.TaskItem{}
+		if listId != "" {
+			tasks, err := apiClient.TasksIndex(ctx, listId)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, t := range *tasks {
+				existing[strings.ToLower(t.Title)] = t
+			}
+		}
+
+		for _, t := range l.Tasks {
+			key := strings.ToLower(l.Name) + "\n" + strings.ToLower(t.Title)
+
+			if i, ok := planned[key]; ok {
+				changes[i].Items = appendMissingItems(changes[i].Items, t.Items)
+				continue
+			}
+
+			change := markdownChange{List: l.Name, Task: t, Items: appendMissingItems(nil, t.Items)}
+			if match, ok := existing[strings.ToLower(t.Title)]; ok {
+				change.Existing = &match
+			}
+
+			planned[key] = len(changes)
+			changes = append(changes, change)
+		}
+	}
+
+	for i, change := range changes {
+		if change.Existing == nil {
+			continue
+		}
+
+		listId := ids[strings.ToLower(change.List)]
+		if err := diffMarkdownTask(&changes[i], listId); err != nil {
+			return nil, err
+		}
+	}
+
+	return changes, nil
+}
+
+// Sets the status change of an existing task, and keeps only the checklist
+// items it doesn't have yet.
+func diffMarkdownTask(change *markdownChange, listId string) error {
+	if completed := change.Existing.Status == "completed"; completed != change.Task.Done {
+		change.Status = markdownStatus(change.Task.Done)
+	}
+
+	if len(change.Items) == 0 {
+		return nil
+	}
+
+	items, err := apiClient.ChecklistItemsIndex(ctx, listId, change.Existing.Id)
+	if err != nil {
+		return err
+	}
+
+	have := []markdown.Item{}
+	for _, item := range *items {
+		have = append(have, markdown.Item{Title: item.DisplayName})
+	}
+
+	change.Items = appendMissingItems(have, change.Items)[len(have):]
+	return nil
+}
+
+// Creates the task of a change (or updates the existing one) and adds its
+// checklist items to it.
+func applyMarkdownChange(change markdownChange, listId string) error {
+	taskId := ""
+
+	if change.Existing == nil {
+		task, err := apiClient.TasksCreate(ctx, listId, newMarkdownTask(change.Task))
+		if err != nil {
+			return err
+		}
+
+		taskId = task.Id
+	} else {
+		taskId = change.Existing.Id
+
+		if change.Status != "" {
+			_, err := apiClient.TasksUpdate(ctx, listId, taskId, &api.TaskItem{Status: change.Status})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, item := range change.Items {
+		_, err := apiClient.ChecklistItemsCreate(
+			ctx,
+			listId,
+			taskId,
+			&api.ChecklistItem{DisplayName: item.Title, IsChecked: item.Done},
+		)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Appends the `items` whose (case insensitive) titles aren't in `to` yet.
+func appendMissingItems(to, items []markdown.Item) []markdown.Item {
+	seen := map[string]bool{}
+	for _, item := range to {
+		seen[strings.ToLower(item.Title)] = true
+	}
+
+	for _, item := range items {
+		key := strings.ToLower(item.Title)
+		if !seen[key] {
+			seen[key] = true
+			to = append(to, item)
+		}
+	}
+
+	return to
+}
+
+// Converts a Markdown task to a TaskItem (without its checklist items).
+func newMarkdownTask(t markdown.Task) *api.TaskItem {
+	task := &api.TaskItem{Title: t.Title}
+	if t.Done {
+		task.Status = markdownStatus(true)
+	}
+
+	return task
+}
+
+// Converts a TaskItem and its checklist items to a Markdown task.
+func toMarkdownTask(t api.TaskItem, items []api.ChecklistItem) markdown.Task {
+	task := markdown.Task{Title: t.Title, Done: t.Status == "completed"}
+
+	for _, item := range items {
+		task.Items = append(task.Items, markdown.Item{Title: item.DisplayName, Done: item.IsChecked})
+	}
+
+	return task
+}
+
+// Returns the status of a task that's checked (or not) in a checklist.
+func markdownStatus(done bool) string {
+	if done {
+		return "completed"
+	}
+
+	return "notStarted"
+}
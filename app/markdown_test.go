@@ -0,0 +1,140 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package app
+
+import (
+	"github.com/betasve/mstd/conf"
+	api "github.com/betasve/mstd/todoapi"
+	apiTest "github.com/betasve/mstd/todoapi/todoapitest"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+const meetingNotes string = `# Sync
+
+## Groceries
+
+- [x] Buy milk
+  - [ ] Whole
+  - [ ] Skimmed
+- [ ] Eggs
+- [ ] buy milk
+  - [ ] Oat
+
+## Work
+
+- [ ] Send the report
+`
+
+func TestImportMarkdownMatchesExistingTasks(test *testing.T) {
+	config = &conf.Config{}
+	apiClient = &apiTest.TodoApiMock{}
+	stubLists()
+
+	apiTest.TasksIndexMockFn = func(l string) (*[]api.TaskItem, error) {
+		return &[]api.TaskItem{{Id: "t1", Title: "Buy Milk", Status: "notStarted"}}, nil
+	}
+
+	apiTest.ChecklistItemsIndexMockFn = func(l, t string) (*[]api.ChecklistItem, error) {
+		return &[]api.ChecklistItem{{Id: "c1", DisplayName: "whole"}}, nil
+	}
+
+	apiTest.ListsCreateMockFn = func(n string) (*api.ListsItem, error) {
+		return &api.ListsItem{Id: "work-id", Name: n}, nil
+	}
+
+	created := map[string]string{}
+	apiTest.TasksCreateMockFn = func(l string, t *api.TaskItem) (*api.TaskItem, error) {
+		created[t.Title] = l
+		return &api.TaskItem{Id: "new-" + t.Title, Title: t.Title}, nil
+	}
+
+	updates := map[string]string{}
+	apiTest.TasksUpdateMockFn = func(l, i string, t *api.TaskItem) (*api.TaskItem, error) {
+		updates[i] = t.Status
+		return t, nil
+	}
+
+	items := map[string][]string{}
+	apiTest.ChecklistItemsCreateMockFn = func(l, t string, c *api.ChecklistItem) (*api.ChecklistItem, error) {
+		items[t] = append(items[t], c.DisplayName)
+		return c, nil
+	}
+
+	path := filepath.Join(test.TempDir(), "notes.md")
+	_ = ioutil.WriteFile(path, []byte(meetingNotes), 0600)
+
+	if err := ImportMarkdown(path, "", false); err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	if len(created) != 2 || created["Eggs"] != "list-id" || created["Send the report"] != "work-id" {
+		test.Errorf("\nExpected only the new tasks to be created\nbut were\n%v", created)
+	}
+
+	if len(updates) != 1 || updates["t1"] != "completed" {
+		test.Errorf("\nExpected the existing task to be completed\nbut the updates were\n%v", updates)
+	}
+
+	if len(items) != 1 || len(items["t1"]) != 2 || items["t1"][0] != "Skimmed" || items["t1"][1] != "Oat" {
+		test.Errorf("\nExpected only the missing items to be added\nbut were\n%v", items)
+	}
+}
+
+func TestImportMarkdownWithoutList(test *testing.T) {
+	config = &conf.Config{}
+	apiClient = &apiTest.TodoApiMock{}
+
+	path := filepath.Join(test.TempDir(), "notes.md")
+	_ = ioutil.WriteFile(path, []byte("- [ ] Loose task\n"), 0600)
+
+	if err := ImportMarkdown(path, "", true); err == nil {
+		test.Error("\nExpected to return error\nbut it was\nnil")
+	}
+}
+
+func TestExportMarkdown(test *testing.T) {
+	config = &conf.Config{}
+	apiClient = &apiTest.TodoApiMock{}
+	stubLists()
+
+	apiTest.TasksIndexMockFn = func(l string) (*[]api.TaskItem, error) {
+		return &[]api.TaskItem{
+			{Id: "t1", Title: "Buy milk"},
+			{Id: "t2", Title: "Eggs", Status: "completed"},
+		}, nil
+	}
+
+	apiTest.ChecklistItemsIndexMockFn = func(l, t string) (*[]api.ChecklistItem, error) {
+		if t == "t1" {
+			return &[]api.ChecklistItem{{DisplayName: "Whole", IsChecked: true}}, nil
+		}
+		return &[]api.ChecklistItem{}, nil
+	}
+
+	path := filepath.Join(test.TempDir(), "tasks.md")
+	if err := ExportMarkdown("", path); err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	expected := "## Groceries\n\n- [ ] Buy milk\n  - [x] Whole\n- [x] Eggs\n"
+	data, _ := ioutil.ReadFile(path)
+
+	if string(data) != expected {
+		test.Errorf("\nExpected the export to be:\n%s\nbut was\n%s", expected, data)
+	}
+}
@@ -0,0 +1,214 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"fmt"
+	api "github.com/betasve/mstd/todoapi"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Moves a task (found by its id) from a list to another one (both found by
+// their ids or names) and prints it. With `keep` the task is only copied.
+func TasksMove(list, taskId, to string, keep bool, columns []string) error {
+	apiClient.SetToken(config.ClientAccessToken())
+
+	fromListId, err := resolveListId(list)
+	if err != nil {
+		return err
+	}
+
+	toListId, err := resolveListId(to)
+	if err != nil {
+		return err
+	}
+
+	if fromListId == toListId {
+		return invalidf("The task is already in %q", to)
+	}
+
+	var moved *api.TaskItem
+	if keep {
+		moved, err = copyTask(fromListId, taskId, toListId)
+	} else {
+		moved, err = moveTask(fromListId, taskId, toListId)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	printTasks(&[]api.TaskItem{*moved}, columns)
+	return nil
+}
+
+// Moves a task to another list. The API can't move tasks, so it's copied
+// (see copyTask) to the target list and then deleted from the source list.
+// When it can't be deleted, the copy is removed, leaving things as they were.
+func moveTask(fromListId, taskId, toListId string) (*api.TaskItem, error) {
+	moved, err := copyTask(fromListId, taskId, toListId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := apiClient.TasksDelete(ctx, fromListId, taskId); err != nil {
+		return nil, rollbackCopy(toListId, moved.Id, "delete the original", err)
+	}
+
+	return moved, nil
+}
+
+// Copies a task, with its checklist items and linked resources, to another
+// list and checks the copy reads back the same as the original. When any of
+// it fails, the (partial) copy is removed.
+func copyTask(fromListId, taskId, toListId string) (*api.TaskItem, error) {
+	task, err := apiClient.TasksShow(ctx, fromListId, taskId)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := apiClient.ChecklistItemsIndex(ctx, fromListId, taskId)
+	if err != nil {
+		return nil, err
+	}
+
+	resources, err := apiClient.LinkedResourcesIndex(ctx, fromListId, taskId)
+	if err != nil {
+		return nil, err
+	}
+
+	copied, err := apiClient.TasksCreate(ctx, toListId, copyableTask(*task))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := addTaskParts(toListId, copied.Id, *items, *resources); err != nil {
+		return nil, rollbackCopy(toListId, copied.Id, "copy the task", err)
+	}
+
+	if err := verifyCopy(*task, *items, *resources, toListId, copied.Id); err != nil {
+		return nil, rollbackCopy(toListId, copied.Id, "verify the copy", err)
+	}
+
+	return copied, nil
+}
+
+// Adds the checklist items and linked resources (of another task) to a task.
+func addTaskParts(listId, taskId string, items []api.ChecklistItem, resources []api.LinkedResource) error {
+	for _, item := range items {
+		item.Id = ""
+		item.CreatedDateTime = ""
+		item.CheckedDateTime = ""
+
+		if _, err := apiClient.ChecklistItemsCreate(ctx, listId, taskId, &item); err != nil {
+			return err
+		}
+	}
+
+	for _, resource := range resources {
+		resource.Id = ""
+
+		if _, err := apiClient.LinkedResourcesCreate(ctx, listId, taskId, &resource); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Reads a copy back and compares it to the original task, returning which
+// of its attributes differ.
+func verifyCopy(
+	task api.TaskItem,
+	items []api.ChecklistItem,
+	resources []api.LinkedResource,
+	listId, copyId string,
+) error {
+	copied, err := apiClient.TasksShow(ctx, listId, copyId)
+	if err != nil {
+		return err
+	}
+
+	copiedItems, err := apiClient.ChecklistItemsIndex(ctx, listId, copyId)
+	if err != nil {
+		return err
+	}
+
+	copiedResources, err := apiClient.LinkedResourcesIndex(ctx, listId, copyId)
+	if err != nil {
+		return err
+	}
+
+	differs := []string{}
+	for name, same := range map[string]bool{
+		"title":            task.Title == copied.Title,
+		"status":           task.Status == copied.Status,
+		"importance":       task.Importance == copied.Importance,
+		"note":             taskNote(&task) == taskNote(copied),
+		"categories":       strings.Join(task.Categories, "\n") == strings.Join(copied.Categories, "\n"),
+		"due date":         sameDateTime(task.DueDateTime, copied.DueDateTime),
+		"reminder":         sameDateTime(task.ReminderDateTime, copied.ReminderDateTime),
+		"recurrence":       reflect.DeepEqual(task.Recurrence, copied.Recurrence),
+		"checklist items":  len(items) == len(*copiedItems),
+		"linked resources": len(resources) == len(*copiedResources),
+	} {
+		if !same {
+			differs = append(differs, name)
+		}
+	}
+
+	if len(differs) > 0 {
+		sort.Strings(differs)
+		return fmt.Errorf("The copy of %q differs in its %s", task.Title, strings.Join(differs, ", "))
+	}
+
+	return nil
+}
+
+// Tells if two dates are the same moment (they may come back in another
+// time zone).
+func sameDateTime(a, b *api.DateTimeTimeZone) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	at, aErr := a.Time()
+	bt, bErr := b.Time()
+	if aErr != nil || bErr != nil {
+		return *a == *b
+	}
+
+	return at.Equal(bt)
+}
+
+// Removes a copy after a step of copying (or moving) a task failed. When
+// even that fails, the copy is left behind and a PartialError says so.
+func rollbackCopy(listId, copyId, step string, err error) error {
+	if rollbackErr := apiClient.TasksDelete(ctx, listId, copyId); rollbackErr != nil {
+		return partialf(
+			"Could not %s (%s), and the copy %s could not be removed: %w",
+			step,
+			err,
+			copyId,
+			rollbackErr,
+		)
+	}
+
+	return fmt.Errorf("Could not %s, the copy was removed: %w", step, err)
+}
@@ -0,0 +1,190 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package app
+
+import (
+	"errors"
+	api "github.com/betasve/mstd/todoapi"
+	apiTest "github.com/betasve/mstd/todoapi/todoapitest"
+	"strings"
+	"testing"
+)
+
+// An in-memory account for the moving tests, keyed by `list/task`.
+type moveAccount struct {
+	tasks     map[string]api.TaskItem
+	items     map[string][]api.ChecklistItem
+	resources map[string][]api.LinkedResource
+	deleted   []string
+}
+
+func stubMoveAccount() *moveAccount {
+	apiClient = &apiTest.TodoApiMock{}
+	a := &moveAccount{
+		tasks: map[string]api.TaskItem{
+			"from/t1": {
+				Id:              "t1",
+				Title:           "report",
+				Importance:      "high",
+				Categories:      []string{"Work"},
+				Body:            &api.ItemBody{Content: "draft first", ContentType: "text"},
+				DueDateTime:     &api.DateTimeTimeZone{DateTime: "2021-05-03T00:00:00.0000000", TimeZone: "UTC"},
+				CreatedDateTime: "2021-05-01",
+			},
+		},
+		items:     map[string][]api.ChecklistItem{"from/t1": {{Id: "i1", DisplayName: "outline", IsChecked: true}}},
+		resources: map[string][]api.LinkedResource{"from/t1": {{Id: "r1", WebUrl: "https://example.com"}}},
+	}
+
+	apiTest.TasksShowMockFn = func(l, i string) (*api.TaskItem, error) {
+		task, ok := a.tasks[l+"/"+i]
+		if !ok {
+			return nil, errors.New("Not found")
+		}
+		return &task, nil
+	}
+	apiTest.TasksCreateMockFn = func(l string, t *api.TaskItem) (*api.TaskItem, error) {
+		t.Id = "copy"
+		a.tasks[l+"/copy"] = *t
+		return t, nil
+	}
+	apiTest.TasksDeleteMockFn = func(l, i string) error {
+		a.deleted = append(a.deleted, l+"/"+i)
+		delete(a.tasks, l+"/"+i)
+		return nil
+	}
+	apiTest.ChecklistItemsIndexMockFn = func(l, t string) (*[]api.ChecklistItem, error) {
+		items := a.items[l+"/"+t]
+		return &items, nil
+	}
+	apiTest.ChecklistItemsCreateMockFn = func(l, t string, c *api.ChecklistItem) (*api.ChecklistItem, error) {
+		a.items[l+"/"+t] = append(a.items[l+"/"+t], *c)
+		return c, nil
+	}
+	apiTest.LinkedResourcesIndexMockFn = func(l, t string) (*[]api.LinkedResource, error) {
+		resources := a.resources[l+"/"+t]
+		return &resources, nil
+	}
+	apiTest.LinkedResourcesCreateMockFn = func(l, t string, r *api.LinkedResource) (*api.LinkedResource, error) {
+		a.resources[l+"/"+t] = append(a.resources[l+"/"+t], *r)
+		return r, nil
+	}
+
+	return a
+}
+
+func TestMoveTask(test *testing.T) {
+	a := stubMoveAccount()
+
+	moved, err := moveTask("from", "t1", "to")
+	if err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	copied := a.tasks["to/copy"]
+	if moved.Id != "copy" || copied.Title != "report" || copied.CreatedDateTime != "" {
+		test.Errorf("\nExpected a copy without its dates to be created in `to`\nbut got\n%+v", copied)
+	}
+
+	items := a.items["to/copy"]
+	if len(items) != 1 || items[0].Id != "" || !items[0].IsChecked || len(a.resources["to/copy"]) != 1 {
+		test.Errorf("\nExpected the checklist items and linked resources to be copied\nbut got\n%+v", items)
+	}
+
+	if len(a.deleted) != 1 || a.deleted[0] != "from/t1" {
+		test.Errorf("\nExpected the original to be deleted\nbut deleted\n%v", a.deleted)
+	}
+}
+
+func TestCopyTaskKeepsTheOriginal(test *testing.T) {
+	a := stubMoveAccount()
+
+	if _, err := copyTask("from", "t1", "to"); err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	if _, ok := a.tasks["from/t1"]; !ok || len(a.deleted) != 0 {
+		test.Errorf("\nExpected the original to be kept\nbut deleted\n%v", a.deleted)
+	}
+}
+
+func TestMoveTaskRollsBackAFailedCopy(test *testing.T) {
+	a := stubMoveAccount()
+	apiTest.LinkedResourcesCreateMockFn = func(l, t string, r *api.LinkedResource) (*api.LinkedResource, error) {
+		return nil, errors.New("Service unavailable")
+	}
+
+	_, err := moveTask("from", "t1", "to")
+	if err == nil || !strings.Contains(err.Error(), "the copy was removed") {
+		test.Fatalf("\nExpected the copy to be rolled back\nbut got\n%v", err)
+	}
+
+	if len(a.deleted) != 1 || a.deleted[0] != "to/copy" {
+		test.Errorf("\nExpected only the copy to be deleted\nbut deleted\n%v", a.deleted)
+	}
+}
+
+func TestMoveTaskRollsBackAMismatchingCopy(test *testing.T) {
+	a := stubMoveAccount()
+	apiTest.TasksCreateMockFn = func(l string, t *api.TaskItem) (*api.TaskItem, error) {
+		t.Id = "copy"
+		t.Importance = "normal"
+		a.tasks[l+"/copy"] = *t
+		return t, nil
+	}
+
+	_, err := moveTask("from", "t1", "to")
+	if err == nil || !strings.Contains(err.Error(), "differs in its importance") {
+		test.Fatalf("\nExpected the copy to differ in its importance\nbut got\n%v", err)
+	}
+
+	if _, ok := a.tasks["to/copy"]; ok {
+		test.Error("\nExpected the copy to be removed\nbut it was\nkept")
+	}
+}
+
+func TestMoveTaskRollsBackWhenTheOriginalStays(test *testing.T) {
+	a := stubMoveAccount()
+	apiTest.TasksDeleteMockFn = func(l, i string) error {
+		if l == "from" {
+			return errors.New("Forbidden")
+		}
+
+		a.deleted = append(a.deleted, l+"/"+i)
+		return nil
+	}
+
+	_, err := moveTask("from", "t1", "to")
+	if err == nil || ExitCode(err) == ExitPartialFailure {
+		test.Fatalf("\nExpected a failure without partial changes\nbut got\n%v", err)
+	}
+
+	if len(a.deleted) != 1 || a.deleted[0] != "to/copy" {
+		test.Errorf("\nExpected the copy to be deleted\nbut deleted\n%v", a.deleted)
+	}
+}
+
+func TestMoveTaskFailedRollback(test *testing.T) {
+	stubMoveAccount()
+	apiTest.TasksDeleteMockFn = func(l, i string) error {
+		return errors.New("Forbidden")
+	}
+
+	_, err := moveTask("from", "t1", "to")
+	if ExitCode(err) != ExitPartialFailure || !strings.Contains(err.Error(), "the copy copy could not be removed") {
+		test.Errorf("\nExpected a partial failure leaving the copy\nbut got\n%v", err)
+	}
+}
@@ -0,0 +1,151 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	api "github.com/betasve/mstd/todoapi"
+	"strings"
+)
+
+// Maps the task columns we display in the CLI to the attributes of a task
+// in the API, for choosing (`--fields`) and ordering (`--sort`) them.
+var taskColumnsToFields map[string]string = map[string]string{
+	"title":      "title",
+	"status":     "status",
+	"importance": "importance",
+	"due":        "dueDateTime",
+	"reminder":   "reminderDateTime",
+	"repeat":     "recurrence",
+	"id":         "id",
+}
+
+// Maps the list columns we display in the CLI to the attributes of a list
+// in the API.
+var listColumnsToFields map[string]string = map[string]string{
+	"display name": "displayName",
+	"shared":       "isShared",
+	"owner":        "isOwner",
+	"system name":  "wellknownListName",
+	"id":           "id",
+}
+
+// The attributes of a task holding a date and a time zone, which are
+// ordered by their date.
+var dateTimeFields []string = []string{"dueDateTime", "reminderDateTime", "completedDateTime"}
+
+// Holds the query options of the `ls` commands. They are sent to the API,
+// so only the items (and the attributes of them) that are shown get
+// downloaded.
+type QueryOptions struct {
+	// An OData `$filter`, passed as is, e.g. `status ne 'completed'`.
+	Filter string
+	// The columns to order by, each followed by `desc` (or prefixed with
+	// `-`) for the descending order, e.g. `due, -importance`.
+	Sort []string
+	// The columns to download, all when empty.
+	Fields []string
+	// The most items shown, all when zero.
+	Top int
+}
+
+// Tells whether any query option is set.
+func (q QueryOptions) isSet() bool {
+	return q.Filter != "" || len(q.Sort) > 0 || len(q.Fields) > 0 || q.Top != 0
+}
+
+// Returns the columns to show: the `Fields` asked for, unless other columns
+// are explicitly given.
+func (q QueryOptions) columns(columns []string) []string {
+	if len(q.Fields) > 0 && len(columns) == 1 && columns[0] == "all" {
+		return q.Fields
+	}
+
+	return columns
+}
+
+// Converts the QueryOptions to an api.Query, mapping the columns to the
+// attributes of the items through `fields`. The names not found there are
+// passed as they are (e.g. `createdDateTime`).
+func buildQuery(opts QueryOptions, fields map[string]string) (api.Query, error) {
+	query := api.Query{Filter: api.Filter(strings.TrimSpace(opts.Filter)), Top: opts.Top}
+
+	if opts.Top < 0 {
+		return query, invalidf("Invalid --top %d, it can't be negative", opts.Top)
+	}
+
+	for _, column := range opts.Fields {
+		if column == "" || column == "all" {
+			continue
+		}
+
+		query.Select = append(query.Select, queryField(column, fields))
+	}
+
+	for _, s := range opts.Sort {
+		order, err := parseSortOrder(s, fields)
+		if err != nil {
+			return query, err
+		}
+
+		if order.Field != "" {
+			query.OrderBy = append(query.OrderBy, order)
+		}
+	}
+
+	return query, nil
+}
+
+// Parses a column to order by, e.g. `due`, `-due` or `due desc`.
+func parseSortOrder(s string, fields map[string]string) (api.Order, error) {
+	s = strings.TrimSpace(s)
+	descending := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+
+	if i := strings.LastIndex(s, " "); i != -1 {
+		switch direction := strings.ToLower(s[i+1:]); direction {
+		case "asc", "desc":
+			descending = direction == "desc"
+			s = strings.TrimSpace(s[:i])
+		default:
+			if _, ok := fields[s]; !ok {
+				return api.Order{}, invalidf("Invalid --sort %q, expected asc or desc after the column", s)
+			}
+		}
+	}
+
+	if s == "" {
+		return api.Order{}, nil
+	}
+
+	field := queryField(s, fields)
+	for _, f := range dateTimeFields {
+		if field == f {
+			field += "/dateTime"
+		}
+	}
+
+	return api.Order{Field: field, Descending: descending}, nil
+}
+
+// Returns the attribute in the API a column stands for.
+func queryField(column string, fields map[string]string) string {
+	if field, ok := fields[column]; ok {
+		return field
+	}
+
+	return column
+}
@@ -0,0 +1,100 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package app
+
+import (
+	"github.com/betasve/mstd/conf"
+	api "github.com/betasve/mstd/todoapi"
+	apiTest "github.com/betasve/mstd/todoapi/todoapitest"
+	"reflect"
+	"testing"
+)
+
+func TestBuildQuery(test *testing.T) {
+	query, err := buildQuery(QueryOptions{
+		Filter: " status ne 'completed' ",
+		Sort:   []string{"-due", "title asc", "createdDateTime desc"},
+		Fields: []string{"title", "due", "createdDateTime"},
+		Top:    5,
+	}, taskColumnsToFields)
+
+	if err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	expected := api.Query{
+		Filter: "status ne 'completed'",
+		OrderBy: []api.Order{
+			api.Desc("dueDateTime/dateTime"),
+			api.Asc("title"),
+			api.Desc("createdDateTime"),
+		},
+		Select: []string{"title", "dueDateTime", "createdDateTime"},
+		Top:    5,
+	}
+
+	if !reflect.DeepEqual(query, expected) {
+		test.Errorf("\nExpected the query to be\n%+v\nbut was\n%+v", expected, query)
+	}
+}
+
+func TestBuildQueryListColumns(test *testing.T) {
+	query, err := buildQuery(QueryOptions{Sort: []string{"display name desc"}}, listColumnsToFields)
+	if err != nil || !reflect.DeepEqual(query.OrderBy, []api.Order{api.Desc("displayName")}) {
+		test.Errorf("\nExpected to order by the name, descending\nbut was\n%+v %v", query.OrderBy, err)
+	}
+}
+
+func TestBuildQueryErrors(test *testing.T) {
+	for _, opts := range []QueryOptions{{Sort: []string{"due sideways"}}, {Top: -1}} {
+		if _, err := buildQuery(opts, taskColumnsToFields); ExitCode(err) != ExitValidation {
+			test.Errorf("\nExpected a validation error for\n%+v\nbut was\n%v", opts, err)
+		}
+	}
+}
+
+func TestTasksIndexWithQuery(test *testing.T) {
+	config = &conf.Config{}
+	apiClient = &apiTest.TodoApiMock{}
+	stubLists()
+
+	var sent api.Query
+	apiTest.TasksQueryMockFn = func(l string, q api.Query) (*api.TasksResponse, error) {
+		sent = q
+		return &api.TasksResponse{Tasks: []api.TaskItem{{Title: "Milk"}}}, nil
+	}
+	apiTest.TasksIndexMockFn = func(l string) (*[]api.TaskItem, error) {
+		test.Error("\nExpected the tasks to be queried\nbut all of them were retrieved")
+		return &[]api.TaskItem{}, nil
+	}
+
+	defer func() {
+		apiTest.TasksQueryMockFn = func(l string, q api.Query) (*api.TasksResponse, error) {
+			return &api.TasksResponse{}, nil
+		}
+		apiTest.TasksIndexMockFn = func(l string) (*[]api.TaskItem, error) {
+			return &[]api.TaskItem{}, nil
+		}
+	}()
+
+	if err := TasksIndex("Groceries", QueryOptions{Top: 5}, []string{"all"}); err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	if sent.Top != 5 {
+		test.Errorf("\nExpected the top 5 tasks to be asked for\nbut was\n%+v", sent)
+	}
+}
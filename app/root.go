@@ -22,23 +22,194 @@ limitations under the License.
 package app
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/betasve/mstd/cache"
 	"github.com/betasve/mstd/conf"
+	httpService "github.com/betasve/mstd/ext/http"
 	"github.com/betasve/mstd/ext/log"
+	"github.com/betasve/mstd/login"
 	api "github.com/betasve/mstd/todoapi"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
 )
 
+const cacheFileName string = "cache.json"
+const deltaFileName string = "delta.json"
+
+// The environment variables that point the app at another root of the API
+// and another login API (e.g. the ones of `mstd dev fake-graph`), overriding
+// the endpoints set in the config file.
+const GraphUrlEnv string = "MSTD_GRAPH_URL"
+const LoginUrlEnv string = "MSTD_LOGIN_URL"
+
+// The version of the app, set when building it with
+// `-ldflags "-X github.com/betasve/mstd/app.Version=..."`.
+var Version string = "dev"
+
 var config *conf.Config
 var CfgFilePath string
+var Profile string
 var apiClient api.TodoApiClient
+var cacheClient *cache.Client
+
+// The time a single request to the API may take (zero means no limit).
+var Timeout time.Duration
+
+// Where and how the messages of the app are logged: the lowest level shown,
+// the format (text, logfmt or json) and the file to append them to (the
+// standard error when empty).
+var LogLevel string = "info"
+var LogFormat string = log.FormatText
+var LogFile string
+
+// The format the results and the errors are printed in: text or json.
+var Output string = OutputText
+
+// Trace the requests to stderr: `Verbose` a line for each, `Debug` their
+// headers and bodies too.
+var Verbose bool
+var Debug bool
+
+// The requests are made with this context, so cancelling it (e.g. on Ctrl-C)
+// aborts the ones in flight.
+var ctx context.Context = context.Background()
+
+// Sets the context the requests of the app are made with.
+func SetContext(c context.Context) {
+	ctx = c
+}
 
 // This is app's entry point. It's being invoked by the command-line tool
 // that is being used. Here we read the config file from the path that's being
 // set for it and initializing the configuration for the app.
-func InitAppConfig() {
+func InitAppConfig() error {
+	if Output != OutputText && Output != OutputJson {
+		return invalidf("Unknown output format %q (use text or json)", Output)
+	}
+
+	if err := initLogging(); err != nil {
+		return err
+	}
+
 	config = &conf.Config{}
+	if err := config.SetProfile(Profile); err != nil {
+		return &ValidationError{Message: err.Error()}
+	}
+
 	if err := config.InitConfig(CfgFilePath); err != nil {
-		log.Client.Fatal(err)
+		return err
+	}
+
+	opts := httpService.Options{
+		Timeout:   Timeout,
+		Proxy:     config.Proxy(),
+		CaBundle:  config.CaBundle(),
+		UserAgent: "mstd/" + Version,
+		Gzip:      config.Gzip(),
+	}
+	if Verbose || Debug {
+		opts.Trace = os.Stderr
+		opts.TraceBodies = Debug
+	}
+
+	httpClient, err := httpService.New(opts)
+	if err != nil {
+		return err
 	}
 
-	apiClient = &api.TodoApi{}
+	api.SetHttpClient(httpClient)
+	login.SetHttpClient(httpClient)
+	applyEndpoints()
+
+	remote := &api.TodoApi{}
+	apiClient = remote
+
+	if dir, err := stateDir(); err == nil {
+		deltas := cache.NewDeltaStore(filepath.Join(dir, deltaFileName))
+		remote.SetDeltaStore(deltas)
+
+		cacheClient = cache.New(remote, filepath.Join(dir, cacheFileName), deltas)
+		apiClient = cacheClient
+	} else {
+		log.Client.Debug("Running without the local cache", "error", err)
+	}
+
+	return nil
+}
+
+// Sets up the logger with the level, format and file asked for.
+func initLogging() error {
+	level, err := log.ParseLevel(LogLevel)
+	if err != nil {
+		return &ValidationError{Message: err.Error()}
+	}
+
+	if !log.Formats[LogFormat] {
+		return invalidf("Unknown log format %q (use text, logfmt or json)", LogFormat)
+	}
+
+	var out io.Writer = os.Stderr
+	if LogFile != "" {
+		file, err := os.OpenFile(LogFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return fmt.Errorf("Could not open the log file: %s", err)
+		}
+
+		out = file
+	}
+
+	log.Client = log.Logger{Level: level, Format: LogFormat, Out: out}
+
+	return nil
+}
+
+// Returns the directory (inside the user's config directory) where the app
+// keeps the files of the current profile, e.g. the history of the shell.
+func profileDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "mstd", config.Profile()), nil
+}
+
+// Returns the directory where the app keeps the state of the current profile
+// for the API it talks to, e.g. its local cache. The one of the global cloud
+// is the directory of the profile, while any other one (be it another cloud,
+// `graph_url` or the MSTD_GRAPH_URL override) gets its own subdirectory, so
+// that the lists and the delta links of one never end up in the other.
+func stateDir() (string, error) {
+	dir, err := profileDir()
+	if err != nil {
+		return "", err
+	}
+
+	if api.IsDefaultApiRoot() {
+		return dir, nil
+	}
+
+	sum := sha256.Sum256([]byte(api.ApiRoot()))
+
+	return filepath.Join(dir, "endpoints", hex.EncodeToString(sum[:8])), nil
+}
+
+// Points the app at the endpoints of the cloud (and the tenant) set in the
+// config file, unless the environment overrides them.
+func applyEndpoints() {
+	api.SetApiRoot(config.GraphUrl() + "/" + config.ApiVersion())
+	login.SetAuthority(config.AuthorityHost(), config.Tenant())
+
+	if root := os.Getenv(GraphUrlEnv); root != "" {
+		api.SetApiRoot(root)
+	}
+
+	if root := os.Getenv(LoginUrlEnv); root != "" {
+		login.SetBaseRequestUrl(root)
+	}
 }
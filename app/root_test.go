@@ -0,0 +1,48 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package app
+
+import (
+	"github.com/betasve/mstd/conf"
+	api "github.com/betasve/mstd/todoapi"
+	"path/filepath"
+	"testing"
+)
+
+func TestStateDirIsKeptApartPerEndpoint(test *testing.T) {
+	test.Setenv("XDG_CONFIG_HOME", "/config")
+	config = &conf.Config{}
+	config.SetProfile("work")
+	defer api.SetApiRoot("https://graph.microsoft.com/v1.0")
+
+	dir, err := stateDir()
+	if err != nil || dir != filepath.Join("/config", "mstd", "work") {
+		test.Errorf("\nExpected the state of the global cloud in\n/config/mstd/work\nbut was\n%s (%v)", dir, err)
+	}
+
+	api.SetApiRoot("http://localhost:8081/v1.0")
+	fake, _ := stateDir()
+	api.SetApiRoot("https://graph.microsoft.us/v1.0")
+	usgov, _ := stateDir()
+
+	if fake == dir || usgov == dir || fake == usgov {
+		test.Errorf("\nExpected every endpoint to have its own state\nbut they were\n%s\n%s\n%s", dir, fake, usgov)
+	}
+
+	if filepath.Dir(filepath.Dir(fake)) != dir {
+		test.Errorf("\nExpected the state of the endpoint inside\n%s\nbut was\n%s", dir, fake)
+	}
+}
@@ -0,0 +1,490 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/betasve/mstd/shell"
+	api "github.com/betasve/mstd/todoapi"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+)
+
+// The name of the file (in the state directory) the shell's history is kept
+// in.
+const historyFileName string = "history"
+
+// Holds the state of a shell session: the current list and what's known
+// about the lists and its tasks (used for completion).
+type shellSession struct {
+	list  *api.ListsItem
+	lists []api.ListsItem
+	tasks []api.TaskItem
+}
+
+// Starts an interactive shell, running commands over the lists and tasks
+// with the config loaded (and the user logged in) only once.
+func Shell() error {
+	session := &shellSession{}
+	sh := shell.New(session.commands())
+
+	sh.Prompt = func() string {
+		if session.list == nil {
+			return "mstd> "
+		}
+
+		return fmt.Sprintf("mstd (%s)> ", session.list.Name)
+	}
+
+	if dir, err := profileDir(); err == nil {
+		sh.HistoryPath = filepath.Join(dir, historyFileName)
+	}
+
+	fmt.Fprintln(os.Stdout, "Type `help` to see the commands, `exit` or Ctrl+D to leave.")
+
+	return sh.Run()
+}
+
+// Returns the commands of the shell.
+func (s *shellSession) commands() []*shell.Command {
+	return []*shell.Command{
+		{
+			Name: "lists", Help: "Shows all the lists",
+			Run: s.loggedIn(s.showLists),
+		},
+		{
+			Name: "use", Args: "LIST", Help: "Makes LIST (an id or a name) the current list",
+			Run: s.loggedIn(s.use), Complete: s.completeLists,
+		},
+		{
+			Name: "mklist", Args: "NAME", Help: "Creates a list and makes it the current one",
+			Run: s.loggedIn(s.createList),
+		},
+		{
+			Name: "ls", Help: "Shows the tasks in the current list",
+			Run: s.loggedIn(s.showTasks),
+		},
+		{
+			Name: "show", Args: "TASK", Help: "Shows the details of a task (by its id or title)",
+			Run: s.loggedIn(s.showTask), Complete: s.completeTasks,
+		},
+		{
+			Name: "add", Args: "TITLE", Help: "Adds a task to the current list",
+			Run: s.loggedIn(s.addTask),
+		},
+		{
+			Name: "done", Args: "TASK", Help: "Marks a task completed",
+			Run: s.loggedIn(s.updateWith(func(args []string) (*api.TaskItem, error) {
+				return &api.TaskItem{Status: "completed"}, nil
+			})),
+			Complete: s.completeTasks,
+		},
+		{
+			Name: "undo", Args: "TASK", Help: "Marks a completed task not started",
+			Run: s.loggedIn(s.updateWith(func(args []string) (*api.TaskItem, error) {
+				return &api.TaskItem{Status: "notStarted"}, nil
+			})),
+			Complete: s.completeTasks,
+		},
+		{
+			Name: "star", Args: "TASK", Help: "Marks a task important",
+			Run: s.loggedIn(s.updateWith(func(args []string) (*api.TaskItem, error) {
+				return &api.TaskItem{Importance: "high"}, nil
+			})),
+			Complete: s.completeTasks,
+		},
+		{
+			Name: "unstar", Args: "TASK", Help: "Sets the importance of a task back to normal",
+			Run: s.loggedIn(s.updateWith(func(args []string) (*api.TaskItem, error) {
+				return &api.TaskItem{Importance: "normal"}, nil
+			})),
+			Complete: s.completeTasks,
+		},
+		{
+			Name: "rename", Args: "TASK TITLE", Help: "Renames a task",
+			Run: s.loggedIn(s.updateWith(func(args []string) (*api.TaskItem, error) {
+				if len(args) == 0 {
+					return nil, errors.New("Usage: rename TASK TITLE")
+				}
+				return buildTask(TaskOptions{Title: strings.Join(args, " ")})
+			})),
+			Complete: s.completeTasks,
+		},
+		{
+			Name: "due", Args: "TASK WHEN", Help: "Sets the due date of a task, e.g. `due milk next friday`",
+			Run: s.loggedIn(s.updateWith(func(args []string) (*api.TaskItem, error) {
+				if len(args) == 0 {
+					return nil, errors.New("Usage: due TASK WHEN")
+				}
+				return buildTask(TaskOptions{Due: strings.Join(args, " ")})
+			})),
+			Complete: s.completeTasks,
+		},
+		{
+			Name: "note", Args: "TASK TEXT", Help: "Sets the notes of a task",
+			Run: s.loggedIn(s.updateWith(func(args []string) (*api.TaskItem, error) {
+				body := &api.ItemBody{Content: strings.Join(args, " "), ContentType: "text"}
+				return &api.TaskItem{Body: body}, nil
+			})),
+			Complete: s.completeTasks,
+		},
+		{
+			Name: "mv", Args: "TASK LIST", Help: "Moves a task to another list",
+			Run: s.loggedIn(s.moveTask), Complete: s.completeTaskThenList,
+		},
+		{
+			Name: "rm", Args: "TASK", Help: "Deletes a task",
+			Run: s.loggedIn(s.deleteTask), Complete: s.completeTasks,
+		},
+		{
+			Name: "sync", Help: "Synchronizes the local cache with To Do",
+			Run: s.loggedIn(func(args []string) error { return Sync() }),
+		},
+	}
+}
+
+// Wraps a command so the user is logged in again (once the session expires)
+// before it's run. Each command gets its own context, so Ctrl+C cancels the
+// command's requests but leaves the shell running.
+func (s *shellSession) loggedIn(run func(args []string) error) func(args []string) error {
+	return func(args []string) error {
+		parent := ctx
+		commandCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		ctx = commandCtx
+
+		defer func() {
+			stop()
+			ctx = parent
+		}()
+
+		if err := refreshLogin(); err != nil {
+			return err
+		}
+
+		return run(args)
+	}
+}
+
+// Prints the lists.
+func (s *shellSession) showLists(args []string) error {
+	lists, err := apiClient.ListsIndex(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.lists = *lists
+	printResults(lists, []string{"all"})
+
+	return nil
+}
+
+// Sets the current list.
+func (s *shellSession) use(args []string) error {
+	if len(args) != 1 {
+		return errors.New("Usage: use LIST")
+	}
+
+	list, err := s.findList(args[0])
+	if err != nil {
+		return err
+	}
+
+	s.list = list
+	s.tasks = nil
+
+	return nil
+}
+
+// Creates a list and makes it the current one.
+func (s *shellSession) createList(args []string) error {
+	if len(args) == 0 {
+		return errors.New("Usage: mklist NAME")
+	}
+
+	list, err := apiClient.ListsCreate(ctx, strings.Join(args, " "))
+	if err != nil {
+		return err
+	}
+
+	s.lists = append(s.lists, *list)
+	s.list = list
+	s.tasks = nil
+
+	return nil
+}
+
+// Prints the tasks of the current list.
+func (s *shellSession) showTasks(args []string) error {
+	if err := s.loadTasks(); err != nil {
+		return err
+	}
+
+	printTasks(&s.tasks, []string{"title", "status", "importance", "due", "repeat"})
+
+	return nil
+}
+
+// Prints the details of a task.
+func (s *shellSession) showTask(args []string) error {
+	if len(args) != 1 {
+		return errors.New("Usage: show TASK")
+	}
+
+	task, err := s.findTask(args[0])
+	if err != nil {
+		return err
+	}
+
+	printTaskDetails(task)
+
+	return nil
+}
+
+// Adds a task to the current list.
+func (s *shellSession) addTask(args []string) error {
+	if s.list == nil {
+		return errors.New("No current list, pick one with `use LIST`")
+	}
+
+	if len(args) == 0 {
+		return errors.New("Usage: add TITLE")
+	}
+
+	task, err := buildTask(TaskOptions{Title: strings.Join(args, " ")})
+	if err != nil {
+		return err
+	}
+
+	created, err := apiClient.TasksCreate(ctx, s.list.Id, task)
+	if err != nil {
+		return err
+	}
+
+	s.tasks = append(s.tasks, *created)
+	fmt.Fprintf(os.Stdout, "Added %q\n", created.Title)
+
+	return nil
+}
+
+// Returns a command updating a task (its first argument) with the changes
+// built from the rest of its arguments.
+func (s *shellSession) updateWith(
+	changes func(args []string) (*api.TaskItem, error),
+) func(args []string) error {
+	return func(args []string) error {
+		if len(args) == 0 {
+			return errors.New("Which task? Give its id or title")
+		}
+
+		task, err := s.findTask(args[0])
+		if err != nil {
+			return err
+		}
+
+		update, err := changes(args[1:])
+		if err != nil {
+			return err
+		}
+
+		updated, err := apiClient.TasksUpdate(ctx, s.list.Id, task.Id, update)
+		if err != nil {
+			return err
+		}
+
+		s.putTask(*updated)
+		printTasks(&[]api.TaskItem{*updated}, []string{"title", "status", "importance", "due"})
+
+		return nil
+	}
+}
+
+// Moves a task of the current list to another one.
+func (s *shellSession) moveTask(args []string) error {
+	if len(args) != 2 {
+		return errors.New("Usage: mv TASK LIST")
+	}
+
+	task, err := s.findTask(args[0])
+	if err != nil {
+		return err
+	}
+
+	to, err := s.findList(args[1])
+	if err != nil {
+		return err
+	}
+
+	if _, err := moveTask(s.list.Id, task.Id, to.Id); err != nil {
+		return err
+	}
+
+	s.removeTask(task.Id)
+	fmt.Fprintf(os.Stdout, "Moved %q to %q\n", task.Title, to.Name)
+
+	return nil
+}
+
+// Deletes a task of the current list.
+func (s *shellSession) deleteTask(args []string) error {
+	if len(args) != 1 {
+		return errors.New("Usage: rm TASK")
+	}
+
+	task, err := s.findTask(args[0])
+	if err != nil {
+		return err
+	}
+
+	if err := apiClient.TasksDelete(ctx, s.list.Id, task.Id); err != nil {
+		return err
+	}
+
+	s.removeTask(task.Id)
+	fmt.Fprintf(os.Stdout, "Deleted %q\n", task.Title)
+
+	return nil
+}
+
+// Finds a list by its id or its (case insensitive) name.
+func (s *shellSession) findList(name string) (*api.ListsItem, error) {
+	lists, err := apiClient.ListsIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.lists = *lists
+
+	for _, l := range s.lists {
+		if l.Id == name || strings.EqualFold(l.Name, name) {
+			list := l
+			return &list, nil
+		}
+	}
+
+	return nil, &NotFoundError{Kind: "List", Name: name}
+}
+
+// Finds a task of the current list by its id or its (case insensitive and
+// unique) title.
+func (s *shellSession) findTask(ref string) (*api.TaskItem, error) {
+	if err := s.loadTasks(); err != nil {
+		return nil, err
+	}
+
+	var found *api.TaskItem
+	for i, t := range s.tasks {
+		if t.Id == ref {
+			return &s.tasks[i], nil
+		}
+
+		if strings.EqualFold(t.Title, ref) {
+			if found != nil {
+				return nil, fmt.Errorf("More than one task is titled %q, use its id", ref)
+			}
+			found = &s.tasks[i]
+		}
+	}
+
+	if found == nil {
+		return nil, fmt.Errorf("Task %q not found in %q", ref, s.list.Name)
+	}
+
+	return found, nil
+}
+
+// Loads the tasks of the current list.
+func (s *shellSession) loadTasks() error {
+	if s.list == nil {
+		return errors.New("No current list, pick one with `use LIST`")
+	}
+
+	tasks, err := apiClient.TasksIndex(ctx, s.list.Id)
+	if err != nil {
+		return err
+	}
+
+	s.tasks = *tasks
+
+	return nil
+}
+
+// Replaces a task in the known tasks.
+func (s *shellSession) putTask(task api.TaskItem) {
+	for i, t := range s.tasks {
+		if t.Id == task.Id {
+			s.tasks[i] = task
+		}
+	}
+}
+
+// Removes a task from the known tasks.
+func (s *shellSession) removeTask(id string) {
+	for i, t := range s.tasks {
+		if t.Id == id {
+			s.tasks = append(s.tasks[:i], s.tasks[i+1:]...)
+			return
+		}
+	}
+}
+
+// Completes the names of the lists (loading them the first time).
+func (s *shellSession) completeLists(args []string) []string {
+	if s.lists == nil {
+		if lists, err := apiClient.ListsIndex(ctx); err == nil {
+			s.lists = *lists
+		}
+	}
+
+	names := []string{}
+	for _, l := range s.lists {
+		names = append(names, l.Name)
+	}
+
+	return names
+}
+
+// Completes the titles of the tasks in the current list (loading them the
+// first time), for the first argument only.
+func (s *shellSession) completeTasks(args []string) []string {
+	if len(args) != 1 || s.list == nil {
+		return nil
+	}
+
+	if s.tasks == nil {
+		_ = s.loadTasks()
+	}
+
+	titles := []string{}
+	for _, t := range s.tasks {
+		titles = append(titles, t.Title)
+	}
+
+	return titles
+}
+
+// Completes a task for the first argument and a list for the second one.
+func (s *shellSession) completeTaskThenList(args []string) []string {
+	if len(args) == 2 {
+		return s.completeLists(args)
+	}
+
+	return s.completeTasks(args)
+}
@@ -0,0 +1,71 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package app
+
+import (
+	"github.com/betasve/mstd/conf"
+	api "github.com/betasve/mstd/todoapi"
+	apiTest "github.com/betasve/mstd/todoapi/todoapitest"
+	"testing"
+)
+
+func TestShellSessionCommands(test *testing.T) {
+	config = &conf.Config{}
+	apiClient = &apiTest.TodoApiMock{}
+	stubLists()
+
+	session := &shellSession{}
+
+	if err := session.addTask([]string{"milk"}); err == nil {
+		test.Error("\nExpected adding without a current list to return error\nbut it was\nnil")
+	}
+
+	if err := session.use([]string{"groceries"}); err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	apiTest.TasksIndexMockFn = func(l string) (*[]api.TaskItem, error) {
+		return &[]api.TaskItem{{Id: "t1", Title: "Milk"}, {Id: "t2", Title: "Eggs"}}, nil
+	}
+
+	var updated string
+	var changes *api.TaskItem
+	apiTest.TasksUpdateMockFn = func(l, i string, t *api.TaskItem) (*api.TaskItem, error) {
+		updated = l + "/" + i
+		changes = t
+		return &api.TaskItem{Id: i, Title: "Milk", Status: t.Status}, nil
+	}
+
+	done := session.updateWith(func(args []string) (*api.TaskItem, error) {
+		return &api.TaskItem{Status: "completed"}, nil
+	})
+
+	if err := done([]string{"milk"}); err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	if updated != "list-id/t1" || changes.Status != "completed" {
+		test.Errorf("\nExpected list-id/t1 to be completed\nbut updated\n%s with %+v", updated, changes)
+	}
+
+	if err := done([]string{"bread"}); err == nil {
+		test.Error("\nExpected an unknown task to return error\nbut it was\nnil")
+	}
+
+	if titles := session.completeTasks([]string{""}); len(titles) != 2 {
+		test.Errorf("\nExpected the titles to be completed\nbut got\n%v", titles)
+	}
+}
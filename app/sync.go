@@ -0,0 +1,59 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Sends the changes that were made while offline to the API and refreshes
+// the local cache of all the lists and tasks, printing a summary.
+func Sync() error {
+	if cacheClient == nil {
+		return errors.New("The local cache is not available")
+	}
+
+	apiClient.SetToken(config.ClientAccessToken())
+	report, err := cacheClient.Sync(ctx)
+
+	if report != nil {
+		fmt.Fprintf(os.Stdout, "Sent %d queued change(s)\n", report.Replayed)
+
+		for _, failure := range report.Failed {
+			fmt.Fprintf(os.Stdout, "Dropped rejected change: %s\n", failure)
+		}
+
+		if report.Pending > 0 {
+			fmt.Fprintf(os.Stdout, "%d change(s) still waiting\n", report.Pending)
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(
+		os.Stdout,
+		"Cached %d list(s) and %d task(s)\n",
+		report.Lists,
+		report.Tasks,
+	)
+
+	return nil
+}
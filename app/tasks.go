@@ -0,0 +1,482 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"github.com/betasve/mstd/dateparse"
+	tm "github.com/betasve/mstd/ext/time"
+	api "github.com/betasve/mstd/todoapi"
+	"github.com/olekukonko/tablewriter" // TODO: Replace with the abstracted ext/tablewriter
+	"os"
+	"strings"
+	"time"
+)
+
+// Maps the task column values we display in the CLI to the attributes of
+// a [taskRow].
+var TaskColumnsToKeysMap map[string]string = map[string]string{
+	"title":      "Title",
+	"status":     "Status",
+	"importance": "Importance",
+	"due":        "Due",
+	"reminder":   "Reminder",
+	"repeat":     "Repeat",
+	"id":         "Id",
+}
+
+// The time of the day a reminder is set to when only its date is given (e.g.
+// `--remind tomorrow`).
+const defaultReminderTimeOfDay time.Duration = 9 * time.Hour
+
+// The headers of the table that's printed as a result of the Task operations
+// (in the order they are displayed).
+var TaskItemHeaders []string = []string{
+	"title", "status", "importance", "due", "reminder", "repeat", "id",
+}
+
+// Holds the attributes of a task that can be set from the CLI.
+type TaskOptions struct {
+	Title      string
+	Status     string
+	Importance string
+	Due        string
+	Remind     string
+	Repeat     RepeatOptions
+}
+
+// Holds the recurrence settings of a task. `Shorthand` is parsed first (see
+// [todoapi.ParseRecurrence]) and the rest of the (structured) values are
+// applied on top of it.
+type RepeatOptions struct {
+	Shorthand  string
+	Type       string
+	Interval   int
+	Days       string
+	DayOfMonth int
+	Month      int
+	Index      string
+	Start      string
+	Until      string
+	Count      int
+}
+
+// A flattened, printable representation of a TaskItem.
+type taskRow struct {
+	Title      string
+	Status     string
+	Importance string
+	Due        string
+	Reminder   string
+	Repeat     string
+	Id         string
+}
+
+// Prints a formatted table with all the tasks in a list (found by its id or
+// name), containing only the columns listed in `columns`. When any of the
+// `query` options are set, only the tasks (and the columns) they ask for
+// are retrieved.
+func TasksIndex(list string, query QueryOptions, columns []string) error {
+	apiClient.SetToken(config.ClientAccessToken())
+
+	listId, err := resolveListId(list)
+	if err != nil {
+		return err
+	}
+
+	if !query.isSet() {
+		tasks, err := apiClient.TasksIndex(ctx, listId)
+		if err != nil {
+			return err
+		}
+
+		printTasks(tasks, columns)
+		return nil
+	}
+
+	q, err := buildQuery(query, taskColumnsToFields)
+	if err != nil {
+		return err
+	}
+
+	result, err := apiClient.TasksQuery(ctx, listId, q)
+	if err != nil {
+		return err
+	}
+
+	printTasks(&result.Tasks, query.columns(columns))
+	return nil
+}
+
+// Prints all the details of a single task, including its recurrence rule.
+func TasksShow(list, taskId string) error {
+	apiClient.SetToken(config.ClientAccessToken())
+
+	listId, err := resolveListId(list)
+	if err != nil {
+		return err
+	}
+
+	task, err := apiClient.TasksShow(ctx, listId, taskId)
+	if err != nil {
+		return err
+	}
+
+	printTaskDetails(task)
+	return nil
+}
+
+// Creates a new task in a list and prints it back to output, formatted with
+// the list of columns mentioned in `columns`.
+func TasksCreate(list string, opts TaskOptions, columns []string) error {
+	apiClient.SetToken(config.ClientAccessToken())
+
+	listId, err := resolveListId(list)
+	if err != nil {
+		return err
+	}
+
+	task, err := buildNewTask(opts)
+	if err != nil {
+		return err
+	}
+
+	newTask, err := apiClient.TasksCreate(ctx, listId, task)
+	if err != nil {
+		return err
+	}
+
+	printTasks(&[]api.TaskItem{*newTask}, columns)
+	return nil
+}
+
+// Updates the attributes of a task that are set in `opts` and prints the
+// updated task back to output.
+func TasksUpdate(list, taskId string, opts TaskOptions, columns []string) error {
+	apiClient.SetToken(config.ClientAccessToken())
+
+	listId, err := resolveListId(list)
+	if err != nil {
+		return err
+	}
+
+	task, err := buildTask(opts)
+	if err != nil {
+		return err
+	}
+
+	updatedTask, err := apiClient.TasksUpdate(ctx, listId, taskId, task)
+	if err != nil {
+		return err
+	}
+
+	printTasks(&[]api.TaskItem{*updatedTask}, columns)
+	return nil
+}
+
+// Returns a copy of a task without the attributes the API sets itself, to
+// create it again (e.g. in another list).
+func copyableTask(task api.TaskItem) *api.TaskItem {
+	task.Id = ""
+	task.CreatedDateTime = ""
+	task.LastModifiedDateTime = ""
+
+	return &task
+}
+
+// Finds the id of a list by either its id or its (case insensitive) name.
+func resolveListId(list string) (string, error) {
+	lists, err := apiClient.ListsIndex(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	for _, l := range *lists {
+		if l.Id == list || strings.EqualFold(l.Name, list) {
+			return l.Id, nil
+		}
+	}
+
+	return "", &NotFoundError{Kind: "List", Name: list}
+}
+
+// Converts the TaskOptions received from the CLI to a TaskItem, holding only
+// the attributes that were set.
+func buildTask(opts TaskOptions) (*api.TaskItem, error) {
+	task := &api.TaskItem{
+		Title:      opts.Title,
+		Status:     opts.Status,
+		Importance: opts.Importance,
+	}
+
+	loc, err := appLocation()
+	if err != nil {
+		return nil, err
+	}
+
+	var due time.Time
+	if opts.Due != "" {
+		due, err = dateparse.Parser{Location: loc}.Parse(opts.Due)
+		if err != nil {
+			return nil, err
+		}
+
+		task.DueDateTime = api.NewDateTimeTimeZone(due)
+	}
+
+	if opts.Remind != "" {
+		parser := dateparse.Parser{
+			Location:         loc,
+			DefaultTimeOfDay: defaultReminderTimeOfDay,
+		}
+
+		reminder, err := parser.DateTimeTimeZone(opts.Remind)
+		if err != nil {
+			return nil, err
+		}
+
+		task.IsReminderOn = true
+		task.ReminderDateTime = reminder
+	}
+
+	recurrence, err := buildRecurrence(opts.Repeat, due)
+	if err != nil {
+		return nil, err
+	}
+
+	task.Recurrence = recurrence
+
+	return task, nil
+}
+
+// Converts the TaskOptions to a TaskItem to create (see buildTask). A
+// recurring task is due on the day its recurrence starts, unless it's due
+// on another one, since the API does not accept a recurring task without a
+// due date. An update keeps the due date the task has.
+func buildNewTask(opts TaskOptions) (*api.TaskItem, error) {
+	task, err := buildTask(opts)
+	if err != nil || task.Recurrence == nil || task.DueDateTime != nil {
+		return task, err
+	}
+
+	loc, err := appLocation()
+	if err != nil {
+		return nil, err
+	}
+
+	start, err := time.ParseInLocation(api.RecurrenceDateLayout, task.Recurrence.Range.StartDate, loc)
+	if err != nil {
+		return nil, invalidf("Invalid start date of the recurrence: %s", err)
+	}
+
+	task.DueDateTime = api.NewDateTimeTimeZone(start)
+
+	return task, nil
+}
+
+// Returns the location the dates entered in the CLI are in. It's the time
+// zone set in the config file, or the local one when there's none.
+func appLocation() (*time.Location, error) {
+	if config.TimeZone() == "" {
+		return time.Local, nil
+	}
+
+	loc, err := time.LoadLocation(config.TimeZone())
+	if err != nil {
+		return nil, invalidf("Invalid time_zone in config file: %s", err)
+	}
+
+	return loc, nil
+}
+
+// Builds a PatternedRecurrence out of the RepeatOptions. Returns `nil` when
+// no recurrence was requested. The range starts on `due` (or today when it's
+// not set), unless a start date is explicitly given.
+func buildRecurrence(opts RepeatOptions, due time.Time) (*api.PatternedRecurrence, error) {
+	var r *api.PatternedRecurrence
+
+	if opts.Shorthand != "" {
+		parsed, err := api.ParseRecurrence(opts.Shorthand)
+		if err != nil {
+			return nil, err
+		}
+		r = parsed
+	} else if opts.Type != "" {
+		r = &api.PatternedRecurrence{
+			Pattern: api.RecurrencePattern{Interval: 1},
+			Range:   api.RecurrenceRange{Type: api.RangeNoEnd},
+		}
+	} else {
+		return nil, nil
+	}
+
+	if err := applyRepeatOptions(opts, r); err != nil {
+		return nil, err
+	}
+
+	if r.Range.StartDate == "" {
+		if due.IsZero() {
+			due = tm.Client.Now()
+		}
+		r.Range.StartDate = due.Format(api.RecurrenceDateLayout)
+	}
+
+	return r, r.Validate()
+}
+
+// Applies the structured RepeatOptions on top of a recurrence.
+func applyRepeatOptions(opts RepeatOptions, r *api.PatternedRecurrence) error {
+	if opts.Type != "" {
+		r.Pattern.Type = opts.Type
+	}
+
+	if opts.Interval != 0 {
+		r.Pattern.Interval = opts.Interval
+	}
+
+	if opts.Days != "" {
+		days, err := api.ParseWeekDays(opts.Days)
+		if err != nil {
+			return err
+		}
+		r.Pattern.DaysOfWeek = days
+	}
+
+	if opts.DayOfMonth != 0 {
+		r.Pattern.DayOfMonth = opts.DayOfMonth
+	}
+
+	if opts.Month != 0 {
+		r.Pattern.Month = opts.Month
+	}
+
+	if opts.Index != "" {
+		index, err := api.ParseWeekIndex(opts.Index)
+		if err != nil {
+			return err
+		}
+		r.Pattern.Index = index
+	}
+
+	if opts.Start != "" {
+		r.Range.StartDate = opts.Start
+	}
+
+	if opts.Until != "" {
+		r.Range.Type = api.RangeEndDate
+		r.Range.EndDate = opts.Until
+	}
+
+	if opts.Count != 0 {
+		r.Range.Type = api.RangeNumbered
+		r.Range.NumberOfOccurrences = opts.Count
+	}
+
+	return nil
+}
+
+// Renders a table of tasks, showing only the requested `columns` (or with
+// `--output json`, prints them as JSON).
+func printTasks(tasks *[]api.TaskItem, columns []string) {
+	if Output == OutputJson {
+		printJson(os.Stdout, tasks)
+		return
+	}
+
+	rows := []interface{}{}
+	for _, task := range *tasks {
+		rows = append(rows, newTaskRow(task))
+	}
+
+	printTable(rows, columns, TaskItemHeaders, TaskColumnsToKeysMap)
+}
+
+// Renders all the attributes of a task, one per row (or with `--output
+// json`, prints it as JSON).
+func printTaskDetails(task *api.TaskItem) {
+	if Output == OutputJson {
+		printJson(os.Stdout, task)
+		return
+	}
+
+	row := newTaskRow(*task)
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"field", "value"})
+	table.SetAutoWrapText(false)
+
+	table.AppendBulk([][]string{
+		{"title", row.Title},
+		{"id", row.Id},
+		{"status", row.Status},
+		{"importance", row.Importance},
+		{"due", row.Due},
+		{"reminder", row.Reminder},
+		{"repeat", row.Repeat},
+		{"categories", strings.Join(task.Categories, ", ")},
+		{"note", taskNote(task)},
+		{"created", task.CreatedDateTime},
+		{"modified", task.LastModifiedDateTime},
+	})
+
+	table.Render()
+}
+
+// Flattens a TaskItem to a taskRow.
+func newTaskRow(task api.TaskItem) taskRow {
+	row := taskRow{
+		Title:      task.Title,
+		Status:     task.Status,
+		Importance: task.Importance,
+		Due:        formatDateTime(task.DueDateTime),
+		Reminder:   formatDateTime(task.ReminderDateTime),
+		Id:         task.Id,
+	}
+
+	if task.Recurrence != nil {
+		row.Repeat = task.Recurrence.String()
+	}
+
+	return row
+}
+
+// Formats a DateTimeTimeZone for display. Times at midnight are shown as
+// dates only.
+func formatDateTime(d *api.DateTimeTimeZone) string {
+	if d == nil {
+		return ""
+	}
+
+	t, err := d.Time()
+	if err != nil {
+		return d.DateTime
+	}
+
+	if t.Hour() == 0 && t.Minute() == 0 {
+		return t.Format("2006-01-02")
+	}
+
+	return t.Format("2006-01-02 15:04 MST")
+}
+
+// Returns the content of a task's note (body) if there is one.
+func taskNote(task *api.TaskItem) string {
+	if task.Body == nil {
+		return ""
+	}
+
+	return task.Body.Content
+}
@@ -0,0 +1,136 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package app
+
+import (
+	"github.com/betasve/mstd/conf"
+	api "github.com/betasve/mstd/todoapi"
+	apiTest "github.com/betasve/mstd/todoapi/todoapitest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func stubLists() {
+	apiTest.ListsIndexMockFn = func() (*[]api.ListsItem, error) {
+		return &[]api.ListsItem{
+			api.ListsItem{Id: "list-id", Name: "Groceries"},
+		}, nil
+	}
+}
+
+func TestTasksCreateWithRepeat(test *testing.T) {
+	config = &conf.Config{}
+	apiClient = &apiTest.TodoApiMock{}
+	stubLists()
+
+	var created *api.TaskItem
+	var listId string
+	apiTest.TasksCreateMockFn = func(l string, t *api.TaskItem) (*api.TaskItem, error) {
+		listId = l
+		created = t
+		return t, nil
+	}
+
+	opts := TaskOptions{
+		Title: "Water plants",
+		Due:   "2021-05-03",
+		Repeat: RepeatOptions{
+			Shorthand: "weekly:mon",
+			Days:      "mon,thu",
+			Count:     4,
+		},
+	}
+
+	if err := TasksCreate("groceries", opts, []string{"all"}); err != nil {
+		test.Fatal(err)
+	}
+
+	if listId != "list-id" {
+		test.Errorf("\nExpected list id to be:\nlist-id\nbut was\n%s", listId)
+	}
+
+	r := created.Recurrence
+	if r == nil {
+		test.Fatal("\nExpected a recurrence\nbut it was\nnil")
+	}
+
+	if len(r.Pattern.DaysOfWeek) != 2 || r.Pattern.DaysOfWeek[1] != "thursday" {
+		test.Errorf("\nExpected structured days to override shorthand\nbut was\n%v", r.Pattern.DaysOfWeek)
+	}
+
+	if r.Range.Type != api.RangeNumbered || r.Range.StartDate != "2021-05-03" {
+		test.Errorf("\nExpected a numbered range starting on the due date\nbut was\n%+v", r.Range)
+	}
+}
+
+func TestRepeatWithoutDueSetsTheDueDateOnlyOnCreate(test *testing.T) {
+	config = &conf.Config{}
+	apiClient = &apiTest.TodoApiMock{}
+	stubLists()
+
+	var created, updated *api.TaskItem
+	apiTest.TasksCreateMockFn = func(l string, t *api.TaskItem) (*api.TaskItem, error) {
+		created = t
+		return t, nil
+	}
+	apiTest.TasksUpdateMockFn = func(l, i string, t *api.TaskItem) (*api.TaskItem, error) {
+		updated = t
+		return t, nil
+	}
+
+	opts := TaskOptions{Repeat: RepeatOptions{Shorthand: "weekly:mon", Start: "2021-05-03"}}
+
+	if err := TasksCreate("groceries", opts, []string{"all"}); err != nil {
+		test.Fatal(err)
+	}
+
+	if created.DueDateTime == nil || !strings.HasPrefix(created.DueDateTime.DateTime, "2021-05-03T00:00:00") {
+		test.Errorf("\nExpected the created task to be due on the start of its recurrence\nbut was\n%+v", created.DueDateTime)
+	}
+
+	if err := TasksUpdate("groceries", "task-id", opts, []string{"all"}); err != nil {
+		test.Fatal(err)
+	}
+
+	if updated.Recurrence == nil || updated.DueDateTime != nil {
+		test.Errorf("\nExpected the update to keep the due date of the task\nbut was\n%+v", updated.DueDateTime)
+	}
+}
+
+func TestTasksCreateWithUnknownList(test *testing.T) {
+	config = &conf.Config{}
+	apiClient = &apiTest.TodoApiMock{}
+	stubLists()
+
+	err := TasksCreate("unknown", TaskOptions{Title: "task"}, []string{"all"})
+	if err == nil {
+		test.Error("\nExpected to return error\nbut it was\nnil")
+	}
+}
+
+func TestBuildRecurrenceStructuredOnly(test *testing.T) {
+	opts := RepeatOptions{Type: api.RecurrenceAbsoluteMonthly, DayOfMonth: 15, Start: "2021-01-15"}
+
+	r, err := buildRecurrence(opts, time.Time{})
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	if r.String() != "every month on day 15" {
+		test.Errorf("\nExpected rule to be:\nevery month on day 15\nbut was\n%s", r.String())
+	}
+}
@@ -0,0 +1,195 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"bytes"
+	api "github.com/betasve/mstd/todoapi"
+	"github.com/betasve/mstd/todotxt"
+	"strings"
+	"time"
+)
+
+// Maps the importance of a task to a todo.txt priority and back. The
+// priorities after `C` are also low.
+var importanceToPriority map[string]string = map[string]string{
+	"high": "A",
+	"low":  "C",
+}
+
+var priorityToImportance map[string]string = map[string]string{
+	"A": "high",
+	"B": "normal",
+}
+
+// Imports the tasks of a todo.txt file. They go into the `list` (an id or
+// a name) unless `projectsAsLists` is set, in which case a task with
+// a `+project` goes into the list named after its (first) project. The rest
+// of the projects, as well as the `@contexts`, become categories.
+func ImportTodoTxt(path, list string, projectsAsLists, dryRun bool) error {
+	data, err := readImportFile(path)
+	if err != nil {
+		return err
+	}
+
+	tasks, err := todotxt.Parse(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	loc, err := appLocation()
+	if err != nil {
+		return err
+	}
+
+	apiClient.SetToken(config.ClientAccessToken())
+
+	var lists []api.ListsItem
+	if projectsAsLists {
+		all, err := apiClient.ListsIndex(ctx)
+		if err != nil {
+			return err
+		}
+		lists = *all
+	}
+
+	items := []importItem{}
+	for _, t := range tasks {
+		item := importItem{List: list, Task: fromTodoTxt(t, loc)}
+		projects := t.Projects
+
+		if projectsAsLists && len(projects) > 0 {
+			item.List = projectListName(projects[0], lists)
+			projects = projects[1:]
+		}
+
+		item.Task.Categories = append(append([]string{}, projects...), item.Task.Categories...)
+
+		if item.List == "" {
+			return invalidf("Task %q has no list to go into, set one with --list", t.Title)
+		}
+
+		items = append(items, item)
+	}
+
+	return importTasks(items, dryRun, DefaultImportBatch)
+}
+
+// Exports the tasks of all the lists (or only `list`, when set) in the
+// todo.txt format to the file at `path` (or the standard output). The name
+// of the list becomes the `+project` of its tasks.
+func ExportTodoTxt(list, path string) error {
+	lists, tasks, err := exportedLists(list)
+	if err != nil {
+		return err
+	}
+
+	loc, err := appLocation()
+	if err != nil {
+		return err
+	}
+
+	out, err := exportWriter(path)
+	if err != nil {
+		return err
+	}
+
+	defer out.Close()
+
+	for _, l := range lists {
+		items := []todotxt.Task{}
+		for _, t := range tasks[l.Id] {
+			items = append(items, toTodoTxt(t, l.Name, loc))
+		}
+
+		if err := todotxt.Write(out, items); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Converts a todo.txt task to a TaskItem (without its projects).
+func fromTodoTxt(t todotxt.Task, loc *time.Location) *api.TaskItem {
+	task := &api.TaskItem{Title: t.Title}
+
+	if t.Completed {
+		task.Status = "completed"
+	}
+
+	if t.Priority != "" {
+		task.Importance = "low"
+		if importance, ok := priorityToImportance[t.Priority]; ok {
+			task.Importance = importance
+		}
+	}
+
+	if due, ok := t.Due(); ok {
+		due = time.Date(due.Year(), due.Month(), due.Day(), 0, 0, 0, 0, loc)
+		task.DueDateTime = api.NewDateTimeTimeZone(due)
+	}
+
+	if len(t.Contexts) > 0 {
+		task.Categories = append([]string{}, t.Contexts...)
+	}
+
+	return task
+}
+
+// Converts a TaskItem of a list to a todo.txt task.
+func toTodoTxt(t api.TaskItem, listName string, loc *time.Location) todotxt.Task {
+	task := todotxt.Task{
+		Completed: t.Status == "completed",
+		Priority:  importanceToPriority[t.Importance],
+		Title:     t.Title,
+		Projects:  []string{listName},
+		Contexts:  t.Categories,
+	}
+
+	if created, err := time.Parse(time.RFC3339, t.CreatedDateTime); err == nil {
+		task.CreationDate = created.In(loc)
+	}
+
+	if t.CompletedDateTime != nil {
+		if completed, err := t.CompletedDateTime.Time(); err == nil {
+			task.CompletionDate = completed.In(loc)
+		}
+	}
+
+	if t.DueDateTime != nil {
+		if due, err := t.DueDateTime.Time(); err == nil {
+			// Due dates are days, so they are kept in their own time zone.
+			task.SetExtension(todotxt.DueKey, due.Format(todotxt.DateLayout))
+		}
+	}
+
+	return task
+}
+
+// Returns the name of the list a todo.txt project stands for: an existing
+// list whose name (with its spaces as dashes) matches it, or the project
+// itself (for a new list).
+func projectListName(project string, lists []api.ListsItem) string {
+	for _, l := range lists {
+		if strings.EqualFold(todotxt.Tag(l.Name), project) {
+			return l.Name
+		}
+	}
+
+	return project
+}
@@ -0,0 +1,117 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package app
+
+import (
+	"github.com/betasve/mstd/conf"
+	api "github.com/betasve/mstd/todoapi"
+	apiTest "github.com/betasve/mstd/todoapi/todoapitest"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestImportTodoTxt(test *testing.T) {
+	config = &conf.Config{}
+	apiClient = &apiTest.TodoApiMock{}
+	stubLists()
+
+	path := filepath.Join(test.TempDir(), "todo.txt")
+	content := "(A) Buy milk +Groceries @store due:2021-05-03\n" +
+		"x Call mom +Family-Calls @phone\n" +
+		"(D) Someday +Groceries +Home\n"
+	_ = ioutil.WriteFile(path, []byte(content), 0600)
+
+	createdLists := []string{}
+	apiTest.ListsCreateMockFn = func(n string) (*api.ListsItem, error) {
+		createdLists = append(createdLists, n)
+		return &api.ListsItem{Id: "new-" + n, Name: n}, nil
+	}
+
+	created := map[string]*api.TaskItem{}
+	apiTest.TasksCreateMockFn = func(l string, t *api.TaskItem) (*api.TaskItem, error) {
+		created[l+"/"+t.Title] = t
+		return t, nil
+	}
+
+	if err := ImportTodoTxt(path, "", true, true); err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	if len(created) != 0 || len(createdLists) != 0 {
+		test.Fatalf("\nExpected a dry run not to create anything\nbut created\n%v %v", created, createdLists)
+	}
+
+	if err := ImportTodoTxt(path, "", true, false); err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	if len(createdLists) != 1 || createdLists[0] != "Family-Calls" {
+		test.Errorf("\nExpected the list Family-Calls to be created\nbut created\n%v", createdLists)
+	}
+
+	milk := created["list-id/Buy milk"]
+	if milk == nil ||
+		milk.Importance != "high" ||
+		milk.DueDateTime == nil ||
+		milk.DueDateTime.DateTime != "2021-05-03T00:00:00.0000000" ||
+		milk.Categories[0] != "store" {
+		test.Errorf("\nExpected milk to be mapped\nbut was\n%+v", milk)
+	}
+
+	if mom := created["new-Family-Calls/Call mom"]; mom == nil || mom.Status != "completed" {
+		test.Errorf("\nExpected a completed task in the new list\nbut was\n%+v", mom)
+	}
+
+	someday := created["list-id/Someday"]
+	if someday == nil || someday.Importance != "low" || someday.Categories[0] != "Home" {
+		test.Errorf("\nExpected a low importance task with a category\nbut was\n%+v", someday)
+	}
+
+	if err := ImportTodoTxt(path, "", false, true); err == nil {
+		test.Error("\nExpected tasks without a list to return error\nbut it was\nnil")
+	}
+}
+
+func TestExportTodoTxt(test *testing.T) {
+	config = &conf.Config{}
+	apiClient = &apiTest.TodoApiMock{}
+	stubLists()
+
+	apiTest.TasksIndexMockFn = func(l string) (*[]api.TaskItem, error) {
+		return &[]api.TaskItem{
+			{
+				Title:       "Buy milk",
+				Importance:  "high",
+				Categories:  []string{"store"},
+				DueDateTime: &api.DateTimeTimeZone{DateTime: "2021-05-03T00:00:00.0000000", TimeZone: "UTC"},
+			},
+			{Title: "Bread", Status: "completed", Importance: "normal"},
+		}, nil
+	}
+
+	path := filepath.Join(test.TempDir(), "todo.txt")
+	if err := ExportTodoTxt("", path); err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	data, _ := ioutil.ReadFile(path)
+	expected := "(A) Buy milk +Groceries @store due:2021-05-03\nx Bread +Groceries\n"
+
+	if string(data) != expected {
+		test.Errorf("\nExpected the export to be:\n%s\nbut was\n%s", expected, data)
+	}
+}
@@ -0,0 +1,79 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	api "github.com/betasve/mstd/todoapi"
+	"github.com/betasve/mstd/tui"
+)
+
+// Implements the `tui.Backend` with the app's API client.
+type uiBackend struct{}
+
+// Starts the interactive, full screen UI.
+func UI() error {
+	apiClient.SetToken(config.ClientAccessToken())
+
+	return tui.Run(uiBackend{})
+}
+
+func (uiBackend) Lists() ([]api.ListsItem, error) {
+	lists, err := apiClient.ListsIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return *lists, nil
+}
+
+func (uiBackend) CreateList(name string) (*api.ListsItem, error) {
+	return apiClient.ListsCreate(ctx, name)
+}
+
+func (uiBackend) RenameList(listId, name string) (*api.ListsItem, error) {
+	return apiClient.ListsUpdate(ctx, listId, name)
+}
+
+func (uiBackend) Tasks(listId string) ([]api.TaskItem, error) {
+	tasks, err := apiClient.TasksIndex(ctx, listId)
+	if err != nil {
+		return nil, err
+	}
+
+	return *tasks, nil
+}
+
+func (uiBackend) CreateTask(listId, title string) (*api.TaskItem, error) {
+	task, err := buildTask(TaskOptions{Title: title})
+	if err != nil {
+		return nil, err
+	}
+
+	return apiClient.TasksCreate(ctx, listId, task)
+}
+
+func (uiBackend) UpdateTask(listId, taskId string, changes *api.TaskItem) (*api.TaskItem, error) {
+	return apiClient.TasksUpdate(ctx, listId, taskId, changes)
+}
+
+func (uiBackend) DeleteTask(listId, taskId string) error {
+	return apiClient.TasksDelete(ctx, listId, taskId)
+}
+
+func (uiBackend) MoveTask(fromListId, taskId, toListId string) (*api.TaskItem, error) {
+	return moveTask(fromListId, taskId, toListId)
+}
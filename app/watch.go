@@ -0,0 +1,303 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"errors"
+	"fmt"
+	"github.com/betasve/mstd/cache"
+	tm "github.com/betasve/mstd/ext/time"
+	api "github.com/betasve/mstd/todoapi"
+	"github.com/olekukonko/tablewriter"
+	"os"
+	"time"
+)
+
+// The shortest interval between two refreshes of `mstd watch`, keeping it
+// well within the API's throttling limits.
+const MinWatchInterval time.Duration = 5 * time.Second
+
+// The longest the watch waits between two refreshes when backing off.
+const maxWatchInterval time.Duration = 5 * time.Minute
+
+// The terminal escape sequence moving the cursor home and clearing the screen.
+const clearScreen string = "\033[H\033[2J"
+
+// The highlights of the tasks on the watch screen.
+const (
+	watchNew       int = tablewriter.FgGreenColor
+	watchCompleted int = tablewriter.FgBlueColor
+	watchOverdue   int = tablewriter.FgRedColor
+)
+
+// Keeps the tasks shown by `mstd watch` and what happened to them since the
+// watch started.
+type watchState struct {
+	tasks     []api.TaskItem
+	added     map[string]bool
+	completed map[string]bool
+}
+
+// Keeps a table of the tasks in a list (found by its id or name) on screen,
+// refreshing it every `interval` with the changes since the previous round.
+// New, completed and overdue tasks are highlighted. It runs until it's
+// interrupted (Ctrl+C).
+func Watch(list string, interval time.Duration, columns []string) error {
+	if interval < MinWatchInterval {
+		return invalidf("Interval should be at least %s", MinWatchInterval)
+	}
+
+	apiClient.SetToken(config.ClientAccessToken())
+
+	listId, err := resolveListId(list)
+	if err != nil {
+		return err
+	}
+
+	state, err := startWatch(listId)
+	if err != nil {
+		return err
+	}
+
+	wait := interval
+	var lastErr error
+
+	for {
+		printWatchScreen(list, state, columns, wait, lastErr)
+
+		select {
+		case <-ctx.Done():
+			fmt.Fprintln(os.Stdout)
+			return nil
+		case <-time.After(wait):
+		}
+
+		if err := refreshLogin(); err != nil {
+			return err
+		}
+
+		delta, err := apiClient.TasksDelta(ctx, listId)
+		if ctx.Err() != nil {
+			fmt.Fprintln(os.Stdout)
+			return nil
+		}
+
+		if err != nil && !retriableWatchError(err) {
+			return err
+		}
+
+		if err == nil {
+			state.apply(delta, true)
+		}
+
+		wait = nextWatchWait(err, wait, interval)
+		lastErr = err
+	}
+}
+
+// Loads the tasks of the list and makes the first delta query, so the next
+// rounds get only the changes made after the watch started.
+func startWatch(listId string) (*watchState, error) {
+	tasks, err := apiClient.TasksIndex(ctx, listId)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &watchState{
+		tasks:     *tasks,
+		added:     map[string]bool{},
+		completed: map[string]bool{},
+	}
+
+	delta, err := apiClient.TasksDelta(ctx, listId)
+	if err != nil {
+		return nil, err
+	}
+
+	state.apply(delta, false)
+
+	return state, nil
+}
+
+// Applies the changes of a delta query to the tasks. When `track` is set,
+// the tasks that are new or got completed are remembered to be highlighted.
+func (w *watchState) apply(delta *api.TasksDelta, track bool) {
+	previous := map[string]api.TaskItem{}
+	for _, t := range w.tasks {
+		previous[t.Id] = t
+	}
+
+	if delta.Full {
+		w.tasks = []api.TaskItem{}
+	}
+
+	for _, t := range append(delta.Added, delta.Updated...) {
+		old, existed := previous[t.Id]
+
+		if track && !existed {
+			w.added[t.Id] = true
+		}
+
+		if track && existed && old.Status != "completed" && t.Status == "completed" {
+			w.completed[t.Id] = true
+		}
+
+		w.put(t)
+	}
+
+	for _, id := range delta.Removed {
+		w.remove(id)
+		delete(w.added, id)
+		delete(w.completed, id)
+	}
+}
+
+// Adds or replaces a task.
+func (w *watchState) put(task api.TaskItem) {
+	for i, t := range w.tasks {
+		if t.Id == task.Id {
+			w.tasks[i] = task
+			return
+		}
+	}
+
+	w.tasks = append(w.tasks, task)
+}
+
+// Removes a task.
+func (w *watchState) remove(id string) {
+	for i, t := range w.tasks {
+		if t.Id == id {
+			w.tasks = append(w.tasks[:i], w.tasks[i+1:]...)
+			return
+		}
+	}
+}
+
+// Returns the color a task is highlighted in at `now` (`0` for none).
+// Completed tasks take precedence over the new ones, and those over the
+// overdue ones.
+func (w *watchState) highlight(task api.TaskItem, now time.Time) int {
+	switch {
+	case w.completed[task.Id] && task.Status == "completed":
+		return watchCompleted
+	case w.added[task.Id]:
+		return watchNew
+	case isOverdue(task, now):
+		return watchOverdue
+	}
+
+	return 0
+}
+
+// Checks if a task is not completed and its due date has passed. Tasks due
+// on a date (with no time) are overdue once that day is over.
+func isOverdue(task api.TaskItem, now time.Time) bool {
+	if task.Status == "completed" || task.DueDateTime == nil {
+		return false
+	}
+
+	due, err := task.DueDateTime.Time()
+	if err != nil {
+		return false
+	}
+
+	if due.Hour() == 0 && due.Minute() == 0 {
+		due = due.AddDate(0, 0, 1)
+	}
+
+	return now.After(due)
+}
+
+// Checks if a failed refresh is worth retrying (being offline or throttled)
+// rather than ending the watch.
+func retriableWatchError(err error) bool {
+	var apiErr *api.ApiError
+	if errors.As(err, &apiErr) {
+		return apiErr.Throttled()
+	}
+
+	return cache.IsOffline(err)
+}
+
+// Returns how long to wait before the next refresh. After a failed one it's
+// doubled (up to `maxWatchInterval`), or set to what the API asked for when
+// throttled. After a successful one it's back to `interval`.
+func nextWatchWait(err error, wait, interval time.Duration) time.Duration {
+	if err == nil {
+		return interval
+	}
+
+	var apiErr *api.ApiError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		return apiErr.RetryAfter
+	}
+
+	wait *= 2
+	if wait > maxWatchInterval {
+		return maxWatchInterval
+	}
+
+	return wait
+}
+
+// Redraws the whole screen: a status line, the table of the tasks and
+// a legend of the highlights.
+func printWatchScreen(
+	list string,
+	state *watchState,
+	columns []string,
+	wait time.Duration,
+	lastErr error,
+) {
+	now := tm.Client.Now()
+
+	rows := []interface{}{}
+	colors := []int{}
+	for _, task := range state.tasks {
+		rows = append(rows, newTaskRow(task))
+		colors = append(colors, state.highlight(task, now))
+	}
+
+	fmt.Fprint(os.Stdout, clearScreen)
+	fmt.Fprintf(
+		os.Stdout,
+		"Watching %q, updated at %s, next update in %s (Ctrl+C to exit)\n",
+		list,
+		now.Format("15:04:05"),
+		wait,
+	)
+
+	if lastErr != nil {
+		fmt.Fprintf(os.Stdout, "Last update failed, retrying: %s\n", lastErr)
+	}
+
+	printHighlightedTable(rows, colors, columns, TaskItemHeaders, TaskColumnsToKeysMap)
+
+	fmt.Fprintf(
+		os.Stdout,
+		"%s  %s  %s\n",
+		colorize("new", watchNew),
+		colorize("completed", watchCompleted),
+		colorize("overdue", watchOverdue),
+	)
+}
+
+// Wraps a text in the terminal escape sequences of a (tablewriter) color.
+func colorize(text string, color int) string {
+	return fmt.Sprintf("\033[%dm%s\033[0m", color, text)
+}
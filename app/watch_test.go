@@ -0,0 +1,120 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package app
+
+import (
+	"errors"
+	api "github.com/betasve/mstd/todoapi"
+	"testing"
+	"time"
+)
+
+func TestWatchStateHighlights(test *testing.T) {
+	now := time.Date(2021, 5, 5, 12, 0, 0, 0, time.UTC)
+	state := &watchState{
+		tasks: []api.TaskItem{
+			{Id: "t1", Status: "notStarted"},
+			{Id: "t2", Status: "notStarted", DueDateTime: api.NewDateTimeTimeZone(now.AddDate(0, 0, -1))},
+			{Id: "t3", Status: "notStarted"},
+		},
+		added:     map[string]bool{},
+		completed: map[string]bool{},
+	}
+
+	state.apply(&api.TasksDelta{
+		Added:   []api.TaskItem{{Id: "t4", Status: "notStarted"}},
+		Updated: []api.TaskItem{{Id: "t1", Status: "completed"}},
+		Removed: []string{"t3"},
+	}, true)
+
+	expected := map[string]int{
+		"t1": watchCompleted,
+		"t2": watchOverdue,
+		"t4": watchNew,
+	}
+
+	if len(state.tasks) != len(expected) {
+		test.Fatalf("\nExpected %d tasks\nbut got\n%v", len(expected), state.tasks)
+	}
+
+	for _, task := range state.tasks {
+		if color := state.highlight(task, now); color != expected[task.Id] {
+			test.Errorf("\nExpected %s to be highlighted in:\n%d\nbut was\n%d", task.Id, expected[task.Id], color)
+		}
+	}
+}
+
+func TestWatchStateFullDeltaIsNotHighlightedWhenUntracked(test *testing.T) {
+	state := &watchState{
+		tasks:     []api.TaskItem{{Id: "gone"}},
+		added:     map[string]bool{},
+		completed: map[string]bool{},
+	}
+
+	state.apply(&api.TasksDelta{Added: []api.TaskItem{{Id: "t1"}}, Full: true}, false)
+
+	if len(state.tasks) != 1 || state.tasks[0].Id != "t1" {
+		test.Errorf("\nExpected only the tasks of the full delta\nbut got\n%v", state.tasks)
+	}
+
+	if len(state.added) != 0 {
+		test.Errorf("\nExpected no new tasks\nbut got\n%v", state.added)
+	}
+}
+
+func TestIsOverdueForDateOnlyDue(test *testing.T) {
+	task := api.TaskItem{
+		DueDateTime: &api.DateTimeTimeZone{DateTime: "2021-05-05T00:00:00.0000000", TimeZone: "UTC"},
+	}
+
+	if isOverdue(task, time.Date(2021, 5, 5, 23, 0, 0, 0, time.UTC)) {
+		test.Error("\nExpected a task due today not to be overdue\nbut it was")
+	}
+
+	if !isOverdue(task, time.Date(2021, 5, 6, 1, 0, 0, 0, time.UTC)) {
+		test.Error("\nExpected a task due yesterday to be overdue\nbut it was not")
+	}
+}
+
+func TestNextWatchWait(test *testing.T) {
+	interval := 30 * time.Second
+	throttled := &api.ApiError{StatusCode: 429, RetryAfter: 42 * time.Second}
+
+	cases := []struct {
+		err      error
+		wait     time.Duration
+		expected time.Duration
+	}{
+		{nil, 4 * time.Minute, interval},
+		{throttled, interval, 42 * time.Second},
+		{&api.ApiError{StatusCode: 503}, interval, 2 * interval},
+		{errors.New("offline"), 4 * time.Minute, maxWatchInterval},
+	}
+
+	for _, c := range cases {
+		if result := nextWatchWait(c.err, c.wait, interval); result != c.expected {
+			test.Errorf("\nExpected wait after %v to be:\n%s\nbut was\n%s", c.err, c.expected, result)
+		}
+	}
+
+	if !retriableWatchError(throttled) {
+		test.Error("\nExpected a throttled request to be retried\nbut it was not")
+	}
+
+	if retriableWatchError(&api.ApiError{StatusCode: 401}) {
+		test.Error("\nExpected an unauthorized request not to be retried\nbut it was")
+	}
+}
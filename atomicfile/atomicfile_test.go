@@ -0,0 +1,73 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package atomicfile
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteKeepsTheFileOnFailure(test *testing.T) {
+	path := filepath.Join(test.TempDir(), "backup.json")
+	if err := ioutil.WriteFile(path, []byte("previous"), 0644); err != nil {
+		test.Fatal(err)
+	}
+
+	err := Write(path, func(out io.Writer) error {
+		fmt.Fprint(out, "half")
+		return errors.New("interrupted")
+	})
+	if err == nil || err.Error() != "interrupted" {
+		test.Errorf("\nExpected error to be:\ninterrupted\nbut was\n%v", err)
+	}
+
+	if data, _ := ioutil.ReadFile(path); string(data) != "previous" {
+		test.Errorf("\nExpected the file to be left as it was\nbut was\n%s", data)
+	}
+
+	if err := WriteFile(path, []byte("new")); err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	if data, _ := ioutil.ReadFile(path); string(data) != "new" {
+		test.Errorf("\nExpected the file to be replaced\nbut was\n%s", data)
+	}
+
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0644 {
+		test.Errorf("\nExpected the permissions to be kept\nbut were\n%v", info.Mode())
+	}
+
+	if entries, _ := ioutil.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		test.Errorf("\nExpected no temporary file to be left\nbut there were\n%d files", len(entries))
+	}
+}
+
+func TestWriteFileCreatesTheDirectory(test *testing.T) {
+	path := filepath.Join(test.TempDir(), "mstd", "work", "cache.json")
+
+	if err := WriteFile(path, []byte("{}")); err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		test.Errorf("\nExpected a new file only the user can read\nbut was\n%v, %v", info, err)
+	}
+}
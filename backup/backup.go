@@ -0,0 +1,87 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// The `backup` package holds the format of the backups of an account: a
+// single, versioned JSON archive with all of its lists and, for each of their
+// tasks, the checklist items and linked resources.
+package backup
+
+import (
+	"encoding/json"
+	"fmt"
+	api "github.com/betasve/mstd/todoapi"
+	"io"
+	"time"
+)
+
+// The version of the archives written. It's increased whenever the format
+// changes in a way older versions of the tool can't read.
+const Version int = 1
+
+type Archive struct {
+	Version         int       `json:"version"`
+	CreatedDateTime time.Time `json:"createdDateTime"`
+	Profile         string    `json:"profile,omitempty"`
+	Lists           []List    `json:"lists"`
+}
+
+type List struct {
+	List  api.ListsItem `json:"list"`
+	Tasks []Task        `json:"tasks"`
+}
+
+type Task struct {
+	Task            api.TaskItem         `json:"task"`
+	ChecklistItems  []api.ChecklistItem  `json:"checklistItems,omitempty"`
+	LinkedResources []api.LinkedResource `json:"linkedResources,omitempty"`
+}
+
+// Returns how many tasks the archive holds.
+func (a *Archive) TasksCount() int {
+	count := 0
+	for _, l := range a.Lists {
+		count += len(l.Tasks)
+	}
+
+	return count
+}
+
+// Writes the archive as (indented) JSON, stamped with the current version.
+func Write(w io.Writer, archive *Archive) error {
+	archive.Version = Version
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(archive)
+}
+
+// Reads an archive, refusing the ones of an unknown (newer) version.
+func Read(r io.Reader) (*Archive, error) {
+	archive := Archive{}
+	if err := json.NewDecoder(r).Decode(&archive); err != nil {
+		return nil, fmt.Errorf("Invalid backup archive: %s", err)
+	}
+
+	if archive.Version < 1 || archive.Version > Version {
+		return nil, fmt.Errorf(
+			"Unsupported backup archive version %d (supported up to %d)",
+			archive.Version,
+			Version,
+		)
+	}
+
+	return &archive, nil
+}
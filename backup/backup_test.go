@@ -0,0 +1,55 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package backup
+
+import (
+	"bytes"
+	api "github.com/betasve/mstd/todoapi"
+	"strings"
+	"testing"
+)
+
+func TestWriteAndRead(test *testing.T) {
+	archive := &Archive{Lists: []List{{
+		List: api.ListsItem{Id: "l1", Name: "Groceries"},
+		Tasks: []Task{{
+			Task:           api.TaskItem{Id: "t1", Title: "Buy milk"},
+			ChecklistItems: []api.ChecklistItem{{DisplayName: "Whole", IsChecked: true}},
+		}},
+	}}}
+
+	buffer := bytes.Buffer{}
+	if err := Write(&buffer, archive); err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	read, err := Read(&buffer)
+	if err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	if read.Version != Version || read.TasksCount() != 1 || !read.Lists[0].Tasks[0].ChecklistItems[0].IsChecked {
+		test.Errorf("\nExpected the archive to be read back\nbut was\n%+v", read)
+	}
+}
+
+func TestReadUnsupportedVersions(test *testing.T) {
+	for _, data := range []string{`{"version": 2, "lists": []}`, `{"lists": []}`, `[]`} {
+		if _, err := Read(strings.NewReader(data)); err == nil {
+			test.Errorf("\nExpected %s to return error\nbut it was\nnil", data)
+		}
+	}
+}
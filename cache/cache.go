@@ -0,0 +1,746 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The `cache` package keeps a local, on-disk copy of the lists and tasks of
+// the account. It wraps a `todoapi.TodoApiClient` (implementing the same
+// interface) so the rest of the app is unaware of it. Reads go to the API and
+// refresh the copy, but fall back to it when the API can't be reached. Writes
+// made while offline are queued and replayed by `Sync`.
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/betasve/mstd/ext/log"
+	tm "github.com/betasve/mstd/ext/time"
+	api "github.com/betasve/mstd/todoapi"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The kinds of the write operations that can be queued.
+const (
+	OpListCreate string = "listCreate"
+	OpListUpdate string = "listUpdate"
+	OpTaskCreate string = "taskCreate"
+	OpTaskUpdate string = "taskUpdate"
+	OpTaskDelete string = "taskDelete"
+)
+
+// The prefix of the ids given to items created while offline.
+const localIdPrefix string = "local-"
+
+// Wraps a TodoApiClient with the local copy.
+type Client struct {
+	api.TodoApiClient
+	mu     sync.Mutex
+	store  *store
+	deltas *DeltaStore
+}
+
+// A write operation made while offline, waiting to be sent to the API.
+type Operation struct {
+	Kind     string        `json:"kind"`
+	ListId   string        `json:"listId,omitempty"`
+	Id       string        `json:"id,omitempty"`
+	Name     string        `json:"name,omitempty"`
+	Task     *api.TaskItem `json:"task,omitempty"`
+	QueuedAt time.Time     `json:"queuedAt"`
+}
+
+// Summarizes the outcome of a Sync.
+type SyncReport struct {
+	Replayed int
+	Failed   []error
+	Pending  int
+	Lists    int
+	Tasks    int
+}
+
+// Creates a Client wrapping `remote` and keeping its copy in the file at
+// `path`. When `deltas` (the store of the remote's delta queries) is given,
+// syncing fetches only the changes since the previous sync.
+func New(remote api.TodoApiClient, path string, deltas *DeltaStore) *Client {
+	return &Client{
+		TodoApiClient: remote,
+		store:         &store{path: path},
+		deltas:        deltas,
+	}
+}
+
+// Retrieves the lists from the API, refreshing the local copy. When offline
+// the copy is returned instead.
+func (c *Client) ListsIndex(ctx context.Context) (*[]api.ListsItem, error) {
+	lists, err := c.TodoApiClient.ListsIndex(ctx)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	snap, loadErr := c.store.load()
+	if loadErr != nil {
+		return lists, err
+	}
+
+	if err == nil {
+		snap.Lists = *lists
+		snap.ListsSyncedAt = tm.Client.Now()
+		c.keep(snap)
+		return lists, nil
+	}
+
+	if !IsOffline(err) || snap.ListsSyncedAt.IsZero() {
+		return nil, err
+	}
+
+	warnStale(snap.ListsSyncedAt, len(snap.Queue))
+	cached := append([]api.ListsItem{}, snap.Lists...)
+
+	return &cached, nil
+}
+
+// Creates a list through the API. When offline the creation is queued and
+// the list gets a temporary (local) id until it's synced.
+func (c *Client) ListsCreate(ctx context.Context, name string) (*api.ListsItem, error) {
+	list, err := c.TodoApiClient.ListsCreate(ctx, name)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	snap, loadErr := c.store.load()
+	if loadErr != nil {
+		return list, err
+	}
+
+	if err == nil {
+		snap.Lists = append(snap.Lists, *list)
+		c.keep(snap)
+		return list, nil
+	}
+
+	if !isOfflineCreate(err) {
+		return nil, timedOutCreate(err)
+	}
+
+	list = &api.ListsItem{Id: snap.nextLocalId(), Name: name, Owner: true}
+	snap.Lists = append(snap.Lists, *list)
+	snap.enqueue(Operation{Kind: OpListCreate, Id: list.Id, Name: name})
+
+	return list, c.store.save(snap)
+}
+
+// Renames a list through the API. When offline the change is queued.
+func (c *Client) ListsUpdate(ctx context.Context, id, name string) (*api.ListsItem, error) {
+	list, err := c.TodoApiClient.ListsUpdate(ctx, id, name)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	snap, loadErr := c.store.load()
+	if loadErr != nil {
+		return list, err
+	}
+
+	if err == nil {
+		snap.putList(*list)
+		c.keep(snap)
+		return list, nil
+	}
+
+	if !IsOffline(err) {
+		return nil, err
+	}
+
+	cached, ok := snap.findList(id)
+	if !ok {
+		return nil, err
+	}
+
+	cached.Name = name
+	snap.putList(cached)
+	snap.enqueue(Operation{Kind: OpListUpdate, Id: id, Name: name})
+
+	return &cached, c.store.save(snap)
+}
+
+// Retrieves the tasks of a list from the API, refreshing the local copy.
+// When offline the copy is returned instead.
+func (c *Client) TasksIndex(ctx context.Context, listId string) (*[]api.TaskItem, error) {
+	tasks, err := c.TodoApiClient.TasksIndex(ctx, listId)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	snap, loadErr := c.store.load()
+	if loadErr != nil {
+		return tasks, err
+	}
+
+	if err == nil {
+		snap.Tasks[listId] = *tasks
+		snap.TasksSyncedAt[listId] = tm.Client.Now()
+		c.keep(snap)
+		return tasks, nil
+	}
+
+	syncedAt, ok := snap.TasksSyncedAt[listId]
+	if !IsOffline(err) || (!ok && !IsLocalId(listId)) {
+		return nil, err
+	}
+
+	warnStale(syncedAt, len(snap.Queue))
+	cached := append([]api.TaskItem{}, snap.Tasks[listId]...)
+
+	return &cached, nil
+}
+
+// Retrieves a task from the API. When offline the local copy is returned.
+func (c *Client) TasksShow(ctx context.Context, listId, taskId string) (*api.TaskItem, error) {
+	task, err := c.TodoApiClient.TasksShow(ctx, listId, taskId)
+	if err == nil || !IsOffline(err) {
+		return task, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	snap, loadErr := c.store.load()
+	if loadErr != nil {
+		return nil, err
+	}
+
+	cached, ok := snap.findTask(listId, taskId)
+	if !ok {
+		return nil, err
+	}
+
+	warnStale(snap.TasksSyncedAt[listId], len(snap.Queue))
+
+	return &cached, nil
+}
+
+// Retrieves the changes of the tasks of a list from the API and applies them
+// to the local copy too, so it doesn't miss the changes consumed by others
+// (e.g. `mstd watch`) before the next sync.
+func (c *Client) TasksDelta(ctx context.Context, listId string) (*api.TasksDelta, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	snap, loadErr := c.store.load()
+
+	// Without a copy of the list to apply the changes to, start over.
+	if _, ok := snap.TasksSyncedAt[listId]; loadErr == nil && c.deltas != nil && !ok {
+		if err := c.deltas.ResetDelta(api.TasksDeltaKey(listId)); err != nil {
+			return nil, err
+		}
+	}
+
+	delta, err := c.TodoApiClient.TasksDelta(ctx, listId)
+	if err != nil || loadErr != nil {
+		return delta, err
+	}
+
+	applyTasksDelta(snap, listId, delta)
+	snap.TasksSyncedAt[listId] = tm.Client.Now()
+	c.keep(snap)
+
+	return delta, nil
+}
+
+// Creates a task through the API. When offline the creation is queued and
+// the task gets a temporary (local) id until it's synced.
+func (c *Client) TasksCreate(ctx context.Context, listId string, task *api.TaskItem) (*api.TaskItem, error) {
+	created, err := c.TodoApiClient.TasksCreate(ctx, listId, task)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	snap, loadErr := c.store.load()
+	if loadErr != nil {
+		return created, err
+	}
+
+	if err == nil {
+		snap.putTask(listId, *created)
+		c.keep(snap)
+		return created, nil
+	}
+
+	if !isOfflineCreate(err) {
+		return nil, timedOutCreate(err)
+	}
+
+	local := *task
+	local.Id = snap.nextLocalId()
+	if local.Status == "" {
+		local.Status = "notStarted"
+	}
+
+	snap.putTask(listId, local)
+	snap.enqueue(Operation{Kind: OpTaskCreate, ListId: listId, Id: local.Id, Task: task})
+
+	return &local, c.store.save(snap)
+}
+
+// Updates a task through the API. When offline the change is queued.
+func (c *Client) TasksUpdate(ctx context.Context, listId, taskId string, task *api.TaskItem) (*api.TaskItem, error) {
+	updated, err := c.TodoApiClient.TasksUpdate(ctx, listId, taskId, task)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	snap, loadErr := c.store.load()
+	if loadErr != nil {
+		return updated, err
+	}
+
+	if err == nil {
+		snap.putTask(listId, *updated)
+		c.keep(snap)
+		return updated, nil
+	}
+
+	if !IsOffline(err) {
+		return nil, err
+	}
+
+	cached, ok := snap.findTask(listId, taskId)
+	if !ok {
+		return nil, err
+	}
+
+	mergeTask(&cached, task)
+	snap.putTask(listId, cached)
+	snap.enqueue(Operation{Kind: OpTaskUpdate, ListId: listId, Id: taskId, Task: task})
+
+	return &cached, c.store.save(snap)
+}
+
+// Deletes a task through the API. When offline the deletion is queued, unless
+// the task was itself created offline, in which case its queued operations
+// are simply dropped.
+func (c *Client) TasksDelete(ctx context.Context, listId, taskId string) error {
+	err := c.TodoApiClient.TasksDelete(ctx, listId, taskId)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	snap, loadErr := c.store.load()
+	if loadErr != nil {
+		return err
+	}
+
+	if err == nil {
+		snap.removeTask(listId, taskId)
+		c.keep(snap)
+		return nil
+	}
+
+	if !IsOffline(err) {
+		return err
+	}
+
+	snap.removeTask(listId, taskId)
+
+	if IsLocalId(taskId) {
+		snap.dequeue(taskId)
+	} else {
+		snap.enqueue(Operation{Kind: OpTaskDelete, ListId: listId, Id: taskId})
+	}
+
+	return c.store.save(snap)
+}
+
+// Runs a batch of requests through the API, applying the tasks it created,
+// updated and deleted to the local copy. A batch isn't queued when offline,
+// the error is returned for the caller to fall back to single requests.
+func (c *Client) Batch(ctx context.Context, requests []api.BatchRequest) (map[string]*api.BatchResponse, error) {
+	responses, err := c.TodoApiClient.Batch(ctx, requests)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	snap, loadErr := c.store.load()
+	if loadErr != nil {
+		return responses, err
+	}
+
+	for _, r := range requests {
+		response, ok := responses[r.Id]
+		listId, taskId, isTask := taskUrlIds(r.Url)
+		if !ok || !isTask {
+			continue
+		}
+
+		switch {
+		case r.Method == "POST" && response.Status == 201,
+			r.Method == "PATCH" && response.Status == 200:
+			task := api.TaskItem{}
+			if response.Decode(&task) == nil {
+				snap.putTask(listId, task)
+			}
+		case r.Method == "DELETE" && response.Status == 204:
+			snap.removeTask(listId, taskId)
+		}
+	}
+
+	c.keep(snap)
+	return responses, err
+}
+
+// Replays the queued operations against the API and then refreshes the
+// local copy of all the lists and their tasks. Operations rejected by the
+// API are dropped and reported, while the ones that fail because the API
+// can't be reached are kept for the next sync.
+func (c *Client) Sync(ctx context.Context) (*SyncReport, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	snap, err := c.store.load()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &SyncReport{}
+	ids := map[string]string{}
+
+	for i, op := range snap.Queue {
+		err := c.replay(ctx, op, ids)
+		if id, ok := ids[op.Id]; ok && err == nil {
+			snap.replaceId(op.Id, id)
+		}
+
+		offline := IsOffline(err)
+		if op.Kind == OpListCreate || op.Kind == OpTaskCreate {
+			offline = isOfflineCreate(err)
+			err = timedOutCreate(err)
+		}
+
+		if err != nil && offline {
+			// The rest of the operations refer to what was created so far
+			// by the remote ids from now on.
+			snap.Queue = snap.Queue[i:]
+			for j := range snap.Queue {
+				snap.Queue[j].ListId = remapId(snap.Queue[j].ListId, ids)
+				snap.Queue[j].Id = remapId(snap.Queue[j].Id, ids)
+			}
+
+			report.Pending = len(snap.Queue)
+
+			if saveErr := c.store.save(snap); saveErr != nil {
+				return report, saveErr
+			}
+
+			return report, err
+		}
+
+		if err != nil {
+			report.Failed = append(report.Failed, fmt.Errorf("%s %s: %s", op.Kind, op.Id, err))
+		} else {
+			report.Replayed++
+		}
+	}
+
+	snap.Queue = []Operation{}
+
+	if err := c.refresh(ctx, snap, report); err != nil {
+		// Keep the emptied queue even if the refresh didn't succeed.
+		_ = c.store.save(snap)
+		return report, err
+	}
+
+	return report, c.store.save(snap)
+}
+
+// Sends a queued operation to the API, replacing the local ids with the
+// ones the API gave to the items created earlier in the same sync.
+func (c *Client) replay(ctx context.Context, op Operation, ids map[string]string) error {
+	listId := remapId(op.ListId, ids)
+	id := remapId(op.Id, ids)
+
+	switch op.Kind {
+	case OpListCreate:
+		list, err := c.TodoApiClient.ListsCreate(ctx, op.Name)
+		if err == nil {
+			ids[op.Id] = list.Id
+		}
+		return err
+	case OpListUpdate:
+		_, err := c.TodoApiClient.ListsUpdate(ctx, id, op.Name)
+		return err
+	case OpTaskCreate:
+		task, err := c.TodoApiClient.TasksCreate(ctx, listId, op.Task)
+		if err == nil {
+			ids[op.Id] = task.Id
+		}
+		return err
+	case OpTaskUpdate:
+		_, err := c.TodoApiClient.TasksUpdate(ctx, listId, id, op.Task)
+		return err
+	case OpTaskDelete:
+		return c.TodoApiClient.TasksDelete(ctx, listId, id)
+	}
+
+	return fmt.Errorf("Unknown queued operation %q", op.Kind)
+}
+
+// Brings the local copy up to date with the current state of the account.
+func (c *Client) refresh(ctx context.Context, snap *snapshot, report *SyncReport) error {
+	if c.deltas == nil {
+		return c.refreshFully(ctx, snap, report)
+	}
+
+	// Without a copy to apply the changes to, start the delta queries over.
+	if snap.ListsSyncedAt.IsZero() {
+		if err := c.deltas.ResetDelta(api.ListsDeltaKey); err != nil {
+			return err
+		}
+	}
+
+	listsDelta, err := c.TodoApiClient.ListsDelta(ctx)
+	if err != nil {
+		return err
+	}
+
+	applyListsDelta(snap, listsDelta)
+
+	now := tm.Client.Now()
+	snap.ListsSyncedAt = now
+
+	for _, list := range snap.Lists {
+		if _, ok := snap.TasksSyncedAt[list.Id]; !ok {
+			if err := c.deltas.ResetDelta(api.TasksDeltaKey(list.Id)); err != nil {
+				return err
+			}
+		}
+
+		tasksDelta, err := c.TodoApiClient.TasksDelta(ctx, list.Id)
+		if err != nil {
+			return err
+		}
+
+		applyTasksDelta(snap, list.Id, tasksDelta)
+		snap.TasksSyncedAt[list.Id] = now
+		report.Tasks += len(snap.Tasks[list.Id])
+	}
+
+	report.Lists = len(snap.Lists)
+
+	return nil
+}
+
+// Replaces the local copy with all the lists and tasks of the account.
+func (c *Client) refreshFully(ctx context.Context, snap *snapshot, report *SyncReport) error {
+	lists, err := c.TodoApiClient.ListsIndex(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := tm.Client.Now()
+	snap.Lists = *lists
+	snap.ListsSyncedAt = now
+	snap.Tasks = map[string][]api.TaskItem{}
+	snap.TasksSyncedAt = map[string]time.Time{}
+
+	for _, list := range *lists {
+		tasks, err := c.TodoApiClient.TasksIndex(ctx, list.Id)
+		if err != nil {
+			return err
+		}
+
+		snap.Tasks[list.Id] = *tasks
+		snap.TasksSyncedAt[list.Id] = now
+		report.Tasks += len(*tasks)
+	}
+
+	report.Lists = len(*lists)
+
+	return nil
+}
+
+// Applies the changes of the lists to the copy.
+func applyListsDelta(snap *snapshot, delta *api.ListsDelta) {
+	if delta.Full {
+		keep := map[string]bool{}
+		for _, l := range append(delta.Added, delta.Updated...) {
+			keep[l.Id] = true
+		}
+
+		for _, l := range append([]api.ListsItem{}, snap.Lists...) {
+			if !keep[l.Id] {
+				snap.removeList(l.Id)
+			}
+		}
+	}
+
+	for _, l := range append(delta.Added, delta.Updated...) {
+		snap.putList(l)
+	}
+
+	for _, id := range delta.Removed {
+		snap.removeList(id)
+	}
+}
+
+// Applies the changes of the tasks of a list to the copy.
+func applyTasksDelta(snap *snapshot, listId string, delta *api.TasksDelta) {
+	if delta.Full {
+		snap.Tasks[listId] = []api.TaskItem{}
+	}
+
+	for _, t := range append(delta.Added, delta.Updated...) {
+		snap.putTask(listId, t)
+	}
+
+	for _, id := range delta.Removed {
+		snap.removeTask(listId, id)
+	}
+}
+
+// Returns the number of operations waiting to be synced.
+func (c *Client) PendingOperations() (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	snap, err := c.store.load()
+	if err != nil {
+		return 0, err
+	}
+
+	return len(snap.Queue), nil
+}
+
+// Saves the local copy after a successful API call. Failing to do so only
+// results in a warning, as the call itself went through.
+func (c *Client) keep(snap *snapshot) {
+	if err := c.store.save(snap); err != nil {
+		log.Client.Warn("Could not update the local cache", "error", err)
+	}
+}
+
+// Checks if an error means the API could not be reached (as opposed to the
+// API rejecting the request or the request being cancelled).
+func IsOffline(err error) bool {
+	var urlErr *url.Error
+
+	// A request cancelled by the user didn't fail to reach the API.
+	return errors.As(err, &urlErr) && !errors.Is(err, context.Canceled)
+}
+
+// Tells whether a request timed out (be it the deadline of its context or
+// the timeout of the HTTP client).
+func IsTimeout(err error) bool {
+	var urlErr *url.Error
+
+	return errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &urlErr) && urlErr.Timeout())
+}
+
+// Does the same as IsOffline for the requests creating a list or a task.
+// Unlike the other requests, they can't be safely sent twice, and one that
+// timed out may have reached the API. So it's a failure, rather than a
+// change to queue (and replay).
+func isOfflineCreate(err error) bool {
+	return IsOffline(err) && !IsTimeout(err)
+}
+
+// Tells the user that a create that timed out may have been made.
+func timedOutCreate(err error) error {
+	if err == nil || !IsTimeout(err) {
+		return err
+	}
+
+	return fmt.Errorf("The request timed out, check if it was made before trying again: %w", err)
+}
+
+// Lets the user know the data shown is the local copy.
+func warnStale(syncedAt time.Time, queued int) {
+	since := "never"
+	if !syncedAt.IsZero() {
+		since = syncedAt.Format("2006-01-02 15:04")
+	}
+
+	log.Client.Warn(
+		"Offline: showing the cached data, queued changes wait for `mstd sync`",
+		"cachedAt", since,
+		"queued", queued,
+	)
+}
+
+// Copies the attributes set in `changes` over `task`.
+func mergeTask(task *api.TaskItem, changes *api.TaskItem) {
+	if changes.Title != "" {
+		task.Title = changes.Title
+	}
+	if changes.Status != "" {
+		task.Status = changes.Status
+	}
+	if changes.Importance != "" {
+		task.Importance = changes.Importance
+	}
+	if changes.Body != nil {
+		task.Body = changes.Body
+	}
+	if changes.Categories != nil {
+		task.Categories = changes.Categories
+	}
+	if changes.DueDateTime != nil {
+		task.DueDateTime = changes.DueDateTime
+	}
+	if changes.ReminderDateTime != nil {
+		task.IsReminderOn = changes.IsReminderOn
+		task.ReminderDateTime = changes.ReminderDateTime
+	}
+	if changes.Recurrence != nil {
+		task.Recurrence = changes.Recurrence
+	}
+}
+
+// Returns the id the API gave to an item created while offline, or the id
+// itself when it's not a local one.
+func remapId(id string, ids map[string]string) string {
+	if remote, ok := ids[id]; ok {
+		return remote
+	}
+
+	return id
+}
+
+// Finds the ids of the list and the task (empty for the tasks of a list) in
+// the url of a batch request, e.g. `/me/todo/lists/{list}/tasks/{task}`.
+func taskUrlIds(url string) (string, string, bool) {
+	if i := strings.Index(url, "?"); i != -1 {
+		url = url[:i]
+	}
+
+	parts := strings.Split(strings.Trim(url, "/"), "/")
+	if len(parts) < 5 || len(parts) > 6 || parts[2] != "lists" || parts[4] != "tasks" {
+		return "", "", false
+	}
+
+	if len(parts) == 6 {
+		return parts[3], parts[5], true
+	}
+
+	return parts[3], "", true
+}
+
+// Checks if an id was given to an item created while offline (and not
+// synced yet), so the API doesn't know it.
+func IsLocalId(id string) bool {
+	return strings.HasPrefix(id, localIdPrefix)
+}
@@ -0,0 +1,454 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cache
+
+import (
+	"context"
+	"errors"
+	"github.com/betasve/mstd/ext/log"
+	logtest "github.com/betasve/mstd/ext/log/logtest"
+	api "github.com/betasve/mstd/todoapi"
+	apiTest "github.com/betasve/mstd/todoapi/todoapitest"
+	"net/url"
+	"path/filepath"
+	"testing"
+)
+
+var offlineErr = &url.Error{Op: "Get", URL: "https://graph", Err: errors.New("no route to host")}
+
+func init() {
+	log.Client = logtest.LoggerServiceMock{}
+}
+
+func newTestClient(test *testing.T) *Client {
+	return New(&apiTest.TodoApiMock{}, filepath.Join(test.TempDir(), "cache.json"), nil)
+}
+
+func stubOnline() {
+	apiTest.ListsIndexMockFn = func() (*[]api.ListsItem, error) {
+		return &[]api.ListsItem{{Id: "l1", Name: "Groceries"}}, nil
+	}
+	apiTest.TasksIndexMockFn = func(l string) (*[]api.TaskItem, error) {
+		return &[]api.TaskItem{{Id: "t1", Title: "milk"}}, nil
+	}
+	apiTest.ListsCreateMockFn = func(n string) (*api.ListsItem, error) {
+		return &api.ListsItem{Id: "remote-" + n, Name: n}, nil
+	}
+	apiTest.TasksCreateMockFn = func(l string, t *api.TaskItem) (*api.TaskItem, error) {
+		created := *t
+		created.Id = "remote-" + t.Title
+		return &created, nil
+	}
+}
+
+func stubOffline() {
+	apiTest.ListsIndexMockFn = func() (*[]api.ListsItem, error) { return nil, offlineErr }
+	apiTest.TasksIndexMockFn = func(l string) (*[]api.TaskItem, error) { return nil, offlineErr }
+	apiTest.ListsCreateMockFn = func(n string) (*api.ListsItem, error) { return nil, offlineErr }
+	apiTest.TasksCreateMockFn = func(l string, t *api.TaskItem) (*api.TaskItem, error) {
+		return nil, offlineErr
+	}
+}
+
+func TestReadsServedFromCacheWhenOffline(test *testing.T) {
+	c := newTestClient(test)
+
+	stubOnline()
+	_, _ = c.ListsIndex(context.Background())
+	_, _ = c.TasksIndex(context.Background(), "l1")
+
+	stubOffline()
+
+	var warned bool
+	logtest.WarnMock = func(msg string, fields ...interface{}) { warned = true }
+
+	lists, err := c.ListsIndex(context.Background())
+	if err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	if len(*lists) != 1 || (*lists)[0].Name != "Groceries" {
+		test.Errorf("\nExpected the cached lists\nbut got\n%v", *lists)
+	}
+
+	tasks, err := c.TasksIndex(context.Background(), "l1")
+	if err != nil || len(*tasks) != 1 {
+		test.Errorf("\nExpected the cached tasks\nbut got\n%v, %s", tasks, err)
+	}
+
+	if !warned {
+		test.Error("\nExpected a staleness warning\nbut there was none")
+	}
+}
+
+func TestReadsFailWhenOfflineWithoutCache(test *testing.T) {
+	c := newTestClient(test)
+	stubOffline()
+
+	if _, err := c.ListsIndex(context.Background()); err != offlineErr {
+		test.Errorf("\nExpected error to be:\n%s\nbut was\n%v", offlineErr, err)
+	}
+}
+
+func TestApiErrorsAreNotQueued(test *testing.T) {
+	c := newTestClient(test)
+	apiErr := errors.New("Unsuccessful request to To Do API")
+	apiTest.ListsCreateMockFn = func(n string) (*api.ListsItem, error) { return nil, apiErr }
+
+	if _, err := c.ListsCreate(context.Background(), "new"); err != apiErr {
+		test.Errorf("\nExpected error to be:\n%s\nbut was\n%v", apiErr, err)
+	}
+
+	if pending, _ := c.PendingOperations(); pending != 0 {
+		test.Errorf("\nExpected no queued operations\nbut got\n%d", pending)
+	}
+}
+
+func TestOfflineWritesAreQueuedAndSynced(test *testing.T) {
+	c := newTestClient(test)
+	stubOffline()
+
+	list, err := c.ListsCreate(context.Background(), "Work")
+	if err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	if !IsLocalId(list.Id) {
+		test.Errorf("\nExpected a local id\nbut got\n%s", list.Id)
+	}
+
+	task, err := c.TasksCreate(context.Background(), list.Id, &api.TaskItem{Title: "report"})
+	if err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	if task.Status != "notStarted" {
+		test.Errorf("\nExpected status to be:\nnotStarted\nbut was\n%s", task.Status)
+	}
+
+	if pending, _ := c.PendingOperations(); pending != 2 {
+		test.Errorf("\nExpected 2 queued operations\nbut got\n%d", pending)
+	}
+
+	report, err := c.Sync(context.Background())
+	if err != offlineErr {
+		test.Errorf("\nExpected sync to fail while offline\nbut got\n%v", err)
+	}
+
+	if report.Pending != 2 {
+		test.Errorf("\nExpected 2 pending operations\nbut got\n%d", report.Pending)
+	}
+
+	stubOnline()
+
+	var createdIn string
+	apiTest.TasksCreateMockFn = func(l string, t *api.TaskItem) (*api.TaskItem, error) {
+		createdIn = l
+		return &api.TaskItem{Id: "remote-task"}, nil
+	}
+
+	report, err = c.Sync(context.Background())
+	if err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	if report.Replayed != 2 {
+		test.Errorf("\nExpected 2 replayed operations\nbut got\n%d", report.Replayed)
+	}
+
+	if createdIn != "remote-Work" {
+		test.Errorf("\nExpected the task to be created in:\nremote-Work\nbut was\n%s", createdIn)
+	}
+
+	if pending, _ := c.PendingOperations(); pending != 0 {
+		test.Errorf("\nExpected no queued operations\nbut got\n%d", pending)
+	}
+}
+
+func TestSyncGoingOfflineKeepsTheRemoteIds(test *testing.T) {
+	c := newTestClient(test)
+	stubOffline()
+
+	list, _ := c.ListsCreate(context.Background(), "Work")
+	_, _ = c.TasksCreate(context.Background(), list.Id, &api.TaskItem{Title: "report"})
+
+	// The list gets created, then the connection drops again.
+	stubOnline()
+	apiTest.TasksCreateMockFn = func(l string, t *api.TaskItem) (*api.TaskItem, error) {
+		return nil, offlineErr
+	}
+
+	if _, err := c.Sync(context.Background()); err != offlineErr {
+		test.Fatalf("\nExpected sync to fail while offline\nbut got\n%v", err)
+	}
+
+	stubOnline()
+
+	var createdIn string
+	apiTest.TasksCreateMockFn = func(l string, t *api.TaskItem) (*api.TaskItem, error) {
+		createdIn = l
+		return &api.TaskItem{Id: "remote-task"}, nil
+	}
+
+	report, err := c.Sync(context.Background())
+	if err != nil || report.Replayed != 1 || len(report.Failed) != 0 {
+		test.Fatalf("\nExpected the task to be replayed\nbut got\n%+v, %v", report, err)
+	}
+
+	if createdIn != "remote-Work" {
+		test.Errorf("\nExpected the task to be created in:\nremote-Work\nbut was\n%s", createdIn)
+	}
+}
+
+func TestSyncGivesTheReplayedCreatesTheirRemoteIds(test *testing.T) {
+	dir := test.TempDir()
+	deltas := NewDeltaStore(filepath.Join(dir, "delta.json"))
+	c := New(&apiTest.TodoApiMock{}, filepath.Join(dir, "cache.json"), deltas)
+
+	stubOnline()
+	apiTest.ListsDeltaMockFn = func() (*api.ListsDelta, error) {
+		return &api.ListsDelta{Added: []api.ListsItem{{Id: "l1", Name: "Groceries"}}, Full: true}, nil
+	}
+	apiTest.TasksDeltaMockFn = func(l string) (*api.TasksDelta, error) {
+		if IsLocalId(l) {
+			return nil, &api.ApiError{StatusCode: 404}
+		}
+		return &api.TasksDelta{}, nil
+	}
+
+	if _, err := c.Sync(context.Background()); err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	stubOffline()
+	list, _ := c.ListsCreate(context.Background(), "Work")
+	_, _ = c.TasksCreate(context.Background(), list.Id, &api.TaskItem{Title: "report"})
+
+	stubOnline()
+	apiTest.ListsDeltaMockFn = func() (*api.ListsDelta, error) {
+		return &api.ListsDelta{Added: []api.ListsItem{{Id: "remote-Work", Name: "Work"}}}, nil
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.Sync(context.Background()); err != nil {
+			test.Fatalf("\nExpected sync %d to succeed\nbut got\n%s", i+1, err)
+		}
+	}
+
+	stubOffline()
+
+	lists, _ := c.ListsIndex(context.Background())
+	if len(*lists) != 2 || (*lists)[1].Id != "remote-Work" {
+		test.Errorf("\nExpected the list once, by its remote id\nbut got\n%v", *lists)
+	}
+
+	tasks, _ := c.TasksIndex(context.Background(), "remote-Work")
+	if len(*tasks) != 1 || (*tasks)[0].Id != "remote-report" {
+		test.Errorf("\nExpected the task by its remote id\nbut got\n%v", *tasks)
+	}
+}
+
+func TestSyncAppliesDeltas(test *testing.T) {
+	dir := test.TempDir()
+	deltas := NewDeltaStore(filepath.Join(dir, "delta.json"))
+	c := New(&apiTest.TodoApiMock{}, filepath.Join(dir, "cache.json"), deltas)
+
+	apiTest.ListsDeltaMockFn = func() (*api.ListsDelta, error) {
+		return &api.ListsDelta{
+			Added: []api.ListsItem{{Id: "l1", Name: "Groceries"}, {Id: "l2", Name: "Work"}},
+			Full:  true,
+		}, nil
+	}
+	apiTest.TasksDeltaMockFn = func(l string) (*api.TasksDelta, error) {
+		return &api.TasksDelta{Added: []api.TaskItem{{Id: "t-" + l}}, Full: true}, nil
+	}
+
+	report, err := c.Sync(context.Background())
+	if err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	if report.Lists != 2 || report.Tasks != 2 {
+		test.Errorf("\nExpected 2 lists and 2 tasks\nbut got\n%d and %d", report.Lists, report.Tasks)
+	}
+
+	apiTest.ListsDeltaMockFn = func() (*api.ListsDelta, error) {
+		return &api.ListsDelta{
+			Updated: []api.ListsItem{{Id: "l1", Name: "Shopping"}},
+			Removed: []string{"l2"},
+		}, nil
+	}
+	apiTest.TasksDeltaMockFn = func(l string) (*api.TasksDelta, error) {
+		return &api.TasksDelta{Added: []api.TaskItem{{Id: "new"}}}, nil
+	}
+
+	if _, err = c.Sync(context.Background()); err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	stubOffline()
+
+	lists, _ := c.ListsIndex(context.Background())
+	if len(*lists) != 1 || (*lists)[0].Name != "Shopping" {
+		test.Errorf("\nExpected only the renamed list\nbut got\n%v", *lists)
+	}
+
+	tasks, _ := c.TasksIndex(context.Background(), "l1")
+	if len(*tasks) != 2 {
+		test.Errorf("\nExpected the new task to be added\nbut got\n%v", *tasks)
+	}
+}
+
+func TestDeltaStorePersistsStates(test *testing.T) {
+	path := filepath.Join(test.TempDir(), "delta.json")
+	state := &api.DeltaState{Link: "https://delta", Ids: []string{"l1"}}
+
+	if err := NewDeltaStore(path).SaveDelta(api.ListsDeltaKey, state); err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	deltas := NewDeltaStore(path)
+	loaded, err := deltas.LoadDelta(api.ListsDeltaKey)
+	if err != nil || loaded == nil || loaded.Link != state.Link {
+		test.Errorf("\nExpected the saved state\nbut got\n%v, %v", loaded, err)
+	}
+
+	_ = deltas.ResetDelta(api.ListsDeltaKey)
+
+	if loaded, _ = deltas.LoadDelta(api.ListsDeltaKey); loaded != nil {
+		test.Errorf("\nExpected no state after reset\nbut got\n%v", loaded)
+	}
+}
+
+func TestTasksDeltaUpdatesTheCopy(test *testing.T) {
+	c := newTestClient(test)
+
+	stubOnline()
+	_, _ = c.TasksIndex(context.Background(), "l1")
+
+	apiTest.TasksDeltaMockFn = func(l string) (*api.TasksDelta, error) {
+		return &api.TasksDelta{
+			Added:   []api.TaskItem{{Id: "t2", Title: "eggs"}},
+			Removed: []string{"t1"},
+		}, nil
+	}
+
+	if _, err := c.TasksDelta(context.Background(), "l1"); err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	stubOffline()
+
+	tasks, _ := c.TasksIndex(context.Background(), "l1")
+	if len(*tasks) != 1 || (*tasks)[0].Id != "t2" {
+		test.Errorf("\nExpected the copy to hold only the added task\nbut got\n%v", *tasks)
+	}
+}
+
+func TestOfflineDeletes(test *testing.T) {
+	c := newTestClient(test)
+
+	stubOnline()
+	_, _ = c.TasksIndex(context.Background(), "l1")
+
+	stubOffline()
+	apiTest.TasksDeleteMockFn = func(l, i string) error { return offlineErr }
+
+	local, _ := c.TasksCreate(context.Background(), "l1", &api.TaskItem{Title: "draft"})
+
+	if err := c.TasksDelete(context.Background(), "l1", local.Id); err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	if err := c.TasksDelete(context.Background(), "l1", "t1"); err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	if pending, _ := c.PendingOperations(); pending != 1 {
+		test.Errorf("\nExpected only the deletion of t1 to be queued\nbut got\n%d", pending)
+	}
+
+	tasks, _ := c.TasksIndex(context.Background(), "l1")
+	if len(*tasks) != 0 {
+		test.Errorf("\nExpected no cached tasks\nbut got\n%v", *tasks)
+	}
+
+	apiTest.TasksDeleteMockFn = func(l, i string) error { return nil }
+}
+
+func TestBatchUpdatesTheCopy(test *testing.T) {
+	c := newTestClient(test)
+
+	stubOnline()
+	_, _ = c.TasksIndex(context.Background(), "l1")
+
+	apiTest.TasksUpdateMockFn = func(l, i string, t *api.TaskItem) (*api.TaskItem, error) {
+		return &api.TaskItem{Id: i, Title: "milk", Status: t.Status}, nil
+	}
+
+	responses, err := c.Batch(context.Background(), []api.BatchRequest{
+		api.NewTaskCreateRequest("1", "l1", &api.TaskItem{Title: "eggs"}),
+		api.NewTaskUpdateRequest("2", "l1", "t1", &api.TaskItem{Status: "completed"}),
+	})
+
+	if err != nil || len(responses) != 2 {
+		test.Fatalf("\nExpected the responses of both requests\nbut got\n%v, %v", responses, err)
+	}
+
+	stubOffline()
+	tasks, _ := c.TasksIndex(context.Background(), "l1")
+
+	expected := map[string]string{"t1": "completed", "remote-eggs": ""}
+	if len(*tasks) != 2 {
+		test.Fatalf("\nExpected the cached tasks to be:\n%v\nbut got\n%v", expected, *tasks)
+	}
+
+	for _, t := range *tasks {
+		if status, ok := expected[t.Id]; !ok || status != t.Status {
+			test.Errorf("\nExpected the cached tasks to be:\n%v\nbut got\n%v", expected, *tasks)
+		}
+	}
+}
+
+func TestIsOfflineIgnoresCancelledRequests(test *testing.T) {
+	cancelled := &url.Error{Op: "Get", URL: "https://graph", Err: context.Canceled}
+
+	if IsOffline(cancelled) {
+		test.Errorf("\nExpected a cancelled request not to count as offline\nbut\nit did")
+	}
+
+	if !IsOffline(offlineErr) {
+		test.Errorf("\nExpected an unreachable API to count as offline\nbut\nit did not")
+	}
+}
+
+func TestTimedOutCreatesAreNotQueued(test *testing.T) {
+	c := newTestClient(test)
+	timeout := &url.Error{Op: "Post", URL: "https://graph", Err: context.DeadlineExceeded}
+	apiTest.TasksCreateMockFn = func(l string, t *api.TaskItem) (*api.TaskItem, error) {
+		return nil, timeout
+	}
+	defer stubOnline()
+
+	_, err := c.TasksCreate(context.Background(), "l1", &api.TaskItem{Title: "report"})
+	if !errors.Is(err, timeout) || !IsTimeout(err) {
+		test.Errorf("\nExpected the timeout to fail the create\nbut got\n%v", err)
+	}
+
+	if pending, _ := c.PendingOperations(); pending != 0 {
+		test.Errorf("\nExpected no queued operations\nbut got\n%d", pending)
+	}
+}
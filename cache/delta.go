@@ -0,0 +1,128 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/betasve/mstd/atomicfile"
+	api "github.com/betasve/mstd/todoapi"
+	"io/ioutil"
+	"os"
+	"sync"
+)
+
+// Persists the delta states of the API's delta queries in a JSON file,
+// implementing `todoapi.DeltaStore`.
+type DeltaStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+// The content of the delta states file.
+type deltaFile struct {
+	Version int                        `json:"version"`
+	States  map[string]*api.DeltaState `json:"states"`
+}
+
+// Creates a DeltaStore keeping its states in the file at `path`.
+func NewDeltaStore(path string) *DeltaStore {
+	return &DeltaStore{path: path}
+}
+
+// Loads the state kept under `key`. It's `nil` when there's none.
+func (d *DeltaStore) LoadDelta(key string) (*api.DeltaState, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	file, err := d.read()
+	if err != nil {
+		return nil, err
+	}
+
+	return file.States[key], nil
+}
+
+// Keeps `state` under `key`.
+func (d *DeltaStore) SaveDelta(key string, state *api.DeltaState) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	file, err := d.read()
+	if err != nil {
+		return err
+	}
+
+	file.States[key] = state
+
+	return d.write(file)
+}
+
+// Forgets the state kept under `key`, making the next delta query for it
+// start from scratch.
+func (d *DeltaStore) ResetDelta(key string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	file, err := d.read()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := file.States[key]; !ok {
+		return nil
+	}
+
+	delete(file.States, key)
+
+	return d.write(file)
+}
+
+// Reads the delta states file. A missing file results in no states.
+func (d *DeltaStore) read() (*deltaFile, error) {
+	file := &deltaFile{}
+
+	data, err := ioutil.ReadFile(d.path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if err == nil {
+		if err := json.Unmarshal(data, file); err != nil {
+			return nil, fmt.Errorf("Corrupted delta file %s: %s", d.path, err)
+		}
+	}
+
+	if file.Version != storeVersion || file.States == nil {
+		file = &deltaFile{
+			Version: storeVersion,
+			States:  map[string]*api.DeltaState{},
+		}
+	}
+
+	return file, nil
+}
+
+// Writes the delta states file.
+func (d *DeltaStore) write(file *deltaFile) error {
+	data, err := json.Marshal(file)
+	if err != nil {
+		return err
+	}
+
+	return atomicfile.WriteFile(d.path, data)
+}
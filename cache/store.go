@@ -0,0 +1,212 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/betasve/mstd/atomicfile"
+	tm "github.com/betasve/mstd/ext/time"
+	api "github.com/betasve/mstd/todoapi"
+	"io/ioutil"
+	"os"
+	"time"
+)
+
+// The version of the format of the cache file. A file in another version is
+// discarded (and rebuilt on the next read).
+const storeVersion int = 1
+
+// Reads and writes the local copy as a single JSON file.
+type store struct {
+	path string
+}
+
+// The content of the cache file.
+type snapshot struct {
+	Version       int                       `json:"version"`
+	Lists         []api.ListsItem           `json:"lists"`
+	ListsSyncedAt time.Time                 `json:"listsSyncedAt"`
+	Tasks         map[string][]api.TaskItem `json:"tasks"`
+	TasksSyncedAt map[string]time.Time      `json:"tasksSyncedAt"`
+	Queue         []Operation               `json:"queue"`
+	LastLocalId   int                       `json:"lastLocalId"`
+}
+
+// Reads the cache file. A missing file results in an empty snapshot.
+func (s *store) load() (*snapshot, error) {
+	snap := &snapshot{}
+
+	data, err := ioutil.ReadFile(s.path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if err == nil {
+		if err := json.Unmarshal(data, snap); err != nil {
+			return nil, fmt.Errorf("Corrupted cache file %s: %s", s.path, err)
+		}
+	}
+
+	if snap.Version != storeVersion {
+		snap = &snapshot{Version: storeVersion}
+	}
+
+	if snap.Tasks == nil {
+		snap.Tasks = map[string][]api.TaskItem{}
+	}
+
+	if snap.TasksSyncedAt == nil {
+		snap.TasksSyncedAt = map[string]time.Time{}
+	}
+
+	return snap, nil
+}
+
+// Writes the cache file.
+func (s *store) save(snap *snapshot) error {
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+
+	return atomicfile.WriteFile(s.path, data)
+}
+
+// Adds an operation to the end of the queue.
+func (snap *snapshot) enqueue(op Operation) {
+	op.QueuedAt = tm.Client.Now()
+	snap.Queue = append(snap.Queue, op)
+}
+
+// Drops the queued operations of an item.
+func (snap *snapshot) dequeue(id string) {
+	queue := []Operation{}
+	for _, op := range snap.Queue {
+		if op.Id != id {
+			queue = append(queue, op)
+		}
+	}
+
+	snap.Queue = queue
+}
+
+// Generates an id for an item created while offline.
+func (snap *snapshot) nextLocalId() string {
+	snap.LastLocalId++
+	return fmt.Sprintf("%s%d", localIdPrefix, snap.LastLocalId)
+}
+
+// Gives an item created while offline (a list or a task) the id the API gave
+// it once its creation was replayed, so the copy doesn't hold it twice and
+// the list is refreshed by its remote id. The tasks of the list are fetched
+// anew, as the API has no delta of them under the local id.
+func (snap *snapshot) replaceId(localId, id string) {
+	for i := range snap.Lists {
+		if snap.Lists[i].Id == localId {
+			snap.Lists[i].Id = id
+		}
+	}
+
+	if tasks, ok := snap.Tasks[localId]; ok {
+		delete(snap.Tasks, localId)
+		snap.Tasks[id] = tasks
+	}
+
+	delete(snap.TasksSyncedAt, localId)
+
+	for _, tasks := range snap.Tasks {
+		for i := range tasks {
+			if tasks[i].Id == localId {
+				tasks[i].Id = id
+			}
+		}
+	}
+}
+
+// Finds a list in the copy by its id.
+func (snap *snapshot) findList(id string) (api.ListsItem, bool) {
+	for _, l := range snap.Lists {
+		if l.Id == id {
+			return l, true
+		}
+	}
+
+	return api.ListsItem{}, false
+}
+
+// Adds or replaces a list in the copy.
+func (snap *snapshot) putList(list api.ListsItem) {
+	for i, l := range snap.Lists {
+		if l.Id == list.Id {
+			snap.Lists[i] = list
+			return
+		}
+	}
+
+	snap.Lists = append(snap.Lists, list)
+}
+
+// Removes a list (and the copy of its tasks) from the copy.
+func (snap *snapshot) removeList(id string) {
+	for i, l := range snap.Lists {
+		if l.Id == id {
+			snap.Lists = append(snap.Lists[:i], snap.Lists[i+1:]...)
+			break
+		}
+	}
+
+	delete(snap.Tasks, id)
+	delete(snap.TasksSyncedAt, id)
+}
+
+// Finds a task in the copy of a list by its id.
+func (snap *snapshot) findTask(listId, taskId string) (api.TaskItem, bool) {
+	for _, t := range snap.Tasks[listId] {
+		if t.Id == taskId {
+			return t, true
+		}
+	}
+
+	return api.TaskItem{}, false
+}
+
+// Adds or replaces a task in the copy of a list.
+func (snap *snapshot) putTask(listId string, task api.TaskItem) {
+	tasks := snap.Tasks[listId]
+
+	for i, t := range tasks {
+		if t.Id == task.Id {
+			tasks[i] = task
+			return
+		}
+	}
+
+	snap.Tasks[listId] = append(tasks, task)
+}
+
+// Removes a task from the copy of a list.
+func (snap *snapshot) removeTask(listId, taskId string) {
+	tasks := snap.Tasks[listId]
+
+	for i, t := range tasks {
+		if t.Id == taskId {
+			snap.Tasks[listId] = append(tasks[:i], tasks[i+1:]...)
+			return
+		}
+	}
+}
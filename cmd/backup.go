@@ -0,0 +1,53 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"github.com/betasve/mstd/app"
+	"github.com/spf13/cobra"
+)
+
+var backupFile string
+
+// Defines the `backup` command that archives the whole account.
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Backs up all the lists and tasks of your To-Do account",
+	Long: `Writes every list of your To-Do account, with all of their tasks and
+	the checklist items and linked resources of those, into a single
+	(versioned) JSON archive, which can be restored with the restore command.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if app.LoginNeeded() {
+			if err := app.Login(); err != nil {
+				return err
+			}
+		}
+
+		return app.Backup(backupFile)
+	},
+}
+
+// Adds the `backupCmd` to the command-line tool, enabling it for use.
+func init() {
+	rootCmd.AddCommand(backupCmd)
+
+	backupCmd.Flags().StringVarP(
+		&backupFile,
+		"file", "f", "",
+		"The file to write the archive to (the standard output by default)",
+	)
+}
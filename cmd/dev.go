@@ -0,0 +1,37 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// Definition of the `devCmd`, grouping the tools for developing the app.
+var devCmd = &cobra.Command{
+	Use:   "dev",
+	Short: "Tools for developing mstd",
+	Long: `A command that groups the tools that help with developing and trying
+	out the app, without a To-Do account.`,
+	// The tools don't need the config file (or a login).
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return nil
+	},
+}
+
+// Adds the `devCmd` to the command-line tool, enabling it for use.
+func init() {
+	rootCmd.AddCommand(devCmd)
+}
@@ -0,0 +1,47 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"github.com/betasve/mstd/app"
+	"github.com/spf13/cobra"
+)
+
+var fakeGraphAddr string
+
+// Defines the `fake-graph` sub-command that serves a fake Microsoft Graph.
+var devFakeGraphCmd = &cobra.Command{
+	Use:   "fake-graph",
+	Short: "Serves a fake Microsoft Graph to try the app against",
+	Long: `Serves an in-memory stand-in for the To-Do API and the login endpoints
+	(any credentials log in), to try the app end to end without an account.
+	Point the app at it by setting ` + app.GraphUrlEnv + ` and ` + app.LoginUrlEnv + `
+	as printed on start. Press Ctrl+C to stop it.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return app.FakeGraph(fakeGraphAddr)
+	},
+}
+
+// Adds the `devFakeGraphCmd` to the command-line tool, enabling it for use.
+func init() {
+	devCmd.AddCommand(devFakeGraphCmd)
+
+	devFakeGraphCmd.Flags().StringVar(
+		&fakeGraphAddr,
+		"addr", "localhost:8081",
+		"The address to serve the fake Graph on",
+	)
+}
@@ -0,0 +1,50 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var exportList string
+var exportFile string
+
+// Definition of the `exportCmd` to lay the ground for exporting tasks to
+// other formats.
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Exports tasks to other formats",
+	Long: `A command that provides the capability of exporting the tasks in the
+	lists of your Microsoft To-Do account to the formats of other tools.`,
+}
+
+// Registers the command with the command-line tool (enabling it for usage) as
+// well as sets the flags that are shared by all of its sub-commands.
+func init() {
+	rootCmd.AddCommand(exportCmd)
+
+	exportCmd.PersistentFlags().StringVarP(
+		&exportList,
+		"list", "l", "",
+		"The id or the name of the list to export (default is all of them)",
+	)
+	exportCmd.PersistentFlags().StringVarP(
+		&exportFile,
+		"file", "f", "",
+		"The file to write to (default is the standard output)",
+	)
+}
@@ -0,0 +1,46 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/betasve/mstd/app"
+	"github.com/spf13/cobra"
+)
+
+// Defines the `export ics` sub-command to export tasks to iCalendar.
+var exportIcsCmd = &cobra.Command{
+	Use:   "ics",
+	Short: "Exports tasks as iCalendar VTODOs",
+	Long: `Writes the tasks as the VTODOs of an iCalendar (RFC 5545) file, which
+	can be imported by CalDAV clients and calendar tools. The recurrence of
+	a task becomes an RRULE and its reminder a VALARM.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if app.LoginNeeded() {
+			if err := app.Login(); err != nil {
+				return err
+			}
+		}
+
+		return app.ExportIcs(exportList, exportFile)
+	},
+}
+
+// Adds the `exportIcsCmd` to the command-line tool, enabling it for use.
+func init() {
+	exportCmd.AddCommand(exportIcsCmd)
+}
@@ -0,0 +1,46 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"github.com/betasve/mstd/app"
+	"github.com/spf13/cobra"
+)
+
+// Defines the `export md` sub-command to export tasks as Markdown checklists.
+var exportMdCmd = &cobra.Command{
+	Use:     "md",
+	Aliases: []string{"markdown"},
+	Short:   "Exports tasks as Markdown checklists",
+	Long: `Writes the tasks as Markdown checklists: a "## List" heading per list,
+	followed by a "- [ ] task" (or "- [x] task", when completed) line per
+	task, with its checklist items nested under it.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if app.LoginNeeded() {
+			if err := app.Login(); err != nil {
+				return err
+			}
+		}
+
+		return app.ExportMarkdown(exportList, exportFile)
+	},
+}
+
+// Adds the `exportMdCmd` to the command-line tool, enabling it for use.
+func init() {
+	exportCmd.AddCommand(exportMdCmd)
+}
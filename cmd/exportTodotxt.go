@@ -0,0 +1,46 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/betasve/mstd/app"
+	"github.com/spf13/cobra"
+)
+
+// Defines the `export todotxt` sub-command to export tasks to todo.txt.
+var exportTodotxtCmd = &cobra.Command{
+	Use:   "todotxt",
+	Short: "Exports tasks in the todo.txt format",
+	Long: `Writes the tasks in the todo.txt format, one per line. The list of a
+	task becomes its +project, its categories become @contexts, a high
+	importance becomes priority (A) and a low one (C).`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if app.LoginNeeded() {
+			if err := app.Login(); err != nil {
+				return err
+			}
+		}
+
+		return app.ExportTodoTxt(exportList, exportFile)
+	},
+}
+
+// Adds the `exportTodotxtCmd` to the command-line tool, enabling it for use.
+func init() {
+	exportCmd.AddCommand(exportTodotxtCmd)
+}
@@ -0,0 +1,76 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"github.com/betasve/mstd/app"
+	"github.com/spf13/cobra"
+	"strings"
+)
+
+var findConcurrency int
+
+// Defines the `find` command to search the tasks in all the lists.
+var findCmd = &cobra.Command{
+	Use:   "find [EXPRESSION]",
+	Short: "Finds the tasks matching an expression in all the lists",
+	Long: `Finds the tasks matching an expression in all the lists of your To-Do
+account and prints them along with the name of their list. E.g.
+
+  mstd find 'due < friday and importance = high and not completed'
+
+An expression is made of terms comparing a field of a task to a value,
+combined with and (or a comma), or, not and parentheses:
+  list, title, category  = != ~ (a regular expression)
+  status                 = != (or just the status, e.g. completed)
+  importance, due        = != < <= > >= (due can be none)
+
+Dates can be written like in the other commands, e.g. tomorrow, friday,
+"next week" or 2021-05-03. As To Do can't search across the lists, the tasks
+of a few lists at a time (see --concurrency) are downloaded and matched.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if app.LoginNeeded() {
+			if err := app.Login(); err != nil {
+				return err
+			}
+		}
+
+		return app.TasksFind(
+			strings.Join(args, " "),
+			findConcurrency,
+			parseStringToList(showColumns, ListSeparator, noSpaceLowerCase),
+		)
+	},
+}
+
+// Adds the `findCmd` to the command-line tool, as well as setting the
+// arguments it can take.
+func init() {
+	rootCmd.AddCommand(findCmd)
+
+	findCmd.Flags().StringVarP(
+		&showColumns,
+		"columns", "c", "all",
+		"Which columns to show, default `all`. E.g. -c=\"list, title, due\"",
+	)
+	findCmd.Flags().IntVar(
+		&findConcurrency,
+		"concurrency", app.DefaultBulkConcurrency,
+		fmt.Sprintf("The number of lists searched at the same time (at most %d)", app.MaxBulkConcurrency),
+	)
+}
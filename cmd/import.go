@@ -0,0 +1,50 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var importList string
+var importDryRun bool
+
+// Definition of the `importCmd` to lay the ground for importing tasks from
+// other formats.
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Imports tasks from other formats",
+	Long: `A command that provides the capability of importing tasks (from the
+	files of other tools) into the lists of your Microsoft To-Do account.`,
+}
+
+// Registers the command with the command-line tool (enabling it for usage) as
+// well as sets the flags that are shared by all of its sub-commands.
+func init() {
+	rootCmd.AddCommand(importCmd)
+
+	importCmd.PersistentFlags().StringVarP(
+		&importList,
+		"list", "l", "",
+		"The id or the name of the list to import the tasks into\n(it's created if it doesn't exist)",
+	)
+	importCmd.PersistentFlags().BoolVar(
+		&importDryRun,
+		"dry-run", false,
+		"Only show what would be imported, without changing anything",
+	)
+}
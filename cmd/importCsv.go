@@ -0,0 +1,113 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"github.com/betasve/mstd/app"
+	"github.com/spf13/cobra"
+	"time"
+	"unicode/utf8"
+)
+
+var importCsvMap string
+var importCsvDateFormat string
+var importCsvNoHeader bool
+var importCsvDelimiter string
+var importCsvBatchSize int
+var importCsvBatchPause time.Duration
+
+// Defines the `import csv` sub-command to import the rows of a CSV file.
+var importCsvCmd = &cobra.Command{
+	Use:   "csv FILE",
+	Short: "Imports the rows of a CSV file as tasks",
+	Long: `Imports the rows of a CSV file (or the standard input, when FILE is -)
+	as tasks. --map maps the columns, by their header or their position, to
+	the attributes of the tasks: title, dueDate, reminderDate, importance,
+	status, categories, note and list. E.g. --map "Title=title,Due=dueDate,
+	Owner=categories" or --map "1=title,3=dueDate". Without it, the columns
+	with a known header (e.g. Title, Due Date, Priority, Tags) are mapped.
+	Whether the first row is a header is detected. The tasks go into the list
+	of their list column, or else into --list. The rows that can't be read
+	are reported by their number and skipped. The tasks are created in
+	batches, so that large imports aren't throttled by the API.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if importCsvDelimiter == `\t` {
+			importCsvDelimiter = "\t"
+		}
+
+		delimiter, size := utf8.DecodeRuneInString(importCsvDelimiter)
+		if size != len(importCsvDelimiter) || size == 0 {
+			return flagError(cmd, fmt.Errorf("the --delimiter should be a single character"))
+		}
+
+		if app.LoginNeeded() {
+			if err := app.Login(); err != nil {
+				return err
+			}
+		}
+
+		return app.ImportCsv(
+			args[0],
+			importList,
+			app.CsvOptions{
+				Mapping:    importCsvMap,
+				DateFormat: importCsvDateFormat,
+				NoHeader:   importCsvNoHeader,
+				Delimiter:  delimiter,
+				Batch:      app.ImportBatch{Size: importCsvBatchSize, Pause: importCsvBatchPause},
+			},
+			importDryRun,
+		)
+	},
+}
+
+// Adds the `importCsvCmd` to the command-line tool, enabling it for use.
+func init() {
+	importCmd.AddCommand(importCsvCmd)
+
+	importCsvCmd.Flags().StringVarP(
+		&importCsvMap,
+		"map", "m", "",
+		"Maps the columns to attributes, e.g. \"Title=title,Due=dueDate,Owner=categories\"",
+	)
+	importCsvCmd.Flags().StringVar(
+		&importCsvDateFormat,
+		"date-format", "",
+		"The format of the dates, e.g. DD/MM/YYYY or MM/DD/YY HH:mm (natural dates by default)",
+	)
+	importCsvCmd.Flags().BoolVar(
+		&importCsvNoHeader,
+		"no-header", false,
+		"Read the first row as a task, rather than detecting if it's a header",
+	)
+	importCsvCmd.Flags().StringVar(
+		&importCsvDelimiter,
+		"delimiter", ",",
+		"The character separating the cells, e.g. ; or \\t",
+	)
+	importCsvCmd.Flags().IntVar(
+		&importCsvBatchSize,
+		"batch-size", app.DefaultImportBatch.Size,
+		"How many tasks to create before pausing",
+	)
+	importCsvCmd.Flags().DurationVar(
+		&importCsvBatchPause,
+		"batch-pause", app.DefaultImportBatch.Pause,
+		"How long to pause between the batches",
+	)
+}
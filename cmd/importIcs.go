@@ -0,0 +1,49 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/betasve/mstd/app"
+	"github.com/spf13/cobra"
+)
+
+// Defines the `import ics` sub-command to import the VTODOs of an iCalendar
+// file.
+var importIcsCmd = &cobra.Command{
+	Use:   "ics FILE",
+	Short: "Imports the tasks (VTODOs) of an iCalendar file",
+	Long: `Imports the VTODOs of an iCalendar file (or the standard input, when
+	FILE is -), e.g. one exported from a CalDAV client. The SUMMARY becomes the
+	title, DESCRIPTION the note, DUE the due date, PRIORITY the importance,
+	STATUS the status, RRULE the recurrence and the first VALARM the reminder.
+	The tasks go into --list, or into the list they were exported from.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if app.LoginNeeded() {
+			if err := app.Login(); err != nil {
+				return err
+			}
+		}
+
+		return app.ImportIcs(args[0], importList, importDryRun)
+	},
+}
+
+// Adds the `importIcsCmd` to the command-line tool, enabling it for use.
+func init() {
+	importCmd.AddCommand(importIcsCmd)
+}
@@ -0,0 +1,51 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"github.com/betasve/mstd/app"
+	"github.com/spf13/cobra"
+)
+
+// Defines the `import md` sub-command to import Markdown checklists.
+var importMdCmd = &cobra.Command{
+	Use:     "md FILE",
+	Aliases: []string{"markdown"},
+	Short:   "Imports the checklists of a Markdown file",
+	Long: `Imports the checklists of a Markdown file (or the standard input, when
+	FILE is -). The "- [ ] task" and "- [x] task" lines under a "## List"
+	heading go into that list (which is created when missing), while the ones
+	outside of a heading go into --list. Checklist lines nested under a task
+	become its checklist items. Tasks already in their list (matched by
+	title) aren't duplicated: their status is updated instead and the
+	checklist items they lack are added, so a file can be imported again
+	after editing it.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if app.LoginNeeded() {
+			if err := app.Login(); err != nil {
+				return err
+			}
+		}
+
+		return app.ImportMarkdown(args[0], importList, importDryRun)
+	},
+}
+
+// Adds the `importMdCmd` to the command-line tool, enabling it for use.
+func init() {
+	importCmd.AddCommand(importMdCmd)
+}
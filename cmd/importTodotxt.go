@@ -0,0 +1,56 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/betasve/mstd/app"
+	"github.com/spf13/cobra"
+)
+
+var importProjectsAsLists bool
+
+// Defines the `import todotxt` sub-command to import a todo.txt file.
+var importTodotxtCmd = &cobra.Command{
+	Use:   "todotxt FILE",
+	Short: "Imports the tasks of a todo.txt file",
+	Long: `Imports the tasks of a todo.txt file (or the standard input, when FILE
+	is -). Priority (A) becomes a high importance, (B) a normal and the rest
+	a low one, due:YYYY-MM-DD the due date, an x marks the task completed and
+	the @contexts and +projects become categories (or with --projects-as-lists
+	a task goes into the list named after its project).`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if app.LoginNeeded() {
+			if err := app.Login(); err != nil {
+				return err
+			}
+		}
+
+		return app.ImportTodoTxt(args[0], importList, importProjectsAsLists, importDryRun)
+	},
+}
+
+// Adds the `importTodotxtCmd` to the command-line tool, enabling it for use.
+func init() {
+	importCmd.AddCommand(importTodotxtCmd)
+
+	importTodotxtCmd.Flags().BoolVar(
+		&importProjectsAsLists,
+		"projects-as-lists", false,
+		"Put the tasks with a +project into the list named after it",
+	)
+}
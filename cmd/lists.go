@@ -16,11 +16,17 @@ limitations under the License.
 package cmd
 
 import (
+	"github.com/betasve/mstd/app"
 	"github.com/spf13/cobra"
 )
 
 var showColumns string
 
+var queryFilter string
+var querySort string
+var queryFields string
+var queryTop int
+
 // Definition of the `listsCmd` to lay the ground for perofrming operations
 // over the lists' section of the API.
 var listsCmd = &cobra.Command{
@@ -41,3 +47,45 @@ func init() {
 		"Which columns to show, default `all`. E.g. -col=\"Display Name, Id\"",
 	)
 }
+
+// Sets the flags (shared by the ls commands) for the query options that are
+// sent to To Do, so only the items (and the columns) asked for are
+// downloaded. `example` is a column to show in the help.
+func addQueryFlags(cmd *cobra.Command, example string) {
+	flags := cmd.Flags()
+
+	flags.StringVar(
+		&queryFilter,
+		"filter", "",
+		"Only show the items matching an OData filter, passed to To Do as is, e.g.\n"+
+			"--filter \"status ne 'completed' and importance eq 'high'\"",
+	)
+	flags.StringVar(
+		&querySort,
+		"sort", "",
+		"The columns to order by, each followed by desc (or prefixed with -) for\n"+
+			"the descending order, e.g. --sort \"-"+example+", id\"",
+	)
+	flags.StringVar(
+		&queryFields,
+		"fields", "",
+		"Only download these columns (and show them, unless --columns is set),\n"+
+			"e.g. --fields \""+example+", id\"",
+	)
+	flags.IntVar(&queryTop, "top", 0, "Show at most this many items (default all)")
+}
+
+// Returns the query options set through the flags of `addQueryFlags`.
+func queryOptions() app.QueryOptions {
+	opts := app.QueryOptions{Filter: queryFilter, Top: queryTop}
+
+	if querySort != "" {
+		opts.Sort = parseStringToList(querySort, ListSeparator, noSpaceLowerCase)
+	}
+
+	if queryFields != "" {
+		opts.Fields = parseStringToList(queryFields, ListSeparator, noSpaceLowerCase)
+	}
+
+	return opts
+}
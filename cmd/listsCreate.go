@@ -29,7 +29,9 @@ var listsCreateCmd = &cobra.Command{
 	Long:  `Create a new list in To Do app`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if app.LoginNeeded() {
-			app.Login()
+			if err := app.Login(); err != nil {
+				return err
+			}
 		}
 
 		return app.ListsCreate(
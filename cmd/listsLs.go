@@ -26,13 +26,18 @@ import (
 var listsLsCmd = &cobra.Command{
 	Use:   "ls",
 	Short: "Shows To-Do Lists",
-	Long:  `Prints all the (task-)lists, residing inside your To-Do account`,
+	Long: `Prints all the (task-)lists, residing inside your To-Do account. With
+	--filter, --sort, --fields and --top only the lists (and the columns)
+	asked for are downloaded.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if app.LoginNeeded() {
-			app.Login()
+			if err := app.Login(); err != nil {
+				return err
+			}
 		}
 
 		return app.ListsIndex(
+			queryOptions(),
 			parseStringToList(showColumns, ListSeparator, noSpaceLowerCase),
 		)
 	},
@@ -42,4 +47,5 @@ var listsLsCmd = &cobra.Command{
 // enabling it for use.
 func init() {
 	listsCmd.AddCommand(listsLsCmd)
+	addQueryFlags(listsLsCmd, "display name")
 }
@@ -32,7 +32,9 @@ var listsUpdateCmd = &cobra.Command{
 	Long:  `Update a list's properties in To Do app`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if app.LoginNeeded() {
-			app.Login()
+			if err := app.Login(); err != nil {
+				return err
+			}
 		}
 
 		return app.ListsUpdate(
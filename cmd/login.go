@@ -27,8 +27,8 @@ var loginCmd = &cobra.Command{
 	Long: `Triggers a browser to open the login page for MS. If your OS is not
 	recognized by the tool, you will be presented an url to copy/paste in
 	your browser`,
-	Run: func(cmd *cobra.Command, args []string) {
-		app.Login()
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return app.Login()
 	},
 }
 
@@ -0,0 +1,70 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"github.com/betasve/mstd/app"
+	"github.com/spf13/cobra"
+)
+
+var restoreOnConflict string
+var restoreIdMap string
+var restoreDryRun bool
+
+// Defines the `restore` command that recreates the lists and tasks of a
+// backup.
+var restoreCmd = &cobra.Command{
+	Use:   "restore FILE",
+	Short: "Restores the lists and tasks of a backup",
+	Long: `Recreates the lists and tasks of a backup archive (or the standard
+	input, when FILE is -) in your To-Do account, or the one of another
+	profile with --profile. The archived lists go into the existing ones of
+	the same name, or new ones. A task conflicts with one of the same title
+	in its list, and --on-conflict decides whether to skip it, overwrite the
+	existing task with it or restore it as a duplicate. The tasks get new ids,
+	which --id-map saves along with the archived ones.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if app.LoginNeeded() {
+			if err := app.Login(); err != nil {
+				return err
+			}
+		}
+
+		return app.Restore(args[0], restoreOnConflict, restoreIdMap, restoreDryRun)
+	},
+}
+
+// Adds the `restoreCmd` to the command-line tool, enabling it for use.
+func init() {
+	rootCmd.AddCommand(restoreCmd)
+
+	restoreCmd.Flags().StringVar(
+		&restoreOnConflict,
+		"on-conflict", app.ConflictSkip,
+		"What to do with a task that's already in its list: skip, overwrite or duplicate",
+	)
+	restoreCmd.Flags().StringVar(
+		&restoreIdMap,
+		"id-map", "",
+		"A file to write how the archived ids map to the restored ones (as JSON)",
+	)
+	restoreCmd.Flags().BoolVar(
+		&restoreDryRun,
+		"dry-run", false,
+		"Show what would be restored, without changing anything",
+	)
+}
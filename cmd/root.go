@@ -26,11 +26,19 @@ THE SOFTWARE.
 package cmd
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"github.com/betasve/mstd/app"
 	"github.com/spf13/cobra"
-	"log"
+	"os"
+	"os/signal"
+	"time"
 )
 
+// The default time a single request to the API may take.
+const defaultTimeout time.Duration = 30 * time.Second
+
 // rootCmd represents the base command when called without any subcommands.
 // We choose to have this command only print some help instructions.
 var rootCmd = &cobra.Command{
@@ -42,22 +50,126 @@ in Microsoft's To Do application https://todo.microsoft.com/tasks/.
 To begin your journey you have to:
 1. Configure your personal API key (https://github.com/kiblee/tod0/blob/master/GET_KEY.md)
 2. Set personal API key in your config file
-3. Start using it (mstd --help)`,
+3. Start using it (mstd --help)
+
+Errors are printed to the standard error (as JSON with --output json) and
+the exit code tells what went wrong:
+  0   success
+  1   any other failure
+  2   invalid flags, arguments or input
+  3   not logged in, or the session could not be refreshed
+  4   a list or a task was not found
+  5   a conflicting change was made in the meantime
+  6   To Do could not be reached (or did not respond in time)
+  7   throttled by To Do, try again later
+  8   only some of the changes were made
+  130 interrupted with Ctrl+C`,
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return app.InitAppConfig()
+	},
 }
 
 // The method that's attached to execute the `root` command. As mentioned above
-// it just executes `rootCmd` and logs any possible errors.
+// it just executes `rootCmd`, printing any possible error and exiting with its
+// code. Ctrl+C cancels the requests in flight, and a second one exits right
+// away.
 func Execute() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	app.SetContext(ctx)
+	rootCmd.Version = app.Version
+	wrapArgsErrors(rootCmd)
+
 	if err := rootCmd.Execute(); err != nil {
-		log.Fatal(err)
+		app.PrintError(os.Stderr, err)
+		stop()
+		os.Exit(app.ExitCode(err))
+	}
+}
+
+// Marks the errors of parsing the flags as invalid input.
+func flagError(cmd *cobra.Command, err error) error {
+	return &app.ValidationError{
+		Message: fmt.Sprintf("%s\nSee `%s --help` for the usage.", err, cmd.CommandPath()),
+	}
+}
+
+// Marks the errors of the argument validators of a command and its
+// subcommands (e.g. a missing or an extra argument) as invalid input, like
+// the ones of the flags.
+func wrapArgsErrors(cmd *cobra.Command) {
+	if validate := cmd.Args; validate != nil {
+		cmd.Args = func(cmd *cobra.Command, args []string) error {
+			err := validate(cmd, args)
+
+			var validationErr *app.ValidationError
+			if err == nil || errors.As(err, &validationErr) {
+				return err
+			}
+
+			return flagError(cmd, err)
+		}
+	}
+
+	for _, sub := range cmd.Commands() {
+		wrapArgsErrors(sub)
 	}
 }
 
 // Going in the init() method (each time the root command is invoked, and sub-
-// commands are provided) we initialize our `app` and get env vars for config
-// file path that the user possibly did set.
+// commands are provided) we get the flags (e.g. the config file path) that
+// the user possibly did set. The `app` is initialized with them right before
+// the command is run.
 func init() {
-	cobra.OnInitialize(app.InitAppConfig)
+	rootCmd.SetFlagErrorFunc(flagError)
 
 	rootCmd.PersistentFlags().StringVar(&app.CfgFilePath, "config", "", "config file (default is $HOME/.mstd.yaml)")
+	rootCmd.PersistentFlags().StringVar(
+		&app.Profile,
+		"profile", "",
+		"profile to use (letters, digits, _ and -), each with its own config file\n($HOME/.mstd-PROFILE.yaml) and state (default is the \"default\" profile)",
+	)
+	rootCmd.PersistentFlags().DurationVar(
+		&app.Timeout,
+		"timeout", defaultTimeout,
+		"time a single request to To Do may take, e.g. 10s or 1m (0 for no limit)",
+	)
+	rootCmd.PersistentFlags().BoolVarP(
+		&app.Verbose,
+		"verbose", "v", false,
+		"print the method, URL, status, latency and request ids of each request to To Do",
+	)
+	rootCmd.PersistentFlags().BoolVar(
+		&app.Debug,
+		"debug", false,
+		"like --verbose, adding the headers and bodies (with the secrets redacted)",
+	)
+	rootCmd.PersistentFlags().StringVarP(
+		&app.Output,
+		"output", "o", app.Output,
+		"the format the results and the errors are printed in: text or json",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&app.LogLevel,
+		"log-level", app.LogLevel,
+		"the lowest level of the messages logged: debug, info, warn or error",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&app.LogFormat,
+		"log-format", app.LogFormat,
+		"the format of the messages logged: text, logfmt or json",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&app.LogFile,
+		"log-file", "",
+		"append the messages logged to this file (default is the standard error)",
+	)
 }
@@ -0,0 +1,47 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/betasve/mstd/app"
+	"github.com/spf13/cobra"
+)
+
+// Defines the `shell` command that starts an interactive prompt.
+var shellCmd = &cobra.Command{
+	Use:   "shell",
+	Short: "Starts an interactive shell",
+	Long: `Starts a prompt where commands over your lists and tasks can be run
+	one after another, without loading the config and logging in each time.
+	Pick a list with "use LIST" and then e.g. "add milk", "done milk" or "ls".
+	It has a history (up and down arrows) and completes commands and names
+	(tab). Type "help" for all the commands.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if app.LoginNeeded() {
+			if err := app.Login(); err != nil {
+				return err
+			}
+		}
+
+		return app.Shell()
+	},
+}
+
+// Adds the `shellCmd` to the command-line tool, enabling it for use.
+func init() {
+	rootCmd.AddCommand(shellCmd)
+}
@@ -0,0 +1,146 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"github.com/betasve/mstd/app"
+	"github.com/spf13/cobra"
+)
+
+var taskList string
+var taskOpts app.TaskOptions
+var bulkOpts app.BulkOptions
+
+// Definition of the `tasksCmd` to lay the ground for performing operations
+// over the tasks inside a list.
+var tasksCmd = &cobra.Command{
+	Use:   "tasks",
+	Short: "Perform operations over the tasks in a To-Do List",
+	Long: `A command that provides the capability of listing, showing, creating,
+	editing, completing, deleting and moving tasks in the lists of your
+	Microsoft To-Do account. Complete, delete and update also take all the
+	tasks matching a filter (--where), in one list or in all of them (-l '*').`,
+}
+
+// Registers the command with the command-line tool (enabling it for usage) as
+// well as sets the flags that are shared by all of its sub-commands.
+func init() {
+	rootCmd.AddCommand(tasksCmd)
+
+	tasksCmd.PersistentFlags().StringVarP(
+		&showColumns,
+		"columns", "c", "all",
+		"Which columns to show, default `all`. E.g. -c=\"title, due\"",
+	)
+	tasksCmd.PersistentFlags().StringVarP(
+		&taskList,
+		"list", "l", "",
+		"The id or the name of the list the tasks belong to",
+	)
+	_ = tasksCmd.MarkPersistentFlagRequired("list")
+}
+
+// Sets the flags (shared by the create and update commands) for the
+// attributes of a task, including its recurrence.
+func addTaskAttributeFlags(cmd *cobra.Command) {
+	flags := cmd.Flags()
+
+	flags.StringVar(&taskOpts.Importance, "importance", "", "Set the importance: low, normal or high")
+	flags.StringVar(
+		&taskOpts.Due,
+		"due", "",
+		"Set the due date, e.g. tomorrow, \"next friday 5pm\", \"in 3 days\", 2021-05-03",
+	)
+	flags.StringVar(
+		&taskOpts.Remind,
+		"remind", "",
+		"Set a reminder, e.g. \"in 2h\", \"tomorrow 9am\", \"may 3 17:30\"",
+	)
+
+	flags.StringVar(
+		&taskOpts.Repeat.Shorthand,
+		"repeat", "",
+		"Repeat the task, e.g. daily, weekdays, weekly:mon,wed, monthly:15,\n"+
+			"monthly:last-fri, yearly:12-25, yearly:first-mon-sep, optionally\n"+
+			"followed by ;every=N and ;until=YYYY-MM-DD or ;count=N",
+	)
+	flags.StringVar(
+		&taskOpts.Repeat.Type,
+		"repeat-type", "",
+		"Recurrence type: daily, weekly, absoluteMonthly, relativeMonthly,\n"+
+			"absoluteYearly or relativeYearly",
+	)
+	flags.IntVar(&taskOpts.Repeat.Interval, "repeat-interval", 0, "Repeat every N days/weeks/months/years")
+	flags.StringVar(&taskOpts.Repeat.Days, "repeat-days", "", "Days of week to repeat on, e.g. mon,wed")
+	flags.IntVar(&taskOpts.Repeat.DayOfMonth, "repeat-day-of-month", 0, "Day of month to repeat on")
+	flags.IntVar(&taskOpts.Repeat.Month, "repeat-month", 0, "Month (1-12) to repeat on")
+	flags.StringVar(&taskOpts.Repeat.Index, "repeat-index", "", "Week of month: first, second, third, fourth or last")
+	flags.StringVar(&taskOpts.Repeat.Start, "repeat-start", "", "Date the recurrence starts on (YYYY-MM-DD)")
+	flags.StringVar(&taskOpts.Repeat.Until, "repeat-until", "", "Date the recurrence ends on (YYYY-MM-DD)")
+	flags.IntVar(&taskOpts.Repeat.Count, "repeat-count", 0, "Number of times the task repeats")
+}
+
+// Sets the flags (shared by the complete, delete and update commands) for
+// acting on all the tasks matching a filter instead of a single one.
+func addBulkFlags(cmd *cobra.Command) {
+	flags := cmd.Flags()
+
+	flags.StringVarP(
+		&bulkOpts.Where,
+		"where", "w", "",
+		"Act on all the tasks matching a filter instead of a single one, e.g.\n"+
+			"\"due < friday and not completed\" (see mstd find --help for the\n"+
+			"fields and operators)",
+	)
+	flags.BoolVar(&bulkOpts.DryRun, "dry-run", false, "With --where, only print the tasks that would be changed")
+	flags.BoolVarP(&bulkOpts.Yes, "yes", "y", false, "With --where, change the tasks without asking for confirmation")
+	flags.IntVar(
+		&bulkOpts.Concurrency,
+		"concurrency", app.DefaultBulkConcurrency,
+		fmt.Sprintf("With --where, the number of batches of changes sent at the same time (at most %d)", app.MaxBulkConcurrency),
+	)
+}
+
+// Runs `single` with the task ID given as the argument, or `bulk` when
+// --where is set instead. Exactly one of the two has to be given.
+func runSingleOrBulk(
+	cmd *cobra.Command,
+	args []string,
+	single func(taskId string) error,
+	bulk func() error,
+) error {
+	if len(args) == 1 && bulkOpts.Where != "" {
+		return flagError(cmd, fmt.Errorf("either a task ID or --where can be given, not both"))
+	}
+
+	if len(args) == 0 && bulkOpts.Where == "" {
+		return flagError(cmd, fmt.Errorf("either a task ID or --where is required"))
+	}
+
+	if app.LoginNeeded() {
+		if err := app.Login(); err != nil {
+			return err
+		}
+	}
+
+	if bulkOpts.Where != "" {
+		return bulk()
+	}
+
+	return single(args[0])
+}
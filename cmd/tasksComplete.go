@@ -0,0 +1,56 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"github.com/betasve/mstd/app"
+	"github.com/spf13/cobra"
+)
+
+// Defines the `tasks complete` sub-command to mark a task (or all the tasks
+// matching --where) as completed.
+var tasksCompleteCmd = &cobra.Command{
+	Use:   "complete [ID]",
+	Short: "Completes a task",
+	Long: `Marks a task as completed, or all the tasks matching --where (after a
+	preview and a confirmation), e.g.
+
+	  mstd tasks complete -l Groceries --where 'title ~ ^buy'`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runSingleOrBulk(
+			cmd,
+			args,
+			func(taskId string) error {
+				return app.TasksComplete(
+					taskList,
+					taskId,
+					parseStringToList(showColumns, ListSeparator, noSpaceLowerCase),
+				)
+			},
+			func() error {
+				return app.TasksBulk(taskList, app.BulkComplete, app.TaskOptions{}, bulkOpts)
+			},
+		)
+	},
+}
+
+// Adds the `tasksCompleteCmd` to the command-line tool, as well as setting
+// the arguments it can take.
+func init() {
+	tasksCmd.AddCommand(tasksCompleteCmd)
+	addBulkFlags(tasksCompleteCmd)
+}
@@ -0,0 +1,53 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/betasve/mstd/app"
+	"github.com/spf13/cobra"
+	"strings"
+)
+
+// A command responsible for creating a new task. It takes the passed
+// arguments as the title of the task and its flags as its attributes.
+var tasksCreateCmd = &cobra.Command{
+	Use:   "create [TITLE]",
+	Short: "Create a new task",
+	Long:  `Create a new task in a list in To Do app`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if app.LoginNeeded() {
+			if err := app.Login(); err != nil {
+				return err
+			}
+		}
+
+		taskOpts.Title = strings.Join(args, " ")
+
+		return app.TasksCreate(
+			taskList,
+			taskOpts,
+			parseStringToList(showColumns, ListSeparator, noSpaceLowerCase),
+		)
+	},
+}
+
+// Adds the command to be executable by the command-line tool, along with the
+// flags for the task's attributes.
+func init() {
+	tasksCmd.AddCommand(tasksCreateCmd)
+	addTaskAttributeFlags(tasksCreateCmd)
+}
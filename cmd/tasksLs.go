@@ -0,0 +1,53 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/betasve/mstd/app"
+	"github.com/spf13/cobra"
+)
+
+// Defines the `tasks ls` sub-command to get the tasks in a list and print
+// them to the user.
+var tasksLsCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "Shows the tasks in a To-Do List",
+	Long: `Prints all the tasks, residing inside a list in your To-Do account.
+	With --filter, --sort, --fields and --top only the tasks (and the columns)
+	asked for are downloaded, e.g.
+
+	  mstd tasks ls -l Work --filter "status ne 'completed'" --sort due --top 5`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if app.LoginNeeded() {
+			if err := app.Login(); err != nil {
+				return err
+			}
+		}
+
+		return app.TasksIndex(
+			taskList,
+			queryOptions(),
+			parseStringToList(showColumns, ListSeparator, noSpaceLowerCase),
+		)
+	},
+}
+
+// Adds the `tasksLsCmd` to the command-line tool, enabling it for use.
+func init() {
+	tasksCmd.AddCommand(tasksLsCmd)
+	addQueryFlags(tasksLsCmd, "due")
+}
@@ -0,0 +1,69 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"github.com/betasve/mstd/app"
+	"github.com/spf13/cobra"
+)
+
+var moveTo string
+var moveKeep bool
+
+// Defines the `tasks mv` sub-command to move a task to another list.
+var tasksMvCmd = &cobra.Command{
+	Use:   "mv [ID]",
+	Short: "Moves a task to another list",
+	Long: `Moves a task to another list of your To-Do account. As To Do can't move
+	tasks, it's copied (with its note, dates, importance, recurrence,
+	categories, checklist items and linked resources), the copy is checked
+	against it and then the original is deleted. If any step fails, the copy
+	is removed again. With --keep the original is kept.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if app.LoginNeeded() {
+			if err := app.Login(); err != nil {
+				return err
+			}
+		}
+
+		return app.TasksMove(
+			taskList,
+			args[0],
+			moveTo,
+			moveKeep,
+			parseStringToList(showColumns, ListSeparator, noSpaceLowerCase),
+		)
+	},
+}
+
+// Adds the `tasksMvCmd` to the command-line tool, as well as setting the
+// arguments it can take.
+func init() {
+	tasksCmd.AddCommand(tasksMvCmd)
+
+	tasksMvCmd.Flags().StringVarP(
+		&moveTo,
+		"to", "t", "",
+		"The id or the name of the list to move the task to",
+	)
+	tasksMvCmd.Flags().BoolVar(
+		&moveKeep,
+		"keep", false,
+		"Copy the task, keeping the original",
+	)
+	_ = tasksMvCmd.MarkFlagRequired("to")
+}
@@ -0,0 +1,44 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/betasve/mstd/app"
+	"github.com/spf13/cobra"
+)
+
+// Defines the `tasks show` sub-command to print all the details of a task.
+var tasksShowCmd = &cobra.Command{
+	Use:   "show [ID]",
+	Short: "Shows the details of a task",
+	Long:  `Prints all the attributes of a task, including its recurrence rule`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if app.LoginNeeded() {
+			if err := app.Login(); err != nil {
+				return err
+			}
+		}
+
+		return app.TasksShow(taskList, args[0])
+	},
+}
+
+// Adds the `tasksShowCmd` to the command-line tool, enabling it for use.
+func init() {
+	tasksCmd.AddCommand(tasksShowCmd)
+}
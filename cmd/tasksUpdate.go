@@ -0,0 +1,66 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/betasve/mstd/app"
+	"github.com/spf13/cobra"
+)
+
+// Defines the command for updating a task (or all the tasks matching
+// --where). Only the attributes whose flags are set get changed.
+var tasksUpdateCmd = &cobra.Command{
+	Use:   "update [ID]",
+	Short: "Update a task",
+	Long: `Update a task's properties in To Do app, or the properties of all the
+tasks matching --where (after a preview and a confirmation), e.g.
+
+  mstd tasks update -l '*' --where 'category = Work and due < today' --due monday`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runSingleOrBulk(
+			cmd,
+			args,
+			func(taskId string) error {
+				return app.TasksUpdate(
+					taskList,
+					taskId,
+					taskOpts,
+					parseStringToList(showColumns, ListSeparator, noSpaceLowerCase),
+				)
+			},
+			func() error {
+				return app.TasksBulk(taskList, app.BulkUpdate, taskOpts, bulkOpts)
+			},
+		)
+	},
+}
+
+// Adds the command to the command-line tool, as well as setting the arguments
+// it can take.
+func init() {
+	tasksCmd.AddCommand(tasksUpdateCmd)
+	addTaskAttributeFlags(tasksUpdateCmd)
+	addBulkFlags(tasksUpdateCmd)
+
+	tasksUpdateCmd.Flags().StringVar(&taskOpts.Title, "title", "", "Set the title of a task")
+	tasksUpdateCmd.Flags().StringVar(
+		&taskOpts.Status,
+		"status", "",
+		"Set the status: notStarted, inProgress, completed, waitingOnOthers or deferred",
+	)
+}
@@ -0,0 +1,45 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/betasve/mstd/app"
+	"github.com/spf13/cobra"
+)
+
+// Defines the `ui` command that starts the interactive, full screen UI.
+var uiCmd = &cobra.Command{
+	Use:   "ui",
+	Short: "Opens an interactive, full screen UI",
+	Long: `Opens a keyboard driven UI with a pane for your lists and one for the
+	tasks of the selected list, where they can be added, completed, renamed,
+	starred, moved, deleted and have their notes edited. Press ? for help.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if app.LoginNeeded() {
+			if err := app.Login(); err != nil {
+				return err
+			}
+		}
+
+		return app.UI()
+	},
+}
+
+// Adds the `uiCmd` to the command-line tool, enabling it for use.
+func init() {
+	rootCmd.AddCommand(uiCmd)
+}
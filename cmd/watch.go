@@ -0,0 +1,71 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/betasve/mstd/app"
+	"github.com/spf13/cobra"
+	"time"
+)
+
+var watchList string
+var watchColumns string
+var watchInterval time.Duration
+
+// Defines the `watch` command that keeps the tasks of a list on screen.
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Keeps the tasks in a To-Do List on screen, as they change",
+	Long: `Shows the tasks in a list of your To-Do account and refreshes them
+	with the changes made since the last refresh. New, completed and overdue
+	tasks are highlighted. Press Ctrl+C to exit.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if app.LoginNeeded() {
+			if err := app.Login(); err != nil {
+				return err
+			}
+		}
+
+		return app.Watch(
+			watchList,
+			watchInterval,
+			parseStringToList(watchColumns, ListSeparator, noSpaceLowerCase),
+		)
+	},
+}
+
+// Adds the `watchCmd` to the command-line tool, enabling it for use.
+func init() {
+	rootCmd.AddCommand(watchCmd)
+
+	watchCmd.Flags().StringVarP(
+		&watchColumns,
+		"columns", "c", "title, status, importance, due",
+		"Which columns to show. E.g. -c=\"title, due\" or -c=all",
+	)
+	watchCmd.Flags().StringVarP(
+		&watchList,
+		"list", "l", "",
+		"The id or the name of the list to watch",
+	)
+	watchCmd.Flags().DurationVarP(
+		&watchInterval,
+		"interval", "i", 30*time.Second,
+		"How often to check for changes, e.g. 10s, 1m (at least "+app.MinWatchInterval.String()+")",
+	)
+	_ = watchCmd.MarkFlagRequired("list")
+}
@@ -26,6 +26,8 @@ import (
 	"github.com/betasve/mstd/ext/homedir"
 	tm "github.com/betasve/mstd/ext/time"
 	"github.com/betasve/mstd/ext/viper"
+	"net/url"
+	"regexp"
 	"strconv"
 	"strings"
 	"sync"
@@ -42,8 +44,53 @@ const defaultAuthCallbackHost string = "auth_callback_host_and_port"
 const defaultAuthCallbackPath string = "auth_callback_path"
 const defaultAccessTokenExpiryConfig string = "ate"
 const defaultRefreshTokenExpiryConfig string = "rte"
+const defaultTimeZoneConfig string = "time_zone"
+const defaultProxyConfig string = "proxy"
+const defaultCaBundleConfig string = "ca_bundle"
+const defaultGzipConfig string = "gzip"
+const defaultCloudConfig string = "cloud"
+const defaultGraphUrlConfig string = "graph_url"
+const defaultApiVersionConfig string = "api_version"
+const defaultAuthorityHostConfig string = "authority_host"
+const defaultTenantConfig string = "tenant"
 const nanosecondsInASecond int64 = 1_000_000_000
 
+// The name of the profile used when none is selected.
+const DefaultProfile string = "default"
+
+// The cloud, API version and tenant used when the config file sets none.
+const DefaultCloud string = "global"
+const DefaultApiVersion string = "v1.0"
+const DefaultTenant string = "common"
+
+// The endpoints of a Microsoft cloud: the base URL of Graph (without the API
+// version) and the host signing in is done with.
+type Cloud struct {
+	GraphUrl      string
+	AuthorityHost string
+}
+
+// The clouds that can be set as `cloud` in the config file. Their endpoints
+// can still be overridden with `graph_url` and `authority_host` (e.g. to
+// point the app at `mstd dev fake-graph`).
+var Clouds map[string]Cloud = map[string]Cloud{
+	"global":    {"https://graph.microsoft.com", "https://login.microsoftonline.com"},
+	"usgov":     {"https://graph.microsoft.us", "https://login.microsoftonline.us"},
+	"usgov-dod": {"https://dod-graph.microsoft.us", "https://login.microsoftonline.us"},
+	"china":     {"https://microsoftgraph.chinacloudapi.cn", "https://login.chinacloudapi.cn"},
+}
+
+// The versions of the API that can be set as `api_version`.
+var ApiVersions map[string]bool = map[string]bool{"v1.0": true, "beta": true}
+
+// A tenant is `common`, `organizations`, `consumers`, a tenant id (a GUID) or
+// a domain name.
+var tenantPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9.-]*$`)
+
+// A profile name ends up in the name of its config file and of the directory
+// of its state, so it's limited to letters, digits, `_` and `-`.
+var profilePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
 type Config struct {
 	mu                    sync.Mutex
 	clientId              string
@@ -55,6 +102,15 @@ type Config struct {
 	refreshTokenExpiresAt t.Time
 	authCallbackHost      string
 	authCallbackPath      string
+	timeZone              string
+	proxy                 string
+	caBundle              string
+	gzip                  bool
+	graphUrl              string
+	apiVersion            string
+	authorityHost         string
+	tenant                string
+	profile               string
 }
 
 // Initializes the Config struct, holding most of the configuration related
@@ -64,7 +120,7 @@ type Config struct {
 func (c *Config) InitConfig(cfgFilePath string) error {
 	setEnvVariables()
 
-	if err := setViperConfig(cfgFilePath); err != nil {
+	if err := setViperConfig(cfgFilePath, configFileName(c.Profile())); err != nil {
 		return err
 	}
 	if err := validateConfigFileAttributes(); err != nil {
@@ -76,6 +132,28 @@ func (c *Config) InitConfig(cfgFilePath string) error {
 	return nil
 }
 
+// A setter method for the profile. It has to be set before initializing the
+// config, as each profile has its own config file. An empty one stands for
+// the DefaultProfile.
+func (c *Config) SetProfile(profile string) error {
+	if profile != "" && !profilePattern.MatchString(profile) {
+		return fmt.Errorf("Invalid profile %q, use only letters, digits, _ and -", profile)
+	}
+
+	c.profile = profile
+
+	return nil
+}
+
+// A getter function for the profile.
+func (c *Config) Profile() string {
+	if c.profile == "" {
+		return DefaultProfile
+	}
+
+	return c.profile
+}
+
 // A getter function for the clientId.
 func (c *Config) ClientId() string {
 	return c.clientId
@@ -121,6 +199,52 @@ func (c *Config) AuthCallbackPath() string {
 	return c.authCallbackPath
 }
 
+// A getter function for the timeZone (an IANA name, e.g. `Europe/Sofia`).
+// It is optional, so it's empty when the local time zone should be used.
+func (c *Config) TimeZone() string {
+	return c.timeZone
+}
+
+// A getter function for the proxy (a URL). It is optional, so it's empty when
+// the proxy should be taken from the environment (`HTTPS_PROXY`).
+func (c *Config) Proxy() string {
+	return c.proxy
+}
+
+// A getter function for the caBundle, the path to a PEM file of CA
+// certificates to trust besides the system ones. It is optional.
+func (c *Config) CaBundle() string {
+	return c.caBundle
+}
+
+// A getter function for gzip, telling if the responses of the API should be
+// compressed.
+func (c *Config) Gzip() bool {
+	return c.gzip
+}
+
+// A getter function for the graphUrl, the base URL of Graph (without the API
+// version). It's the one of the cloud set, unless it's overridden.
+func (c *Config) GraphUrl() string {
+	return c.graphUrl
+}
+
+// A getter function for the apiVersion (`v1.0` or `beta`).
+func (c *Config) ApiVersion() string {
+	return c.apiVersion
+}
+
+// A getter function for the authorityHost, the URL of the host signing in is
+// done with. It's the one of the cloud set, unless it's overridden.
+func (c *Config) AuthorityHost() string {
+	return c.authorityHost
+}
+
+// A getter function for the tenant signing in is done in.
+func (c *Config) Tenant() string {
+	return c.tenant
+}
+
 // A getter function for the clientId key string.
 func clientId() string {
 	return viper.Client.GetString(defaultClientIdConfig)
@@ -174,6 +298,77 @@ func authCallbackPath() string {
 	return viper.Client.GetString(defaultAuthCallbackPath)
 }
 
+// A getter function to provide the time zone the dates entered in the CLI
+// are in.
+func timeZone() string {
+	return viper.Client.GetString(defaultTimeZoneConfig)
+}
+
+// A getter function to provide the proxy the requests are sent through.
+func proxy() string {
+	return viper.Client.GetString(defaultProxyConfig)
+}
+
+// A getter function to provide the path to the CA bundle.
+func caBundle() string {
+	return viper.Client.GetString(defaultCaBundleConfig)
+}
+
+// A getter function to provide if the responses should be compressed. It's
+// on unless it's explicitly turned off.
+func gzip() bool {
+	on, err := strconv.ParseBool(viper.Client.GetString(defaultGzipConfig))
+
+	return err != nil || on
+}
+
+// A getter function to provide the cloud the app talks to.
+func cloud() string {
+	if name := viper.Client.GetString(defaultCloudConfig); name != "" {
+		return name
+	}
+
+	return DefaultCloud
+}
+
+// A getter function to provide the base URL of Graph, which is the one of
+// the cloud unless it's overridden.
+func graphUrl() string {
+	if url := viper.Client.GetString(defaultGraphUrlConfig); url != "" {
+		return strings.TrimSuffix(url, "/")
+	}
+
+	return Clouds[cloud()].GraphUrl
+}
+
+// A getter function to provide the version of the API.
+func apiVersion() string {
+	if version := viper.Client.GetString(defaultApiVersionConfig); version != "" {
+		return version
+	}
+
+	return DefaultApiVersion
+}
+
+// A getter function to provide the host signing in is done with, which is
+// the one of the cloud unless it's overridden.
+func authorityHost() string {
+	if host := viper.Client.GetString(defaultAuthorityHostConfig); host != "" {
+		return strings.TrimSuffix(host, "/")
+	}
+
+	return Clouds[cloud()].AuthorityHost
+}
+
+// A getter function to provide the tenant signing in is done in.
+func tenant() string {
+	if name := viper.Client.GetString(defaultTenantConfig); name != "" {
+		return name
+	}
+
+	return DefaultTenant
+}
+
 // A setter method for the accessToken.
 func (c *Config) SetClientAccessToken(in string) error {
 	c.mu.Lock()
@@ -275,6 +470,14 @@ func (c *Config) populateConfigValues() {
 	c.refreshTokenExpiresAt = clientRefreshTokenExpiry()
 	c.authCallbackHost = authCallbackHost()
 	c.authCallbackPath = authCallbackPath()
+	c.timeZone = timeZone()
+	c.proxy = proxy()
+	c.caBundle = caBundle()
+	c.gzip = gzip()
+	c.graphUrl = graphUrl()
+	c.apiVersion = apiVersion()
+	c.authorityHost = authorityHost()
+	c.tenant = tenant()
 }
 
 // A function to concert seconds into a time.Duration object
@@ -285,8 +488,19 @@ func secondsToDuration(s int) (t.Duration, error) {
 	return durSecs, err
 }
 
-// A funciton to set the config file path for the Viper tool.
-func setViperConfig(cfgFilePath string) error {
+// Returns the name of the config file (in the home directory) of a profile,
+// e.g. `.mstd` for the default profile and `.mstd-work` for `work`.
+func configFileName(profile string) string {
+	if profile == DefaultProfile {
+		return defaultConfigFileName
+	}
+
+	return defaultConfigFileName + "-" + profile
+}
+
+// A funciton to set the config file path for the Viper tool. Without an
+// explicit path, the file named `configName` in the home directory is used.
+func setViperConfig(cfgFilePath, configName string) error {
 	if cfgFilePath != "" {
 		viper.Client.SetConfigFile(cfgFilePath)
 	} else {
@@ -295,7 +509,7 @@ func setViperConfig(cfgFilePath string) error {
 			return err
 		}
 		viper.Client.AddConfigPath(home)
-		viper.Client.SetConfigName(defaultConfigFileName)
+		viper.Client.SetConfigName(configName)
 	}
 
 	return readConfigFile()
@@ -318,12 +532,13 @@ func readConfigFile() error {
 
 // Validates the presence of the necessary values in our config file.
 func validateConfigFileAttributes() error {
-	var err [5]error
+	var err [6]error
 	err[0] = validateClientIdConfigPresence()
 	err[1] = validateClientSecretConfigPresence()
 	err[2] = validateClientPermissionsConfigPresence()
 	err[3] = validateAuthCallbackHostConfigPresence()
 	err[4] = validateAuthCallbackPathConfigPresence()
+	err[5] = validateEndpointsConfig()
 
 	str := []string{"Errors in config file:"}
 	for _, e := range err {
@@ -383,6 +598,46 @@ func validateAuthCallbackPathConfigPresence() error {
 	return nil
 }
 
+// Validates the cloud, the endpoints, the API version and the tenant set in
+// our config (all of them are optional).
+func validateEndpointsConfig() error {
+	if _, ok := Clouds[cloud()]; !ok {
+		return fmt.Errorf(
+			"Unknown %s %q in config file (use global, usgov, usgov-dod or china)",
+			defaultCloudConfig,
+			cloud(),
+		)
+	}
+
+	if !ApiVersions[apiVersion()] {
+		return fmt.Errorf(
+			"Unknown %s %q in config file (use v1.0 or beta)",
+			defaultApiVersionConfig,
+			apiVersion(),
+		)
+	}
+
+	if !tenantPattern.MatchString(tenant()) {
+		return fmt.Errorf("Invalid %s %q in config file", defaultTenantConfig, tenant())
+	}
+
+	if err := validateUrlConfig(defaultGraphUrlConfig, graphUrl()); err != nil {
+		return err
+	}
+
+	return validateUrlConfig(defaultAuthorityHostConfig, authorityHost())
+}
+
+// Validates that a URL in our config is an absolute http(s) one.
+func validateUrlConfig(key, value string) error {
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return fmt.Errorf("Invalid %s %q in config file (use an http(s) URL)", key, value)
+	}
+
+	return nil
+}
+
 // Retrieves the path to user's home directory
 func homeDir() (string, error) {
 	home, err := homedir.Client.Dir()
@@ -24,6 +24,7 @@ import (
 	tt "github.com/betasve/mstd/ext/time/timetest"
 	"github.com/betasve/mstd/ext/viper"
 	vt "github.com/betasve/mstd/ext/viper/vipertest"
+	"strings"
 	"testing"
 	"time"
 )
@@ -32,6 +33,16 @@ func TestInitConfigSuccess(test *testing.T) {
 	viper.Client = vt.ViperServiceMock{}
 	cfgFilePath := "file/path"
 	vt.GetString = "testViperString"
+	vt.GetStringFunc = func(key string) string {
+		// The endpoints are optional, and default to the global cloud.
+		switch key {
+		case defaultCloudConfig, defaultGraphUrlConfig, defaultApiVersionConfig,
+			defaultAuthorityHostConfig, defaultTenantConfig:
+			return ""
+		default:
+			return vt.GetString
+		}
+	}
 
 	config := Config{}
 	err := config.InitConfig(cfgFilePath)
@@ -129,6 +140,40 @@ func TestGetAuthCallbackPath(test *testing.T) {
 	testAccessorMethodFor(cfg.AuthCallbackPath, stub, test)
 }
 
+func TestGetTimeZone(test *testing.T) {
+	stub := "Europe/Sofia"
+	cfg := Config{timeZone: stub}
+
+	testAccessorMethodFor(cfg.TimeZone, stub, test)
+}
+
+func TestGetProxy(test *testing.T) {
+	stub := "http://proxy.corp:3128"
+	cfg := Config{proxy: stub}
+
+	testAccessorMethodFor(cfg.Proxy, stub, test)
+}
+
+func TestGetCaBundle(test *testing.T) {
+	stub := "/etc/ssl/corp.pem"
+	cfg := Config{caBundle: stub}
+
+	testAccessorMethodFor(cfg.CaBundle, stub, test)
+}
+
+func TestGzip(test *testing.T) {
+	viper.Client = vt.ViperServiceMock{}
+	vt.GetStringFunc = nil
+
+	for value, expected := range map[string]bool{"": true, "true": true, "false": false, "0": false} {
+		vt.GetString = value
+
+		if result := gzip(); result != expected {
+			test.Errorf("\nExpected gzip for %q to be:\n%t\nbut was\n%t", value, expected, result)
+		}
+	}
+}
+
 func TestGetClientRefreshToken(test *testing.T) {
 	stub := "testClientRefreshToken"
 	cfg := Config{refreshToken: stub}
@@ -571,7 +616,7 @@ func TestSetViperConfigWithConfigFilePath(test *testing.T) {
 
 	viper.Client = vt.ViperServiceMock{}
 
-	err := setViperConfig(cfgFilePath)
+	err := setViperConfig(cfgFilePath, defaultConfigFileName)
 	if err != nil {
 		test.Errorf("expected\nno errors\nbut got\n%s", err)
 	}
@@ -587,7 +632,7 @@ func TestSetViperConfigWithoutConfigFilePath(test *testing.T) {
 	vt.AddConfigPathFunc = func(in string) { addConfigPathResult = "homedir" }
 	vt.SetConfigNameFunc = func(in string) { setConfigNameResult = vt.ConfigFileUsed }
 
-	err := setViperConfig(cfgFilePath)
+	err := setViperConfig(cfgFilePath, defaultConfigFileName)
 	if addConfigPathResult != "homedir" && setConfigNameResult != vt.ConfigFileUsed {
 		test.Errorf(
 			"expected config path \n%s \n but was\n%s"+
@@ -604,6 +649,39 @@ func TestSetViperConfigWithoutConfigFilePath(test *testing.T) {
 	}
 }
 
+func TestConfigFileName(test *testing.T) {
+	if result := configFileName(DefaultProfile); result != defaultConfigFileName {
+		test.Errorf("expected\n%s\nbut got\n%s", defaultConfigFileName, result)
+	}
+
+	if result := configFileName("work"); result != ".mstd-work" {
+		test.Errorf("expected\n.mstd-work\nbut got\n%s", result)
+	}
+}
+
+func TestProfileDefault(test *testing.T) {
+	cfg := Config{}
+
+	testAccessorMethodFor(cfg.Profile, DefaultProfile, test)
+
+	if err := cfg.SetProfile("work_2-b"); err != nil {
+		test.Errorf("expected\nnil\nbut got\n%s", err)
+	}
+	testAccessorMethodFor(cfg.Profile, "work_2-b", test)
+}
+
+func TestSetProfileInvalid(test *testing.T) {
+	for _, profile := range []string{"../work", "work/home", "work home", ".", "wörk"} {
+		cfg := Config{}
+
+		if err := cfg.SetProfile(profile); err == nil {
+			test.Errorf("expected an error for the profile %q\nbut got\nnil", profile)
+		}
+
+		testAccessorMethodFor(cfg.Profile, DefaultProfile, test)
+	}
+}
+
 func TestSetEnvVariables(test *testing.T) {
 	viper.Client = vt.ViperServiceMock{}
 	var funcInvoked bool
@@ -663,6 +741,73 @@ func TestValidateConfigFileAttributesSuccess(test *testing.T) {
 	}
 }
 
+func TestEndpointsDefaultToTheGlobalCloud(test *testing.T) {
+	viper.Client = vt.ViperServiceMock{}
+	vt.GetString = ""
+	vt.GetStringFunc = nil
+
+	if graphUrl() != "https://graph.microsoft.com" || apiVersion() != "v1.0" {
+		test.Errorf("\nExpected the global v1.0 API\nbut was\n%s/%s", graphUrl(), apiVersion())
+	}
+
+	if authorityHost() != "https://login.microsoftonline.com" || tenant() != "common" {
+		test.Errorf("\nExpected the common tenant\nbut was\n%s/%s", authorityHost(), tenant())
+	}
+}
+
+func TestEndpointsOfACloud(test *testing.T) {
+	viper.Client = vt.ViperServiceMock{}
+	vt.GetStringFunc = func(key string) string {
+		switch key {
+		case defaultCloudConfig:
+			return "china"
+		case defaultAuthorityHostConfig:
+			return "http://localhost:8081/"
+		default:
+			return ""
+		}
+	}
+
+	if graphUrl() != "https://microsoftgraph.chinacloudapi.cn" {
+		test.Errorf("\nExpected the Graph URL of China\nbut was\n%s", graphUrl())
+	}
+
+	if authorityHost() != "http://localhost:8081" {
+		test.Errorf("\nExpected the overridden authority host\nbut was\n%s", authorityHost())
+	}
+}
+
+func TestValidateEndpointsConfig(test *testing.T) {
+	viper.Client = vt.ViperServiceMock{}
+
+	for config, valid := range map[string]bool{
+		"":                                true,
+		"cloud=usgov":                     true,
+		"cloud=mars":                      false,
+		"api_version=beta":                true,
+		"api_version=v2.0":                false,
+		"tenant=consumers":                true,
+		"tenant=contoso.onmicrosoft.com":  true,
+		"tenant=a/b":                      false,
+		"graph_url=http://localhost:8081": true,
+		"graph_url=localhost:8081":        false,
+		"authority_host=ftp://login":      false,
+	} {
+		pair := strings.SplitN(config, "=", 2)
+		vt.GetStringFunc = func(key string) string {
+			if key == pair[0] {
+				return pair[1]
+			}
+
+			return ""
+		}
+
+		if err := validateEndpointsConfig(); (err == nil) != valid {
+			test.Errorf("\nExpected %q to be valid: %t\nbut got\n%v", config, valid, err)
+		}
+	}
+}
+
 func TestValidateConfigFileAttributesClientIdFailure(test *testing.T) {
 	viper.Client = vt.ViperServiceMock{}
 	vt.GetStringFunc = func(key string) string {
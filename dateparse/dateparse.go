@@ -0,0 +1,513 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The `dateparse` package is in charge of turning the (more or less) natural
+// language date expressions that are typed in the CLI (e.g. `tomorrow`,
+// `next friday 5pm`, `in 2h`) into times. It reads the current time through
+// the `ext/time` clock so the expressions can be resolved in tests against
+// a fixed point in time.
+package dateparse
+
+import (
+	"fmt"
+	tm "github.com/betasve/mstd/ext/time"
+	api "github.com/betasve/mstd/todoapi"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Holds the settings used for resolving the expressions.
+type Parser struct {
+	// The location the expressions are resolved in. Defaults to time.Local.
+	Location *time.Location
+	// The time of day (as an offset from midnight) given to the expressions
+	// that only name a date (e.g. `tomorrow`).
+	DefaultTimeOfDay time.Duration
+}
+
+// The absolute layouts that are accepted as they are.
+var absoluteLayouts []string = []string{
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// Named times of the day.
+var namedTimes map[string]time.Duration = map[string]time.Duration{
+	"midnight":  0,
+	"morning":   9 * time.Hour,
+	"noon":      12 * time.Hour,
+	"afternoon": 15 * time.Hour,
+	"evening":   18 * time.Hour,
+	"tonight":   20 * time.Hour,
+}
+
+// Matches the clock times, e.g. `5pm`, `5:30pm`, `17:00`.
+var clockRegexp = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?(am|pm)?$`)
+
+// Matches the compact relative amounts, e.g. `2h`, `30min`, `3days`.
+var amountRegexp = regexp.MustCompile(`^(\d+)([a-z]*)$`)
+
+// The words that carry no meaning for the parser.
+var fillerWords map[string]bool = map[string]bool{
+	"at": true, "on": true, "the": true, "of": true,
+}
+
+// A parser resolving expressions in the local time zone, giving no time
+// (midnight) to date-only expressions.
+var Default = Parser{}
+
+// Parses an expression with the Default parser.
+func Parse(expr string) (time.Time, error) {
+	return Default.Parse(expr)
+}
+
+// Parses an expression and converts it to the API's `dateTimeTimeZone`
+// representation, in the parser's location.
+func (p Parser) DateTimeTimeZone(expr string) (*api.DateTimeTimeZone, error) {
+	result, err := p.Parse(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	return api.NewDateTimeTimeZone(result), nil
+}
+
+// Parses an expression into a time. Supported are absolute dates (and times)
+// like `2021-05-03` or `2021-05-03 17:00`, relative ones like `now`, `today`,
+// `tomorrow`, `in 2h`, `in 3 days`, `next week`, week days (`friday`,
+// `next fri`), month days (`may 3`, `3 may`) and clock times (`5pm`, `17:30`,
+// `noon`) that can follow any of the date expressions.
+func (p Parser) Parse(expr string) (time.Time, error) {
+	loc := p.location()
+	expr = strings.ToLower(strings.TrimSpace(expr))
+
+	if expr == "" {
+		return time.Time{}, fmt.Errorf("Empty date expression")
+	}
+
+	for _, layout := range absoluteLayouts {
+		if result, err := time.ParseInLocation(layout, expr, loc); err == nil {
+			if layout == "2006-01-02" {
+				result = result.Add(p.DefaultTimeOfDay)
+			}
+			return result, nil
+		}
+	}
+
+	s := state{
+		now:    tm.Client.Now().In(loc),
+		tokens: tokenize(expr),
+	}
+	s.date = midnight(s.now)
+
+	for s.pos < len(s.tokens) {
+		if err := s.consume(); err != nil {
+			return time.Time{}, fmt.Errorf("Cannot parse date %q: %s", expr, err)
+		}
+	}
+
+	switch {
+	case s.exact:
+		return s.date, nil
+	case s.hasClock:
+		return s.date.Add(s.clock), nil
+	case s.hasDate:
+		return s.date.Add(p.DefaultTimeOfDay), nil
+	}
+
+	return time.Time{}, fmt.Errorf("Cannot parse date %q", expr)
+}
+
+// Returns the location of the parser, defaulting to the local one.
+func (p Parser) location() *time.Location {
+	if p.Location == nil {
+		return time.Local
+	}
+
+	return p.Location
+}
+
+// Holds the progress of parsing a single expression.
+type state struct {
+	now      time.Time
+	tokens   []string
+	pos      int
+	date     time.Time
+	clock    time.Duration
+	hasDate  bool
+	hasClock bool
+	exact    bool
+}
+
+// Consumes the next expression out of the tokens, updating the state.
+func (s *state) consume() error {
+	token := s.next()
+
+	switch token {
+	case "now":
+		s.setExact(s.now)
+		return nil
+	case "today":
+		s.setDate(midnight(s.now))
+		return nil
+	case "tonight":
+		s.setDate(midnight(s.now))
+		return s.setClock(namedTimes["tonight"])
+	case "tomorrow":
+		s.setDate(midnight(s.now).AddDate(0, 0, 1))
+		return nil
+	case "yesterday":
+		s.setDate(midnight(s.now).AddDate(0, 0, -1))
+		return nil
+	case "in":
+		return s.consumeRelative()
+	case "next":
+		return s.consumeNext()
+	}
+
+	if d, ok := namedTimes[token]; ok {
+		return s.setClock(d)
+	}
+
+	if day, ok := parseWeekday(token); ok {
+		s.setDate(upcoming(s.now, day, false))
+		return nil
+	}
+
+	if month, ok := parseMonth(token); ok {
+		day, err := strconv.Atoi(s.next())
+		if err != nil {
+			return fmt.Errorf("expected a day after %q", token)
+		}
+		return s.setMonthDay(month, day)
+	}
+
+	if d, ok := parseClock(token, s.peek()); ok {
+		if d.hasSuffixToken {
+			s.next()
+		}
+		return s.setClock(d.offset)
+	}
+
+	if day, err := strconv.Atoi(token); err == nil {
+		if month, ok := parseMonth(s.peek()); ok {
+			s.next()
+			return s.setMonthDay(month, day)
+		}
+	}
+
+	if token == "" {
+		return fmt.Errorf("unexpected end of expression")
+	}
+
+	return fmt.Errorf("unknown word %q", token)
+}
+
+// Consumes an `in N unit` expression (the `in` being already consumed).
+func (s *state) consumeRelative() error {
+	amount, unit, err := splitAmount(s.next(), s)
+	if err != nil {
+		return err
+	}
+
+	base := s.now
+	if s.hasDate {
+		base = s.date
+	}
+
+	result, err := addAmount(base, amount, unit)
+	if err != nil {
+		return err
+	}
+
+	s.setExact(result)
+	return nil
+}
+
+// Consumes a `next ...` expression (the `next` being already consumed).
+func (s *state) consumeNext() error {
+	token := s.next()
+
+	if day, ok := parseWeekday(token); ok {
+		s.setDate(upcoming(s.now, day, true))
+		return nil
+	}
+
+	today := midnight(s.now)
+
+	switch normalizeUnit(token) {
+	case "day":
+		s.setDate(today.AddDate(0, 0, 1))
+	case "week":
+		s.setDate(today.AddDate(0, 0, 7))
+	case "month":
+		s.setDate(today.AddDate(0, 1, 0))
+	case "year":
+		s.setDate(today.AddDate(1, 0, 0))
+	default:
+		return fmt.Errorf("unexpected %q after next", token)
+	}
+
+	return nil
+}
+
+// Sets the date (to the next occurrence of) a day in a month.
+func (s *state) setMonthDay(month time.Month, day int) error {
+	if day < 1 || day > 31 {
+		return fmt.Errorf("invalid day of month %d", day)
+	}
+
+	result := time.Date(s.now.Year(), month, day, 0, 0, 0, 0, s.now.Location())
+	if result.Before(midnight(s.now)) {
+		result = result.AddDate(1, 0, 0)
+	}
+
+	s.setDate(result)
+	return nil
+}
+
+// Sets the (midnight of the) date of the result.
+func (s *state) setDate(date time.Time) {
+	s.date = date
+	s.hasDate = true
+	s.exact = false
+}
+
+// Sets an exact point in time as the result.
+func (s *state) setExact(t time.Time) {
+	s.date = t
+	s.hasDate = true
+	s.exact = true
+}
+
+// Sets the time of the day of the result. When an exact time is already set
+// (e.g. by `in 2 days`) its date is kept and its time of the day replaced.
+func (s *state) setClock(d time.Duration) error {
+	if s.hasClock {
+		return fmt.Errorf("time of day given twice")
+	}
+
+	if s.exact {
+		s.date = midnight(s.date)
+		s.exact = false
+	}
+
+	s.clock = d
+	s.hasClock = true
+
+	return nil
+}
+
+// Returns the next token, moving forward. Returns `""` at the end.
+func (s *state) next() string {
+	token := s.peek()
+	if s.pos < len(s.tokens) {
+		s.pos++
+	}
+
+	return token
+}
+
+// Returns the next token without moving forward.
+func (s *state) peek() string {
+	if s.pos >= len(s.tokens) {
+		return ""
+	}
+
+	return s.tokens[s.pos]
+}
+
+// A clock time parsed out of one or two tokens.
+type clockTime struct {
+	offset         time.Duration
+	hasSuffixToken bool
+}
+
+// Parses a clock time, e.g. `5pm`, `17:30` or `5` followed by a `pm` token.
+func parseClock(token, following string) (clockTime, bool) {
+	m := clockRegexp.FindStringSubmatch(token)
+	if m == nil {
+		return clockTime{}, false
+	}
+
+	result := clockTime{}
+	suffix := m[3]
+
+	if suffix == "" && (following == "am" || following == "pm") {
+		suffix = following
+		result.hasSuffixToken = true
+	}
+
+	// A bare number is only a clock time when it has a suffix or minutes,
+	// otherwise it's ambiguous with a day of month (e.g. `3 may`).
+	if suffix == "" && m[2] == "" {
+		return clockTime{}, false
+	}
+
+	hour, _ := strconv.Atoi(m[1])
+	minute, _ := strconv.Atoi("0" + m[2])
+
+	if suffix != "" && (hour < 1 || hour > 12) {
+		return clockTime{}, false
+	}
+
+	switch suffix {
+	case "am":
+		if hour == 12 {
+			hour = 0
+		}
+	case "pm":
+		if hour != 12 {
+			hour += 12
+		}
+	}
+
+	if hour > 23 || minute > 59 {
+		return clockTime{}, false
+	}
+
+	result.offset = time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute
+	return result, true
+}
+
+// Splits a relative amount (e.g. `2h` or `2` followed by `hours`) into its
+// number and its (normalized) unit.
+func splitAmount(token string, s *state) (int, string, error) {
+	m := amountRegexp.FindStringSubmatch(token)
+	if m == nil {
+		return 0, "", fmt.Errorf("expected an amount after in, got %q", token)
+	}
+
+	amount, _ := strconv.Atoi(m[1])
+	unit := m[2]
+	if unit == "" {
+		unit = s.next()
+	}
+
+	normalized := normalizeUnit(unit)
+	if normalized == "" {
+		return 0, "", fmt.Errorf("unknown unit %q", unit)
+	}
+
+	return amount, normalized, nil
+}
+
+// Adds an amount of units to a time.
+func addAmount(t time.Time, amount int, unit string) (time.Time, error) {
+	switch unit {
+	case "minute":
+		return t.Add(time.Duration(amount) * time.Minute), nil
+	case "hour":
+		return t.Add(time.Duration(amount) * time.Hour), nil
+	case "day":
+		return t.AddDate(0, 0, amount), nil
+	case "week":
+		return t.AddDate(0, 0, 7*amount), nil
+	case "month":
+		return t.AddDate(0, amount, 0), nil
+	case "year":
+		return t.AddDate(amount, 0, 0), nil
+	}
+
+	return t, fmt.Errorf("unknown unit %q", unit)
+}
+
+// Converts the accepted spellings of a unit to its singular full name.
+func normalizeUnit(unit string) string {
+	switch unit {
+	case "m", "min", "mins", "minute", "minutes":
+		return "minute"
+	case "h", "hr", "hrs", "hour", "hours":
+		return "hour"
+	case "d", "day", "days":
+		return "day"
+	case "w", "wk", "wks", "week", "weeks":
+		return "week"
+	case "mo", "month", "months":
+		return "month"
+	case "y", "yr", "yrs", "year", "years":
+		return "year"
+	}
+
+	return ""
+}
+
+// Parses a (short or full) week day name.
+func parseWeekday(token string) (time.Weekday, bool) {
+	if len(token) < 3 {
+		return 0, false
+	}
+
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		name := strings.ToLower(d.String())
+		if strings.HasPrefix(name, token) {
+			return d, true
+		}
+	}
+
+	return 0, false
+}
+
+// Parses a (short or full) month name.
+func parseMonth(token string) (time.Month, bool) {
+	if len(token) < 3 {
+		return 0, false
+	}
+
+	for m := time.January; m <= time.December; m++ {
+		name := strings.ToLower(m.String())
+		if strings.HasPrefix(name, token) {
+			return m, true
+		}
+	}
+
+	return 0, false
+}
+
+// Returns the midnight of the upcoming `day`. Today counts as upcoming only
+// when `strict` is false.
+func upcoming(now time.Time, day time.Weekday, strict bool) time.Time {
+	diff := (int(day) - int(now.Weekday()) + 7) % 7
+	if diff == 0 && strict {
+		diff = 7
+	}
+
+	return midnight(now).AddDate(0, 0, diff)
+}
+
+// Returns the beginning of the day of a time.
+func midnight(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+// Splits an expression into its meaningful tokens.
+func tokenize(expr string) []string {
+	tokens := []string{}
+
+	for _, token := range strings.Fields(strings.ReplaceAll(expr, ",", " ")) {
+		if !fillerWords[token] {
+			tokens = append(tokens, token)
+		}
+	}
+
+	return tokens
+}
@@ -0,0 +1,126 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package dateparse
+
+import (
+	tm "github.com/betasve/mstd/ext/time"
+	"github.com/betasve/mstd/ext/time/timetest"
+	"testing"
+	"time"
+)
+
+// Wednesday, 2021-05-05 14:20 in Sofia.
+var sofia, _ = time.LoadLocation("Europe/Sofia")
+var fixedNow = time.Date(2021, 5, 5, 14, 20, 0, 0, sofia)
+
+func init() {
+	tm.Client = timetest.TimeMock{}
+	timetest.TimeNowMockFunc = func() time.Time { return fixedNow }
+}
+
+func TestParseExpressions(test *testing.T) {
+	p := Parser{Location: sofia}
+
+	cases := map[string]time.Time{
+		"now":                  fixedNow,
+		"today":                time.Date(2021, 5, 5, 0, 0, 0, 0, sofia),
+		"tomorrow":             time.Date(2021, 5, 6, 0, 0, 0, 0, sofia),
+		"tomorrow 9am":         time.Date(2021, 5, 6, 9, 0, 0, 0, sofia),
+		"tonight":              time.Date(2021, 5, 5, 20, 0, 0, 0, sofia),
+		"in 2h":                time.Date(2021, 5, 5, 16, 20, 0, 0, sofia),
+		"in 30 minutes":        time.Date(2021, 5, 5, 14, 50, 0, 0, sofia),
+		"in 3 days at 5pm":     time.Date(2021, 5, 8, 17, 0, 0, 0, sofia),
+		"friday":               time.Date(2021, 5, 7, 0, 0, 0, 0, sofia),
+		"wednesday":            time.Date(2021, 5, 5, 0, 0, 0, 0, sofia),
+		"next wed":             time.Date(2021, 5, 12, 0, 0, 0, 0, sofia),
+		"next friday 5pm":      time.Date(2021, 5, 7, 17, 0, 0, 0, sofia),
+		"next friday 5:30 pm":  time.Date(2021, 5, 7, 17, 30, 0, 0, sofia),
+		"next week":            time.Date(2021, 5, 12, 0, 0, 0, 0, sofia),
+		"may 3":                time.Date(2022, 5, 3, 0, 0, 0, 0, sofia),
+		"25 dec, noon":         time.Date(2021, 12, 25, 12, 0, 0, 0, sofia),
+		"2021-06-01":           time.Date(2021, 6, 1, 0, 0, 0, 0, sofia),
+		"2021-06-01 08:15":     time.Date(2021, 6, 1, 8, 15, 0, 0, sofia),
+		"17:45":                time.Date(2021, 5, 5, 17, 45, 0, 0, sofia),
+		"12am":                 time.Date(2021, 5, 5, 0, 0, 0, 0, sofia),
+		"Tomorrow At Midnight": time.Date(2021, 5, 6, 0, 0, 0, 0, sofia),
+	}
+
+	for expr, expected := range cases {
+		result, err := p.Parse(expr)
+
+		if err != nil {
+			test.Errorf("\nExpected %q to parse\nbut got\n%s", expr, err)
+			continue
+		}
+
+		if !result.Equal(expected) {
+			test.Errorf("\nExpected %q to be:\n%s\nbut was\n%s", expr, expected, result)
+		}
+	}
+}
+
+func TestParseDefaultTimeOfDay(test *testing.T) {
+	p := Parser{Location: sofia, DefaultTimeOfDay: 9 * time.Hour}
+
+	result, _ := p.Parse("tomorrow")
+	expected := time.Date(2021, 5, 6, 9, 0, 0, 0, sofia)
+
+	if !result.Equal(expected) {
+		test.Errorf("\nExpected:\n%s\nbut was\n%s", expected, result)
+	}
+
+	result, _ = p.Parse("in 2h")
+	expected = time.Date(2021, 5, 5, 16, 20, 0, 0, sofia)
+
+	if !result.Equal(expected) {
+		test.Errorf("\nExpected exact times to be kept:\n%s\nbut was\n%s", expected, result)
+	}
+}
+
+func TestParseFailure(test *testing.T) {
+	for _, expr := range []string{
+		"",
+		"someday",
+		"in",
+		"in 2 fortnights",
+		"next",
+		"next blue moon",
+		"13pm",
+		"may",
+		"5pm 6pm",
+	} {
+		if _, err := Parse(expr); err == nil {
+			test.Errorf("\nExpected %q to return error\nbut it was\nnil", expr)
+		}
+	}
+}
+
+func TestDateTimeTimeZone(test *testing.T) {
+	p := Parser{Location: sofia}
+
+	result, err := p.DateTimeTimeZone("next friday 5pm")
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	if result.DateTime != "2021-05-07T17:00:00.0000000" {
+		test.Errorf("\nExpected date time to be:\n2021-05-07T17:00:00.0000000\nbut was\n%s", result.DateTime)
+	}
+
+	if result.TimeZone != "Europe/Sofia" {
+		test.Errorf("\nExpected time zone to be:\nEurope/Sofia\nbut was\n%s", result.TimeZone)
+	}
+}
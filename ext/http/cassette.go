@@ -0,0 +1,372 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Tells whether the requests are sent (and recorded to the cassette) or
+// answered with the interactions recorded in it.
+type CassetteMode int
+
+const (
+	// Answers the requests from the cassette, without touching the network.
+	CassetteReplay CassetteMode = iota
+	// Sends the requests and records them (scrubbed) to the cassette,
+	// replacing what it held.
+	CassetteRecord
+)
+
+// Replaces what the Pattern matches (in the URLs and the bodies recorded)
+// with the Replacement, e.g. to keep personal data out of the cassettes.
+type Scrubber struct {
+	Pattern     *regexp.Regexp
+	Replacement string
+}
+
+// The scrubbers every cassette is recorded with (on top of the secrets being
+// redacted): e-mail addresses and the user in the `@odata.context` of Graph
+// (e.g. `users('...')`).
+var DefaultScrubbers = []Scrubber{
+	{
+		regexp.MustCompile(`[A-Za-z0-9._%+-]+(?:@|%40)[A-Za-z0-9.-]+\.[A-Za-z]{2,}`),
+		"user@example.com",
+	},
+	{regexp.MustCompile(`users\('[^']*'\)`), "users('user')"},
+}
+
+// The JSON fields (at any depth of the bodies recorded) holding what the user
+// wrote, e.g. the titles of the lists and the tasks, their notes and their
+// categories. Their values are recorded as [REDACTED] (each string of the
+// arrays, one by one), unless the cassette keeps them.
+var ScrubbedFields = map[string]bool{
+	"title":       true,
+	"displayName": true,
+	"content":     true,
+	"categories":  true,
+	"webUrl":      true,
+}
+
+// The ids Graph gives the lists, the tasks and the rest of To Do (e.g.
+// `AAMkADIy...AAA=`). Each is recorded as a placeholder of its own
+// (`graph-id-1`, `graph-id-2`, ...), the same in the URLs and the bodies, so
+// the requests of a test still find the ids it got.
+var graphIdPattern = regexp.MustCompile(`A[AQ]Mk[A-Za-z0-9_-]+(?:=|%3D)*`)
+
+// The only headers recorded. The rest (e.g. the request ids, dates and
+// diagnostics) change with every run or tell too much.
+var cassetteHeaders = map[string]bool{
+	"Content-Type": true,
+	"Location":     true,
+	"Prefer":       true,
+	"Retry-After":  true,
+}
+
+// A recorded request and the response it got.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// A recorded body is kept as JSON when it's JSON (so the cassettes are easy
+// to review), and as text otherwise.
+type RecordedBody struct {
+	Json json.RawMessage `json:"json,omitempty"`
+	Text string          `json:"text,omitempty"`
+}
+
+type RecordedRequest struct {
+	Method  string            `json:"method"`
+	Url     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    *RecordedBody     `json:"body,omitempty"`
+}
+
+type RecordedResponse struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    *RecordedBody     `json:"body,omitempty"`
+}
+
+// The file the interactions are recorded to (as JSON).
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Records the requests to a cassette, or replays them from it.
+type cassetteTransport struct {
+	next      http.RoundTripper
+	path      string
+	mode      CassetteMode
+	scrubbers []Scrubber
+	cassette  *Cassette
+	// The values of the ScrubbedFields recorded as they are.
+	keep map[string]bool
+	// The placeholders of the Graph ids recorded, by id.
+	ids   map[string]string
+	idsMu sync.Mutex
+	// The interactions already replayed, by index.
+	played map[int]bool
+	mu     sync.Mutex
+}
+
+// Makes a transport recording to (or replaying from) the cassette at `path`.
+func newCassetteTransport(next http.RoundTripper, path string, mode CassetteMode, scrubbers []Scrubber, keep []string) (*cassetteTransport, error) {
+	t := &cassetteTransport{
+		next:      next,
+		path:      path,
+		mode:      mode,
+		scrubbers: append(append([]Scrubber{}, DefaultScrubbers...), scrubbers...),
+		cassette:  &Cassette{Interactions: []Interaction{}},
+		keep:      map[string]bool{},
+		ids:       map[string]string{},
+		played:    map[int]bool{},
+	}
+
+	for _, value := range keep {
+		t.keep[value] = true
+	}
+
+	if mode == CassetteRecord {
+		return t, nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read the cassette: %s", err)
+	}
+
+	if err := json.Unmarshal(data, t.cassette); err != nil {
+		return nil, fmt.Errorf("Invalid cassette %s: %s", path, err)
+	}
+
+	return t, nil
+}
+
+func (t *cassetteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.mode == CassetteReplay {
+		return t.replay(req)
+	}
+
+	res, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resBody, err := readBody(&res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, t.record(Interaction{
+		Request: RecordedRequest{
+			Method:  req.Method,
+			Url:     t.scrub(redactUrl(req.URL.String())),
+			Headers: recordedHeaders(req.Header),
+			Body:    t.recordBody(body),
+		},
+		Response: RecordedResponse{
+			Status:  res.StatusCode,
+			Headers: recordedHeaders(res.Header),
+			Body:    t.recordBody(resBody),
+		},
+	})
+}
+
+// Answers a request with the first interaction not replayed yet that has
+// the same method, path and query (the host may differ, so a cassette
+// recorded against one cloud replays against any other).
+func (t *cassetteTransport) replay(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := req.Method + " " + t.scrub(redactUrl(req.URL.RequestURI()))
+
+	for i, interaction := range t.cassette.Interactions {
+		if t.played[i] || interactionKey(interaction.Request) != key {
+			continue
+		}
+
+		t.played[i] = true
+		recorded := interaction.Response
+		res := &http.Response{
+			Status:     fmt.Sprintf("%d %s", recorded.Status, http.StatusText(recorded.Status)),
+			StatusCode: recorded.Status,
+			Proto:      "HTTP/1.1",
+			ProtoMajor: 1,
+			ProtoMinor: 1,
+			Header:     http.Header{},
+			Body:       ioutil.NopCloser(strings.NewReader(recorded.Body.String())),
+			Request:    req,
+		}
+
+		for name, value := range recorded.Headers {
+			res.Header.Set(name, value)
+		}
+
+		return res, nil
+	}
+
+	return nil, fmt.Errorf("No interaction recorded in %s for %s", t.path, key)
+}
+
+// Appends an interaction to the cassette and writes it down, so what was
+// recorded survives a test that fails halfway.
+func (t *cassetteTransport) record(interaction Interaction) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.cassette.Interactions = append(t.cassette.Interactions, interaction)
+
+	data, err := json.MarshalIndent(t.cassette, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(t.path), 0755); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(t.path, append(data, '\n'), 0644)
+}
+
+// Redacts and scrubs a body to record it.
+func (t *cassetteTransport) recordBody(body []byte) *RecordedBody {
+	if len(body) == 0 {
+		return nil
+	}
+
+	scrubbed := t.scrub(t.scrubFields(redactBody(string(body))))
+
+	compact := &bytes.Buffer{}
+	if err := json.Compact(compact, []byte(scrubbed)); err == nil {
+		return &RecordedBody{Json: compact.Bytes()}
+	}
+
+	return &RecordedBody{Text: scrubbed}
+}
+
+// Returns the body as it was received (the JSON ones compacted).
+func (b *RecordedBody) String() string {
+	if b == nil {
+		return ""
+	}
+
+	compact := &bytes.Buffer{}
+	if len(b.Json) > 0 && json.Compact(compact, b.Json) == nil {
+		return compact.String()
+	}
+
+	return b.Text
+}
+
+// Applies the scrubbers to a recorded URL or body, and replaces the Graph ids
+// in it with their placeholders.
+func (t *cassetteTransport) scrub(text string) string {
+	for _, s := range t.scrubbers {
+		text = s.Pattern.ReplaceAllString(text, s.Replacement)
+	}
+
+	return graphIdPattern.ReplaceAllStringFunc(text, t.graphIdPlaceholder)
+}
+
+// Returns the placeholder of a Graph id, the same for an id wherever it's
+// found (be its padding escaped, as in a URL, or not).
+func (t *cassetteTransport) graphIdPlaceholder(id string) string {
+	id = strings.ReplaceAll(id, "%3D", "=")
+
+	t.idsMu.Lock()
+	defer t.idsMu.Unlock()
+
+	if _, ok := t.ids[id]; !ok {
+		t.ids[id] = fmt.Sprintf("graph-id-%d", len(t.ids)+1)
+	}
+
+	return t.ids[id]
+}
+
+// Redacts the values of the ScrubbedFields of a JSON body. The bodies which
+// aren't JSON are returned as they are.
+func (t *cassetteTransport) scrubFields(body string) string {
+	decoder := json.NewDecoder(strings.NewReader(body))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return body
+	}
+
+	scrubbed := &bytes.Buffer{}
+	encoder := json.NewEncoder(scrubbed)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(t.scrubValue(value, false)); err != nil {
+		return body
+	}
+
+	return scrubbed.String()
+}
+
+// Walks a decoded JSON value, redacting the strings in it when they are (or
+// are inside) one of the ScrubbedFields.
+func (t *cassetteTransport) scrubValue(value interface{}, scrubbed bool) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			v[key] = t.scrubValue(field, scrubbed || ScrubbedFields[key])
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = t.scrubValue(item, scrubbed)
+		}
+	case string:
+		if scrubbed && !t.keep[v] {
+			return redacted
+		}
+	}
+
+	return value
+}
+
+// Returns the method, path and query a recorded request is matched by.
+func interactionKey(req RecordedRequest) string {
+	uri := req.Url
+	if i := strings.Index(uri, "://"); i >= 0 {
+		uri = uri[i+3:]
+		if j := strings.Index(uri, "/"); j >= 0 {
+			uri = uri[j:]
+		} else {
+			uri = "/"
+		}
+	}
+
+	return req.Method + " " + uri
+}
+
+// Returns the headers worth recording.
+func recordedHeaders(header http.Header) map[string]string {
+	recorded := map[string]string{}
+	for name := range header {
+		if cassetteHeaders[http.CanonicalHeaderKey(name)] {
+			recorded[http.CanonicalHeaderKey(name)] = header.Get(name)
+		}
+	}
+
+	if len(recorded) == 0 {
+		return nil
+	}
+
+	return recorded
+}
@@ -0,0 +1,176 @@
+package http
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+const listsResponse string = `{"@odata.context":"https://graph.microsoft.com/v1.0/$metadata#users('jane%40contoso.com')/todo/lists",` +
+	`"value":[{"id":"AAMk-1","displayName":"Ask jane@contoso.com"}]}`
+
+func recordCassette(test *testing.T, path string) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("request-id", "req-1")
+
+		if r.URL.Path == "/token" {
+			fmt.Fprint(w, tokenResponse)
+			return
+		}
+
+		fmt.Fprint(w, listsResponse)
+	}))
+	defer server.Close()
+
+	client, err := New(Options{
+		Cassette:     path,
+		CassetteMode: CassetteRecord,
+		Scrubbers:    []Scrubber{{regexp.MustCompile(`AAMk-\d+`), "list-id"}},
+	})
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	req, _ := http.NewRequest("POST", server.URL+"/token", strings.NewReader("code=secret-code"))
+	if _, err := client.Do(req); err != nil {
+		test.Fatal(err)
+	}
+
+	req, _ = http.NewRequest("GET", server.URL+"/v1.0/me/todo/lists?$top=100", nil)
+	req.Header.Set("Authorization", "Bearer secret-bearer")
+	res, err := client.Do(req)
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	if body, _ := ioutil.ReadAll(res.Body); string(body) != listsResponse {
+		test.Errorf("\nExpected the recorded body to still be read:\n%s\nbut was\n%s", listsResponse, body)
+	}
+}
+
+func TestCassetteRecordScrubs(test *testing.T) {
+	path := filepath.Join(test.TempDir(), "cassettes", "lists.json")
+	recordCassette(test, path)
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	out := string(data)
+	for _, leaked := range []string{"secret-", "jane", "contoso", "AAMk", "Authorization", "req-1"} {
+		if strings.Contains(out, leaked) {
+			test.Errorf("\nExpected %q to be scrubbed\nbut the cassette was\n%s", leaked, out)
+		}
+	}
+
+	for _, expected := range []string{"users('user')", `"displayName": "[REDACTED]"`, `"id": "list-id"`, "code=[REDACTED]"} {
+		if !strings.Contains(out, expected) {
+			test.Errorf("\nExpected the cassette to contain:\n%s\nbut it was\n%s", expected, out)
+		}
+	}
+}
+
+func TestCassetteReplay(test *testing.T) {
+	path := filepath.Join(test.TempDir(), "lists.json")
+	recordCassette(test, path)
+
+	client, err := New(Options{Cassette: path, CassetteMode: CassetteReplay})
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	// Replayed by path and query, whatever the host.
+	req, _ := http.NewRequest("GET", "https://graph.microsoft.us/v1.0/me/todo/lists?$top=100", nil)
+	res, err := client.Do(req)
+	if err != nil {
+		test.Fatalf("\nExpected no error\nbut got\n%s", err)
+	}
+
+	body, _ := ioutil.ReadAll(res.Body)
+	if res.StatusCode != 200 || res.Header.Get("Content-Type") != "application/json" ||
+		!strings.Contains(string(body), `"displayName":"[REDACTED]"`) {
+		test.Errorf("\nExpected the recorded response\nbut was\n%d %v %s", res.StatusCode, res.Header, body)
+	}
+
+	// Each interaction is replayed once.
+	req, _ = http.NewRequest("GET", "https://graph.microsoft.us/v1.0/me/todo/lists?$top=100", nil)
+	if _, err := client.Do(req); err == nil || !strings.Contains(err.Error(), "No interaction recorded") {
+		test.Errorf("\nExpected no interaction to be left\nbut got\n%v", err)
+	}
+}
+
+func TestCassetteRecordScrubsFieldsAndIds(test *testing.T) {
+	const task string = `{"id":"AAMkADIyAAA=","title":"Call Jane about the <biopsy>","body":{"content":"Her number",` +
+		`"contentType":"text"},"categories":["Health","Family"],"importance":"high"}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.Method == "GET" {
+			fmt.Fprint(w, `{"value":[{"id":"AQMkAGI2AAA=","displayName":"Groceries"},{"id":"AAMkADIyAAA=","displayName":"Clinic"}]}`)
+			return
+		}
+
+		fmt.Fprint(w, task)
+	}))
+	defer server.Close()
+
+	path := filepath.Join(test.TempDir(), "tasks.json")
+	client, err := New(Options{Cassette: path, CassetteMode: CassetteRecord, CassetteKeep: []string{"Groceries"}})
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	req, _ := http.NewRequest("GET", server.URL+"/v1.0/me/todo/lists", nil)
+	if _, err := client.Do(req); err != nil {
+		test.Fatal(err)
+	}
+
+	req, _ = http.NewRequest("POST", server.URL+"/v1.0/me/todo/lists/AQMkAGI2AAA%3D/tasks", strings.NewReader(task))
+	res, err := client.Do(req)
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	if body, _ := ioutil.ReadAll(res.Body); string(body) != task {
+		test.Errorf("\nExpected the response to be read as it was:\n%s\nbut was\n%s", task, body)
+	}
+
+	data, _ := ioutil.ReadFile(path)
+	out := string(data)
+	for _, leaked := range []string{"AAMk", "AQMk", "Jane", "biopsy", "number", "Health", "Family", "Clinic"} {
+		if strings.Contains(out, leaked) {
+			test.Errorf("\nExpected %q to be scrubbed\nbut the cassette was\n%s", leaked, out)
+		}
+	}
+
+	for _, expected := range []string{
+		`"displayName": "Groceries"`,
+		`"url": "` + server.URL + `/v1.0/me/todo/lists/graph-id-1/tasks"`,
+		`"id": "graph-id-2"`,
+		`"categories": [
+              "[REDACTED]",
+              "[REDACTED]"
+            ]`,
+		`"contentType": "text"`,
+		`"importance": "high"`,
+	} {
+		if !strings.Contains(out, expected) {
+			test.Errorf("\nExpected the cassette to contain:\n%s\nbut it was\n%s", expected, out)
+		}
+	}
+}
+
+func TestCassetteReplayMissing(test *testing.T) {
+	_, err := New(Options{Cassette: filepath.Join(test.TempDir(), "none.json")})
+
+	if err == nil {
+		test.Error("\nExpected an error for a missing cassette\nbut got\nnil")
+	}
+}
@@ -1,6 +1,7 @@
 package httptest
 
 import (
+	"context"
 	"io"
 	"io/ioutil"
 	"net/http"
@@ -32,7 +33,7 @@ func (c *ClientMock) HandleFunc(path string, handler func(http.ResponseWriter, *
 	HandlerStubFn(path, handler)
 }
 
-func (c *ClientMock) ListenAndServe(addr string, handler http.Handler) error {
+func (c *ClientMock) ListenAndServe(ctx context.Context, addr string, handler http.Handler) error {
 	return ListenAndServeStubFn(addr, handler)
 }
 
@@ -1,6 +1,7 @@
 package http
 
 import (
+	"context"
 	"io"
 	"net/http"
 )
@@ -9,22 +10,59 @@ type HttpClient interface {
 	Do(req *http.Request) (*http.Response, error)
 	NewRequest(method, url string, body io.Reader) (*http.Request, error)
 	HandleFunc(path string, handler func(http.ResponseWriter, *http.Request))
-	ListenAndServe(addr string, handler http.Handler) error
+	ListenAndServe(ctx context.Context, addr string, handler http.Handler) error
 }
 
-type Client struct{}
+// A Client made with New shares one connection pool between all of its
+// requests. The zero value uses the default one of `net/http`.
+type Client struct {
+	client    *http.Client
+	userAgent string
+}
 
 func (c *Client) Do(req *http.Request) (*http.Response, error) {
-	httpClient := http.Client{}
-	return httpClient.Do(req)
+	if c.userAgent != "" && req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+
+	if c.client == nil {
+		return http.DefaultClient.Do(req)
+	}
+
+	return c.client.Do(req)
 }
 
 func (c *Client) HandleFunc(path string, handler func(http.ResponseWriter, *http.Request)) {
 	http.HandleFunc(path, handler)
 }
 
-func (c *Client) ListenAndServe(addr string, handler http.Handler) error {
-	return http.ListenAndServe(addr, handler)
+// Serves until the server fails or the context is done, in which case the
+// server is shut down (letting the requests being handled finish) and the
+// context's error is returned.
+func (c *Client) ListenAndServe(ctx context.Context, addr string, handler http.Handler) error {
+	server := &http.Server{Addr: addr, Handler: handler}
+	stopped := make(chan struct{})
+	shutDown := make(chan struct{})
+
+	go func() {
+		defer close(shutDown)
+
+		select {
+		case <-ctx.Done():
+			server.Shutdown(context.Background())
+		case <-stopped:
+		}
+	}()
+
+	err := server.ListenAndServe()
+	close(stopped)
+	<-shutDown
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	return err
 }
 
 func (c *Client) NewRequest(method, url string, body io.Reader) (*http.Request, error) {
@@ -0,0 +1,202 @@
+package http
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// What the secrets are replaced with in the trace.
+const redacted string = "[REDACTED]"
+
+// The headers identifying a request on Microsoft's side, worth attaching to
+// a support ticket.
+var requestIdHeaders = []string{"request-id", "client-request-id"}
+
+// The headers whose values are never traced.
+var secretHeaders = map[string]bool{
+	"Authorization": true,
+	"Cookie":        true,
+	"Set-Cookie":    true,
+}
+
+// The form fields (and query parameters) whose values are never traced.
+var secretFields = map[string]bool{
+	"access_token":  true,
+	"client_secret": true,
+	"code":          true,
+	"id_token":      true,
+	"refresh_token": true,
+}
+
+// The JSON fields whose values are never traced. Unlike in forms, `code` is
+// kept, as it's the code of the errors of the API.
+var secretJsonFields = regexp.MustCompile(
+	`("(?:access_token|client_secret|id_token|refresh_token)"\s*:\s*)"[^"]*"`,
+)
+
+// Writes a line (or with bodies, a few) about each request to out.
+type tracingTransport struct {
+	next   http.RoundTripper
+	out    io.Writer
+	bodies bool
+	mu     sync.Mutex
+}
+
+func (t *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	trace := &strings.Builder{}
+	fmt.Fprintf(trace, "> %s %s\n", req.Method, redactUrl(req.URL.String()))
+
+	if t.bodies {
+		writeTracedHeaders(trace, ">", req.Header)
+
+		body, err := readBody(&req.Body)
+		if err != nil {
+			return nil, err
+		}
+		writeTracedBody(trace, ">", body)
+	}
+
+	start := time.Now()
+	res, err := t.next.RoundTrip(req)
+	latency := time.Since(start).Round(time.Millisecond)
+
+	if err != nil {
+		fmt.Fprintf(trace, "< failed after %s: %s\n", latency, err)
+		t.write(trace.String())
+		return res, err
+	}
+
+	fmt.Fprintf(trace, "< %s in %s%s\n", res.Status, latency, requestIds(res.Header))
+
+	if t.bodies {
+		writeTracedHeaders(trace, "<", res.Header)
+
+		body, err := readBody(&res.Body)
+		if err != nil {
+			t.write(trace.String())
+			return nil, err
+		}
+		writeTracedBody(trace, "<", body)
+	}
+
+	t.write(trace.String())
+
+	return res, nil
+}
+
+// Writes the trace of a request at once, so the ones of parallel requests
+// don't interleave.
+func (t *tracingTransport) write(trace string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	fmt.Fprint(t.out, trace)
+}
+
+// Reads a body, replacing it with a copy that can still be read.
+func readBody(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil || *body == http.NoBody {
+		return nil, nil
+	}
+
+	data, err := ioutil.ReadAll(*body)
+	(*body).Close()
+	if err != nil {
+		return nil, err
+	}
+
+	*body = ioutil.NopCloser(bytes.NewReader(data))
+
+	return data, nil
+}
+
+// Returns the ids Microsoft gave to a request, e.g. ` request-id=...`.
+func requestIds(header http.Header) string {
+	ids := ""
+	for _, name := range requestIdHeaders {
+		if id := header.Get(name); id != "" {
+			ids += fmt.Sprintf(" %s=%s", name, id)
+		}
+	}
+
+	return ids
+}
+
+// Writes the headers (sorted by name) with the secret ones redacted.
+func writeTracedHeaders(trace io.Writer, prefix string, header http.Header) {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, value := range header[name] {
+			if secretHeaders[http.CanonicalHeaderKey(name)] {
+				value = redactHeader(value)
+			}
+
+			fmt.Fprintf(trace, "%s %s: %s\n", prefix, name, value)
+		}
+	}
+}
+
+// Writes a body (if any) with the secrets in it redacted.
+func writeTracedBody(trace io.Writer, prefix string, body []byte) {
+	if len(body) == 0 {
+		return
+	}
+
+	fmt.Fprintf(trace, "%s %s\n", prefix, redactBody(string(body)))
+}
+
+// Keeps the scheme of a header's value (e.g. `Bearer`) but not the secret.
+func redactHeader(value string) string {
+	if i := strings.Index(value, " "); i > 0 {
+		return value[:i+1] + redacted
+	}
+
+	return redacted
+}
+
+// Redacts the secret parameters in the query of a URL.
+func redactUrl(rawUrl string) string {
+	i := strings.Index(rawUrl, "?")
+	if i < 0 {
+		return rawUrl
+	}
+
+	return rawUrl[:i+1] + redactForm(rawUrl[i+1:])
+}
+
+// Redacts the secrets in a body, be it a form or JSON.
+func redactBody(body string) string {
+	trimmed := strings.TrimSpace(body)
+	if strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+		return secretJsonFields.ReplaceAllString(body, `$1"`+redacted+`"`)
+	}
+
+	return redactForm(body)
+}
+
+// Redacts the values of the secret fields of a URL encoded form, keeping the
+// order of the fields.
+func redactForm(form string) string {
+	pairs := strings.Split(form, "&")
+	for i, pair := range pairs {
+		key := strings.SplitN(pair, "=", 2)[0]
+		if secretFields[key] {
+			pairs[i] = key + "=" + redacted
+		}
+	}
+
+	return strings.Join(pairs, "&")
+}
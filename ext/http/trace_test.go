@@ -0,0 +1,89 @@
+package http
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const tokenResponse string = `{"token_type":"Bearer","access_token":"secret-access","refresh_token":"secret-refresh"}`
+
+func tracedServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("request-id", "req-1")
+		fmt.Fprint(w, tokenResponse)
+	}))
+}
+
+func TestTraceRedactsSecrets(test *testing.T) {
+	server := tracedServer()
+	defer server.Close()
+
+	trace := &bytes.Buffer{}
+	client, _ := New(Options{Trace: trace, TraceBodies: true})
+
+	req, _ := http.NewRequest(
+		"POST",
+		server.URL+"/token?code=secret-query",
+		strings.NewReader("client_id=id&client_secret=secret-client&code=secret-code&grant_type=authorization_code"),
+	)
+	req.Header.Set("Authorization", "Bearer secret-bearer")
+
+	res, err := client.Do(req)
+	if err != nil {
+		test.Fatalf("\nExpected no error\nbut got\n%s", err)
+	}
+
+	body, _ := ioutil.ReadAll(res.Body)
+	if string(body) != tokenResponse {
+		test.Errorf("\nExpected the traced body to still be read:\n%s\nbut was\n%s", tokenResponse, body)
+	}
+
+	out := trace.String()
+	if strings.Contains(out, "secret-") {
+		test.Errorf("\nExpected the secrets to be redacted\nbut the trace was\n%s", out)
+	}
+
+	for _, expected := range []string{
+		"> POST " + server.URL + "/token?code=[REDACTED]",
+		"> Authorization: Bearer [REDACTED]",
+		"> client_id=id&client_secret=[REDACTED]&code=[REDACTED]&grant_type=authorization_code",
+		"< 200 OK in ",
+		" request-id=req-1",
+		`"access_token":"[REDACTED]"`,
+	} {
+		if !strings.Contains(out, expected) {
+			test.Errorf("\nExpected the trace to contain:\n%s\nbut it was\n%s", expected, out)
+		}
+	}
+}
+
+func TestTraceWithoutBodies(test *testing.T) {
+	server := tracedServer()
+	defer server.Close()
+
+	trace := &bytes.Buffer{}
+	client, _ := New(Options{Trace: trace})
+
+	req, _ := http.NewRequest("GET", server.URL+"/lists", nil)
+	if _, err := client.Do(req); err != nil {
+		test.Fatalf("\nExpected no error\nbut got\n%s", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(trace.String()), "\n")
+	if len(lines) != 2 || lines[0] != "> GET "+server.URL+"/lists" {
+		test.Errorf("\nExpected a line for the request and one for the response\nbut the trace was\n%s", trace)
+	}
+}
+
+func TestRedactBodyKeepsErrorCodes(test *testing.T) {
+	body := `{"error":{"code":"ErrorItemNotFound","message":"Not found"}}`
+
+	if result := redactBody(body); result != body {
+		test.Errorf("\nExpected the body to be kept:\n%s\nbut was\n%s", body, result)
+	}
+}
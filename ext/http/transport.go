@@ -0,0 +1,110 @@
+package http
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// The number of idle (kept alive) connections kept per host.
+const maxIdleConnsPerHost int = 10
+
+// Configures the Client made by New.
+type Options struct {
+	// The time (zero means none) a request may take, including reading the
+	// response body.
+	Timeout time.Duration
+	// The URL of the proxy to use. When empty it's taken from the
+	// HTTPS_PROXY (and NO_PROXY) environment variables.
+	Proxy string
+	// The path to a PEM file of CA certificates to trust besides the
+	// system ones, e.g. the one of a corporate proxy.
+	CaBundle string
+	// Sent with every request, unless it sets its own.
+	UserAgent string
+	// Asks for gzip compressed responses (and decompresses them).
+	Gzip bool
+	// When set, a trace of every request is written to it, with the secrets
+	// (tokens, client secret and auth code) redacted.
+	Trace io.Writer
+	// Adds the headers and the bodies to the trace.
+	TraceBodies bool
+	// When set, the requests are recorded to (or replayed from, depending on
+	// the CassetteMode) the cassette file at this path, scrubbed with the
+	// DefaultScrubbers and the Scrubbers.
+	Cassette     string
+	CassetteMode CassetteMode
+	Scrubbers    []Scrubber
+	// The values of the ScrubbedFields recorded as they are, e.g. the made
+	// up titles of the lists a test creates.
+	CassetteKeep []string
+}
+
+// Makes a Client reusing its connections between requests.
+func New(opts Options) (*Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = maxIdleConnsPerHost
+	transport.DisableCompression = !opts.Gzip
+
+	if opts.Proxy != "" {
+		proxy, err := url.Parse(opts.Proxy)
+		if err != nil || proxy.Host == "" {
+			return nil, fmt.Errorf("Invalid proxy URL %q", opts.Proxy)
+		}
+
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	if opts.CaBundle != "" {
+		roots, err := caCertPool(opts.CaBundle)
+		if err != nil {
+			return nil, err
+		}
+
+		transport.TLSClientConfig = &tls.Config{RootCAs: roots}
+	}
+
+	var roundTripper http.RoundTripper = transport
+	if opts.Cassette != "" {
+		cassette, err := newCassetteTransport(transport, opts.Cassette, opts.CassetteMode, opts.Scrubbers, opts.CassetteKeep)
+		if err != nil {
+			return nil, err
+		}
+
+		roundTripper = cassette
+	}
+
+	if opts.Trace != nil {
+		roundTripper = &tracingTransport{next: roundTripper, out: opts.Trace, bodies: opts.TraceBodies}
+	}
+
+	return &Client{
+		client:    &http.Client{Transport: roundTripper, Timeout: opts.Timeout},
+		userAgent: opts.UserAgent,
+	}, nil
+}
+
+// Returns the system's CA certificates with the ones in the PEM file at
+// `path` added.
+func caCertPool(path string) (*x509.CertPool, error) {
+	pem, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read the CA bundle: %s", err)
+	}
+
+	roots, err := x509.SystemCertPool()
+	if err != nil {
+		roots = x509.NewCertPool()
+	}
+
+	if !roots.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("No certificates found in the CA bundle %s", path)
+	}
+
+	return roots, nil
+}
@@ -6,8 +6,16 @@ var PrintfMock = func(s string, v ...interface{}) {}
 var PrintlnMock = func(v ...interface{}) {}
 var FatalMock = func(in ...interface{}) {}
 var FatalfMock = func(format string, in ...interface{}) {}
+var DebugMock = func(msg string, fields ...interface{}) {}
+var InfoMock = func(msg string, fields ...interface{}) {}
+var WarnMock = func(msg string, fields ...interface{}) {}
+var ErrorMock = func(msg string, fields ...interface{}) {}
 
 func (l LoggerServiceMock) Printf(s string, v ...interface{})       { PrintfMock(s, v...) }
 func (l LoggerServiceMock) Println(v ...interface{})                { PrintlnMock(v...) }
 func (l LoggerServiceMock) Fatal(in ...interface{})                 { FatalMock(in) }
 func (l LoggerServiceMock) Fatalf(format string, in ...interface{}) { FatalfMock(format, in) }
+func (l LoggerServiceMock) Debug(msg string, fields ...interface{}) { DebugMock(msg, fields...) }
+func (l LoggerServiceMock) Info(msg string, fields ...interface{})  { InfoMock(msg, fields...) }
+func (l LoggerServiceMock) Warn(msg string, fields ...interface{})  { WarnMock(msg, fields...) }
+func (l LoggerServiceMock) Error(msg string, fields ...interface{}) { ErrorMock(msg, fields...) }
@@ -1,7 +1,14 @@
 package log
 
 import (
+	"encoding/json"
+	"fmt"
+	"io"
 	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 var Client LoggerService = Logger{}
@@ -11,9 +18,60 @@ type LoggerService interface {
 	Println(v ...interface{})
 	Fatal(in ...interface{})
 	Fatalf(format string, in ...interface{})
+	Debug(msg string, fields ...interface{})
+	Info(msg string, fields ...interface{})
+	Warn(msg string, fields ...interface{})
+	Error(msg string, fields ...interface{})
 }
 
-type Logger struct{}
+// The severity of a message. The zero value is LevelInfo.
+type Level int
+
+const (
+	LevelDebug Level = iota - 1
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// The formats the messages can be written in.
+const (
+	FormatText   string = "text"
+	FormatLogfmt string = "logfmt"
+	FormatJson   string = "json"
+)
+
+var Formats = map[string]bool{FormatText: true, FormatLogfmt: true, FormatJson: true}
+
+var levelNames = map[Level]string{
+	LevelDebug: "debug",
+	LevelInfo:  "info",
+	LevelWarn:  "warn",
+	LevelError: "error",
+}
+
+// Returns the level with the given name (e.g. `warn`).
+func ParseLevel(name string) (Level, error) {
+	for level, levelName := range levelNames {
+		if strings.EqualFold(name, levelName) {
+			return level, nil
+		}
+	}
+
+	return LevelInfo, fmt.Errorf("Unknown log level %q (use debug, info, warn or error)", name)
+}
+
+// The levelled methods write the messages of at least Level to Out (the
+// standard error when nil) in Format (text when empty). Their fields are
+// key-value pairs, e.g. `Warn("Retrying", "attempt", 2)`.
+type Logger struct {
+	Level  Level
+	Format string
+	Out    io.Writer
+}
+
+// Returns the time the messages are logged at.
+var now = time.Now
 
 func (l Logger) Printf(s string, v ...interface{}) {
 	log.Printf(s, v...)
@@ -30,3 +88,125 @@ func (l Logger) Fatal(in ...interface{}) {
 func (l Logger) Fatalf(format string, in ...interface{}) {
 	log.Fatalf(format, in...)
 }
+
+func (l Logger) Debug(msg string, fields ...interface{}) {
+	l.log(LevelDebug, msg, fields)
+}
+
+func (l Logger) Info(msg string, fields ...interface{}) {
+	l.log(LevelInfo, msg, fields)
+}
+
+func (l Logger) Warn(msg string, fields ...interface{}) {
+	l.log(LevelWarn, msg, fields)
+}
+
+func (l Logger) Error(msg string, fields ...interface{}) {
+	l.log(LevelError, msg, fields)
+}
+
+// Writes a message (as a single line) unless it's below the logger's level.
+func (l Logger) log(level Level, msg string, fields []interface{}) {
+	if level < l.Level {
+		return
+	}
+
+	out := l.Out
+	if out == nil {
+		out = os.Stderr
+	}
+
+	var line string
+	switch l.Format {
+	case FormatJson:
+		line = jsonLine(level, msg, fields)
+	case FormatLogfmt:
+		line = logfmtLine(level, msg, fields)
+	default:
+		line = textLine(level, msg, fields)
+	}
+
+	fmt.Fprintln(out, line)
+}
+
+// Formats a message for people, e.g. `warn: Retrying attempt=2`.
+func textLine(level Level, msg string, fields []interface{}) string {
+	line := levelNames[level] + ": " + msg
+	for i := 0; i < len(fields); i += 2 {
+		key, value := field(fields, i)
+		line += " " + key + "=" + logfmtValue(value)
+	}
+
+	return line
+}
+
+// Formats a message as logfmt, e.g. `time=... level=warn msg=Retrying`.
+func logfmtLine(level Level, msg string, fields []interface{}) string {
+	pairs := []string{
+		"time=" + now().Format(time.RFC3339),
+		"level=" + levelNames[level],
+		"msg=" + logfmtValue(msg),
+	}
+
+	for i := 0; i < len(fields); i += 2 {
+		key, value := field(fields, i)
+		pairs = append(pairs, key+"="+logfmtValue(value))
+	}
+
+	return strings.Join(pairs, " ")
+}
+
+// Formats a message as a JSON object, keeping the order of the fields.
+func jsonLine(level Level, msg string, fields []interface{}) string {
+	pairs := []string{
+		`"time":` + jsonValue(now().Format(time.RFC3339)),
+		`"level":` + jsonValue(levelNames[level]),
+		`"msg":` + jsonValue(msg),
+	}
+
+	for i := 0; i < len(fields); i += 2 {
+		key, value := field(fields, i)
+		pairs = append(pairs, jsonValue(key)+":"+jsonValue(value))
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// Returns the key and the value of the field starting at `i`.
+func field(fields []interface{}, i int) (string, interface{}) {
+	key := fmt.Sprint(fields[i])
+	if i+1 == len(fields) {
+		return key, "(missing)"
+	}
+
+	value := fields[i+1]
+	if err, ok := value.(error); ok {
+		value = err.Error()
+	}
+
+	return key, value
+}
+
+// Quotes a value when needed to be read back as logfmt.
+func logfmtValue(value interface{}) string {
+	s := fmt.Sprint(value)
+	if s == "" || strings.ContainsAny(s, " =\"\t\n") {
+		return strconv.Quote(s)
+	}
+
+	return s
+}
+
+// Encodes a value as JSON, falling back to its string form.
+func jsonValue(value interface{}) string {
+	if stringer, ok := value.(fmt.Stringer); ok {
+		value = stringer.String()
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprint(value))
+	}
+
+	return string(data)
+}
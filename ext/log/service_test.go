@@ -0,0 +1,57 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func stubNow() {
+	now = func() time.Time { return time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC) }
+}
+
+func TestLoggerFormats(test *testing.T) {
+	stubNow()
+	defer func() { now = time.Now }()
+
+	expectations := map[string]string{
+		FormatText:   "warn: Could not save path=\"/tmp/a b\" error=denied\n",
+		FormatLogfmt: "time=2021-03-04T05:06:07Z level=warn msg=\"Could not save\" path=\"/tmp/a b\" error=denied\n",
+		FormatJson:   `{"time":"2021-03-04T05:06:07Z","level":"warn","msg":"Could not save","path":"/tmp/a b","error":"denied"}` + "\n",
+	}
+
+	for format, expected := range expectations {
+		out := &bytes.Buffer{}
+		logger := Logger{Format: format, Out: out}
+
+		logger.Warn("Could not save", "path", "/tmp/a b", "error", errors.New("denied"))
+
+		if out.String() != expected {
+			test.Errorf("\nExpected the %s line to be:\n%s\nbut was\n%s", format, expected, out)
+		}
+	}
+}
+
+func TestLoggerSkipsLowerLevels(test *testing.T) {
+	out := &bytes.Buffer{}
+	logger := Logger{Level: LevelWarn, Out: out}
+
+	logger.Debug("debug")
+	logger.Info("info")
+	logger.Error("error")
+
+	if out.String() != "error: error\n" {
+		test.Errorf("\nExpected only the error to be logged\nbut got\n%s", out)
+	}
+}
+
+func TestParseLevel(test *testing.T) {
+	if level, err := ParseLevel("DEBUG"); level != LevelDebug || err != nil {
+		test.Errorf("\nExpected the debug level\nbut got\n%v, %v", level, err)
+	}
+
+	if _, err := ParseLevel("loud"); err == nil {
+		test.Errorf("\nExpected an error for an unknown level\nbut got\nnil")
+	}
+}
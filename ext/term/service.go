@@ -0,0 +1,32 @@
+package term
+
+import (
+	"golang.org/x/term"
+)
+
+type TermService interface {
+	IsTerminal(fd int) bool
+	MakeRaw(fd int) (*term.State, error)
+	Restore(fd int, state *term.State) error
+	GetSize(fd int) (width, height int, err error)
+}
+
+type Term struct{}
+
+var Client TermService = Term{}
+
+func (t Term) IsTerminal(fd int) bool {
+	return term.IsTerminal(fd)
+}
+
+func (t Term) MakeRaw(fd int) (*term.State, error) {
+	return term.MakeRaw(fd)
+}
+
+func (t Term) Restore(fd int, state *term.State) error {
+	return term.Restore(fd, state)
+}
+
+func (t Term) GetSize(fd int) (width, height int, err error) {
+	return term.GetSize(fd)
+}
@@ -0,0 +1,28 @@
+package termtest
+
+import (
+	"golang.org/x/term"
+)
+
+type TermMock struct{}
+
+var IsTerminalMockFn = func(fd int) bool { return true }
+var MakeRawMockFn = func(fd int) (*term.State, error) { return &term.State{}, nil }
+var RestoreMockFn = func(fd int, state *term.State) error { return nil }
+var GetSizeMockFn = func(fd int) (int, int, error) { return 80, 24, nil }
+
+func (t TermMock) IsTerminal(fd int) bool {
+	return IsTerminalMockFn(fd)
+}
+
+func (t TermMock) MakeRaw(fd int) (*term.State, error) {
+	return MakeRawMockFn(fd)
+}
+
+func (t TermMock) Restore(fd int, state *term.State) error {
+	return RestoreMockFn(fd, state)
+}
+
+func (t TermMock) GetSize(fd int) (int, int, error) {
+	return GetSizeMockFn(fd)
+}
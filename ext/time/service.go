@@ -1,6 +1,7 @@
 package time
 
 import (
+	"context"
 	t "time"
 )
 
@@ -8,6 +9,7 @@ type TimeService interface {
 	Add(d t.Duration) t.Time
 	Now() t.Time
 	ParseDuration(s string) (t.Duration, error)
+	Sleep(ctx context.Context, d t.Duration) error
 	Unix() int64
 	UnixNano() int64
 }
@@ -31,6 +33,18 @@ func (tm Time) ParseDuration(s string) (t.Duration, error) {
 	return t.ParseDuration(s)
 }
 
+func (tm Time) Sleep(ctx context.Context, d t.Duration) error {
+	timer := t.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
 func (tm Time) Unix() int64 {
 	return tm.time.Unix()
 }
@@ -1,12 +1,14 @@
 package timetest
 
 import (
+	"context"
 	t "time"
 )
 
 var TimeAddMockFunc = func(d t.Duration) t.Time { return t.Now() }
 var TimeNowMockFunc = func() t.Time { return t.Now() }
 var TimeParseDurationMockFunc = func(s string) (t.Duration, error) { return t.Since(t.Now()), nil }
+var TimeSleepMockFunc = func(d t.Duration) {}
 var TimeUnixMockFunc = func() int64 { return t.Now().Unix() }
 var TimeUnixNanoMockFunc = func() int64 { return t.Now().UnixNano() }
 
@@ -24,6 +26,11 @@ func (tm TimeMock) ParseDuration(s string) (t.Duration, error) {
 	return TimeParseDurationMockFunc(s)
 }
 
+func (tm TimeMock) Sleep(ctx context.Context, d t.Duration) error {
+	TimeSleepMockFunc(d)
+	return ctx.Err()
+}
+
 func (tm TimeMock) Unix() int64 {
 	return TimeUnixMockFunc()
 }
@@ -0,0 +1,132 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakegraph
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+)
+
+// The most requests a batch may hold.
+const maxBatchSize int = 20
+
+type batchRequest struct {
+	Id        string            `json:"id"`
+	Method    string            `json:"method"`
+	Url       string            `json:"url"`
+	Body      json.RawMessage   `json:"body"`
+	Headers   map[string]string `json:"headers"`
+	DependsOn []string          `json:"dependsOn"`
+}
+
+type batchResponse struct {
+	Id      string            `json:"id"`
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+}
+
+// Serves a JSON batch, running its requests in order. A request depending on
+// one that failed isn't run, but answered with 424 Failed Dependency.
+func (s *Server) serveBatch(w http.ResponseWriter, r *http.Request, base string) {
+	payload := struct {
+		Requests []batchRequest `json:"requests"`
+	}{}
+
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "BadRequest", "Invalid batch payload")
+		return
+	}
+
+	if len(payload.Requests) == 0 || len(payload.Requests) > maxBatchSize {
+		writeError(
+			w,
+			http.StatusBadRequest,
+			"BadRequest",
+			"A batch must hold 1 to "+strconv.Itoa(maxBatchSize)+" requests.",
+		)
+		return
+	}
+
+	statuses := map[string]int{}
+	responses := []batchResponse{}
+
+	for _, req := range payload.Requests {
+		response := s.serveBatchRequest(req, statuses, base)
+		statuses[req.Id] = response.Status
+		responses = append(responses, response)
+	}
+
+	writeJson(w, http.StatusOK, map[string]interface{}{"responses": responses})
+}
+
+// Serves a single request of a batch, unless one it depends on failed.
+func (s *Server) serveBatchRequest(req batchRequest, statuses map[string]int, base string) batchResponse {
+	for _, id := range req.DependsOn {
+		if status, ok := statuses[id]; !ok || status >= 300 {
+			return batchResponse{
+				Id:     req.Id,
+				Status: http.StatusFailedDependency,
+				Body:   errorBody("FailedDependency", "A request this one depends on failed."),
+			}
+		}
+	}
+
+	var body []byte
+	if len(req.Body) > 0 {
+		body = req.Body
+	}
+
+	sub, err := http.NewRequest(req.Method, base+ApiPath+req.Url, bytes.NewReader(body))
+	if err != nil {
+		return batchResponse{
+			Id:     req.Id,
+			Status: http.StatusBadRequest,
+			Body:   errorBody("BadRequest", err.Error()),
+		}
+	}
+
+	for key, value := range req.Headers {
+		sub.Header.Set(key, value)
+	}
+
+	recorder := httptest.NewRecorder()
+	s.serveApi(recorder, sub, base)
+
+	response := batchResponse{Id: req.Id, Status: recorder.Code, Headers: map[string]string{}}
+	for key := range recorder.Header() {
+		response.Headers[key] = recorder.Header().Get(key)
+	}
+
+	if recorder.Body.Len() > 0 {
+		response.Body = bytes.TrimSpace(recorder.Body.Bytes())
+	}
+
+	return response
+}
+
+// Returns the body of an error the way the API does.
+func errorBody(code, message string) json.RawMessage {
+	body, _ := json.Marshal(map[string]interface{}{
+		"error": map[string]string{"code": code, "message": message},
+	})
+
+	return body
+}
@@ -0,0 +1,231 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The `fakegraph` package is an in-process stand-in for the parts of
+// Microsoft Graph the app talks to: the To Do lists and tasks (with their
+// checklist items and linked resources), paging, the common OData query
+// options, delta queries, JSON batching and the login endpoints. Faults
+// (e.g. throttling) can be injected, so the tests (and `mstd dev fake-graph`)
+// can check how the app copes with them without an account.
+package fakegraph
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The path the API is served under, e.g. `/v1.0/me/todo/lists`.
+const ApiPath string = "/v1.0"
+
+// The path the login endpoints are served under (any tenant works in place
+// of `common`).
+const LoginPath string = "/common/oauth2/v2.0"
+
+// The most items a page has, unless the request asks for fewer.
+const DefaultPageSize int = 100
+
+// The layout of the dates the items are stamped with.
+const dateTimeLayout string = "2006-01-02T15:04:05.0000000Z"
+
+// A failure to answer the matching requests with, instead of serving them.
+type Fault struct {
+	// The method of the requests to fail, any when empty.
+	Method string
+	// The prefix of the path of the requests to fail (e.g.
+	// `/v1.0/me/todo/lists`), any when empty.
+	Path string
+	// The status code and the error code of the response. The error code
+	// defaults to the status text, e.g. `TooManyRequests`.
+	Status int
+	Code   string
+	// Sent as the `Retry-After` header, when set.
+	RetryAfter time.Duration
+	// The number of requests to fail (once when zero).
+	Times int
+}
+
+// An in-memory Microsoft Graph (To Do) server. The zero value isn't usable,
+// use New.
+type Server struct {
+	// The most items a page of a collection has.
+	PageSize int
+
+	mu             sync.Mutex
+	lists          []*list
+	removedLists   []tombstone
+	version        int
+	expiredVersion int
+	lastId         int
+	faults         []*Fault
+	requests       int
+}
+
+// Creates a Server holding only the default "Tasks" list.
+func New() *Server {
+	s := &Server{PageSize: DefaultPageSize}
+	s.addList(item{
+		"displayName":       "Tasks",
+		"wellknownListName": "defaultList",
+	})
+
+	return s
+}
+
+// Makes the matching requests fail (see Fault).
+func (s *Server) Inject(fault Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if fault.Times <= 0 {
+		fault.Times = 1
+	}
+
+	s.faults = append(s.faults, &fault)
+}
+
+// Answers the next `times` requests with 429 Too Many Requests, asking to
+// retry after `retryAfter`.
+func (s *Server) Throttle(times int, retryAfter time.Duration) {
+	s.Inject(Fault{Status: http.StatusTooManyRequests, RetryAfter: retryAfter, Times: times})
+}
+
+// Returns the number of requests served (the ones in a batch included).
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimSuffix(r.URL.Path, "/")
+
+	switch {
+	case strings.Contains(path, "/oauth2/v2.0/"):
+		if !s.fail(w, r.Method, path) {
+			s.serveLogin(w, r, path)
+		}
+	case strings.HasPrefix(path, ApiPath+"/"):
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+			writeError(w, http.StatusUnauthorized, "InvalidAuthenticationToken", "Access token is empty.")
+			return
+		}
+
+		s.serveApi(w, r, "http://"+r.Host)
+	default:
+		writeError(w, http.StatusNotFound, "BadRequest", "Unsupported path "+r.URL.Path)
+	}
+}
+
+// Serves a request to the API (the ones in a batch included), with `base`
+// being the scheme and host the links are made with.
+func (s *Server) serveApi(w http.ResponseWriter, r *http.Request, base string) {
+	path := strings.TrimSuffix(r.URL.Path, "/")
+	if s.fail(w, r.Method, path) {
+		return
+	}
+
+	if path == ApiPath+"/$batch" && r.Method == "POST" {
+		s.serveBatch(w, r, base)
+		return
+	}
+
+	rest := strings.TrimPrefix(path, ApiPath+"/me/todo/lists")
+	if rest == path {
+		writeError(w, http.StatusNotFound, "BadRequest", "Unsupported path "+r.URL.Path)
+		return
+	}
+
+	segments := []string{}
+	if rest != "" {
+		segments = strings.Split(strings.TrimPrefix(rest, "/"), "/")
+	}
+
+	s.serveTodo(w, r, base+path, segments)
+}
+
+// Answers the request with the first fault matching it, if any.
+func (s *Server) fail(w http.ResponseWriter, method, path string) bool {
+	s.mu.Lock()
+	s.requests++
+
+	var fault *Fault
+	for i, f := range s.faults {
+		if (f.Method == "" || f.Method == method) && strings.HasPrefix(path, f.Path) {
+			fault = f
+			if f.Times--; f.Times == 0 {
+				s.faults = append(s.faults[:i], s.faults[i+1:]...)
+			}
+			break
+		}
+	}
+	s.mu.Unlock()
+
+	if fault == nil {
+		return false
+	}
+
+	code := fault.Code
+	if code == "" {
+		code = strings.ReplaceAll(http.StatusText(fault.Status), " ", "")
+	}
+
+	if fault.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(fault.RetryAfter.Seconds())))
+	}
+
+	writeError(w, fault.Status, code, "Injected fault")
+
+	return true
+}
+
+// Gives out the next id, e.g. `task-3`.
+func (s *Server) newId(kind string) string {
+	s.lastId++
+
+	return fmt.Sprintf("%s-%d", kind, s.lastId)
+}
+
+// Bumps the version the changes are stamped with (for the delta queries).
+func (s *Server) bump() int {
+	s.version++
+
+	return s.version
+}
+
+// Writes a JSON response.
+func writeJson(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// Writes an error the way the API does.
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJson(w, status, map[string]interface{}{
+		"error": map[string]string{"code": code, "message": message},
+	})
+}
+
+// Returns the current time in the format of the API.
+func now() string {
+	return time.Now().UTC().Format(dateTimeLayout)
+}
@@ -0,0 +1,280 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakegraph
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func startServer(test *testing.T) (*Server, *httptest.Server) {
+	server := New()
+	ts := httptest.NewServer(server)
+	test.Cleanup(ts.Close)
+
+	return server, ts
+}
+
+func request(test *testing.T, method, url, body string) (int, http.Header, map[string]interface{}) {
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	req.Header.Set("Authorization", "Bearer token")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	defer res.Body.Close()
+	data, _ := io.ReadAll(res.Body)
+
+	decoded := map[string]interface{}{}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			test.Fatalf("\nExpected a JSON body\nbut was\n%s", data)
+		}
+	}
+
+	return res.StatusCode, res.Header, decoded
+}
+
+func values(body map[string]interface{}) []map[string]interface{} {
+	items := []map[string]interface{}{}
+	for _, v := range body["value"].([]interface{}) {
+		items = append(items, v.(map[string]interface{}))
+	}
+
+	return items
+}
+
+func TestServeRequiresToken(test *testing.T) {
+	_, ts := startServer(test)
+
+	res, err := http.Get(ts.URL + ApiPath + "/me/todo/lists")
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	res.Body.Close()
+	if res.StatusCode != http.StatusUnauthorized {
+		test.Errorf("\nExpected status 401\nbut was\n%d", res.StatusCode)
+	}
+}
+
+func TestServeTasksPaging(test *testing.T) {
+	server, ts := startServer(test)
+	server.PageSize = 2
+	listId := server.AddList("Groceries")
+	for _, title := range []string{"Milk", "Eggs", "Bread"} {
+		server.AddTask(listId, title)
+	}
+
+	status, _, page := request(test, "GET", ts.URL+ApiPath+"/me/todo/lists/"+listId+"/tasks", "")
+	if status != http.StatusOK || len(values(page)) != 2 {
+		test.Fatalf("\nExpected a first page of 2 tasks\nbut was\n%d %v", status, page)
+	}
+
+	next, _ := page["@odata.nextLink"].(string)
+	if !strings.HasPrefix(next, ts.URL) {
+		test.Fatalf("\nExpected an absolute next link\nbut was\n%s", next)
+	}
+
+	_, _, page = request(test, "GET", next, "")
+	tasks := values(page)
+	if len(tasks) != 1 || tasks[0]["title"] != "Bread" || page["@odata.nextLink"] != nil {
+		test.Errorf("\nExpected a last page with Bread\nbut was\n%v", page)
+	}
+}
+
+func TestServeTasksQuery(test *testing.T) {
+	server, ts := startServer(test)
+	server.PageSize = 1
+	listId := server.AddList("Groceries")
+	for _, title := range []string{"Milk", "Eggs", "Bread", "Butter"} {
+		server.AddTask(listId, title)
+	}
+
+	query := url.Values{
+		"$filter":  {"startswith(title,'B') or title eq 'Milk'"},
+		"$orderby": {"title desc"},
+		"$select":  {"title"},
+		"$count":   {"true"},
+	}
+
+	_, _, page := request(test, "GET", ts.URL+ApiPath+"/me/todo/lists/"+listId+"/tasks?"+query.Encode(), "")
+	tasks := values(page)
+	if len(tasks) != 1 || tasks[0]["title"] != "Milk" || tasks[0]["status"] != nil || page["@odata.count"] != 3.0 {
+		test.Fatalf("\nExpected a first page with Milk (only its title) out of 3\nbut was\n%v", page)
+	}
+
+	next, _ := page["@odata.nextLink"].(string)
+	_, _, page = request(test, "GET", next, "")
+	if tasks := values(page); len(tasks) != 1 || tasks[0]["title"] != "Butter" {
+		test.Errorf("\nExpected the next page to keep the query\nbut was\n%v", page)
+	}
+
+	status, _, _ := request(test, "GET", ts.URL+ApiPath+"/me/todo/lists/"+listId+"/tasks?$filter=title%20has%20'x'", "")
+	if status != http.StatusBadRequest {
+		test.Errorf("\nExpected an unsupported filter to be a bad request\nbut was\n%d", status)
+	}
+}
+
+func TestServeTaskLifecycle(test *testing.T) {
+	server, ts := startServer(test)
+	listId := server.AddList("Work")
+	tasksUrl := ts.URL + ApiPath + "/me/todo/lists/" + listId + "/tasks"
+
+	status, _, task := request(test, "POST", tasksUrl, `{"title":"Report"}`)
+	if status != http.StatusCreated || task["status"] != "notStarted" {
+		test.Fatalf("\nExpected a created task\nbut was\n%d %v", status, task)
+	}
+
+	taskUrl := tasksUrl + "/" + task["id"].(string)
+	_, _, task = request(test, "PATCH", taskUrl, `{"status":"completed"}`)
+	if task["status"] != "completed" || task["title"] != "Report" {
+		test.Errorf("\nExpected the status to be updated\nbut was\n%v", task)
+	}
+
+	status, _, item := request(test, "POST", taskUrl+"/checklistItems", `{"displayName":"Draft"}`)
+	if status != http.StatusCreated || item["displayName"] != "Draft" {
+		test.Errorf("\nExpected a created checklist item\nbut was\n%d %v", status, item)
+	}
+
+	if status, _, _ = request(test, "DELETE", taskUrl, ""); status != http.StatusNoContent {
+		test.Errorf("\nExpected status 204\nbut was\n%d", status)
+	}
+
+	if status, _, _ = request(test, "GET", taskUrl, ""); status != http.StatusNotFound {
+		test.Errorf("\nExpected status 404\nbut was\n%d", status)
+	}
+}
+
+func TestServeTasksDelta(test *testing.T) {
+	server, ts := startServer(test)
+	listId := server.AddList("Work")
+	keep := server.AddTask(listId, "Keep")
+	drop := server.AddTask(listId, "Drop")
+	deltaUrl := ts.URL + ApiPath + "/me/todo/lists/" + listId + "/tasks/delta"
+
+	_, _, page := request(test, "GET", deltaUrl, "")
+	if len(values(page)) != 2 {
+		test.Fatalf("\nExpected all the tasks\nbut was\n%v", page)
+	}
+
+	tasksUrl := ts.URL + ApiPath + "/me/todo/lists/" + listId + "/tasks/"
+	request(test, "PATCH", tasksUrl+keep, `{"title":"Kept"}`)
+	request(test, "DELETE", tasksUrl+drop, "")
+
+	_, _, page = request(test, "GET", page["@odata.deltaLink"].(string), "")
+	changes := values(page)
+	if len(changes) != 2 || changes[0]["title"] != "Kept" || changes[1]["@removed"] == nil {
+		test.Fatalf("\nExpected the update and the removal\nbut was\n%v", changes)
+	}
+
+	server.ExpireDeltaLinks()
+	status, _, _ := request(test, "GET", page["@odata.deltaLink"].(string), "")
+	if status != http.StatusGone {
+		test.Errorf("\nExpected status 410\nbut was\n%d", status)
+	}
+}
+
+func TestServeBatch(test *testing.T) {
+	server, ts := startServer(test)
+	listId := server.AddList("Work")
+	server.Inject(Fault{Method: "DELETE", Status: http.StatusNotFound})
+
+	body := `{"requests":[
+		{"id":"1","method":"POST","url":"/me/todo/lists/` + listId + `/tasks","body":{"title":"One"}},
+		{"id":"2","method":"DELETE","url":"/me/todo/lists/` + listId + `/tasks/missing"},
+		{"id":"3","method":"GET","url":"/me/todo/lists/` + listId + `/tasks","dependsOn":["2"]}
+	]}`
+
+	status, _, result := request(test, "POST", ts.URL+ApiPath+"/$batch", body)
+	if status != http.StatusOK {
+		test.Fatalf("\nExpected status 200\nbut was\n%d %v", status, result)
+	}
+
+	statuses := []float64{}
+	for _, r := range result["responses"].([]interface{}) {
+		statuses = append(statuses, r.(map[string]interface{})["status"].(float64))
+	}
+
+	if len(statuses) != 3 || statuses[0] != 201 || statuses[1] != 404 || statuses[2] != 424 {
+		test.Errorf("\nExpected statuses 201, 404 and 424\nbut was\n%v", statuses)
+	}
+}
+
+func TestServeThrottle(test *testing.T) {
+	server, ts := startServer(test)
+	server.Throttle(1, 3*time.Second)
+
+	status, header, body := request(test, "GET", ts.URL+ApiPath+"/me/todo/lists", "")
+	if status != http.StatusTooManyRequests || header.Get("Retry-After") != "3" {
+		test.Errorf("\nExpected a throttled response\nbut was\n%d %v", status, header)
+	}
+
+	if code := body["error"].(map[string]interface{})["code"]; code != "TooManyRequests" {
+		test.Errorf("\nExpected code TooManyRequests\nbut was\n%s", code)
+	}
+
+	if status, _, _ = request(test, "GET", ts.URL+ApiPath+"/me/todo/lists", ""); status != http.StatusOK {
+		test.Errorf("\nExpected the next request to succeed\nbut was\n%d", status)
+	}
+}
+
+func TestServeLogin(test *testing.T) {
+	_, ts := startServer(test)
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+
+	res, err := client.Get(ts.URL + LoginPath + "/authorize?redirect_uri=" +
+		url.QueryEscape("http://localhost:8080/callback"))
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	res.Body.Close()
+	if location := res.Header.Get("Location"); location != "http://localhost:8080/callback?code="+AuthCode {
+		test.Fatalf("\nExpected a redirect with the code\nbut was\n%s", location)
+	}
+
+	form := url.Values{"grant_type": {"authorization_code"}, "code": {AuthCode}}
+	status, _, token := request(test, "POST", ts.URL+LoginPath+"/token?"+form.Encode(), "")
+	if status != http.StatusBadRequest {
+		test.Errorf("\nExpected the code to be read from the body only\nbut was\n%d %v", status, token)
+	}
+
+	res, err = http.PostForm(ts.URL+LoginPath+"/token", form)
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	defer res.Body.Close()
+	json.NewDecoder(res.Body).Decode(&token)
+	if res.StatusCode != http.StatusOK || token["access_token"] == nil || token["refresh_token"] == nil {
+		test.Errorf("\nExpected the tokens\nbut was\n%d %v", res.StatusCode, token)
+	}
+}
@@ -0,0 +1,106 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakegraph
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// The code the fake authorization endpoint redirects back with.
+const AuthCode string = "fake-code"
+
+// How long the access tokens given out are valid (in seconds).
+const tokenLifetime int = 3600
+
+// Serves the login endpoints: `authorize`, which redirects right back with
+// the AuthCode (no sign-in needed), and `token`, which hands out tokens for
+// the AuthCode or a refresh token it gave out.
+func (s *Server) serveLogin(w http.ResponseWriter, r *http.Request, path string) {
+	switch {
+	case strings.HasSuffix(path, "/authorize") && r.Method == "GET":
+		s.serveAuthorize(w, r)
+	case strings.HasSuffix(path, "/token") && r.Method == "POST":
+		s.serveToken(w, r)
+	default:
+		writeError(w, http.StatusNotFound, "BadRequest", "Unsupported path "+r.URL.Path)
+	}
+}
+
+func (s *Server) serveAuthorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	redirect, err := url.Parse(query.Get("redirect_uri"))
+	if err != nil || redirect.Host == "" {
+		writeTokenError(w, "invalid_request", "The redirect_uri is missing or invalid.")
+		return
+	}
+
+	values := redirect.Query()
+	values.Set("code", AuthCode)
+	if state := query.Get("state"); state != "" {
+		values.Set("state", state)
+	}
+
+	redirect.RawQuery = values.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (s *Server) serveToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeTokenError(w, "invalid_request", "The body could not be parsed.")
+		return
+	}
+
+	switch r.PostForm.Get("grant_type") {
+	case "authorization_code":
+		if r.PostForm.Get("code") != AuthCode {
+			writeTokenError(w, "invalid_grant", "The authorization code is invalid.")
+			return
+		}
+	case "refresh_token":
+		if !strings.HasPrefix(r.PostForm.Get("refresh_token"), "fake-refresh-token-") {
+			writeTokenError(w, "invalid_grant", "The refresh token is invalid or expired.")
+			return
+		}
+	default:
+		writeTokenError(w, "unsupported_grant_type", "The grant_type is not supported.")
+		return
+	}
+
+	s.mu.Lock()
+	access := s.newId("fake-access-token")
+	refresh := s.newId("fake-refresh-token")
+	s.mu.Unlock()
+
+	writeJson(w, http.StatusOK, map[string]interface{}{
+		"token_type":     "Bearer",
+		"scope":          r.PostForm.Get("scope"),
+		"expires_in":     tokenLifetime,
+		"ext_expires_in": tokenLifetime,
+		"access_token":   access,
+		"refresh_token":  refresh,
+	})
+}
+
+// Writes an error the way the login API does.
+func writeTokenError(w http.ResponseWriter, code, description string) {
+	writeJson(w, http.StatusBadRequest, map[string]string{
+		"error":             code,
+		"error_description": description,
+	})
+}
@@ -0,0 +1,405 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakegraph
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// A compiled `$filter`, telling whether an item matches it.
+type predicate func(item) bool
+
+// The comparison operators a `$filter` can use.
+var comparisons map[string]func(int) bool = map[string]func(int) bool{
+	"eq": func(c int) bool { return c == 0 },
+	"ne": func(c int) bool { return c != 0 },
+	"lt": func(c int) bool { return c < 0 },
+	"le": func(c int) bool { return c <= 0 },
+	"gt": func(c int) bool { return c > 0 },
+	"ge": func(c int) bool { return c >= 0 },
+}
+
+// Applies the `$filter`, `$orderby` and `$select` of a request to the items
+// of a collection. Only the common parts of OData are understood: the
+// comparisons, `and`, `or`, `not`, `startswith`, `contains` and `any` with an
+// `eq`. Anything else is an error (to answer with a 400).
+func applyQuery(query url.Values, items []item) ([]item, error) {
+	if expr := query.Get("$filter"); expr != "" {
+		match, err := parseFilter(expr)
+		if err != nil {
+			return nil, err
+		}
+
+		matching := []item{}
+		for _, i := range items {
+			if match(i) {
+				matching = append(matching, i)
+			}
+		}
+
+		items = matching
+	}
+
+	if orderBy := query.Get("$orderby"); orderBy != "" {
+		items = append([]item{}, items...)
+		fields := strings.Split(orderBy, ",")
+
+		sort.SliceStable(items, func(a, b int) bool {
+			for _, f := range fields {
+				parts := strings.Fields(f)
+				c := compareValues(lookup(items[a], parts[0]), lookup(items[b], parts[0]))
+				if len(parts) > 1 && parts[1] == "desc" {
+					c = -c
+				}
+
+				if c != 0 {
+					return c < 0
+				}
+			}
+
+			return false
+		})
+	}
+
+	if fields := query.Get("$select"); fields != "" {
+		selected := []item{}
+		for _, i := range items {
+			s := item{"id": i["id"]}
+			for _, f := range strings.Split(fields, ",") {
+				if v, ok := i[strings.TrimSpace(f)]; ok {
+					s[strings.TrimSpace(f)] = v
+				}
+			}
+
+			selected = append(selected, s)
+		}
+
+		items = selected
+	}
+
+	return items, nil
+}
+
+// Returns the value at a `/` separated path of an item, `nil` when missing.
+func lookup(i item, path string) interface{} {
+	var value interface{} = map[string]interface{}(i)
+	for _, key := range strings.Split(path, "/") {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+
+		value = m[key]
+	}
+
+	return value
+}
+
+// Compares two values of an item (or literals), the missing ones first.
+func compareValues(a, b interface{}) int {
+	if a == nil || b == nil {
+		switch {
+		case a == nil && b == nil:
+			return 0
+		case a == nil:
+			return -1
+		default:
+			return 1
+		}
+	}
+
+	if x, ok := a.(float64); ok {
+		if y, ok := b.(float64); ok {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+	}
+
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+// Parses a `$filter` expression into a predicate.
+func parseFilter(expr string) (predicate, error) {
+	p := &filterParser{tokens: tokenizeFilter(expr)}
+
+	match, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("Invalid filter clause: unexpected %q", p.tokens[p.pos])
+	}
+
+	return match, nil
+}
+
+type filterParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *filterParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+
+	return ""
+}
+
+func (p *filterParser) next() string {
+	t := p.peek()
+	p.pos++
+
+	return t
+}
+
+func (p *filterParser) expect(token string) error {
+	if t := p.next(); t != token {
+		return fmt.Errorf("Invalid filter clause: expected %q but got %q", token, t)
+	}
+
+	return nil
+}
+
+func (p *filterParser) or() (predicate, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek() == "or" {
+		p.next()
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+
+		l := left
+		left = func(i item) bool { return l(i) || right(i) }
+	}
+
+	return left, nil
+}
+
+func (p *filterParser) and() (predicate, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek() == "and" {
+		p.next()
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+
+		l := left
+		left = func(i item) bool { return l(i) && right(i) }
+	}
+
+	return left, nil
+}
+
+func (p *filterParser) unary() (predicate, error) {
+	switch t := p.peek(); {
+	case t == "not":
+		p.next()
+		inner, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+
+		return func(i item) bool { return !inner(i) }, nil
+	case t == "(":
+		p.next()
+		inner, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+
+		return inner, p.expect(")")
+	case t == "startswith" || t == "contains":
+		return p.function()
+	case strings.HasSuffix(t, "/any"):
+		return p.any()
+	default:
+		return p.comparison()
+	}
+}
+
+// Parses e.g. `startswith(title,'Buy')`.
+func (p *filterParser) function() (predicate, error) {
+	name := p.next()
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+
+	path := p.next()
+	if err := p.expect(","); err != nil {
+		return nil, err
+	}
+
+	value, err := parseLiteral(p.next())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+
+	test := strings.Contains
+	if name == "startswith" {
+		test = strings.HasPrefix
+	}
+
+	return func(i item) bool {
+		s, ok := lookup(i, path).(string)
+		return ok && test(strings.ToLower(s), strings.ToLower(fmt.Sprint(value)))
+	}, nil
+}
+
+// Parses e.g. `categories/any(v:v eq 'Work')`.
+func (p *filterParser) any() (predicate, error) {
+	path := strings.TrimSuffix(p.next(), "/any")
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+
+	variable := strings.SplitN(p.next(), ":", 2)
+	if len(variable) != 2 || p.next() != variable[0] || p.next() != "eq" {
+		return nil, fmt.Errorf("Invalid filter clause: only `any(v:v eq ...)` is supported")
+	}
+
+	value, err := parseLiteral(p.next())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+
+	return func(i item) bool {
+		values, _ := lookup(i, path).([]interface{})
+		for _, v := range values {
+			if compareValues(v, value) == 0 {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+// Parses e.g. `status ne 'completed'`.
+func (p *filterParser) comparison() (predicate, error) {
+	path := p.next()
+	operator := p.next()
+
+	test, ok := comparisons[operator]
+	if path == "" || !ok {
+		return nil, fmt.Errorf("Invalid filter clause: unsupported operator %q", operator)
+	}
+
+	value, err := parseLiteral(p.next())
+	if err != nil {
+		return nil, err
+	}
+
+	return func(i item) bool {
+		return test(compareValues(lookup(i, path), value))
+	}, nil
+}
+
+// Parses a literal, e.g. `'text'`, `null`, `true`, `3` or (unquoted) dates,
+// which are compared as the text the API stores them in.
+func parseLiteral(token string) (interface{}, error) {
+	switch {
+	case token == "":
+		return nil, fmt.Errorf("Invalid filter clause: missing value")
+	case token == "null":
+		return nil, nil
+	case token == "true" || token == "false":
+		return token == "true", nil
+	case strings.HasPrefix(token, "'"):
+		return strings.ReplaceAll(strings.Trim(token, "'"), "''", "'"), nil
+	}
+
+	if n, err := strconv.ParseFloat(token, 64); err == nil {
+		return n, nil
+	}
+
+	return strings.TrimSuffix(token, "Z"), nil
+}
+
+// Splits a `$filter` expression into its words, quoted strings, parentheses
+// and commas.
+func tokenizeFilter(expr string) []string {
+	tokens := []string{}
+	runes := []rune(expr)
+
+	for i := 0; i < len(runes); {
+		switch r := runes[i]; {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')' || r == ',':
+			tokens = append(tokens, string(r))
+			i++
+		case r == '\'':
+			j := i + 1
+			for j < len(runes) {
+				if runes[j] == '\'' {
+					if j+1 < len(runes) && runes[j+1] == '\'' {
+						j += 2
+						continue
+					}
+					break
+				}
+				j++
+			}
+
+			if j < len(runes) {
+				j++
+			}
+
+			tokens = append(tokens, string(runes[i:j]))
+			i = j
+		default:
+			j := i
+			for j < len(runes) && !unicode.IsSpace(runes[j]) && !strings.ContainsRune("(),'", runes[j]) {
+				j++
+			}
+
+			tokens = append(tokens, string(runes[i:j]))
+			i = j
+		}
+	}
+
+	return tokens
+}
@@ -0,0 +1,450 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakegraph
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// An item of a collection (a list, task, checklist item or linked
+// resource) with its attributes kept as JSON values.
+type item map[string]interface{}
+
+type list struct {
+	attrs        item
+	version      int
+	tasks        []*task
+	removedTasks []tombstone
+}
+
+type task struct {
+	attrs           item
+	version         int
+	checklistItems  []item
+	linkedResources []item
+}
+
+// What's left of a removed item, for the delta queries.
+type tombstone struct {
+	id      string
+	version int
+}
+
+// Adds a list with the given name, returning its id.
+func (s *Server) AddList(name string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.addList(item{"displayName": name}).attrs["id"].(string)
+}
+
+// Adds a task with the given title to a list, returning its id (empty when
+// there's no such list).
+func (s *Server) AddTask(listId, title string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l := s.findList(listId)
+	if l == nil {
+		return ""
+	}
+
+	return s.addTask(l, item{"title": title}).attrs["id"].(string)
+}
+
+// Makes the delta links given out so far expire, so following them results
+// in 410 Gone (and the client has to start over).
+func (s *Server) ExpireDeltaLinks() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.expiredVersion = s.version
+}
+
+func (s *Server) addList(attrs item) *list {
+	version := s.bump()
+	attrs["id"] = s.newId("list")
+	attrs["isOwner"] = true
+	attrs["isShared"] = false
+	if _, ok := attrs["wellknownListName"]; !ok {
+		attrs["wellknownListName"] = "none"
+	}
+
+	l := &list{attrs: attrs, version: version}
+	s.lists = append(s.lists, l)
+
+	return l
+}
+
+func (s *Server) addTask(l *list, attrs item) *task {
+	version := s.bump()
+	attrs["id"] = s.newId("task")
+	attrs["createdDateTime"] = now()
+	attrs["lastModifiedDateTime"] = now()
+	setDefault(attrs, "status", "notStarted")
+	setDefault(attrs, "importance", "normal")
+
+	t := &task{attrs: attrs, version: version}
+	l.tasks = append(l.tasks, t)
+
+	return t
+}
+
+func (s *Server) findList(id string) *list {
+	for _, l := range s.lists {
+		if l.attrs["id"] == id {
+			return l
+		}
+	}
+
+	return nil
+}
+
+func (l *list) findTask(id string) *task {
+	for _, t := range l.tasks {
+		if t.attrs["id"] == id {
+			return t
+		}
+	}
+
+	return nil
+}
+
+// Serves the requests under `/me/todo/lists`, `segments` being the parts of
+// the path after it and `self` the url of the request (without its query).
+func (s *Server) serveTodo(w http.ResponseWriter, r *http.Request, self string, segments []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var body item
+	if r.Method == "POST" || r.Method == "PATCH" {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body == nil {
+			writeError(w, http.StatusBadRequest, "BadRequest", "Invalid JSON body")
+			return
+		}
+	}
+
+	if len(segments) == 0 {
+		s.serveLists(w, r, self, body)
+		return
+	}
+
+	if segments[0] == "delta" && len(segments) == 1 {
+		s.serveListsDelta(w, r, self)
+		return
+	}
+
+	l := s.findList(segments[0])
+	if l == nil {
+		writeError(w, http.StatusNotFound, "ErrorItemNotFound", "The list was not found.")
+		return
+	}
+
+	switch {
+	case len(segments) == 1:
+		s.serveList(w, r, l, body)
+	case segments[1] != "tasks":
+		writeError(w, http.StatusNotFound, "BadRequest", "Unsupported path "+r.URL.Path)
+	case len(segments) == 2:
+		s.serveTasks(w, r, self, l, body)
+	case len(segments) == 3 && segments[2] == "delta":
+		s.serveTasksDelta(w, r, self, l)
+	default:
+		t := l.findTask(segments[2])
+		if t == nil {
+			writeError(w, http.StatusNotFound, "ErrorItemNotFound", "The task was not found.")
+			return
+		}
+
+		s.serveTask(w, r, self, l, t, segments[3:], body)
+	}
+}
+
+func (s *Server) serveLists(w http.ResponseWriter, r *http.Request, self string, body item) {
+	switch r.Method {
+	case "GET":
+		items := []item{}
+		for _, l := range s.lists {
+			items = append(items, l.attrs)
+		}
+
+		s.writePage(w, r, self, items)
+	case "POST":
+		if name, _ := body["displayName"].(string); name == "" {
+			writeError(w, http.StatusBadRequest, "invalidRequest", "The displayName is required.")
+			return
+		}
+
+		writeJson(w, http.StatusCreated, s.addList(body).attrs)
+	default:
+		writeMethodNotAllowed(w)
+	}
+}
+
+func (s *Server) serveList(w http.ResponseWriter, r *http.Request, l *list, body item) {
+	switch r.Method {
+	case "GET":
+		writeJson(w, http.StatusOK, l.attrs)
+	case "PATCH":
+		merge(l.attrs, body)
+		l.version = s.bump()
+		writeJson(w, http.StatusOK, l.attrs)
+	case "DELETE":
+		for i, other := range s.lists {
+			if other == l {
+				s.lists = append(s.lists[:i], s.lists[i+1:]...)
+				break
+			}
+		}
+
+		s.removedLists = append(s.removedLists, tombstone{id: l.attrs["id"].(string), version: s.bump()})
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeMethodNotAllowed(w)
+	}
+}
+
+func (s *Server) serveTasks(w http.ResponseWriter, r *http.Request, self string, l *list, body item) {
+	switch r.Method {
+	case "GET":
+		items := []item{}
+		for _, t := range l.tasks {
+			items = append(items, t.attrs)
+		}
+
+		s.writePage(w, r, self, items)
+	case "POST":
+		writeJson(w, http.StatusCreated, s.addTask(l, body).attrs)
+	default:
+		writeMethodNotAllowed(w)
+	}
+}
+
+// Serves a task, or (by the rest of the `segments`) its checklist items or
+// linked resources.
+func (s *Server) serveTask(
+	w http.ResponseWriter,
+	r *http.Request,
+	self string,
+	l *list,
+	t *task,
+	segments []string,
+	body item,
+) {
+	if len(segments) == 1 && segments[0] == "checklistItems" {
+		t.checklistItems = s.serveSubItems(w, r, self, t.checklistItems, "item", body)
+		return
+	}
+
+	if len(segments) == 1 && segments[0] == "linkedResources" {
+		t.linkedResources = s.serveSubItems(w, r, self, t.linkedResources, "resource", body)
+		return
+	}
+
+	if len(segments) != 0 {
+		writeError(w, http.StatusNotFound, "BadRequest", "Unsupported path "+r.URL.Path)
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		writeJson(w, http.StatusOK, t.attrs)
+	case "PATCH":
+		merge(t.attrs, body)
+		t.attrs["lastModifiedDateTime"] = now()
+		t.version = s.bump()
+		writeJson(w, http.StatusOK, t.attrs)
+	case "DELETE":
+		for i, other := range l.tasks {
+			if other == t {
+				l.tasks = append(l.tasks[:i], l.tasks[i+1:]...)
+				break
+			}
+		}
+
+		l.removedTasks = append(l.removedTasks, tombstone{id: t.attrs["id"].(string), version: s.bump()})
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeMethodNotAllowed(w)
+	}
+}
+
+// Serves the checklist items or linked resources of a task, returning them
+// with the created one added.
+func (s *Server) serveSubItems(
+	w http.ResponseWriter,
+	r *http.Request,
+	self string,
+	items []item,
+	kind string,
+	body item,
+) []item {
+	switch r.Method {
+	case "GET":
+		s.writePage(w, r, self, items)
+	case "POST":
+		body["id"] = s.newId(kind)
+		body["createdDateTime"] = now()
+		if checked, _ := body["isChecked"].(bool); checked {
+			setDefault(body, "checkedDateTime", now())
+		}
+
+		items = append(items, body)
+		writeJson(w, http.StatusCreated, body)
+	default:
+		writeMethodNotAllowed(w)
+	}
+
+	return items
+}
+
+// Writes a page of a collection, linking to the next one (if any) through
+// `@odata.nextLink`. The page is as long as `$top` asks for, but no longer
+// than the server's PageSize. The `$filter`, `$orderby`, `$select` and
+// `$count` of the request are applied (see applyQuery).
+func (s *Server) writePage(w http.ResponseWriter, r *http.Request, self string, items []item) {
+	query := r.URL.Query()
+	items, err := applyQuery(query, items)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "BadRequest", err.Error())
+		return
+	}
+
+	size := s.PageSize
+	if top, err := strconv.Atoi(query.Get("$top")); err == nil && top > 0 && top < size {
+		size = top
+	}
+
+	skip, _ := strconv.Atoi(query.Get("$skip"))
+	if skip < 0 || skip > len(items) {
+		skip = len(items)
+	}
+
+	end := skip + size
+	if end > len(items) {
+		end = len(items)
+	}
+
+	page := item{"@odata.context": self, "value": items[skip:end]}
+	if query.Get("$count") == "true" {
+		page["@odata.count"] = len(items)
+	}
+
+	if end < len(items) {
+		next := url.Values{"$top": {strconv.Itoa(size)}, "$skip": {strconv.Itoa(end)}}
+		for _, option := range []string{"$filter", "$orderby", "$select", "$count"} {
+			if value := query.Get(option); value != "" {
+				next.Set(option, value)
+			}
+		}
+
+		page["@odata.nextLink"] = self + "?" + next.Encode()
+	}
+
+	writeJson(w, http.StatusOK, page)
+}
+
+func (s *Server) serveListsDelta(w http.ResponseWriter, r *http.Request, self string) {
+	since, ok := s.deltaToken(w, r)
+	if !ok {
+		return
+	}
+
+	changed := []item{}
+	for _, l := range s.lists {
+		if l.version > since {
+			changed = append(changed, l.attrs)
+		}
+	}
+
+	s.writeDelta(w, self, changed, s.removedLists, since)
+}
+
+func (s *Server) serveTasksDelta(w http.ResponseWriter, r *http.Request, self string, l *list) {
+	since, ok := s.deltaToken(w, r)
+	if !ok {
+		return
+	}
+
+	changed := []item{}
+	for _, t := range l.tasks {
+		if t.version > since {
+			changed = append(changed, t.attrs)
+		}
+	}
+
+	s.writeDelta(w, self, changed, l.removedTasks, since)
+}
+
+// Reads the version a delta query asks for the changes since (zero for all
+// the items), answering with 410 Gone when its link expired.
+func (s *Server) deltaToken(w http.ResponseWriter, r *http.Request) (int, bool) {
+	token := r.URL.Query().Get("$deltatoken")
+	if token == "" {
+		return 0, true
+	}
+
+	since, err := strconv.Atoi(token)
+	if err != nil || since <= s.expiredVersion || since > s.version {
+		writeError(w, http.StatusGone, "SyncStateNotFound", "The delta link expired.")
+		return 0, false
+	}
+
+	return since, true
+}
+
+// Writes the changes since a version (in a single page), with the removed
+// items marked as such and a delta link for the next round.
+func (s *Server) writeDelta(w http.ResponseWriter, self string, changed []item, removed []tombstone, since int) {
+	values := changed
+	if since > 0 {
+		for _, t := range removed {
+			if t.version > since {
+				values = append(values, item{"id": t.id, "@removed": item{"reason": "deleted"}})
+			}
+		}
+	}
+
+	next := url.Values{"$deltatoken": {strconv.Itoa(s.version)}}
+	writeJson(w, http.StatusOK, item{
+		"@odata.context":   self,
+		"value":            values,
+		"@odata.deltaLink": self + "?" + next.Encode(),
+	})
+}
+
+func writeMethodNotAllowed(w http.ResponseWriter) {
+	writeError(w, http.StatusMethodNotAllowed, "BadRequest", "The method is not allowed.")
+}
+
+// Copies the attributes of `changes` (but the id) over `attrs`.
+func merge(attrs, changes item) {
+	for key, value := range changes {
+		if key != "id" {
+			attrs[key] = value
+		}
+	}
+}
+
+func setDefault(attrs item, key string, value interface{}) {
+	if _, ok := attrs[key]; !ok {
+		attrs[key] = value
+	}
+}
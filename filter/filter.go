@@ -0,0 +1,449 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The `filter` package is in charge of the conditions the tasks are picked
+// by in the CLI, e.g. `due < friday and importance = high and not
+// completed`. A filter is made of terms, each comparing a field of a task
+// (or the name of its list) to a value, combined with `and` (or a comma),
+// `or`, `not` and parentheses. A bare status (e.g. `completed`) stands for
+// the term `status = completed`.
+package filter
+
+import (
+	"fmt"
+	"github.com/betasve/mstd/dateparse"
+	api "github.com/betasve/mstd/todoapi"
+	"regexp"
+	"strings"
+)
+
+// The fields a term can compare, along with the operators it can use.
+var fieldOperators map[string][]string = map[string][]string{
+	"list":       {"=", "!=", "~"},
+	"title":      {"=", "!=", "~"},
+	"status":     {"=", "!="},
+	"importance": {"=", "!=", "<", "<=", ">", ">="},
+	"due":        {"=", "!=", "<", "<=", ">", ">="},
+	"category":   {"=", "!=", "~"},
+}
+
+// The statuses a task can have.
+var statuses []string = []string{"notStarted", "inProgress", "completed", "waitingOnOthers", "deferred"}
+
+// The importances a task can have, in order.
+var importances []string = []string{"low", "normal", "high"}
+
+// The value `due` is compared to for the tasks without a due date.
+const noDate string = "none"
+
+// The layout the due dates are compared in (they're compared by day).
+const dateLayout string = "2006-01-02"
+
+// A single condition of a filter, e.g. `due < 2021-06-01`.
+type Term struct {
+	Field    string
+	Operator string
+	Value    string
+	// The value as a regular expression (for `~`) or as a date (for `due`).
+	pattern *regexp.Regexp
+	date    string
+}
+
+// A part of a filter telling whether a task matches: a Term, or the parts
+// combined with `and`, `or` or `not`.
+type expr interface {
+	Match(list string, task api.TaskItem) bool
+}
+
+// Matches the tasks all of its parts match.
+type andExpr []expr
+
+// Matches the tasks any of its parts matches.
+type orExpr []expr
+
+// Matches the tasks its part doesn't match.
+type notExpr struct {
+	expr expr
+}
+
+// Picks the tasks matching its expression. The empty filter matches all the
+// tasks.
+type Filter struct {
+	expr expr
+}
+
+// Parses a filter, resolving the dates in it (e.g. `friday`) with `dates`.
+func Parse(expr string, dates dateparse.Parser) (*Filter, error) {
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tokens) == 0 {
+		return &Filter{}, nil
+	}
+
+	p := &parser{tokens: tokens, dates: dates}
+	root, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(p.tokens) > 0 {
+		if p.tokens[0].kind == closeToken {
+			return nil, fmt.Errorf("Unexpected `)` in the filter")
+		}
+
+		return nil, fmt.Errorf("Expected `and`, `or` or a comma before %q in the filter", p.tokens[0].text)
+	}
+
+	return &Filter{expr: root}, nil
+}
+
+// Tells if a task (in the list named `list`) matches the filter.
+func (f *Filter) Match(list string, task api.TaskItem) bool {
+	return f.expr == nil || f.expr.Match(list, task)
+}
+
+func (e andExpr) Match(list string, task api.TaskItem) bool {
+	for _, part := range e {
+		if !part.Match(list, task) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (e orExpr) Match(list string, task api.TaskItem) bool {
+	for _, part := range e {
+		if part.Match(list, task) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (e notExpr) Match(list string, task api.TaskItem) bool {
+	return !e.expr.Match(list, task)
+}
+
+// Tells if a task (in the list named `list`) matches the term.
+func (t *Term) Match(list string, task api.TaskItem) bool {
+	switch t.Field {
+	case "list":
+		return t.matchText(list)
+	case "title":
+		return t.matchText(task.Title)
+	case "status":
+		return t.compare(strings.EqualFold(task.Status, t.Value), 0)
+	case "importance":
+		order := indexOf(importances, task.Importance) - indexOf(importances, t.Value)
+		return t.compare(order == 0, order)
+	case "due":
+		return t.matchDue(task.DueDateTime)
+	case "category":
+		return t.matchCategories(task.Categories)
+	}
+
+	return false
+}
+
+func (t *Term) String() string {
+	return fmt.Sprintf("%s %s %s", t.Field, t.Operator, t.Value)
+}
+
+// Matches a text: `=` and `!=` ignore the case, `~` is a regular expression.
+func (t *Term) matchText(text string) bool {
+	if t.Operator == "~" {
+		return t.pattern.MatchString(text)
+	}
+
+	return t.compare(strings.EqualFold(text, t.Value), 0)
+}
+
+// Matches the due date (by day). The tasks without one only match `= none`
+// (and `!=` any date).
+func (t *Term) matchDue(due *api.DateTimeTimeZone) bool {
+	date := noDate
+	if due != nil {
+		if parsed, err := due.Time(); err == nil {
+			date = parsed.Format(dateLayout)
+		}
+	}
+
+	if t.date == noDate || date == noDate {
+		if t.Operator != "=" && t.Operator != "!=" {
+			return false
+		}
+
+		return t.compare(date == t.date, 0)
+	}
+
+	return t.compare(date == t.date, strings.Compare(date, t.date))
+}
+
+// Matches the categories: `=` when one of them is the value, `!=` when none
+// is and `~` when one matches the regular expression.
+func (t *Term) matchCategories(categories []string) bool {
+	for _, category := range categories {
+		if t.Operator == "~" && t.pattern.MatchString(category) {
+			return true
+		}
+
+		if t.Operator != "~" && strings.EqualFold(category, t.Value) {
+			return t.Operator == "="
+		}
+	}
+
+	return t.Operator == "!="
+}
+
+// Applies the operator, given if the values are equal and (for the ordered
+// ones) how they compare (negative when the field's value is the lesser).
+func (t *Term) compare(equal bool, order int) bool {
+	switch t.Operator {
+	case "=":
+		return equal
+	case "!=":
+		return !equal
+	case "<":
+		return order < 0
+	case "<=":
+		return order <= 0
+	case ">":
+		return order > 0
+	case ">=":
+		return order >= 0
+	}
+
+	return false
+}
+
+// Parses the tokens of a filter, from the loosest binding `or` down to the
+// terms.
+type parser struct {
+	tokens []token
+	dates  dateparse.Parser
+}
+
+// Parses the parts joined with `or`.
+func (p *parser) or() (expr, error) {
+	parts := orExpr{}
+
+	for {
+		part, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+
+		parts = append(parts, part)
+		if len(p.tokens) == 0 || !p.tokens[0].is("or") {
+			break
+		}
+
+		if p.tokens = p.tokens[1:]; len(p.tokens) == 0 {
+			return nil, fmt.Errorf("Expected a term after the last `or` in the filter")
+		}
+	}
+
+	if len(parts) == 1 {
+		return parts[0], nil
+	}
+
+	return parts, nil
+}
+
+// Parses the parts joined with `and` (or a comma).
+func (p *parser) and() (expr, error) {
+	parts := andExpr{}
+
+	for {
+		part, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+
+		parts = append(parts, part)
+		if len(p.tokens) == 0 || !p.tokens[0].separator() {
+			break
+		}
+
+		if p.tokens = p.tokens[1:]; len(p.tokens) == 0 {
+			return nil, fmt.Errorf("Expected a term after the last `and` in the filter")
+		}
+	}
+
+	if len(parts) == 1 {
+		return parts[0], nil
+	}
+
+	return parts, nil
+}
+
+// Parses a `not`, a part in parentheses or a term.
+func (p *parser) unary() (expr, error) {
+	if len(p.tokens) == 0 {
+		return nil, fmt.Errorf("Expected a term at the end of the filter")
+	}
+
+	switch first := p.tokens[0]; {
+	case first.is("not"):
+		if p.tokens = p.tokens[1:]; len(p.tokens) == 0 {
+			return nil, fmt.Errorf("Expected a term after the last `not` in the filter")
+		}
+
+		part, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+
+		return notExpr{part}, nil
+	case first.kind == openToken:
+		p.tokens = p.tokens[1:]
+		part, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+
+		if len(p.tokens) == 0 || p.tokens[0].kind != closeToken {
+			return nil, fmt.Errorf("Missing a `)` in the filter")
+		}
+
+		p.tokens = p.tokens[1:]
+		return part, nil
+	}
+
+	term, rest, err := parseTerm(p.tokens, p.dates)
+	if err != nil {
+		return nil, err
+	}
+
+	p.tokens = rest
+	return term, nil
+}
+
+// Parses a term off the tokens, returning the ones left after it. A bare
+// status (e.g. `completed`) is taken as `status = completed`.
+func parseTerm(tokens []token, dates dateparse.Parser) (*Term, []token, error) {
+	if tokens[0].kind == wordToken && (len(tokens) == 1 || tokens[1].kind != operatorToken) {
+		if i := indexOfFold(statuses, tokens[0].text); i >= 0 {
+			return &Term{Field: "status", Operator: "=", Value: statuses[i]}, tokens[1:], nil
+		}
+	}
+
+	if len(tokens) < 3 || tokens[0].kind != wordToken || tokens[1].kind != operatorToken {
+		return nil, nil, fmt.Errorf("Expected a term like `status = completed` at %q in the filter", tokens[0].text)
+	}
+
+	term := &Term{Field: strings.ToLower(tokens[0].text), Operator: tokens[1].text}
+	operators, ok := fieldOperators[term.Field]
+	if !ok {
+		return nil, nil, fmt.Errorf(
+			"Unknown field %q in the filter (use list, title, status, importance, due or category)",
+			tokens[0].text,
+		)
+	}
+
+	if indexOf(operators, term.Operator) < 0 {
+		return nil, nil, fmt.Errorf(
+			"The %s can't be compared with %s (use %s)",
+			term.Field,
+			term.Operator,
+			strings.Join(operators, ", "),
+		)
+	}
+
+	value := []string{}
+	rest := tokens[2:]
+	for len(rest) > 0 && !rest[0].endsValue() {
+		value = append(value, rest[0].text)
+		rest = rest[1:]
+	}
+
+	if len(value) == 0 {
+		return nil, nil, fmt.Errorf("Missing the value of %s %s in the filter", term.Field, term.Operator)
+	}
+
+	term.Value = strings.Join(value, " ")
+	if err := term.compile(dates); err != nil {
+		return nil, nil, err
+	}
+
+	return term, rest, nil
+}
+
+// Checks the value of a term and prepares it for matching.
+func (t *Term) compile(dates dateparse.Parser) error {
+	if t.Operator == "~" {
+		pattern, err := regexp.Compile(t.Value)
+		if err != nil {
+			return fmt.Errorf("Invalid regular expression %q in the filter: %s", t.Value, err)
+		}
+
+		t.pattern = pattern
+		return nil
+	}
+
+	switch t.Field {
+	case "status":
+		if i := indexOfFold(statuses, t.Value); i < 0 {
+			return fmt.Errorf("Unknown status %q in the filter (use %s)", t.Value, strings.Join(statuses, ", "))
+		}
+	case "importance":
+		i := indexOfFold(importances, t.Value)
+		if i < 0 {
+			return fmt.Errorf("Unknown importance %q in the filter (use low, normal or high)", t.Value)
+		}
+
+		t.Value = importances[i]
+	case "due":
+		if strings.EqualFold(t.Value, noDate) {
+			t.date = noDate
+			return nil
+		}
+
+		date, err := dates.Parse(t.Value)
+		if err != nil {
+			return fmt.Errorf("Invalid due date %q in the filter: %s", t.Value, err)
+		}
+
+		t.date = date.Format(dateLayout)
+	}
+
+	return nil
+}
+
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func indexOfFold(values []string, value string) int {
+	for i, v := range values {
+		if strings.EqualFold(v, value) {
+			return i
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,121 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package filter
+
+import (
+	"github.com/betasve/mstd/dateparse"
+	tm "github.com/betasve/mstd/ext/time"
+	"github.com/betasve/mstd/ext/time/timetest"
+	api "github.com/betasve/mstd/todoapi"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Wednesday, 2021-05-05 14:20 in UTC.
+var fixedNow = time.Date(2021, 5, 5, 14, 20, 0, 0, time.UTC)
+
+var dates = dateparse.Parser{Location: time.UTC}
+
+func init() {
+	tm.Client = timetest.TimeMock{}
+	timetest.TimeNowMockFunc = func() time.Time { return fixedNow }
+}
+
+func due(date string) *api.DateTimeTimeZone {
+	return &api.DateTimeTimeZone{DateTime: date + "T00:00:00.0000000", TimeZone: "UTC"}
+}
+
+var report = api.TaskItem{
+	Title:       "Quarterly report",
+	Status:      "inProgress",
+	Importance:  "high",
+	Categories:  []string{"Work", "Finance"},
+	DueDateTime: due("2021-05-06"),
+}
+
+var milk = api.TaskItem{Title: "Buy milk", Status: "notStarted", Importance: "normal"}
+
+func TestMatch(test *testing.T) {
+	cases := map[string][2]bool{
+		"":                                     {true, true},
+		"status = inProgress":                  {true, false},
+		"status != completed":                  {true, true},
+		"STATUS = INPROGRESS":                  {true, false},
+		"importance >= normal":                 {true, true},
+		"importance > normal":                  {true, false},
+		"importance < high, importance != low": {false, true},
+		"due < friday":                         {true, false},
+		"due <= 2021-05-05":                    {false, false},
+		"due = tomorrow":                       {true, false},
+		"due = none":                           {false, true},
+		"due != none":                          {true, false},
+		"title ~ ^Buy":                         {false, true},
+		"title = 'buy milk'":                   {false, true},
+		"category = work":                      {true, false},
+		"category != work":                     {false, true},
+		"category ~ ^Fin":                      {true, false},
+		"list = Groceries":                     {false, true},
+		"list ~ (?i)^work and status = inProgress and due < next week": {true, false},
+		"not completed":                                        {true, true},
+		"notstarted":                                           {false, true},
+		"importance = low or inProgress":                       {true, false},
+		"not (importance = high or due = none)":                {false, false},
+		"(title ~ (?i)(milk|report)) and not category = work":  {false, true},
+		"due < friday and importance = high and not completed": {true, false},
+		"NOT inProgress, importance >= normal OR list = Work":  {true, true},
+	}
+
+	for expr, expected := range cases {
+		f, err := Parse(expr, dates)
+		if err != nil {
+			test.Errorf("\nExpected %q to parse\nbut got\n%s", expr, err)
+			continue
+		}
+
+		if result := [2]bool{f.Match("Work", report), f.Match("Groceries", milk)}; result != expected {
+			test.Errorf("\nExpected %q to match (report, milk):\n%v\nbut was\n%v", expr, expected, result)
+		}
+	}
+}
+
+func TestParseErrors(test *testing.T) {
+	cases := map[string]string{
+		"color = red":            "Unknown field",
+		"status < completed":     "can't be compared",
+		"status = done":          "Unknown status",
+		"importance = urgent":    "Unknown importance",
+		"due < someday":          "Invalid due date",
+		"title ~ (":              "Invalid regular expression",
+		"status =":               "Expected a term",
+		"status = completed and": "Expected a term after",
+		"title = 'milk":          "Unterminated quote",
+		"status = completed due": "Unknown status",
+		"(status = completed":    "Missing a `)`",
+		"status = completed)":    "Unexpected `)`",
+		"completed or":           "after the last `or`",
+		"not":                    "after the last `not`",
+		"done":                   "Expected a term",
+		"completed importance":   "Expected `and`, `or` or a comma",
+	}
+
+	for expr, expected := range cases {
+		_, err := Parse(expr, dates)
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			test.Errorf("\nExpected %q to fail with\n%s\nbut got\n%v", expr, expected, err)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filter
+
+import (
+	"fmt"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	// A bare word, e.g. a field or (a part of) a value.
+	wordToken tokenKind = iota
+	// A quoted value, taken as it is.
+	stringToken
+	// A comparison operator, e.g. `<=`.
+	operatorToken
+	// The comma separating the terms.
+	commaToken
+	// The parentheses grouping the terms.
+	openToken
+	closeToken
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+// The operators, the longer ones first.
+var operators []string = []string{"!=", "<=", ">=", "=", "<", ">", "~"}
+
+// Tells if the token is the (case insensitive) keyword `word`, e.g. `or`.
+func (t token) is(word string) bool {
+	return t.kind == wordToken && strings.EqualFold(t.text, word)
+}
+
+// Tells if the token separates two terms: a comma or `and`.
+func (t token) separator() bool {
+	return t.kind == commaToken || t.is("and")
+}
+
+// Tells if the token ends the value of a term.
+func (t token) endsValue() bool {
+	return t.separator() || t.is("or") || t.kind == operatorToken || t.kind == closeToken
+}
+
+// Splits a filter into tokens. Values can be quoted (with ' or ") to hold
+// commas, parentheses, operators or the keywords. The value after `~` (a
+// regular expression) runs to the next space, so it can hold parentheses
+// too.
+func tokenize(expr string) ([]token, error) {
+	tokens := []token{}
+
+	for i := 0; i < len(expr); {
+		c := expr[i]
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == ',':
+			tokens = append(tokens, token{commaToken, ","})
+			i++
+		case c == '(':
+			tokens = append(tokens, token{openToken, "("})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{closeToken, ")"})
+			i++
+		case c == '\'' || c == '"':
+			end := strings.IndexByte(expr[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("Unterminated quote in the filter: %s", expr[i:])
+			}
+
+			tokens = append(tokens, token{stringToken, expr[i+1 : i+1+end]})
+			i += end + 2
+		default:
+			if op := operatorAt(expr[i:]); op != "" {
+				tokens = append(tokens, token{operatorToken, op})
+				i += len(op)
+
+				if op == "~" {
+					for i < len(expr) && expr[i] == ' ' {
+						i++
+					}
+
+					if i < len(expr) && expr[i] != '\'' && expr[i] != '"' {
+						end := i + patternLength(expr[i:])
+						tokens = append(tokens, token{wordToken, expr[i:end]})
+						i = end
+					}
+				}
+
+				continue
+			}
+
+			start := i
+			for i < len(expr) && !strings.ContainsRune(" \t\n,()'\"", rune(expr[i])) && operatorAt(expr[i:]) == "" {
+				i++
+			}
+
+			tokens = append(tokens, token{wordToken, expr[start:i]})
+		}
+	}
+
+	return tokens, nil
+}
+
+// Returns the operator the text starts with, if any.
+func operatorAt(text string) string {
+	for _, op := range operators {
+		if strings.HasPrefix(text, op) {
+			return op
+		}
+	}
+
+	return ""
+}
+
+// Returns the length of the regular expression the text starts with: up to
+// the next space, or to a `)` closing a group opened before it.
+func patternLength(text string) int {
+	depth := 0
+
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case ' ', '\t', '\n':
+			return i
+		case '\\':
+			i++
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		}
+	}
+
+	return len(text)
+}
@@ -0,0 +1,450 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The `ical` package reads and writes iCalendar (RFC 5545) data: nested
+// components (e.g. VCALENDAR, VTODO, VALARM) made of properties. It takes
+// care of the encoding details - folding long lines, escaping text values
+// and parsing property parameters - while the meaning of the components is
+// left to its users.
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// The layouts of the DATE and (UTC) DATE-TIME values.
+const (
+	DateLayout        string = "20060102"
+	DateTimeLayout    string = "20060102T150405"
+	UTCDateTimeLayout string = "20060102T150405Z"
+)
+
+// The longest a line can be (in octets) before it's folded.
+const maxLineLength int = 75
+
+// A property of a component, e.g. `DUE;VALUE=DATE:20210503`.
+type Property struct {
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+// A component, e.g. a VTODO, with its properties and sub-components.
+type Component struct {
+	Name       string
+	Properties []Property
+	Components []*Component
+}
+
+// Creates a component named `name`.
+func NewComponent(name string) *Component {
+	return &Component{Name: name}
+}
+
+// Returns the first property named `name`.
+func (c *Component) Get(name string) (Property, bool) {
+	for _, p := range c.Properties {
+		if p.Name == name {
+			return p, true
+		}
+	}
+
+	return Property{}, false
+}
+
+// Returns the value of the first property named `name`, or an empty string.
+func (c *Component) Value(name string) string {
+	p, _ := c.Get(name)
+	return p.Value
+}
+
+// Returns the (unescaped) text value of the first property named `name`.
+func (c *Component) Text(name string) string {
+	return UnescapeText(c.Value(name))
+}
+
+// Adds a property with a raw value.
+func (c *Component) Add(name, value string, params map[string]string) {
+	c.Properties = append(c.Properties, Property{Name: name, Params: params, Value: value})
+}
+
+// Adds a property with a text value, escaping it.
+func (c *Component) AddText(name, text string) {
+	c.Add(name, EscapeText(text), nil)
+}
+
+// Returns the sub-components named `name`.
+func (c *Component) Children(name string) []*Component {
+	children := []*Component{}
+	for _, child := range c.Components {
+		if child.Name == name {
+			children = append(children, child)
+		}
+	}
+
+	return children
+}
+
+// Writes the component (and its sub-components) as content lines.
+func (c *Component) Encode(w io.Writer) error {
+	lines := []string{"BEGIN:" + c.Name}
+
+	for _, p := range c.Properties {
+		lines = append(lines, p.String())
+	}
+
+	for _, line := range lines {
+		if _, err := io.WriteString(w, fold(line)); err != nil {
+			return err
+		}
+	}
+
+	for _, child := range c.Components {
+		if err := child.Encode(w); err != nil {
+			return err
+		}
+	}
+
+	_, err := io.WriteString(w, fold("END:"+c.Name))
+
+	return err
+}
+
+// Formats the property as a content line (without folding it).
+func (p Property) String() string {
+	line := p.Name
+
+	for _, k := range sortedKeys(p.Params) {
+		value := p.Params[k]
+		if strings.ContainsAny(value, ";:,") {
+			value = `"` + value + `"`
+		}
+		line += ";" + k + "=" + value
+	}
+
+	return line + ":" + p.Value
+}
+
+// Reads all the (top level) components of iCalendar data, usually a single
+// VCALENDAR.
+func Parse(r io.Reader) ([]*Component, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	roots := []*Component{}
+	stack := []*Component{}
+
+	for number, line := range lines {
+		p, err := parseLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("Line %d: %s", number+1, err)
+		}
+
+		switch p.Name {
+		case "BEGIN":
+			c := NewComponent(strings.ToUpper(p.Value))
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.Components = append(parent.Components, c)
+			} else {
+				roots = append(roots, c)
+			}
+			stack = append(stack, c)
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1].Name != strings.ToUpper(p.Value) {
+				return nil, fmt.Errorf("Line %d: Unexpected END:%s", number+1, p.Value)
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("Line %d: Property %s outside of a component", number+1, p.Name)
+			}
+			c := stack[len(stack)-1]
+			c.Properties = append(c.Properties, p)
+		}
+	}
+
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("Component %s is not ended", stack[len(stack)-1].Name)
+	}
+
+	return roots, nil
+}
+
+// Parses a content line, e.g. `DTSTART;TZID="Europe/Sofia":20210503T090000`.
+func parseLine(line string) (Property, error) {
+	p := Property{Params: map[string]string{}}
+
+	i := strings.IndexAny(line, ";:")
+	if i <= 0 {
+		return p, fmt.Errorf("Invalid content line %q", line)
+	}
+
+	p.Name = strings.ToUpper(line[:i])
+	rest := line[i:]
+
+	for strings.HasPrefix(rest, ";") {
+		rest = rest[1:]
+
+		eq := strings.Index(rest, "=")
+		if eq <= 0 {
+			return p, fmt.Errorf("Invalid parameter in %q", line)
+		}
+
+		name := strings.ToUpper(rest[:eq])
+		rest = rest[eq+1:]
+
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				return p, fmt.Errorf("Unterminated quote in %q", line)
+			}
+			value = rest[1 : end+1]
+			rest = rest[end+2:]
+		} else {
+			end := strings.IndexAny(rest, ";:")
+			if end < 0 {
+				return p, fmt.Errorf("Missing value in %q", line)
+			}
+			value = rest[:end]
+			rest = rest[end:]
+		}
+
+		p.Params[name] = value
+	}
+
+	if !strings.HasPrefix(rest, ":") {
+		return p, fmt.Errorf("Missing value in %q", line)
+	}
+
+	p.Value = rest[1:]
+
+	return p, nil
+}
+
+// Reads the content lines, joining the folded ones (the lines starting with
+// a space or a tab continue the previous one).
+func unfold(r io.Reader) ([]string, error) {
+	lines := []string{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines, scanner.Err()
+}
+
+// Folds a content line to lines of at most 75 octets (without splitting
+// a character), ending each with CRLF.
+func fold(line string) string {
+	folded := &strings.Builder{}
+	limit := maxLineLength
+
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+
+		folded.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+		limit = maxLineLength - 1
+	}
+
+	folded.WriteString(line + "\r\n")
+
+	return folded.String()
+}
+
+// Escapes a text value: backslashes, semicolons, commas and new lines.
+func EscapeText(text string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(text)
+}
+
+// Reverses EscapeText.
+func UnescapeText(text string) string {
+	unescaped := &strings.Builder{}
+	escaped := false
+
+	for _, r := range text {
+		switch {
+		case escaped && (r == 'n' || r == 'N'):
+			unescaped.WriteRune('\n')
+		case escaped:
+			unescaped.WriteRune(r)
+		case r == '\\':
+			escaped = true
+			continue
+		default:
+			unescaped.WriteRune(r)
+		}
+
+		escaped = false
+	}
+
+	return unescaped.String()
+}
+
+// Splits a list of (escaped) text values, e.g. the CATEGORIES, on the commas
+// that aren't escaped.
+func SplitText(value string) []string {
+	values := []string{}
+	current := &strings.Builder{}
+	escaped := false
+
+	for _, r := range value {
+		switch {
+		case escaped:
+			current.WriteRune('\\')
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == ',':
+			values = append(values, UnescapeText(current.String()))
+			current.Reset()
+		default:
+			current.WriteRune(r)
+		}
+	}
+
+	if value != "" {
+		values = append(values, UnescapeText(current.String()))
+	}
+
+	return values
+}
+
+// Parses a DATE or DATE-TIME value. UTC ones (ending with `Z`) are in UTC,
+// those with a TZID parameter in its location (`fallback` when unknown) and
+// the rest (floating ones) in `fallback`. It reports whether it was a DATE.
+func ParseTime(p Property, fallback *time.Location) (time.Time, bool, error) {
+	if p.Params["VALUE"] == "DATE" || len(p.Value) == len(DateLayout) {
+		t, err := time.ParseInLocation(DateLayout, p.Value, fallback)
+		return t, true, err
+	}
+
+	if strings.HasSuffix(p.Value, "Z") {
+		t, err := time.Parse(UTCDateTimeLayout, p.Value)
+		return t, false, err
+	}
+
+	loc := fallback
+	if tzid, ok := p.Params["TZID"]; ok {
+		if l, err := time.LoadLocation(strings.TrimPrefix(tzid, "/")); err == nil {
+			loc = l
+		}
+	}
+
+	t, err := time.ParseInLocation(DateTimeLayout, p.Value, loc)
+
+	return t, false, err
+}
+
+// Formats a time as a UTC DATE-TIME value.
+func FormatUTC(t time.Time) string {
+	return t.UTC().Format(UTCDateTimeLayout)
+}
+
+// Parses a DURATION value, e.g. `-PT15M` or `P1DT12H`.
+func ParseDuration(value string) (time.Duration, error) {
+	invalid := fmt.Errorf("Invalid duration %q", value)
+	sign := time.Duration(1)
+
+	switch {
+	case strings.HasPrefix(value, "-"):
+		sign = -1
+		value = value[1:]
+	case strings.HasPrefix(value, "+"):
+		value = value[1:]
+	}
+
+	if !strings.HasPrefix(value, "P") || len(value) < 3 {
+		return 0, invalid
+	}
+
+	units := map[rune]time.Duration{
+		'W': 7 * 24 * time.Hour,
+		'D': 24 * time.Hour,
+		'H': time.Hour,
+		'M': time.Minute,
+		'S': time.Second,
+	}
+
+	var total time.Duration
+	number := 0
+	digits := false
+
+	for _, r := range value[1:] {
+		switch {
+		case r >= '0' && r <= '9':
+			number = number*10 + int(r-'0')
+			digits = true
+		case r == 'T':
+			continue
+		case units[r] != 0 && digits:
+			total += time.Duration(number) * units[r]
+			number = 0
+			digits = false
+		default:
+			return 0, invalid
+		}
+	}
+
+	if digits {
+		return 0, invalid
+	}
+
+	return sign * total, nil
+}
+
+// Returns the keys of a map, sorted.
+func sortedKeys(m map[string]string) []string {
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,135 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+const calendar string = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"BEGIN:VTODO\r\n" +
+	"SUMMARY:Buy milk\\, eggs\\; and bread\r\n" +
+	"DESCRIPTION:A long description that is folded onto\r\n" +
+	"  the next line\\nwith a new line\r\n" +
+	"DUE;TZID=\"Europe/Sofia\":20210503T090000\r\n" +
+	"CATEGORIES:home,errands\\, misc\r\n" +
+	"BEGIN:VALARM\r\n" +
+	"TRIGGER:-PT15M\r\n" +
+	"END:VALARM\r\n" +
+	"END:VTODO\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestParse(test *testing.T) {
+	roots, err := Parse(strings.NewReader(calendar))
+	if err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	todos := roots[0].Children("VTODO")
+	if len(todos) != 1 {
+		test.Fatalf("\nExpected 1 VTODO\nbut got\n%d", len(todos))
+	}
+
+	todo := todos[0]
+
+	if todo.Text("SUMMARY") != "Buy milk, eggs; and bread" {
+		test.Errorf("\nExpected the summary to be unescaped\nbut was\n%s", todo.Text("SUMMARY"))
+	}
+
+	if todo.Text("DESCRIPTION") != "A long description that is folded onto the next line\nwith a new line" {
+		test.Errorf("\nExpected the description to be unfolded\nbut was\n%q", todo.Text("DESCRIPTION"))
+	}
+
+	if categories := SplitText(todo.Value("CATEGORIES")); len(categories) != 2 || categories[1] != "errands, misc" {
+		test.Errorf("\nExpected 2 categories\nbut got\n%q", categories)
+	}
+
+	due, _ := todo.Get("DUE")
+	t, dateOnly, err := ParseTime(due, time.UTC)
+	if err != nil || dateOnly || !t.Equal(time.Date(2021, 5, 3, 6, 0, 0, 0, time.UTC)) {
+		test.Errorf("\nExpected the due time in Sofia\nbut got\n%s, %v", t, err)
+	}
+
+	if len(todo.Children("VALARM")) != 1 {
+		test.Error("\nExpected the VALARM to be nested in the VTODO\nbut it was not")
+	}
+}
+
+func TestParseFailures(test *testing.T) {
+	for _, data := range []string{
+		"BEGIN:VTODO\r\nSUMMARY:x\r\n",
+		"SUMMARY:x\r\n",
+		"BEGIN:VTODO\r\nEND:VEVENT\r\n",
+		"BEGIN:VTODO\r\nNOVALUE\r\nEND:VTODO\r\n",
+	} {
+		if _, err := Parse(strings.NewReader(data)); err == nil {
+			test.Errorf("\nExpected %q to return error\nbut it was\nnil", data)
+		}
+	}
+}
+
+func TestEncodeFoldsLongLines(test *testing.T) {
+	todo := NewComponent("VTODO")
+	todo.AddText("SUMMARY", strings.Repeat("ж", 60))
+	todo.Add("DUE", "20210503", map[string]string{"VALUE": "DATE"})
+
+	out := &bytes.Buffer{}
+	if err := todo.Encode(out); err != nil {
+		test.Fatal(err)
+	}
+
+	encoded := out.String()
+
+	for _, line := range strings.Split(strings.TrimSuffix(encoded, "\r\n"), "\r\n") {
+		if len(line) > maxLineLength {
+			test.Errorf("\nExpected lines of at most %d octets\nbut got\n%d", maxLineLength, len(line))
+		}
+	}
+
+	roots, err := Parse(out)
+	if err != nil || roots[0].Text("SUMMARY") != strings.Repeat("ж", 60) {
+		test.Errorf("\nExpected the summary to be read back\nbut got\n%v", err)
+	}
+
+	if !strings.Contains(encoded, "DUE;VALUE=DATE:20210503\r\n") {
+		test.Errorf("\nExpected the DUE with its parameter\nbut was\n%s", encoded)
+	}
+}
+
+func TestParseDuration(test *testing.T) {
+	cases := map[string]time.Duration{
+		"-PT15M":    -15 * time.Minute,
+		"P1DT12H":   36 * time.Hour,
+		"+P1W":      7 * 24 * time.Hour,
+		"PT1H30M5S": time.Hour + 30*time.Minute + 5*time.Second,
+	}
+
+	for value, expected := range cases {
+		if result, err := ParseDuration(value); err != nil || result != expected {
+			test.Errorf("\nExpected %q to be:\n%s\nbut was\n%s, %v", value, expected, result, err)
+		}
+	}
+
+	for _, value := range []string{"", "P", "PT15", "15M", "PTXM"} {
+		if _, err := ParseDuration(value); err == nil {
+			test.Errorf("\nExpected %q to return error\nbut it was\nnil", value)
+		}
+	}
+}
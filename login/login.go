@@ -21,7 +21,9 @@ limitations under the License.
 package login
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	httpService "github.com/betasve/mstd/ext/http"
 	t "github.com/betasve/mstd/ext/time"
@@ -41,21 +43,58 @@ type AuthData struct {
 	RefreshToken string `json:"refresh_token"`
 }
 
-var baseRequestUrl = "https://login.microsoftonline.com/common/oauth2/v2.0"
+// The error returned when MS' login API doesn't give the tokens, e.g. as the
+// refresh token expired or was revoked. The user has to log in again.
+type AuthError struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+func (e *AuthError) Error() string {
+	if e.Description == "" {
+		return fmt.Sprintf("Could not log in: %s", e.Code)
+	}
+
+	return fmt.Sprintf("Could not log in: %s\n%s", e.Code, e.Description)
+}
+
+// The path (under the tenant) of the OAuth 2.0 endpoints.
+const oauthPath = "/oauth2/v2.0"
+
+var baseRequestUrl = "https://login.microsoftonline.com/common" + oauthPath
+
+// Points the requests at another login API, e.g. a fake one like
+// `http://localhost:8081/common/oauth2/v2.0` in the tests.
+func SetBaseRequestUrl(url string) {
+	baseRequestUrl = strings.TrimSuffix(url, "/")
+}
+
+// Points the requests at the login API of an authority host (e.g.
+// `https://login.microsoftonline.us` for US Gov) and a tenant (`common`,
+// `organizations`, `consumers` or a tenant id).
+func SetAuthority(host, tenant string) {
+	SetBaseRequestUrl(strings.TrimSuffix(host, "/") + "/" + tenant + oauthPath)
+}
+
 var authRequestPath = "/authorize"
 var httpClient httpService.HttpClient = &httpService.Client{}
 var tokenRequestPath = "/token"
 var callbackFn func(string) error
 
+// Sets the HTTP client the requests to MS' login API are sent with.
+func SetHttpClient(client httpService.HttpClient) {
+	httpClient = client
+}
+
 const refreshTokenValidityInHours = 200 * 24
 
 // Logs in a user.
 // TODO: Add logout command to remove attributes from conf file
-func (c *Creds) PerformLogin() error {
+func (c *Creds) PerformLogin(ctx context.Context) error {
 	if c.alreadyLoggedIn() {
-		return c.refreshTokenIfNeeded()
+		return c.refreshTokenIfNeeded(ctx)
 	} else {
-		return c.performLogin()
+		return c.performLogin(ctx)
 	}
 }
 
@@ -65,18 +104,21 @@ func (c *Creds) LoginNeeded() bool {
 }
 
 // Performs the login operation procedure.
-func (c *Creds) performLogin() error {
+func (c *Creds) performLogin(ctx context.Context) error {
 	err := c.loginUrlHandlerFn(c.prepareLoginUrl())
 	if err != nil {
 		return err
 	}
 
-	return CallbackListen(c.authCallbackPath, c.getAccessToken)
+	return CallbackListen(ctx, c.authCallbackPath, func(authKey string) error {
+		return c.getAccessToken(ctx, authKey)
+	})
 }
 
 // Retrieves the access token using MS' login API.
-func (c *Creds) getAccessToken(authKey string) error {
+func (c *Creds) getAccessToken(ctx context.Context, authKey string) error {
 	request, err := buildRequestObjectWithEncodedParams(
+		ctx,
 		baseRequestUrl+tokenRequestPath,
 		c.buildRequestBodyForAuthToken(authKey).Encode(),
 	)
@@ -89,8 +131,9 @@ func (c *Creds) getAccessToken(authKey string) error {
 }
 
 // Retrieves the refresh token using MS' login API.
-func (c *Creds) getRefreshToken() error {
+func (c *Creds) getRefreshToken(ctx context.Context) error {
 	request, err := buildRequestObjectWithEncodedParams(
+		ctx,
 		baseRequestUrl+tokenRequestPath,
 		c.buildRequestBodyForRefreshToken().Encode(),
 	)
@@ -115,6 +158,12 @@ func (c *Creds) processTokenRequest(request *http.Request) error {
 		return err
 	}
 
+	if a.AccessToken == "" {
+		authErr := &AuthError{Code: "no_access_token"}
+		_ = json.Unmarshal(body, authErr)
+		return authErr
+	}
+
 	a.ExtExpiresIn = a.ExtExpiresIn * refreshTokenValidityInHours
 	return c.loginDataCallbackFn(&a)
 }
@@ -137,9 +186,9 @@ func (c *Creds) isRefreshTokenValid() bool {
 }
 
 // Refreshes the token if it's needed.
-func (c *Creds) refreshTokenIfNeeded() error {
+func (c *Creds) refreshTokenIfNeeded(ctx context.Context) error {
 	if !c.isAccessTokenValid() {
-		return c.getRefreshToken()
+		return c.getRefreshToken(ctx)
 	}
 
 	return nil
@@ -171,9 +220,10 @@ func (c *Creds) buildRequestBodyForRefreshToken() url.Values {
 	return data
 }
 
-// Builds a request object with encoded params.
-func buildRequestObjectWithEncodedParams(requestUrl, urlEncodedParams string) (*http.Request, error) {
-	req, err := http.NewRequest(
+// Builds a request object with encoded params, bound to the context.
+func buildRequestObjectWithEncodedParams(ctx context.Context, requestUrl, urlEncodedParams string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(
+		ctx,
 		"POST",
 		requestUrl,
 		strings.NewReader(urlEncodedParams),
@@ -208,18 +258,34 @@ func sendRequest(req *http.Request) ([]byte, error) {
 }
 
 // Spins up a tiny HTTP serer on :8008 to listen for a callback and handle the
-// passed params.
-func CallbackListen(callbackUrl string, cb func(string) error) error {
-	callbackFn = cb
+// passed params. The server is stopped once the callback succeeds or the
+// context is done.
+func CallbackListen(ctx context.Context, callbackUrl string, cb func(string) error) error {
+	listenCtx, stop := context.WithCancel(ctx)
+	defer stop()
+
+	callbackFn = func(code string) error {
+		if err := cb(code); err != nil {
+			return err
+		}
+
+		stop()
+		return nil
+	}
 
 	httpClient.HandleFunc(callbackUrl, responder)
-	err := httpClient.ListenAndServe(":8080", nil)
+	err := httpClient.ListenAndServe(listenCtx, ":8080", nil)
 
-	if err != nil {
-		return err
+	if ctx.Err() != nil {
+		return ctx.Err()
 	}
 
-	return nil
+	// Stopped after a successful callback.
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+
+	return err
 }
 
 // The handler function for the tiny HTTP server. It's doing the actual
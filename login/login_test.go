@@ -1,6 +1,7 @@
 package login
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	httpService "github.com/betasve/mstd/ext/http/httptest"
@@ -25,7 +26,7 @@ func TestPerformLoginWhenValidAccessToken(test *testing.T) {
 	creds.SetAccessToken("acc_token")
 	creds.SetAccessTokenExpiresAt(time.Client.Now().Add(fiveMins))
 
-	err := creds.PerformLogin()
+	err := creds.PerformLogin(context.Background())
 
 	if err != nil {
 		test.Errorf("\nexpected no errors\nbut got\n%s", err)
@@ -43,7 +44,7 @@ func TestPerformLoginWhenValidRefreshToken(test *testing.T) {
 	creds.SetRefreshTokenExpiresAt(time.Client.Now().Add(fiveMins))
 	creds.SetLoginDataCallbackFn(refreshCalledFn)
 
-	err := creds.PerformLogin()
+	err := creds.PerformLogin(context.Background())
 
 	if err != nil {
 		test.Errorf("\nexpected no errors\nbut got\n%s", err)
@@ -73,7 +74,7 @@ func TestPerformLoginWhenNoTokens(test *testing.T) {
 	creds.SetLoginUrlHandlerFn(func(in string) error { return nil })
 	creds.SetAuthCallbackPath("/test")
 
-	err := creds.PerformLogin()
+	err := creds.PerformLogin(context.Background())
 
 	if err != nil {
 		test.Errorf("\nexpected no errors\nbut got\n%s", err)
@@ -119,7 +120,7 @@ func TestGetAccessTokenSuccess(test *testing.T) {
 	creds.SetLoginDataCallbackFn(func(a *AuthData) error { response = a; return nil })
 	authKey := "testAuthKey"
 
-	err := creds.getAccessToken(authKey)
+	err := creds.getAccessToken(context.Background(), authKey)
 
 	if err != nil {
 		test.Errorf("\nexpected no errors\nbut got\n%s", err)
@@ -148,7 +149,7 @@ func TestGetAccessTokenRequestFailure(test *testing.T) {
 		return nil, expectedErr
 	}
 
-	err := creds.getAccessToken(authKey)
+	err := creds.getAccessToken(context.Background(), authKey)
 
 	if err != expectedErr {
 		test.Errorf("\nexpected %s\nbut got\n%s", expectedErr, err)
@@ -165,7 +166,7 @@ func TestGetAccessTokenCallbackFailure(test *testing.T) {
 	authKey := "testAuthKey"
 
 	httpService.MockFn = httpService.DefaultMockFn
-	err := creds.getAccessToken(authKey)
+	err := creds.getAccessToken(context.Background(), authKey)
 
 	if err != expectedErr {
 		test.Errorf("\nexpected %s\nbut got\n%s", expectedErr, err)
@@ -181,7 +182,7 @@ func TestGetRefreshTokenSuccess(test *testing.T) {
 
 	creds.SetLoginDataCallbackFn(func(a *AuthData) error { response = a; return nil })
 
-	err := creds.getRefreshToken()
+	err := creds.getRefreshToken(context.Background())
 
 	if err != nil {
 		test.Errorf("\nexpected no errors\nbut got\n%s", err)
@@ -209,7 +210,7 @@ func TestGetRefreshTokenRequestFailure(test *testing.T) {
 		return nil, expectedErr
 	}
 
-	err := creds.getRefreshToken()
+	err := creds.getRefreshToken(context.Background())
 
 	if err != expectedErr {
 		test.Errorf("\nexpected %s\nbut got\n%s", expectedErr, err)
@@ -225,7 +226,7 @@ func TestGetRefreshTokenCallbackFailure(test *testing.T) {
 	creds.SetLoginDataCallbackFn(func(a *AuthData) error { return expectedErr })
 
 	httpService.MockFn = httpService.DefaultMockFn
-	err := creds.getRefreshToken()
+	err := creds.getRefreshToken(context.Background())
 
 	if err != expectedErr {
 		test.Errorf("\nexpected %s\nbut got\n%s", expectedErr, err)
@@ -288,6 +289,31 @@ func TestProcessTokenRequestFailure(test *testing.T) {
 	}
 }
 
+func TestProcessTokenRequestWithoutToken(test *testing.T) {
+	request, _ := http.NewRequest("POST", "localhost/test", nil)
+
+	creds := Creds{}
+	creds.SetLoginDataCallbackFn(func(a *AuthData) error {
+		test.Error("\nexpected the callback not to be called\nbut it was")
+		return nil
+	})
+
+	httpService.MockFn = func(r *http.Request) (*http.Response, error) {
+		return &http.Response{
+			Body: ioutil.NopCloser(strings.NewReader(
+				`{"error":"invalid_grant","error_description":"The refresh token has expired."}`,
+			)),
+		}, nil
+	}
+
+	err := creds.processTokenRequest(request)
+
+	var authErr *AuthError
+	if !errors.As(err, &authErr) || authErr.Code != "invalid_grant" {
+		test.Errorf("\nexpected an AuthError for invalid_grant\nbut got\n%v", err)
+	}
+}
+
 func TestAlreadyLoggedInWithAccessTokenSucess(test *testing.T) {
 	creds := Creds{}
 	creds.SetAccessToken("acc_token")
@@ -427,7 +453,7 @@ func TestRefreshTokenIfNeededWhenNeeded(test *testing.T) {
 	creds.SetLoginDataCallbackFn(func(a *AuthData) error { return nil })
 	httpService.MockFn = httpService.DefaultMockFn
 
-	err := creds.refreshTokenIfNeeded()
+	err := creds.refreshTokenIfNeeded(context.Background())
 
 	if err != nil {
 		test.Errorf("\nexpected\nno errors\nbut got\n%s", err)
@@ -441,7 +467,7 @@ func TestRefreshTokenIfNeededWhenNotNeeded(test *testing.T) {
 	creds.SetLoginDataCallbackFn(func(a *AuthData) error { return nil })
 	httpService.MockFn = httpService.DefaultMockFn
 
-	err := creds.refreshTokenIfNeeded()
+	err := creds.refreshTokenIfNeeded(context.Background())
 
 	if err != nil {
 		test.Errorf("\nexpected\nno errors\nbut got\n%s", err)
@@ -489,7 +515,7 @@ func TestBuildRequestObjectWithEncodedParamsSuccess(test *testing.T) {
 	testData := url.Values{}
 	testData.Set("some", "value")
 
-	result, err := buildRequestObjectWithEncodedParams(testUrl, testData.Encode())
+	result, err := buildRequestObjectWithEncodedParams(context.Background(), testUrl, testData.Encode())
 
 	result.Header.Add("Content-Type", "application/x-www-form-urlencoded")
 	result.Header.Add("Content-Length", strconv.Itoa(len(testData.Encode())))
@@ -597,7 +623,7 @@ func TestCallbackListenSuccess(test *testing.T) {
 		return nil
 	}
 
-	err := CallbackListen(testUrl, expectedCallbackFn)
+	err := CallbackListen(context.Background(), testUrl, expectedCallbackFn)
 
 	if err != nil {
 		test.Errorf("expected:\nno errors\nbut got\n%s", err)
@@ -620,6 +646,37 @@ func TestCallbackListenSuccess(test *testing.T) {
 	}
 }
 
+func TestCallbackListenStopsAfterTheCallback(test *testing.T) {
+	httpService.HandlerStubFn = func(string, func(w http.ResponseWriter, r *http.Request)) {}
+	httpService.ListenAndServeStubFn = func(s string, h http.Handler) error {
+		if err := callbackFn("code"); err != nil {
+			return err
+		}
+
+		return context.Canceled
+	}
+
+	err := CallbackListen(context.Background(), "/authorize", func(string) error { return nil })
+
+	if err != nil {
+		test.Errorf("expected:\nno errors\nbut got\n%s", err)
+	}
+}
+
+func TestCallbackListenWhenCancelled(test *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	httpService.HandlerStubFn = func(string, func(w http.ResponseWriter, r *http.Request)) {}
+	httpService.ListenAndServeStubFn = func(s string, h http.Handler) error { return nil }
+
+	err := CallbackListen(ctx, "/authorize", func(string) error { return nil })
+
+	if err != context.Canceled {
+		test.Errorf("expected:\n%s\nbut got\n%v", context.Canceled, err)
+	}
+}
+
 func TestResponderSuccess(test *testing.T) {
 	var calledCallbackFnWith string
 	code := "testCode"
@@ -703,6 +760,17 @@ func TestPrepareLoginUrl(test *testing.T) {
 	}
 }
 
+func TestSetAuthority(test *testing.T) {
+	defer SetBaseRequestUrl(baseRequestUrl)
+
+	SetAuthority("https://login.microsoftonline.us/", "organizations")
+
+	expected := "https://login.microsoftonline.us/organizations/oauth2/v2.0"
+	if baseRequestUrl != expected {
+		test.Errorf("\nexpected\n%s\nbut got\n%s", expected, baseRequestUrl)
+	}
+}
+
 //
 // // TODO: Create test helpers for common parts in different
 // // variants of tests for this function
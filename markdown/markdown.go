@@ -0,0 +1,193 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// The `markdown` package reads and writes tasks as Markdown checklists: a
+// `## Heading` per list, followed by `- [ ] task` (or `- [x] task` when done)
+// lines, with the steps of a task as checklist lines nested under it. The
+// rest of the document (e.g. meeting notes around the checklists) is
+// ignored.
+package markdown
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// How many spaces a tab stands for, when comparing indentations.
+const tabWidth int = 4
+
+var headingPattern *regexp.Regexp = regexp.MustCompile(`^(#{1,6})\s+(.*?)(\s+#+)?\s*$`)
+var checkboxPattern *regexp.Regexp = regexp.MustCompile(`^(\s*)(?:[-*+]|\d+[.)])\s+\[([ xX])\]\s+(.*)$`)
+
+// A list of tasks, named after its heading. The tasks before any heading
+// are in a list with no name.
+type List struct {
+	Name  string
+	Tasks []Task
+}
+
+// A task, along with its (nested) checklist items.
+type Task struct {
+	Title string
+	Done  bool
+	Items []Item
+}
+
+// A checklist item (step) of a task.
+type Item struct {
+	Title string
+	Done  bool
+}
+
+// Reads the checklists of a Markdown document. A level 2 heading starts a
+// list and a level 1 heading ends it, while the deeper ones are part of the
+// list they're in. A checklist line indented more than the task before it
+// is an item of that task.
+func Parse(r io.Reader) ([]List, error) {
+	lists := []List{{}}
+	scanner := bufio.NewScanner(r)
+	taskIndent := -1
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if match := headingPattern.FindStringSubmatch(line); match != nil {
+			switch len(match[1]) {
+			case 1:
+				lists = append(lists, List{})
+			case 2:
+				lists = append(lists, List{Name: match[2]})
+			}
+
+			taskIndent = -1
+			continue
+		}
+
+		match := checkboxPattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+
+		title := strings.TrimSpace(match[3])
+		if title == "" {
+			continue
+		}
+
+		done := match[2] != " "
+		indent := indentation(match[1])
+		list := &lists[len(lists)-1]
+
+		if taskIndent != -1 && indent > taskIndent {
+			task := &list.Tasks[len(list.Tasks)-1]
+			task.Items = append(task.Items, Item{Title: title, Done: done})
+			continue
+		}
+
+		list.Tasks = append(list.Tasks, Task{Title: title, Done: done})
+		taskIndent = indent
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return mergeLists(lists), nil
+}
+
+// Writes the lists as Markdown checklists, skipping the ones without
+// tasks.
+func Write(w io.Writer, lists []List) error {
+	separator := ""
+
+	for _, list := range lists {
+		if len(list.Tasks) == 0 {
+			continue
+		}
+
+		if _, err := fmt.Fprintf(w, "%s## %s\n\n", separator, singleLine(list.Name)); err != nil {
+			return err
+		}
+
+		for _, task := range list.Tasks {
+			if _, err := fmt.Fprintf(w, "- %s %s\n", checkbox(task.Done), singleLine(task.Title)); err != nil {
+				return err
+			}
+
+			for _, item := range task.Items {
+				if _, err := fmt.Fprintf(w, "  - %s %s\n", checkbox(item.Done), singleLine(item.Title)); err != nil {
+					return err
+				}
+			}
+		}
+
+		separator = "\n"
+	}
+
+	return nil
+}
+
+// Merges the lists with the same (case insensitive) name, keeping the
+// order they first appear in, and drops the ones without tasks.
+func mergeLists(lists []List) []List {
+	merged := []List{}
+	index := map[string]int{}
+
+	for _, list := range lists {
+		if len(list.Tasks) == 0 {
+			continue
+		}
+
+		key := strings.ToLower(list.Name)
+		if i, ok := index[key]; ok {
+			merged[i].Tasks = append(merged[i].Tasks, list.Tasks...)
+			continue
+		}
+
+		index[key] = len(merged)
+		merged = append(merged, list)
+	}
+
+	return merged
+}
+
+// Returns the width of the leading whitespace of a line.
+func indentation(whitespace string) int {
+	width := 0
+	for _, r := range whitespace {
+		if r == '\t' {
+			width += tabWidth
+		} else {
+			width++
+		}
+	}
+
+	return width
+}
+
+func checkbox(done bool) string {
+	if done {
+		return "[x]"
+	}
+
+	return "[ ]"
+}
+
+// Keeps a title to a single line, so that it doesn't break the checklist.
+func singleLine(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
@@ -0,0 +1,112 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package markdown
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const notes string = `# Weekly sync
+
+Attendees: everyone.
+
+- [ ] Loose task
+- not a task
+
+## Groceries
+
+- [ ] Buy milk
+  - [x] Whole
+	- [ ] Skimmed
+* [X] Eggs
+
+### Later
+
+1. [ ] Flour
+
+## Work ##
+
+- [x] Send the report
+
+## groceries
+
+- [ ] Bread
+`
+
+func TestParse(test *testing.T) {
+	lists, err := Parse(strings.NewReader(notes))
+	if err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	expected := []List{
+		{Tasks: []Task{{Title: "Loose task"}}},
+		{Name: "Groceries", Tasks: []Task{
+			{Title: "Buy milk", Items: []Item{{Title: "Whole", Done: true}, {Title: "Skimmed"}}},
+			{Title: "Eggs", Done: true},
+			{Title: "Flour"},
+			{Title: "Bread"},
+		}},
+		{Name: "Work", Tasks: []Task{{Title: "Send the report", Done: true}}},
+	}
+
+	if !reflect.DeepEqual(lists, expected) {
+		test.Errorf("\nExpected lists to be:\n%+v\nbut was\n%+v", expected, lists)
+	}
+}
+
+func TestNestedLineWithoutTask(test *testing.T) {
+	lists, _ := Parse(strings.NewReader("## List\n\n  - [ ] Indented\n    - [ ] Step\n"))
+
+	expected := []List{{Name: "List", Tasks: []Task{
+		{Title: "Indented", Items: []Item{{Title: "Step"}}},
+	}}}
+
+	if !reflect.DeepEqual(lists, expected) {
+		test.Errorf("\nExpected lists to be:\n%+v\nbut was\n%+v", expected, lists)
+	}
+}
+
+func TestWriteRoundTrip(test *testing.T) {
+	lists := []List{
+		{Name: "Groceries", Tasks: []Task{
+			{Title: "Buy\nmilk", Items: []Item{{Title: "Whole", Done: true}}},
+			{Title: "Eggs", Done: true},
+		}},
+		{Name: "Empty"},
+		{Name: "Work", Tasks: []Task{{Title: "Report"}}},
+	}
+
+	buffer := bytes.Buffer{}
+	if err := Write(&buffer, lists); err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	expected := "## Groceries\n\n- [ ] Buy milk\n  - [x] Whole\n- [x] Eggs\n\n## Work\n\n- [ ] Report\n"
+	written := buffer.String()
+
+	if written != expected {
+		test.Fatalf("\nExpected output to be:\n%s\nbut was\n%s", expected, written)
+	}
+
+	parsed, _ := Parse(strings.NewReader(written))
+	if len(parsed) != 2 || parsed[0].Tasks[0].Title != "Buy milk" || len(parsed[0].Tasks[0].Items) != 1 {
+		test.Errorf("\nExpected the output to be read back\nbut was\n%+v", parsed)
+	}
+}
@@ -0,0 +1,356 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The `shell` package is a small REPL: it reads lines (with editing, history
+// and tab completion when run in a terminal), splits them into arguments and
+// runs the command they name. The commands themselves are provided by the
+// app, keeping the package unaware of lists and tasks.
+package shell
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"github.com/betasve/mstd/ext/term"
+	"github.com/betasve/mstd/tui"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// The most lines kept in the history.
+const maxHistory int = 500
+
+// Returned when the user interrupts (Ctrl+C) the line being typed.
+var errInterrupted error = errors.New("Interrupted")
+
+// A command of the shell. `Complete`, when set, returns the candidates for
+// the completion of its (last) argument.
+type Command struct {
+	Name     string
+	Args     string
+	Help     string
+	Run      func(args []string) error
+	Complete func(args []string) []string
+}
+
+// Holds the state of a shell session.
+type Shell struct {
+	// Returns the prompt shown before each line.
+	Prompt func() string
+	// The file the history is kept in between sessions (none when empty).
+	HistoryPath string
+
+	commands map[string]*Command
+	names    []string
+	history  []string
+	in       *bufio.Reader
+	out      io.Writer
+	fd       int
+	done     bool
+}
+
+// Creates a shell with `commands`, reading from stdin and writing to stdout.
+// The `help` and `exit` commands are always available.
+func New(commands []*Command) *Shell {
+	s := &Shell{
+		Prompt:   func() string { return "> " },
+		commands: map[string]*Command{},
+		in:       bufio.NewReader(os.Stdin),
+		out:      os.Stdout,
+		fd:       int(os.Stdin.Fd()),
+	}
+
+	builtins := []*Command{
+		{Name: "help", Help: "Shows the available commands", Run: s.help},
+		{Name: "exit", Help: "Leaves the shell (as does Ctrl+D)", Run: s.exit},
+	}
+
+	for _, c := range append(commands, builtins...) {
+		s.commands[c.Name] = c
+		s.names = append(s.names, c.Name)
+	}
+
+	sort.Strings(s.names)
+
+	return s
+}
+
+// Reads and runs commands until the input ends or the user exits. Errors of
+// the commands are printed and don't end the session.
+func (s *Shell) Run() error {
+	s.loadHistory()
+
+	for !s.done {
+		line, err := s.readLine()
+		if err == errInterrupted {
+			continue
+		}
+
+		if err == io.EOF {
+			fmt.Fprintln(s.out)
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if err := s.Execute(line); err != nil {
+			fmt.Fprintf(s.out, "Error: %s\n", err)
+		}
+	}
+
+	return nil
+}
+
+// Runs a single line.
+func (s *Shell) Execute(line string) error {
+	args, err := SplitArgs(line)
+	if err != nil || len(args) == 0 {
+		return err
+	}
+
+	s.remember(line)
+
+	command, ok := s.commands[args[0]]
+	if !ok {
+		return fmt.Errorf("Unknown command %q, type `help` to see the available ones", args[0])
+	}
+
+	return command.Run(args[1:])
+}
+
+// Reads a line. In a terminal it's read in raw mode so it can be edited and
+// completed, otherwise it's read as it is (e.g. from a script piped in).
+func (s *Shell) readLine() (string, error) {
+	if !term.Client.IsTerminal(s.fd) {
+		line, err := s.in.ReadString('\n')
+		if err == io.EOF && line != "" {
+			err = nil
+		}
+
+		return strings.TrimRight(line, "\r\n"), err
+	}
+
+	state, err := term.Client.MakeRaw(s.fd)
+	if err != nil {
+		return "", err
+	}
+
+	defer func() { _ = term.Client.Restore(s.fd, state) }()
+
+	prompt := s.Prompt()
+	editor := tui.NewLineEditor("")
+	position := len(s.history)
+	draft := ""
+
+	for {
+		s.redraw(prompt, editor)
+
+		key, err := tui.ReadKey(s.in)
+		if err != nil {
+			return "", err
+		}
+
+		switch key.Kind {
+		case tui.KeyEnter:
+			fmt.Fprint(s.out, "\r\n")
+			return editor.Text(), nil
+		case tui.KeyCtrlC:
+			fmt.Fprint(s.out, "^C\r\n")
+			return "", errInterrupted
+		case tui.KeyCtrlD:
+			if editor.Text() == "" {
+				return "", io.EOF
+			}
+			editor.HandleKey(tui.Key{Kind: tui.KeyDelete})
+		case tui.KeyUp:
+			if position == len(s.history) {
+				draft = editor.Text()
+			}
+			if position > 0 {
+				position--
+				editor.SetText(s.history[position])
+			}
+		case tui.KeyDown:
+			if position < len(s.history) {
+				position++
+				if position == len(s.history) {
+					editor.SetText(draft)
+				} else {
+					editor.SetText(s.history[position])
+				}
+			}
+		case tui.KeyTab:
+			if candidates := s.complete(editor); len(candidates) > 1 {
+				fmt.Fprintf(s.out, "\r\n%s\r\n", strings.Join(candidates, "  "))
+			}
+		default:
+			editor.HandleKey(key)
+		}
+	}
+}
+
+// Redraws the line being edited, placing the cursor where it is in the text.
+func (s *Shell) redraw(prompt string, editor *tui.LineEditor) {
+	fmt.Fprintf(s.out, "\r\033[K%s%s", prompt, editor.Text())
+
+	if back := len([]rune(editor.Text())) - editor.Cursor(); back > 0 {
+		fmt.Fprintf(s.out, "\033[%dD", back)
+	}
+}
+
+// Completes the word at the end of the line: a command name for the first
+// word, or one of the candidates of the command for the rest. The word is
+// extended as much as the candidates agree on, and when they don't (there
+// are more of them) they are returned to be shown.
+func (s *Shell) complete(editor *tui.LineEditor) []string {
+	if editor.Cursor() != len([]rune(editor.Text())) {
+		return nil
+	}
+
+	line := editor.Text()
+	args, err := SplitArgs(line)
+	if err != nil {
+		return nil
+	}
+
+	if line == "" || strings.HasSuffix(line, " ") {
+		args = append(args, "")
+	}
+
+	word := args[len(args)-1]
+	candidates := s.Candidates(args)
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	completed := commonPrefix(candidates)
+	if len(candidates) == 1 {
+		completed = Quote(completed) + " "
+	} else if strings.ContainsAny(completed, " \"'") {
+		return candidates
+	}
+
+	if len(completed) > len(word) || len(candidates) == 1 {
+		editor.SetText(line[:len(line)-len(Quote(word))] + completed)
+	}
+
+	return candidates
+}
+
+// Returns the completion candidates for the last of `args`, matching it as
+// a (case insensitive) prefix.
+func (s *Shell) Candidates(args []string) []string {
+	var options []string
+
+	if len(args) == 1 {
+		options = s.names
+	} else if c, ok := s.commands[args[0]]; ok && c.Complete != nil {
+		options = c.Complete(args[1:])
+	}
+
+	word := strings.ToLower(args[len(args)-1])
+	candidates := []string{}
+	for _, o := range options {
+		if strings.HasPrefix(strings.ToLower(o), word) {
+			candidates = append(candidates, o)
+		}
+	}
+
+	return candidates
+}
+
+// Prints the available commands.
+func (s *Shell) help(args []string) error {
+	for _, name := range s.names {
+		c := s.commands[name]
+		fmt.Fprintf(s.out, "  %-28s %s\n", strings.TrimSpace(c.Name+" "+c.Args), c.Help)
+	}
+
+	return nil
+}
+
+// Ends the session.
+func (s *Shell) exit(args []string) error {
+	s.done = true
+	return nil
+}
+
+// Adds a line to the history (unless it repeats the previous one) and
+// appends it to the history file.
+func (s *Shell) remember(line string) {
+	if len(s.history) > 0 && s.history[len(s.history)-1] == line {
+		return
+	}
+
+	s.history = append(s.history, line)
+	if len(s.history) > maxHistory {
+		s.history = s.history[len(s.history)-maxHistory:]
+	}
+
+	if s.HistoryPath == "" {
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.HistoryPath), 0700); err != nil {
+		return
+	}
+
+	file, err := os.OpenFile(s.HistoryPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+
+	defer file.Close()
+	fmt.Fprintln(file, line)
+}
+
+// Loads the last lines of the history file, if there's one.
+func (s *Shell) loadHistory() {
+	if s.HistoryPath == "" {
+		return
+	}
+
+	data, err := ioutil.ReadFile(s.HistoryPath)
+	if err != nil {
+		return
+	}
+
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if len(lines) > maxHistory {
+		lines = lines[len(lines)-maxHistory:]
+	}
+
+	s.history = lines
+}
+
+// Returns the longest prefix shared by all the words.
+func commonPrefix(words []string) string {
+	prefix := words[0]
+
+	for _, w := range words[1:] {
+		for !strings.HasPrefix(w, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+
+	return prefix
+}
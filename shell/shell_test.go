@@ -0,0 +1,147 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package shell
+
+import (
+	"bufio"
+	"bytes"
+	"github.com/betasve/mstd/ext/term"
+	"github.com/betasve/mstd/ext/term/termtest"
+	"github.com/betasve/mstd/tui"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func init() {
+	term.Client = termtest.TermMock{}
+}
+
+func newTestShell(input string, ran *[]string) (*Shell, *bytes.Buffer) {
+	commands := []*Command{
+		{
+			Name: "use",
+			Run: func(args []string) error {
+				*ran = append(*ran, "use "+strings.Join(args, "|"))
+				return nil
+			},
+			Complete: func(args []string) []string {
+				return []string{"Groceries", "Groceries Extra", "Work Stuff"}
+			},
+		},
+		{Name: "add", Run: func(args []string) error {
+			*ran = append(*ran, "add "+strings.Join(args, "|"))
+			return nil
+		}},
+	}
+
+	out := &bytes.Buffer{}
+	s := New(commands)
+	s.in = bufio.NewReader(strings.NewReader(input))
+	s.out = out
+
+	return s, out
+}
+
+func TestSplitArgs(test *testing.T) {
+	cases := map[string][]string{
+		`add milk`:              {"add", "milk"},
+		`  use "Work Stuff"  `:  {"use", "Work Stuff"},
+		`add it\'s 'a "quote"'`: {"add", "it's", `a "quote"`},
+		`add ""`:                {"add", ""},
+	}
+
+	for line, expected := range cases {
+		if result, err := SplitArgs(line); err != nil || !reflect.DeepEqual(result, expected) {
+			test.Errorf("\nExpected %q to be split to:\n%q\nbut was\n%q, %v", line, expected, result, err)
+		}
+	}
+
+	if _, err := SplitArgs(`use "Work`); err == nil {
+		test.Error("\nExpected an unterminated quote to return error\nbut it was\nnil")
+	}
+
+	if args, _ := SplitArgs(Quote(`a "b" c`)); args[0] != `a "b" c` {
+		test.Errorf("\nExpected a quoted argument to be read back\nbut was\n%q", args)
+	}
+}
+
+func TestRunFromPipedInput(test *testing.T) {
+	termtest.IsTerminalMockFn = func(fd int) bool { return false }
+
+	ran := []string{}
+	s, out := newTestShell("use \"Work Stuff\"\nbogus\n\nadd milk\nexit\nadd never\n", &ran)
+	s.HistoryPath = filepath.Join(test.TempDir(), "history")
+
+	if err := s.Run(); err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	if !reflect.DeepEqual(ran, []string{"use Work Stuff", "add milk"}) {
+		test.Errorf("\nExpected the commands before exit to run\nbut ran\n%q", ran)
+	}
+
+	if !strings.Contains(out.String(), `Unknown command "bogus"`) {
+		test.Errorf("\nExpected an unknown command error\nbut the output was\n%s", out.String())
+	}
+
+	again, _ := newTestShell("", &ran)
+	again.HistoryPath = s.HistoryPath
+	again.loadHistory()
+
+	if len(again.history) != 4 || again.history[3] != "exit" {
+		test.Errorf("\nExpected the history to be kept\nbut it was\n%q", again.history)
+	}
+}
+
+func TestComplete(test *testing.T) {
+	ran := []string{}
+	s, _ := newTestShell("", &ran)
+
+	cases := map[string]string{
+		"u":          "use ",
+		"use gro":    "use Groceries",
+		"use Wo":     `use "Work Stuff" `,
+		"use nobody": "use nobody",
+	}
+
+	for line, expected := range cases {
+		editor := tui.NewLineEditor(line)
+		s.complete(editor)
+
+		if editor.Text() != expected {
+			test.Errorf("\nExpected %q to be completed to:\n%q\nbut was\n%q", line, expected, editor.Text())
+		}
+	}
+}
+
+func TestReadLineWithHistory(test *testing.T) {
+	termtest.IsTerminalMockFn = func(fd int) bool { return true }
+
+	ran := []string{}
+	s, _ := newTestShell("\x1b[A\x1b[A\x1b[B\x7fs\r", &ran)
+	s.history = []string{"add milk", "add eggs"}
+
+	line, err := s.readLine()
+	if err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	if line != "add eggs" {
+		test.Errorf("\nExpected line to be:\nadd eggs\nbut was\n%s", line)
+	}
+}
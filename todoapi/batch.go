@@ -0,0 +1,393 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package todoapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	tm "github.com/betasve/mstd/ext/time"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// The most requests the API accepts in a single batch.
+const MaxBatchSize int = 20
+
+// How many times the throttled requests of a batch are sent again.
+const maxBatchRetries int = 5
+
+// How long to wait before sending the throttled requests again, when the API
+// doesn't say. It's doubled on each retry.
+const batchRetryWait time.Duration = 2 * time.Second
+
+// A request in a batch. Its `Url` is relative to the API root (e.g.
+// `/me/todo/lists`) and its `Id` unique in the batch. A request runs only
+// after the ones it `DependsOn` have succeeded.
+type BatchRequest struct {
+	Id        string            `json:"id"`
+	Method    string            `json:"method"`
+	Url       string            `json:"url"`
+	Body      interface{}       `json:"body,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	DependsOn []string          `json:"dependsOn,omitempty"`
+}
+
+// The response to a request in a batch.
+type BatchResponse struct {
+	Id      string            `json:"id"`
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+}
+
+type batchPayload struct {
+	Requests []BatchRequest `json:"requests"`
+}
+
+type batchResult struct {
+	Responses []BatchResponse `json:"responses"`
+}
+
+// Builds the request that creates a task in a batch.
+func NewTaskCreateRequest(id, listId string, task *TaskItem) BatchRequest {
+	return BatchRequest{
+		Id:     id,
+		Method: "POST",
+		Url:    relativeUrl(listsIndexEndpoint + listId + tasksPath),
+		Body:   task,
+	}
+}
+
+// Builds the request that updates a task in a batch.
+func NewTaskUpdateRequest(id, listId, taskId string, task *TaskItem) BatchRequest {
+	return BatchRequest{
+		Id:     id,
+		Method: "PATCH",
+		Url:    relativeUrl(listsIndexEndpoint + listId + tasksPath + taskId),
+		Body:   task,
+	}
+}
+
+// Builds the request that deletes a task in a batch.
+func NewTaskDeleteRequest(id, listId, taskId string) BatchRequest {
+	return BatchRequest{
+		Id:     id,
+		Method: "DELETE",
+		Url:    relativeUrl(listsIndexEndpoint + listId + tasksPath + taskId),
+	}
+}
+
+// Returns an ApiError when the response doesn't have the `expectedStatus`.
+func (r *BatchResponse) Check(expectedStatus int) error {
+	if r.Status == expectedStatus {
+		return nil
+	}
+
+	return &ApiError{
+		StatusCode: r.Status,
+		Body:       string(r.Body),
+		RetryAfter: parseRetryAfter(r.header("Retry-After")),
+	}
+}
+
+// Unmarshals the body of the response into `v`.
+func (r *BatchResponse) Decode(v interface{}) error {
+	return json.Unmarshal(r.Body, v)
+}
+
+// Returns the task in the body of the response, or an ApiError when the
+// response doesn't have the `expectedStatus`.
+func (r *BatchResponse) Task(expectedStatus int) (*TaskItem, error) {
+	if err := r.Check(expectedStatus); err != nil {
+		return nil, err
+	}
+
+	return unmarshalTask(r.Body)
+}
+
+// Looks up a header of the response, ignoring the case of its name.
+func (r *BatchResponse) header(name string) string {
+	for key, value := range r.Headers {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+
+	return ""
+}
+
+// Runs the requests through as few batches as possible and returns their
+// responses by request id. The requests depending on each other are kept in
+// the same batch. The throttled ones (and the ones failed because they
+// depend on those) are sent again, after the wait the API asks for. An error
+// is returned only when a batch can't be sent at all, along with the
+// responses received until then.
+func (ta *TodoApi) Batch(ctx context.Context, requests []BatchRequest) (map[string]*BatchResponse, error) {
+	return sendBatches(ctx, ta.token, requests)
+}
+
+// The function that is responsible for splitting the requests into batches,
+// sending them and retrying the throttled ones.
+func sendBatches(ctx context.Context, token string, requests []BatchRequest) (map[string]*BatchResponse, error) {
+	if err := validateBatch(requests); err != nil {
+		return nil, err
+	}
+
+	responses := map[string]*BatchResponse{}
+	pending := requests
+	wait := batchRetryWait
+
+	for retries := 0; len(pending) > 0; retries++ {
+		chunks, err := packBatches(pending)
+		if err != nil {
+			return responses, err
+		}
+
+		retry := map[string]bool{}
+		var retryAfter time.Duration
+
+		for _, chunk := range chunks {
+			result, err := sendBatch(ctx, token, chunk)
+
+			var apiErr *ApiError
+			if errors.As(err, &apiErr) && apiErr.Throttled() && retries < maxBatchRetries {
+				for _, r := range chunk {
+					retry[r.Id] = true
+				}
+				retryAfter = maxDuration(retryAfter, apiErr.RetryAfter)
+				continue
+			}
+
+			if err != nil {
+				return responses, err
+			}
+
+			for i := range result {
+				response := &result[i]
+				throttled := response.Status == http.StatusTooManyRequests ||
+					response.Status == http.StatusServiceUnavailable
+
+				if throttled && retries < maxBatchRetries {
+					retry[response.Id] = true
+					retryAfter = maxDuration(retryAfter, parseRetryAfter(response.header("Retry-After")))
+					continue
+				}
+
+				responses[response.Id] = response
+			}
+		}
+
+		pending = requeueBatch(requests, responses, retry, retries < maxBatchRetries)
+		if len(pending) == 0 {
+			break
+		}
+
+		if retryAfter == 0 {
+			retryAfter = wait
+			wait *= 2
+		}
+
+		if err := tm.Client.Sleep(ctx, retryAfter); err != nil {
+			return responses, err
+		}
+	}
+
+	return responses, nil
+}
+
+// Sends a single batch (of up to MaxBatchSize requests).
+func sendBatch(ctx context.Context, token string, requests []BatchRequest) ([]BatchResponse, error) {
+	for i, r := range requests {
+		if r.Body != nil && r.Headers == nil {
+			requests[i].Headers = map[string]string{"Content-Type": string(jsonCT)}
+		}
+	}
+
+	body, err := sendApiRequest(ctx, "POST", batchEndpoint, token, batchPayload{requests}, 200)
+	if err != nil {
+		return nil, err
+	}
+
+	result := batchResult{}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, err
+	}
+
+	return result.Responses, nil
+}
+
+// Returns the requests to send again: the throttled ones and the ones that
+// failed because a request they depend on was throttled (or, when it's not
+// `retrying` anymore, none). The dependencies that already succeeded are
+// dropped from them, as they can't be in the next batch.
+func requeueBatch(
+	requests []BatchRequest,
+	responses map[string]*BatchResponse,
+	retry map[string]bool,
+	retrying bool,
+) []BatchRequest {
+	if !retrying {
+		return nil
+	}
+
+	// A failed dependency may itself depend on a throttled request, so keep
+	// going until nothing more is added.
+	for added := true; added; {
+		added = false
+		for _, r := range requests {
+			response, ok := responses[r.Id]
+			if retry[r.Id] || !ok || response.Status != http.StatusFailedDependency {
+				continue
+			}
+
+			for _, dependency := range r.DependsOn {
+				if retry[dependency] {
+					retry[r.Id] = true
+					delete(responses, r.Id)
+					added = true
+					break
+				}
+			}
+		}
+	}
+
+	pending := []BatchRequest{}
+	for _, r := range requests {
+		if !retry[r.Id] {
+			continue
+		}
+
+		dependsOn := []string{}
+		for _, dependency := range r.DependsOn {
+			if retry[dependency] {
+				dependsOn = append(dependsOn, dependency)
+			}
+		}
+
+		r.DependsOn = dependsOn
+		pending = append(pending, r)
+	}
+
+	return pending
+}
+
+// Splits the requests into batches of up to MaxBatchSize, keeping the ones
+// that depend on each other (directly or not) in the same batch and in
+// their order.
+func packBatches(requests []BatchRequest) ([][]BatchRequest, error) {
+	group := map[string]string{}
+	var root func(id string) string
+	root = func(id string) string {
+		if group[id] == id {
+			return id
+		}
+		group[id] = root(group[id])
+		return group[id]
+	}
+
+	for _, r := range requests {
+		group[r.Id] = r.Id
+	}
+
+	for _, r := range requests {
+		for _, dependency := range r.DependsOn {
+			group[root(r.Id)] = root(dependency)
+		}
+	}
+
+	order := []string{}
+	groups := map[string][]BatchRequest{}
+	for _, r := range requests {
+		id := root(r.Id)
+		if _, ok := groups[id]; !ok {
+			order = append(order, id)
+		}
+		groups[id] = append(groups[id], r)
+	}
+
+	chunks := [][]BatchRequest{}
+	chunk := []BatchRequest{}
+	for _, id := range order {
+		g := groups[id]
+		if len(g) > MaxBatchSize {
+			return nil, fmt.Errorf(
+				"%d requests depend on each other, but a batch can't hold more than %d",
+				len(g),
+				MaxBatchSize,
+			)
+		}
+
+		if len(chunk)+len(g) > MaxBatchSize {
+			chunks = append(chunks, chunk)
+			chunk = []BatchRequest{}
+		}
+
+		chunk = append(chunk, g...)
+	}
+
+	if len(chunk) > 0 {
+		chunks = append(chunks, chunk)
+	}
+
+	return chunks, nil
+}
+
+// Checks that the ids of the requests are set and unique, and that they
+// depend only on requests that come before them.
+func validateBatch(requests []BatchRequest) error {
+	seen := map[string]bool{}
+
+	for _, r := range requests {
+		if r.Id == "" {
+			return fmt.Errorf("A batch request to %s has no id", r.Url)
+		}
+
+		if seen[r.Id] {
+			return fmt.Errorf("The id %q is used by more than one batch request", r.Id)
+		}
+
+		for _, dependency := range r.DependsOn {
+			if !seen[dependency] {
+				return fmt.Errorf(
+					"Batch request %q depends on %q, which isn't before it",
+					r.Id,
+					dependency,
+				)
+			}
+		}
+
+		seen[r.Id] = true
+	}
+
+	return nil
+}
+
+// Turns an endpoint of the API into a url relative to its root, as the
+// requests in a batch expect.
+func relativeUrl(url string) string {
+	return strings.TrimPrefix(url, apiRoot)
+}
+
+func maxDuration(a, b time.Duration) time.Duration {
+	if a > b {
+		return a
+	}
+
+	return b
+}
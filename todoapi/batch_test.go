@@ -0,0 +1,201 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package todoapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	httpService "github.com/betasve/mstd/ext/http/httptest"
+	tm "github.com/betasve/mstd/ext/time"
+	"github.com/betasve/mstd/ext/time/timetest"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Stubs the $batch endpoint with `respond`, which gets the requests of each
+// batch sent (in order) and returns the status of each, by id.
+func stubBatchEndpoint(test *testing.T, respond func(round int, requests []BatchRequest) map[string]int) *[][]BatchRequest {
+	httpService.NewRequestStubFn = http.NewRequest
+	sent := [][]BatchRequest{}
+
+	httpService.MockFn = func(req *http.Request) (*http.Response, error) {
+		if req.Method != "POST" || req.URL.Path != "/v1.0/$batch" {
+			test.Errorf("\nExpected a POST to $batch\nbut was\n%s %s", req.Method, req.URL)
+		}
+
+		payload := struct {
+			Requests []BatchRequest `json:"requests"`
+		}{}
+		body, _ := ioutil.ReadAll(req.Body)
+		_ = json.Unmarshal(body, &payload)
+
+		sent = append(sent, payload.Requests)
+		statuses := respond(len(sent), payload.Requests)
+
+		responses := []string{}
+		for id, status := range statuses {
+			responses = append(responses, fmt.Sprintf(
+				`{"id": %q, "status": %d, "headers": {"retry-after": "3"}, "body": {"id": "new-%s"}}`,
+				id, status, id,
+			))
+		}
+
+		res := &http.Response{StatusCode: 200}
+		res.Body = ioutil.NopCloser(strings.NewReader(
+			`{"responses": [` + strings.Join(responses, ",") + `]}`,
+		))
+		return res, nil
+	}
+
+	return &sent
+}
+
+func stubBatchSleeps() *[]time.Duration {
+	tm.Client = timetest.TimeMock{}
+	sleeps := []time.Duration{}
+	timetest.TimeSleepMockFunc = func(d time.Duration) {
+		sleeps = append(sleeps, d)
+	}
+
+	return &sleeps
+}
+
+func TestBatchSplitsRequests(test *testing.T) {
+	defer func() { tm.Client = tm.Time{} }()
+	stubBatchSleeps()
+
+	sent := stubBatchEndpoint(test, func(round int, requests []BatchRequest) map[string]int {
+		statuses := map[string]int{}
+		for _, r := range requests {
+			statuses[r.Id] = 201
+		}
+		return statuses
+	})
+
+	requests := []BatchRequest{}
+	for i := 0; i < 25; i++ {
+		requests = append(requests, NewTaskCreateRequest(fmt.Sprint(i), "list-id", &TaskItem{Title: "Task"}))
+	}
+
+	// The 18th, 23rd and 24th requests go together, which doesn't fit in the
+	// first batch after the 18 before them.
+	requests[23].DependsOn = []string{"18"}
+	requests[24].DependsOn = []string{"23"}
+
+	api := TodoApi{}
+	responses, err := api.Batch(context.Background(), requests)
+	if err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	if len(*sent) != 2 || len((*sent)[0]) != 18 || len((*sent)[1]) != 7 {
+		test.Errorf("\nExpected batches of 18 and 7\nbut were\n%d", len(*sent))
+	}
+
+	first := (*sent)[0][0]
+	if first.Url != "/me/todo/lists/list-id/tasks/" || first.Headers["Content-Type"] != "application/json" {
+		test.Errorf("\nExpected a relative url with a JSON body\nbut was\n%+v", first)
+	}
+
+	task, err := responses["23"].Task(201)
+	if len(responses) != 25 || err != nil || task.Id != "new-23" {
+		test.Errorf("\nExpected the responses of all the requests\nbut were\n%d, %v", len(responses), err)
+	}
+}
+
+func TestBatchRetriesThrottledRequests(test *testing.T) {
+	defer func() { tm.Client = tm.Time{} }()
+	sleeps := stubBatchSleeps()
+
+	sent := stubBatchEndpoint(test, func(round int, requests []BatchRequest) map[string]int {
+		if round == 1 {
+			return map[string]int{"a": 201, "b": 429, "c": 424, "d": 400}
+		}
+		return map[string]int{"b": 201, "c": 200}
+	})
+
+	requests := []BatchRequest{
+		NewTaskCreateRequest("a", "list-id", &TaskItem{Title: "A"}),
+		NewTaskCreateRequest("b", "list-id", &TaskItem{Title: "B"}),
+		NewTaskUpdateRequest("c", "list-id", "t", &TaskItem{Title: "C"}),
+		NewTaskDeleteRequest("d", "list-id", "t"),
+	}
+	requests[2].DependsOn = []string{"a", "b"}
+
+	api := TodoApi{}
+	responses, err := api.Batch(context.Background(), requests)
+	if err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	if len(*sent) != 2 || len((*sent)[1]) != 2 || !reflect.DeepEqual((*sent)[1][1].DependsOn, []string{"b"}) {
+		test.Errorf("\nExpected the throttled request and its dependent to be sent again\nbut were\n%+v", *sent)
+	}
+
+	if !reflect.DeepEqual(*sleeps, []time.Duration{3 * time.Second}) {
+		test.Errorf("\nExpected to wait as asked\nbut waited\n%v", *sleeps)
+	}
+
+	if responses["b"].Status != 201 || responses["c"].Status != 200 || responses["d"].Check(204) == nil {
+		test.Errorf("\nExpected the final statuses\nbut were\n%+v", responses)
+	}
+}
+
+func TestBatchGivesUpOnThrottling(test *testing.T) {
+	defer func() { tm.Client = tm.Time{} }()
+	sleeps := stubBatchSleeps()
+
+	stubBatchEndpoint(test, func(round int, requests []BatchRequest) map[string]int {
+		return map[string]int{"a": 429}
+	})
+
+	api := TodoApi{}
+	responses, err := api.Batch(context.Background(), []BatchRequest{NewTaskDeleteRequest("a", "list-id", "t")})
+	if err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	apiErr, ok := responses["a"].Check(204).(*ApiError)
+	if len(*sleeps) != maxBatchRetries || !ok || !apiErr.Throttled() || apiErr.RetryAfter != 3*time.Second {
+		test.Errorf("\nExpected the throttled response after %d retries\nbut got\n%+v", maxBatchRetries, responses["a"])
+	}
+}
+
+func TestBatchValidation(test *testing.T) {
+	cases := [][]BatchRequest{
+		{{Method: "GET", Url: "/me"}},
+		{{Id: "1"}, {Id: "1"}},
+		{{Id: "1", DependsOn: []string{"2"}}, {Id: "2"}},
+	}
+
+	chain := []BatchRequest{{Id: "0"}}
+	for i := 1; i <= MaxBatchSize; i++ {
+		chain = append(chain, BatchRequest{Id: fmt.Sprint(i), DependsOn: []string{fmt.Sprint(i - 1)}})
+	}
+	cases = append(cases, chain)
+
+	api := TodoApi{}
+	for _, requests := range cases {
+		if _, err := api.Batch(context.Background(), requests); err == nil {
+			test.Errorf("\nExpected %+v to return error\nbut it was\nnil", requests)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package todoapi
+
+import (
+	extHttp "github.com/betasve/mstd/ext/http"
+	"github.com/betasve/mstd/fakegraph"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+// Set to record the cassettes again (instead of replaying them). The requests
+// are sent to Graph with the access token in MSTD_TOKEN:
+//
+//	MSTD_RECORD=1 MSTD_TOKEN=... go test ./todoapi -run TestListsIndex
+//
+// Without a token, each test is recorded against a fresh fakegraph.Server
+// (holding only the default list), so no test sees what another left.
+const recordEnv string = "MSTD_RECORD"
+
+// The values the tests make up (or find in any account), recorded as they
+// are so they can be checked on replay.
+var cassetteKeep = []string{"Tasks", "Groceries"}
+
+// Makes the requests of a test replay its cassette (see recordEnv), which is
+// named after the test. Returns the token to send them with.
+func useCassette(test *testing.T) string {
+	opts := extHttp.Options{
+		Cassette:     filepath.Join("testdata", "cassettes", test.Name()+".json"),
+		CassetteKeep: cassetteKeep,
+	}
+	token := "token"
+	var fake *httptest.Server
+
+	if os.Getenv(recordEnv) != "" {
+		opts.CassetteMode = extHttp.CassetteRecord
+
+		if token = os.Getenv("MSTD_TOKEN"); token == "" {
+			token = "token"
+			fake = httptest.NewServer(fakegraph.New())
+			SetApiRoot(fake.URL + fakegraph.ApiPath)
+			opts.Scrubbers = []extHttp.Scrubber{
+				{Pattern: regexp.MustCompile(regexp.QuoteMeta(fake.URL)), Replacement: "http://fakegraph"},
+			}
+		}
+	}
+
+	client, err := extHttp.New(opts)
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	previous := httpClient
+	httpClient = client
+
+	test.Cleanup(func() {
+		if fake != nil {
+			fake.Close()
+		}
+
+		httpClient = previous
+		SetApiRoot(defaultApiRoot)
+	})
+
+	return token
+}
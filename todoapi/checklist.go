@@ -0,0 +1,95 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package todoapi
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// The path (relative to a task) of the checklist items' endpoints.
+const checklistItemsPath string = "/checklistItems/"
+
+type ChecklistItem struct {
+	Id              string `json:"id,omitempty"`
+	DisplayName     string `json:"displayName,omitempty"`
+	IsChecked       bool   `json:"isChecked"`
+	CreatedDateTime string `json:"createdDateTime,omitempty"`
+	CheckedDateTime string `json:"checkedDateTime,omitempty"`
+}
+
+type ChecklistItemsResponse struct {
+	Context string          `json:"@odata.context"`
+	Items   []ChecklistItem `json:"value"`
+}
+
+// Retrieves the checklist items (steps) of a task.
+func (ta *TodoApi) ChecklistItemsIndex(ctx context.Context, listId, taskId string) (*[]ChecklistItem, error) {
+	return retrieveChecklistItems(ctx, ta.token, listId, taskId)
+}
+
+// Adds a checklist item (step) to a task.
+func (ta *TodoApi) ChecklistItemsCreate(ctx context.Context, listId, taskId string, item *ChecklistItem) (*ChecklistItem, error) {
+	return createAChecklistItem(ctx, ta.token, listId, taskId, item)
+}
+
+// The function that is responsible for building the HTTP request and handling
+// the response of the 'List checklistItems' API endpoint.
+func retrieveChecklistItems(ctx context.Context, token, listId, taskId string) (*[]ChecklistItem, error) {
+	body, err := sendApiRequest(
+		ctx,
+		"GET",
+		listsIndexEndpoint+listId+tasksPath+taskId+checklistItemsPath,
+		token,
+		nil,
+		200,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	response := ChecklistItemsResponse{}
+	if err = json.Unmarshal(body, &response); err != nil {
+		return nil, err
+	}
+
+	return &response.Items, nil
+}
+
+// The function that is responsible for building the HTTP request and handling
+// the response of the 'Create checklistItem' API endpoint.
+func createAChecklistItem(ctx context.Context, token, listId, taskId string, item *ChecklistItem) (*ChecklistItem, error) {
+	body, err := sendApiRequest(
+		ctx,
+		"POST",
+		listsIndexEndpoint+listId+tasksPath+taskId+checklistItemsPath,
+		token,
+		item,
+		201,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	created := ChecklistItem{}
+	if err := json.Unmarshal(body, &created); err != nil {
+		return nil, err
+	}
+
+	return &created, nil
+}
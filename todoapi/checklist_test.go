@@ -0,0 +1,88 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package todoapi
+
+import (
+	"context"
+	"encoding/json"
+	httpService "github.com/betasve/mstd/ext/http/httptest"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestChecklistItemsIndex(test *testing.T) {
+	httpService.NewRequestStubFn = http.NewRequest
+	api := TodoApi{}
+	api.SetToken("token")
+
+	var path string
+	httpService.MockFn = func(req *http.Request) (*http.Response, error) {
+		path = req.URL.Path
+		res := &http.Response{StatusCode: 200}
+		res.Body = ioutil.NopCloser(strings.NewReader(
+			`{ "value": [{ "id": "c1", "displayName": "Eggs", "isChecked": true }] }`,
+		))
+		return res, nil
+	}
+
+	items, err := api.ChecklistItemsIndex(context.Background(), "list-id", "task-id")
+
+	if err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	if path != "/v1.0/me/todo/lists/list-id/tasks/task-id/checklistItems/" {
+		test.Errorf("\nExpected the checklist items endpoint\nbut was\n%s", path)
+	}
+
+	if len(*items) != 1 || (*items)[0].DisplayName != "Eggs" || !(*items)[0].IsChecked {
+		test.Errorf("\nExpected a checked item Eggs\nbut got\n%+v", *items)
+	}
+}
+
+func TestChecklistItemsCreate(test *testing.T) {
+	httpService.NewRequestStubFn = http.NewRequest
+	api := TodoApi{}
+	api.SetToken("token")
+
+	var sent map[string]interface{}
+	httpService.MockFn = func(req *http.Request) (*http.Response, error) {
+		body, _ := ioutil.ReadAll(req.Body)
+		_ = json.Unmarshal(body, &sent)
+
+		res := &http.Response{StatusCode: 201}
+		res.Body = ioutil.NopCloser(strings.NewReader(
+			`{ "id": "c1", "displayName": "Eggs", "isChecked": false }`,
+		))
+		return res, nil
+	}
+
+	item, err := api.ChecklistItemsCreate(context.Background(), "list-id", "task-id", &ChecklistItem{DisplayName: "Eggs"})
+
+	if err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	if sent["displayName"] != "Eggs" || sent["isChecked"] != false {
+		test.Errorf("\nExpected the item to be sent\nbut was\n%v", sent)
+	}
+
+	if item.Id != "c1" {
+		test.Errorf("\nExpected id to be:\nc1\nbut was\n%s", item.Id)
+	}
+}
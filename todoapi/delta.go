@@ -0,0 +1,271 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package todoapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+)
+
+// The path (relative to a collection) of the delta endpoints.
+const deltaPath string = "delta"
+
+// The key the delta state of the lists is kept under.
+const ListsDeltaKey string = "lists"
+
+// Holds what's needed to ask the API for the next changes of a collection:
+// the `@odata.deltaLink` of the last round and the ids known so far (used to
+// tell the added items from the updated ones).
+type DeltaState struct {
+	Link string   `json:"link"`
+	Ids  []string `json:"ids"`
+}
+
+// Persists the DeltaStates between runs of the app.
+type DeltaStore interface {
+	LoadDelta(key string) (*DeltaState, error)
+	SaveDelta(key string, state *DeltaState) error
+}
+
+// The changes of the lists since the last delta query. When `Full` is set
+// the query started from scratch, so `Added` holds all the lists there are.
+type ListsDelta struct {
+	Added   []ListsItem
+	Updated []ListsItem
+	Removed []string
+	Full    bool
+}
+
+// The changes of the tasks in a list since the last delta query. When `Full`
+// is set the query started from scratch, so `Added` holds all the tasks
+// there are.
+type TasksDelta struct {
+	Added   []TaskItem
+	Updated []TaskItem
+	Removed []string
+	Full    bool
+}
+
+type deltaResponse struct {
+	NextLink  string            `json:"@odata.nextLink"`
+	DeltaLink string            `json:"@odata.deltaLink"`
+	Value     []json.RawMessage `json:"value"`
+}
+
+// The attributes of a delta item needed to tell if it was removed.
+type deltaItem struct {
+	Id      string           `json:"id"`
+	Removed *json.RawMessage `json:"@removed"`
+}
+
+// The raw changes of a collection, before they are unmarshalled to their
+// specific items.
+type rawDelta struct {
+	changed []json.RawMessage
+	ids     []string
+	added   map[string]bool
+	removed []string
+	full    bool
+}
+
+// Returns the key the delta state of the tasks of a list is kept under.
+func TasksDeltaKey(listId string) string {
+	return "tasks/" + listId
+}
+
+// Sets the store the delta states are persisted in. Without one, every
+// delta query starts from scratch.
+func (ta *TodoApi) SetDeltaStore(store DeltaStore) {
+	ta.deltaStore = store
+}
+
+// Retrieves the lists that changed since the last call.
+func (ta *TodoApi) ListsDelta(ctx context.Context) (*ListsDelta, error) {
+	raw, err := retrieveDelta(
+		ctx,
+		ta.token,
+		ta.deltaStore,
+		ListsDeltaKey,
+		listsIndexEndpoint+deltaPath,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	delta := &ListsDelta{Removed: raw.removed, Full: raw.full}
+	for i, item := range raw.changed {
+		list := ListsItem{}
+		if err := json.Unmarshal(item, &list); err != nil {
+			return nil, err
+		}
+
+		if raw.added[raw.ids[i]] {
+			delta.Added = append(delta.Added, list)
+		} else {
+			delta.Updated = append(delta.Updated, list)
+		}
+	}
+
+	return delta, nil
+}
+
+// Retrieves the tasks of a list that changed since the last call.
+func (ta *TodoApi) TasksDelta(ctx context.Context, listId string) (*TasksDelta, error) {
+	raw, err := retrieveDelta(
+		ctx,
+		ta.token,
+		ta.deltaStore,
+		TasksDeltaKey(listId),
+		listsIndexEndpoint+listId+tasksPath+deltaPath,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	delta := &TasksDelta{Removed: raw.removed, Full: raw.full}
+	for i, item := range raw.changed {
+		task := TaskItem{}
+		if err := json.Unmarshal(item, &task); err != nil {
+			return nil, err
+		}
+
+		if raw.added[raw.ids[i]] {
+			delta.Added = append(delta.Added, task)
+		} else {
+			delta.Updated = append(delta.Updated, task)
+		}
+	}
+
+	return delta, nil
+}
+
+// Follows the pages of a delta query (starting from the stored delta link,
+// or from `endpoint` when there's none) and stores the new delta link. An
+// expired delta link makes it start over from scratch.
+func retrieveDelta(ctx context.Context, token string, store DeltaStore, key, endpoint string) (*rawDelta, error) {
+	state, err := loadDeltaState(store, key)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := &rawDelta{full: state.Link == ""}
+	url := state.Link
+	if raw.full {
+		url = endpoint
+	}
+
+	var link string
+	for link == "" {
+		body, err := sendApiRequest(ctx, "GET", url, token, nil, 200)
+
+		var apiErr *ApiError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusGone && !raw.full {
+			state = &DeltaState{}
+			raw = &rawDelta{full: true}
+			url = endpoint
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		res := deltaResponse{}
+		if err = json.Unmarshal(body, &res); err != nil {
+			return nil, err
+		}
+
+		for _, value := range res.Value {
+			item := deltaItem{}
+			if err = json.Unmarshal(value, &item); err != nil {
+				return nil, err
+			}
+
+			if item.Removed != nil {
+				raw.removed = append(raw.removed, item.Id)
+			} else {
+				raw.changed = append(raw.changed, value)
+				raw.ids = append(raw.ids, item.Id)
+			}
+		}
+
+		url = res.NextLink
+		link = res.DeltaLink
+
+		if url == "" && link == "" {
+			return nil, errors.New("Delta response without a next or a delta link")
+		}
+	}
+
+	known := map[string]bool{}
+	for _, id := range state.Ids {
+		known[id] = true
+	}
+
+	raw.added = map[string]bool{}
+	for _, id := range raw.ids {
+		if !known[id] {
+			raw.added[id] = true
+		}
+		known[id] = true
+	}
+
+	for _, id := range raw.removed {
+		delete(known, id)
+	}
+
+	if store == nil {
+		return raw, nil
+	}
+
+	return raw, store.SaveDelta(key, &DeltaState{Link: link, Ids: sortedKeys(known)})
+}
+
+// Loads the delta state kept under `key`, or an empty one.
+func loadDeltaState(store DeltaStore, key string) (*DeltaState, error) {
+	if store == nil {
+		return &DeltaState{}, nil
+	}
+
+	state, err := store.LoadDelta(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if state == nil {
+		return &DeltaState{}, nil
+	}
+
+	return state, nil
+}
+
+// Returns the keys of a set, sorted.
+func sortedKeys(set map[string]bool) []string {
+	keys := []string{}
+	for k := range set {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,153 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package todoapi
+
+import (
+	"context"
+	httpService "github.com/betasve/mstd/ext/http/httptest"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type memoryDeltaStore map[string]*DeltaState
+
+func (m memoryDeltaStore) LoadDelta(key string) (*DeltaState, error) {
+	return m[key], nil
+}
+
+func (m memoryDeltaStore) SaveDelta(key string, state *DeltaState) error {
+	m[key] = state
+	return nil
+}
+
+func stubDeltaResponses(responses map[string]*http.Response) *[]string {
+	requested := []string{}
+
+	httpService.MockFn = func(req *http.Request) (*http.Response, error) {
+		requested = append(requested, req.URL.Host)
+		return responses[req.URL.Host], nil
+	}
+
+	return &requested
+}
+
+func deltaResponseWith(status int, body string) *http.Response {
+	return &http.Response{StatusCode: status, Body: ioutil.NopCloser(strings.NewReader(body))}
+}
+
+func TestListsDelta(test *testing.T) {
+	httpService.NewRequestStubFn = http.NewRequest
+	store := memoryDeltaStore{}
+	api := TodoApi{}
+	api.SetToken("token")
+	api.SetDeltaStore(store)
+
+	stubDeltaResponses(map[string]*http.Response{
+		"graph.microsoft.com": deltaResponseWith(200, `{
+			"@odata.nextLink": "https://page2/",
+			"value": [{ "id": "l1", "displayName": "One" }]
+		}`),
+		"page2": deltaResponseWith(200, `{
+			"@odata.deltaLink": "https://delta1/",
+			"value": [{ "id": "l2", "displayName": "Two" }]
+		}`),
+	})
+
+	delta, err := api.ListsDelta(context.Background())
+	if err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	if !delta.Full || len(delta.Added) != 2 {
+		test.Errorf("\nExpected a full delta with 2 added lists\nbut got\n%+v", delta)
+	}
+
+	if store[ListsDeltaKey].Link != "https://delta1/" {
+		test.Errorf("\nExpected the delta link to be:\nhttps://delta1/\nbut was\n%s", store[ListsDeltaKey].Link)
+	}
+
+	stubDeltaResponses(map[string]*http.Response{
+		"delta1": deltaResponseWith(200, `{
+			"@odata.deltaLink": "https://delta2/",
+			"value": [
+				{ "id": "l1", "displayName": "Renamed" },
+				{ "id": "l3", "displayName": "Three" },
+				{ "id": "l2", "@removed": { "reason": "deleted" } }
+			]
+		}`),
+	})
+
+	delta, err = api.ListsDelta(context.Background())
+	if err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	if delta.Full {
+		test.Error("\nExpected an incremental delta\nbut it was a full one")
+	}
+
+	if len(delta.Added) != 1 || delta.Added[0].Id != "l3" {
+		test.Errorf("\nExpected l3 to be added\nbut got\n%+v", delta.Added)
+	}
+
+	if len(delta.Updated) != 1 || delta.Updated[0].Name != "Renamed" {
+		test.Errorf("\nExpected l1 to be updated\nbut got\n%+v", delta.Updated)
+	}
+
+	if len(delta.Removed) != 1 || delta.Removed[0] != "l2" {
+		test.Errorf("\nExpected l2 to be removed\nbut got\n%v", delta.Removed)
+	}
+
+	if ids := strings.Join(store[ListsDeltaKey].Ids, ","); ids != "l1,l3" {
+		test.Errorf("\nExpected the known ids to be:\nl1,l3\nbut were\n%s", ids)
+	}
+}
+
+func TestTasksDeltaRestartsWhenLinkExpired(test *testing.T) {
+	httpService.NewRequestStubFn = http.NewRequest
+	store := memoryDeltaStore{
+		TasksDeltaKey("list-id"): &DeltaState{Link: "https://expired/", Ids: []string{"t1"}},
+	}
+	api := TodoApi{}
+	api.SetDeltaStore(store)
+
+	requested := stubDeltaResponses(map[string]*http.Response{
+		"expired": deltaResponseWith(410, `{ "error": { "code": "syncStateNotFound" } }`),
+		"graph.microsoft.com": deltaResponseWith(200, `{
+			"@odata.deltaLink": "https://fresh/",
+			"value": [{ "id": "t1", "title": "Task" }]
+		}`),
+	})
+
+	delta, err := api.TasksDelta(context.Background(), "list-id")
+	if err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	if strings.Join(*requested, ",") != "expired,graph.microsoft.com" {
+		test.Errorf("\nExpected the query to start over\nbut requested\n%v", *requested)
+	}
+
+	if !delta.Full || len(delta.Added) != 1 {
+		test.Errorf("\nExpected a full delta with 1 added task\nbut got\n%+v", delta)
+	}
+
+	if store[TasksDeltaKey("list-id")].Link != "https://fresh/" {
+		test.Errorf("\nExpected the delta link to be:\nhttps://fresh/\nbut was\n%s", store[TasksDeltaKey("list-id")].Link)
+	}
+}
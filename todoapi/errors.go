@@ -0,0 +1,58 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package todoapi
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// The error returned when the API responds with an unexpected status code.
+// `RetryAfter` holds the time the API asked to wait before retrying, if any.
+type ApiError struct {
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration
+}
+
+func (e *ApiError) Error() string {
+	return fmt.Sprintf(
+		"Unsuccessful request to To Do API:\n%d\n%s",
+		e.StatusCode,
+		e.Body,
+	)
+}
+
+// Checks if the API rejected the request because of too many requests (or
+// being temporarily unavailable), meaning it should be retried later.
+func (e *ApiError) Throttled() bool {
+	return e.StatusCode == http.StatusTooManyRequests ||
+		e.StatusCode == http.StatusServiceUnavailable
+}
+
+// Parses the value of a `Retry-After` header. Only the number of seconds
+// form is used by the API, so the HTTP date form results in no duration.
+func parseRetryAfter(value string) time.Duration {
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < 0 {
+		return 0
+	}
+
+	return time.Duration(seconds) * time.Second
+}
@@ -0,0 +1,51 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package todoapi
+
+import (
+	"context"
+	"errors"
+	httpService "github.com/betasve/mstd/ext/http/httptest"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestThrottledRequestKeepsRetryAfter(test *testing.T) {
+	httpService.NewRequestStubFn = http.NewRequest
+	httpService.MockFn = func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: 429,
+			Header:     http.Header{"Retry-After": []string{"12"}},
+			Body:       ioutil.NopCloser(strings.NewReader("")),
+		}, nil
+	}
+
+	_, err := sendApiRequest(context.Background(), "GET", "https://graph.microsoft.com/", "token", nil, 200)
+
+	var apiErr *ApiError
+	if !errors.As(err, &apiErr) {
+		test.Fatalf("\nExpected an ApiError\nbut got\n%v", err)
+	}
+
+	if !apiErr.Throttled() || apiErr.RetryAfter != 12*time.Second {
+		test.Errorf("\nExpected a throttled error to retry after 12s\nbut got\n%+v", apiErr)
+	}
+
+	httpService.MockFn = httpService.DefaultMockFn
+}
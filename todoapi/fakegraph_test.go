@@ -0,0 +1,165 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package todoapi
+
+import (
+	"context"
+	"github.com/betasve/mstd/ext/http"
+	tm "github.com/betasve/mstd/ext/time"
+	"github.com/betasve/mstd/ext/time/timetest"
+	"github.com/betasve/mstd/fakegraph"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Points the API at a fake Graph server for the duration of a test.
+func useFakeGraph(test *testing.T) *fakegraph.Server {
+	server := fakegraph.New()
+	ts := httptest.NewServer(server)
+
+	client := httpClient
+	SetApiRoot(ts.URL + fakegraph.ApiPath)
+	SetHttpClient(&http.Client{})
+
+	test.Cleanup(func() {
+		ts.Close()
+		SetApiRoot(defaultApiRoot)
+		SetHttpClient(client)
+	})
+
+	return server
+}
+
+func TestFakeGraphTasks(test *testing.T) {
+	useFakeGraph(test)
+	api := TodoApi{}
+	api.SetToken("token")
+	ctx := context.Background()
+
+	list, err := api.ListsCreate(ctx, "Groceries")
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	task, err := api.TasksCreate(ctx, list.Id, &TaskItem{Title: "Milk"})
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	updated, err := api.TasksUpdate(ctx, list.Id, task.Id, &TaskItem{Status: "completed"})
+	if err != nil || updated.Title != "Milk" || updated.Status != "completed" {
+		test.Fatalf("\nExpected the task to be completed\nbut was\n%v %v", updated, err)
+	}
+
+	tasks, err := api.TasksIndex(ctx, list.Id)
+	if err != nil || len(*tasks) != 1 || (*tasks)[0].Id != task.Id {
+		test.Errorf("\nExpected the task to be listed\nbut was\n%v %v", tasks, err)
+	}
+}
+
+func TestFakeGraphTasksQuery(test *testing.T) {
+	server := useFakeGraph(test)
+	server.PageSize = 2
+	listId := server.AddList("Groceries")
+	for _, title := range []string{"Milk", "Eggs", "Bread", "Butter", "Beer"} {
+		server.AddTask(listId, title)
+	}
+
+	api := TodoApi{}
+	api.SetToken("token")
+
+	result, err := api.TasksQuery(context.Background(), listId, Query{
+		Filter:  Or(StartsWith("title", "B"), Eq("title", "Eggs")),
+		OrderBy: []Order{Asc("title")},
+		Select:  []string{"title"},
+		Top:     3,
+		Count:   true,
+	})
+
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	titles := []string{}
+	for _, task := range result.Tasks {
+		titles = append(titles, task.Title)
+	}
+
+	if strings.Join(titles, ",") != "Beer,Bread,Butter" || result.Count != 4 {
+		test.Errorf("\nExpected the first 3 of 4 matching tasks\nbut were\n%v of %d", titles, result.Count)
+	}
+}
+
+func TestFakeGraphTasksDelta(test *testing.T) {
+	server := useFakeGraph(test)
+	listId := server.AddList("Work")
+	dropped := server.AddTask(listId, "Dropped")
+
+	api := TodoApi{}
+	api.SetToken("token")
+	api.SetDeltaStore(memoryDeltaStore{})
+	ctx := context.Background()
+
+	delta, err := api.TasksDelta(ctx, listId)
+	if err != nil || !delta.Full || len(delta.Added) != 1 {
+		test.Fatalf("\nExpected the task to be added\nbut was\n%v %v", delta, err)
+	}
+
+	if err := api.TasksDelete(ctx, listId, dropped); err != nil {
+		test.Fatal(err)
+	}
+	server.AddTask(listId, "Added")
+	server.ExpireDeltaLinks()
+	server.AddTask(listId, "Later")
+
+	delta, err = api.TasksDelta(ctx, listId)
+	if err != nil || !delta.Full || len(delta.Added) != 2 {
+		test.Errorf("\nExpected to start over after the link expired\nbut was\n%v %v", delta, err)
+	}
+}
+
+func TestFakeGraphBatchThrottled(test *testing.T) {
+	server := useFakeGraph(test)
+	listId := server.AddList("Work")
+
+	tm.Client = timetest.TimeMock{}
+	defer func() { tm.Client = tm.Time{} }()
+	sleeps := []time.Duration{}
+	timetest.TimeSleepMockFunc = func(d time.Duration) { sleeps = append(sleeps, d) }
+
+	server.Throttle(1, 5*time.Second)
+	api := TodoApi{}
+	api.SetToken("token")
+
+	responses, err := api.Batch(context.Background(), []BatchRequest{
+		NewTaskCreateRequest("1", listId, &TaskItem{Title: "One"}),
+		NewTaskCreateRequest("2", listId, &TaskItem{Title: "Two"}),
+	})
+
+	if err != nil || len(responses) != 2 {
+		test.Fatalf("\nExpected 2 responses\nbut was\n%v %v", responses, err)
+	}
+
+	if task, err := responses["2"].Task(201); err != nil || task.Title != "Two" {
+		test.Errorf("\nExpected task Two to be created\nbut was\n%v %v", task, err)
+	}
+
+	if len(sleeps) != 1 || sleeps[0] != 5*time.Second {
+		test.Errorf("\nExpected to wait 5s before retrying\nbut was\n%v", sleeps)
+	}
+}
@@ -0,0 +1,96 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package todoapi
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// The path (relative to a task) of the linked resources' endpoints.
+const linkedResourcesPath string = "/linkedResources/"
+
+type LinkedResource struct {
+	Id              string `json:"id,omitempty"`
+	WebUrl          string `json:"webUrl,omitempty"`
+	ApplicationName string `json:"applicationName,omitempty"`
+	DisplayName     string `json:"displayName,omitempty"`
+	ExternalId      string `json:"externalId,omitempty"`
+}
+
+type LinkedResourcesResponse struct {
+	Context   string           `json:"@odata.context"`
+	Resources []LinkedResource `json:"value"`
+}
+
+// Retrieves the linked resources of a task (e.g. the email it was created
+// from).
+func (ta *TodoApi) LinkedResourcesIndex(ctx context.Context, listId, taskId string) (*[]LinkedResource, error) {
+	return retrieveLinkedResources(ctx, ta.token, listId, taskId)
+}
+
+// Links a resource to a task.
+func (ta *TodoApi) LinkedResourcesCreate(ctx context.Context, listId, taskId string, resource *LinkedResource) (*LinkedResource, error) {
+	return createALinkedResource(ctx, ta.token, listId, taskId, resource)
+}
+
+// The function that is responsible for building the HTTP request and handling
+// the response of the 'List linkedResources' API endpoint.
+func retrieveLinkedResources(ctx context.Context, token, listId, taskId string) (*[]LinkedResource, error) {
+	body, err := sendApiRequest(
+		ctx,
+		"GET",
+		listsIndexEndpoint+listId+tasksPath+taskId+linkedResourcesPath,
+		token,
+		nil,
+		200,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	response := LinkedResourcesResponse{}
+	if err = json.Unmarshal(body, &response); err != nil {
+		return nil, err
+	}
+
+	return &response.Resources, nil
+}
+
+// The function that is responsible for building the HTTP request and handling
+// the response of the 'Create linkedResource' API endpoint.
+func createALinkedResource(ctx context.Context, token, listId, taskId string, resource *LinkedResource) (*LinkedResource, error) {
+	body, err := sendApiRequest(
+		ctx,
+		"POST",
+		listsIndexEndpoint+listId+tasksPath+taskId+linkedResourcesPath,
+		token,
+		resource,
+		201,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	created := LinkedResource{}
+	if err := json.Unmarshal(body, &created); err != nil {
+		return nil, err
+	}
+
+	return &created, nil
+}
@@ -0,0 +1,55 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package todoapi
+
+import (
+	"context"
+	httpService "github.com/betasve/mstd/ext/http/httptest"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestLinkedResourcesIndex(test *testing.T) {
+	httpService.NewRequestStubFn = http.NewRequest
+	api := TodoApi{}
+	api.SetToken("token")
+
+	var path string
+	httpService.MockFn = func(req *http.Request) (*http.Response, error) {
+		path = req.URL.Path
+		res := &http.Response{StatusCode: 200}
+		res.Body = ioutil.NopCloser(strings.NewReader(
+			`{ "value": [{ "id": "r1", "webUrl": "https://example.com/mail/1", "applicationName": "Mail" }] }`,
+		))
+		return res, nil
+	}
+
+	resources, err := api.LinkedResourcesIndex(context.Background(), "list-id", "task-id")
+
+	if err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	if path != "/v1.0/me/todo/lists/list-id/tasks/task-id/linkedResources/" {
+		test.Errorf("\nExpected the linked resources endpoint\nbut was\n%s", path)
+	}
+
+	if len(*resources) != 1 || (*resources)[0].ApplicationName != "Mail" {
+		test.Errorf("\nExpected a resource of Mail\nbut got\n%+v", *resources)
+	}
+}
@@ -24,22 +24,39 @@ package todoapi
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	httpService "github.com/betasve/mstd/ext/http"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"strings"
 )
 
 type TodoApi struct {
-	token string
+	token      string
+	deltaStore DeltaStore
 }
 
 type TodoApiClient interface {
-	ListsIndex() (*[]ListsItem, error)
-	ListsCreate(string) (*ListsItem, error)
-	ListsUpdate(string, string) (*ListsItem, error)
+	ListsIndex(context.Context) (*[]ListsItem, error)
+	ListsQuery(context.Context, Query) (*ListsResponse, error)
+	ListsCreate(context.Context, string) (*ListsItem, error)
+	ListsUpdate(context.Context, string, string) (*ListsItem, error)
+	TasksIndex(context.Context, string) (*[]TaskItem, error)
+	TasksQuery(context.Context, string, Query) (*TasksResponse, error)
+	TasksShow(context.Context, string, string) (*TaskItem, error)
+	TasksCreate(context.Context, string, *TaskItem) (*TaskItem, error)
+	TasksUpdate(context.Context, string, string, *TaskItem) (*TaskItem, error)
+	TasksDelete(context.Context, string, string) error
+	ChecklistItemsIndex(context.Context, string, string) (*[]ChecklistItem, error)
+	ChecklistItemsCreate(context.Context, string, string, *ChecklistItem) (*ChecklistItem, error)
+	LinkedResourcesIndex(context.Context, string, string) (*[]LinkedResource, error)
+	LinkedResourcesCreate(context.Context, string, string, *LinkedResource) (*LinkedResource, error)
+	Batch(context.Context, []BatchRequest) (map[string]*BatchResponse, error)
+	ListsDelta(context.Context) (*ListsDelta, error)
+	TasksDelta(context.Context, string) (*TasksDelta, error)
 	SetToken(string)
 	Token() string
 }
@@ -53,8 +70,10 @@ type ListsItem struct {
 }
 
 type ListsResponse struct {
-	Context string      `json:"@odata.context"`
-	Lists   []ListsItem `json:"value"`
+	Context  string      `json:"@odata.context"`
+	NextLink string      `json:"@odata.nextLink,omitempty"`
+	Count    int         `json:"@odata.count,omitempty"`
+	Lists    []ListsItem `json:"value"`
 }
 
 type ContentType string
@@ -64,24 +83,61 @@ const (
 	jsonCT ContentType = "application/json"
 )
 
-const defaultPageSize string = "?$top=100"
-const listsIndexEndpoint string = "https://graph.microsoft.com/v1.0/me/todo/lists/"
+// The root of the API, which all the endpoints (and the urls of the requests
+// in a batch) are relative to.
+const defaultApiRoot string = "https://graph.microsoft.com/v1.0"
+const listsPath string = "/me/todo/lists/"
+const batchPath string = "/$batch"
+
+var apiRoot string = defaultApiRoot
+var listsIndexEndpoint string = apiRoot + listsPath
+var batchEndpoint string = apiRoot + batchPath
+
+// Points the requests at another root of the API, e.g. a fake one like
+// `http://localhost:8081/v1.0` in the tests.
+func SetApiRoot(root string) {
+	apiRoot = strings.TrimSuffix(root, "/")
+	listsIndexEndpoint = apiRoot + listsPath
+	batchEndpoint = apiRoot + batchPath
+}
+
+// Returns the root of the API the requests are made to.
+func ApiRoot() string {
+	return apiRoot
+}
+
+// Tells whether the requests are made to the root of the API of the global
+// cloud (rather than another cloud or a fake one).
+func IsDefaultApiRoot() bool {
+	return apiRoot == defaultApiRoot
+}
 
 var httpClient httpService.HttpClient = &httpService.Client{}
 
+// Sets the HTTP client the requests to the API are sent with.
+func SetHttpClient(client httpService.HttpClient) {
+	httpClient = client
+}
+
 // Retrieves the collection of `ListItem`s.
-func (ta *TodoApi) ListsIndex() (*[]ListsItem, error) {
-	return retrieveLists(ta.token)
+func (ta *TodoApi) ListsIndex(ctx context.Context) (*[]ListsItem, error) {
+	return retrieveLists(ctx, ta.token)
+}
+
+// Retrieves the `ListItem`s matching a Query, along with their number when
+// the query asks for it.
+func (ta *TodoApi) ListsQuery(ctx context.Context, query Query) (*ListsResponse, error) {
+	return queryLists(ctx, ta.token, query)
 }
 
 // Creates a ListItem setting its name.
-func (ta *TodoApi) ListsCreate(name string) (*ListsItem, error) {
-	return createAList(ta.token, name)
+func (ta *TodoApi) ListsCreate(ctx context.Context, name string) (*ListsItem, error) {
+	return createAList(ctx, ta.token, name)
 }
 
 // Updates a ListItem finding it by its id and changing its name.
-func (ta *TodoApi) ListsUpdate(id, name string) (*ListsItem, error) {
-	return updateList(ta.token, id, name)
+func (ta *TodoApi) ListsUpdate(ctx context.Context, id, name string) (*ListsItem, error) {
+	return updateList(ctx, ta.token, id, name)
 }
 
 // Sets a token to be used for the API communication.
@@ -96,75 +152,59 @@ func (ta *TodoApi) Token() string {
 
 // The function that is responsible for building the HTTP request and handling
 // the response of the Lists API endpoint.
-func retrieveLists(token string) (*[]ListsItem, error) {
-	req, err := constructRequest(
-		"GET",
-		listsIndexEndpoint+defaultPageSize,
-		token,
-		nil,
-		formCT,
-	)
-
+func retrieveLists(ctx context.Context, token string) (*[]ListsItem, error) {
+	listsResponse, err := queryLists(ctx, token, Query{})
 	if err != nil {
 		return nil, err
 	}
 
-	res, err := httpClient.Do(req)
-
-	if err != nil {
-		return nil, err
-	}
-
-	defer res.Body.Close()
-	body, err := ioutil.ReadAll(res.Body)
+	return &listsResponse.Lists, nil
+}
 
-	if err != nil {
-		return nil, err
+// The function that is responsible for building the HTTP requests and
+// handling the responses of the Lists API endpoint with a Query. It follows
+// the `@odata.nextLink`s until all the pages (or the `Top` lists) are
+// retrieved.
+func queryLists(ctx context.Context, token string, query Query) (*ListsResponse, error) {
+	result := ListsResponse{Lists: []ListsItem{}}
+	url := listsIndexEndpoint + query.String()
+
+	for url != "" {
+		body, err := sendApiRequest(ctx, "GET", url, token, nil, 200)
+		if err != nil {
+			return nil, err
+		}
+
+		page := ListsResponse{}
+		if err = json.Unmarshal(body, &page); err != nil {
+			return nil, err
+		}
+
+		if result.Context == "" {
+			result.Context, result.Count = page.Context, page.Count
+		}
+
+		result.Lists = append(result.Lists, page.Lists...)
+		url = page.NextLink
+
+		if query.done(len(result.Lists)) {
+			result.Lists = result.Lists[:query.Top]
+			break
+		}
 	}
 
-	listsResponse := ListsResponse{}
-	if err = json.Unmarshal(body, &listsResponse); err != nil {
-		return nil, err
-	}
+	return &result, nil
+}
 
-	return &listsResponse.Lists, nil
+// The body of the requests creating or renaming a list.
+type listPayload struct {
+	Name string `json:"displayName"`
 }
 
 // The function that is responsible for building the HTTP request and handling
 // the response of the 'Create a list' API endpoint.
-func createAList(token, name string) (*ListsItem, error) {
-	jsonObj := []byte(fmt.Sprintf("{\"displayName\": \"%s\"}", name))
-
-	req, err := constructRequest(
-		"POST",
-		listsIndexEndpoint,
-		token,
-		bytes.NewBuffer(jsonObj),
-		jsonCT,
-	)
-
-	if err != nil {
-		return nil, err
-	}
-
-	res, err := httpClient.Do(req)
-
-	if err != nil {
-		return nil, err
-	}
-
-	defer res.Body.Close()
-	body, err := ioutil.ReadAll(res.Body)
-
-	if res.StatusCode != 201 {
-		return nil,
-			fmt.Errorf(
-				"Unsuccessful request to To Do API:\n%d\n%s",
-				res.StatusCode,
-				string(body),
-			)
-	}
-
+func createAList(ctx context.Context, token, name string) (*ListsItem, error) {
+	body, err := sendApiRequest(ctx, "POST", listsIndexEndpoint, token, listPayload{Name: name}, 201)
 	if err != nil {
 		return nil, err
 	}
@@ -179,39 +219,8 @@ func createAList(token, name string) (*ListsItem, error) {
 
 // The function that is responsible for building the HTTP request and handling
 // the response of the 'Update a list' API endpoint.
-func updateList(token, id, name string) (*ListsItem, error) {
-	jsonObj := []byte(fmt.Sprintf("{\"displayName\": \"%s\"}", name))
-
-	req, err := constructRequest(
-		"PUT",
-		listsIndexEndpoint+id,
-		token,
-		bytes.NewBuffer(jsonObj),
-		jsonCT,
-	)
-
-	if err != nil {
-		return nil, err
-	}
-
-	res, err := httpClient.Do(req)
-
-	if err != nil {
-		return nil, err
-	}
-
-	defer res.Body.Close()
-	body, err := ioutil.ReadAll(res.Body)
-
-	if res.StatusCode != 200 {
-		return nil,
-			fmt.Errorf(
-				"Unsuccessful request to To Do API:\n%d\n%s",
-				res.StatusCode,
-				string(body),
-			)
-	}
-
+func updateList(ctx context.Context, token, id, name string) (*ListsItem, error) {
+	body, err := sendApiRequest(ctx, "PUT", listsIndexEndpoint+id, token, listPayload{Name: name}, 200)
 	if err != nil {
 		return nil, err
 	}
@@ -225,8 +234,10 @@ func updateList(token, id, name string) (*ListsItem, error) {
 }
 
 // A 'helper' function to construct requests with the needed (valid) Auth
-// headers for successfully communicating with the API.
+// headers for successfully communicating with the API. The request is
+// cancelled along with `ctx`.
 func constructRequest(
+	ctx context.Context,
 	method, path, token string,
 	body io.Reader,
 	contentType ContentType,
@@ -237,6 +248,7 @@ func constructRequest(
 		return nil, err
 	}
 
+	req = req.WithContext(ctx)
 	req.Header.Add("Content-Type", string(contentType))
 	req.Header.Add(
 		"Authorization",
@@ -245,3 +257,53 @@ func constructRequest(
 
 	return req, nil
 }
+
+// Builds a request (marshalling the `payload` as its JSON body when present),
+// sends it and returns the response body. Responses with a status code other
+// than the `expectedStatus` are returned as errors.
+func sendApiRequest(
+	ctx context.Context,
+	method, url, token string,
+	payload interface{},
+	expectedStatus int,
+) ([]byte, error) {
+	var reqBody io.Reader
+	contentType := formCT
+
+	if payload != nil {
+		jsonObj, err := json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+
+		reqBody = bytes.NewBuffer(jsonObj)
+		contentType = jsonCT
+	}
+
+	req, err := constructRequest(ctx, method, url, token, reqBody, contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != expectedStatus {
+		return nil, &ApiError{
+			StatusCode: res.StatusCode,
+			Body:       string(body),
+			RetryAfter: parseRetryAfter(res.Header.Get("Retry-After")),
+		}
+	}
+
+	return body, nil
+}
@@ -17,6 +17,8 @@ package todoapi
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	httpService "github.com/betasve/mstd/ext/http/httptest"
@@ -27,83 +29,45 @@ import (
 	"testing"
 )
 
-const listResponse1 string = `{
-  "@odata.type": "#microsoft.graph.todoTaskList",
-  "id": "1",
-  "displayName": "List Title 1",
-  "isOwner": true,
-  "isShared": false,
-  "wellknownListName": "none"
-}`
-
-const listResponse2 string = `{
-				"@odata.type": "#microsoft.graph.todoTaskList",
-				"id": "2",
-        "displayName": "List Title 2",
-        "isOwner": false,
-        "isShared": true,
-        "wellknownListName": "system"
-        }`
-
 func init() {
 	httpClient = &httpService.ClientMock{}
 }
 
 func TestListsIndex(test *testing.T) {
 	api := TodoApi{}
-	api.SetToken("token")
-
-	stubHttp(
-		200,
-		fmt.Sprintf(
-			`{ "@odata.context": "some", "value": [%s, %s] }`,
-			listResponse1,
-			listResponse2,
-		),
-	)
+	api.SetToken(useCassette(test))
 
-	lists, err := api.ListsIndex()
+	if _, err := api.ListsCreate(context.Background(), "Groceries"); err != nil {
+		test.Fatal(err)
+	}
 
-	checkListsIndexExpectations(test, lists, err)
+	lists, err := api.ListsIndex(context.Background())
+
+	checkListsIndexExpectations(test, lists, err, "Groceries")
 }
 
 func TestListsCreate(test *testing.T) {
 	api := TodoApi{}
-	api.SetToken("token")
+	api.SetToken(useCassette(test))
 
-	stubHttp(201, listResponse1)
-
-	listItem, err := api.ListsCreate("name")
+	listItem, err := api.ListsCreate(context.Background(), "Groceries")
 
 	checkCreatedListExpectations(test, listItem, err)
 }
 
 func TestRetrieveListsSuccess(test *testing.T) {
-	stubHttp(
-		200,
-		fmt.Sprintf(
-			`{ "@odata.context": "some", "value": [%s, %s] }`,
-			listResponse1,
-			listResponse2,
-		),
-	)
+	token := useCassette(test)
 
-	lists, err := retrieveLists("token")
+	lists, err := retrieveLists(context.Background(), token)
 
 	checkListsIndexExpectations(test, lists, err)
 }
 
 func TestRetrieveListsFailureUnmarshalling(test *testing.T) {
-	stubHttp(
-		200,
-		fmt.Sprintf(
-			`{ "@odata.context": "some", "value" [%s, %s] }`,
-			listResponse1,
-			listResponse2,
-		),
-	)
+	httpClient = &httpService.ClientMock{}
+	stubHttp(200, `{ "@odata.context": "some", "value" [] }`)
 
-	_, err := retrieveLists("token")
+	_, err := retrieveLists(context.Background(), "token")
 
 	if err == nil {
 		test.Errorf("\nExpected error\nbut got\nnil%s", err)
@@ -111,30 +75,64 @@ func TestRetrieveListsFailureUnmarshalling(test *testing.T) {
 }
 
 func TestCreateAListSuccess(test *testing.T) {
-	stubHttp(201, listResponse1)
+	token := useCassette(test)
 
-	listItem, err := createAList("token", "name")
+	listItem, err := createAList(context.Background(), token, "Groceries")
 
 	checkCreatedListExpectations(test, listItem, err)
 }
 
 func TestCreateAListFailureWithWrongCode(test *testing.T) {
-	stubHttp(304, listResponse1)
+	httpClient = &httpService.ClientMock{}
+	stubHttp(304, "")
 
-	_, err := createAList("token", "name")
+	_, err := createAList(context.Background(), "token", "name")
 
 	if err == nil {
 		test.Error("\nExpected to return error\nbut it was\nnil")
 	}
 }
 
+func TestCreateAndUpdateAListEncodeTheName(test *testing.T) {
+	httpClient = &httpService.ClientMock{}
+	name := `Say "hi" \ bye`
+
+	for _, send := range []func() (*ListsItem, error){
+		func() (*ListsItem, error) { return createAList(context.Background(), "token", name) },
+		func() (*ListsItem, error) { return updateList(context.Background(), "token", "list-id", name) },
+	} {
+		var sent map[string]string
+		httpService.MockFn = func(req *http.Request) (*http.Response, error) {
+			body, _ := ioutil.ReadAll(req.Body)
+			if err := json.Unmarshal(body, &sent); err != nil {
+				test.Errorf("\nExpected a valid JSON body\nbut was\n%s", body)
+			}
+
+			return &http.Response{StatusCode: 409, Body: ioutil.NopCloser(strings.NewReader("{}"))}, nil
+		}
+
+		_, err := send()
+		if sent["displayName"] != name {
+			test.Errorf("\nExpected the name sent to be:\n%s\nbut was\n%s", name, sent["displayName"])
+		}
+
+		var apiErr *ApiError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != 409 {
+			test.Errorf("\nExpected an ApiError with the status 409\nbut got\n%v", err)
+		}
+	}
+}
+
 func TestConstructRequestSuccess(test *testing.T) {
 	method := "GET"
 	path := "some/path"
 	token := "token"
 	body := "body"
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
 	req, _ := constructRequest(
+		ctx,
 		method,
 		path,
 		token,
@@ -146,6 +144,10 @@ func TestConstructRequestSuccess(test *testing.T) {
 		test.Errorf("\nExpected method to be:\n%s\nbut was\n%s", method, req.Method)
 	}
 
+	if req.Context() != ctx {
+		test.Errorf("\nExpected the request to carry the given context\nbut\nit did not")
+	}
+
 	if req.URL.Path != path {
 		test.Errorf("\nExpected path to be:\n%s\nbut was\n%s", path, req.URL.Path)
 	}
@@ -175,8 +177,10 @@ func TestConstructRequestFailure(test *testing.T) {
 	path := "some/path"
 	token := "token"
 	body := "body"
+	ctx := context.Background()
 
 	_, err := constructRequest(
+		ctx,
 		method,
 		path,
 		token,
@@ -200,31 +204,52 @@ func stubHttp(status int, body string) {
 	}
 }
 
-func checkListsIndexExpectations(test *testing.T, lists *[]ListsItem, err error) {
+// Checks that the lists are the default one followed by the `named` ones
+// (made by the test), and that their fields are mapped.
+func checkListsIndexExpectations(test *testing.T, lists *[]ListsItem, err error, named ...string) {
 	if err != nil {
-		test.Errorf("\nExpected error to be:\nnil\nbut was\n%s", err)
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
 	}
 
-	if len(*lists) != 2 {
-		test.Errorf("\nExpected a list of 2:\nbut got\n%d", len(*lists))
+	if len(*lists) != len(named)+1 {
+		test.Fatalf("\nExpected a list of %d:\nbut got\n%v", len(named)+1, *lists)
 	}
 
-	if (*lists)[0].Name != "List Title 1" {
-		test.Errorf("\nExpected first list to have title:\nList Title 1\nbut got\n%s", (*lists)[0].Name)
+	for i, list := range *lists {
+		name, system := "Tasks", "defaultList"
+		if i > 0 {
+			name, system = named[i-1], "none"
+		}
+
+		if list.Id == "" {
+			test.Errorf("\nExpected list %d to have an id\nbut it was\nempty", i)
+		}
+
+		if list.Name != name {
+			test.Errorf("\nExpected list %d to have title:\n%s\nbut got\n%s", i, name, list.Name)
+		}
+
+		if !list.Owner || list.Shared {
+			test.Errorf("\nExpected list %d to be owned and not shared\nbut was\n%+v", i, list)
+		}
+
+		if list.System != system {
+			test.Errorf("\nExpected list %d to have system:\n%s\nbut got\n%s", i, system, list.System)
+		}
 	}
 }
 
 func checkCreatedListExpectations(test *testing.T, listItem *ListsItem, err error) {
 	if err != nil {
-		test.Errorf("\nExpected error to be:\nnil\nbut was\n%s", err)
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
 	}
 
-	if listItem.Id != "1" {
-		test.Errorf("\nExpected id to be:\n1\nbut was\n%s", listItem.Id)
+	if listItem.Id == "" {
+		test.Error("\nExpected an id\nbut it was\nempty")
 	}
 
-	if listItem.Name != "List Title 1" {
-		test.Errorf("\nExpected name to be:\nList Title 1\nbut was\n%s", listItem.Name)
+	if listItem.Name != "Groceries" {
+		test.Errorf("\nExpected name to be:\nGroceries\nbut was\n%s", listItem.Name)
 	}
 
 	if !listItem.Owner {
@@ -0,0 +1,226 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package todoapi
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The most items the API is asked for in a single page.
+const maxPageSize int = 100
+
+// The layout of the date (and time) values in the filters.
+const filterDateTimeLayout string = "2006-01-02T15:04:05Z"
+
+// The OData query options of a request for a collection (of lists or tasks),
+// so the API only sends back the items (and the attributes of them) that are
+// needed.
+type Query struct {
+	// Only the items matching it are retrieved ($filter).
+	Filter Filter
+	// The order the items are retrieved in ($orderby).
+	OrderBy []Order
+	// The attributes of the items that are retrieved, all when empty
+	// ($select).
+	Select []string
+	// The most items retrieved, all when zero ($top). It's also the size of
+	// the pages asked for (up to the 100 the API allows).
+	Top int
+	// Asks for the number of the matching items ($count), see e.g.
+	// TasksResponse.Count.
+	Count bool
+}
+
+// A `$filter` expression, e.g. `status eq 'completed'`. It can be built
+// with Eq, And, StartsWith, etc. or be given as is.
+type Filter string
+
+// A field the items are ordered by.
+type Order struct {
+	Field      string
+	Descending bool
+}
+
+// Orders the items by a field, in ascending order.
+func Asc(field string) Order {
+	return Order{Field: field}
+}
+
+// Orders the items by a field, in descending order.
+func Desc(field string) Order {
+	return Order{Field: field, Descending: true}
+}
+
+// Matches the items whose `field` equals `value`. The field can be a path,
+// e.g. `dueDateTime/dateTime`, and `nil` stands for no value.
+func Eq(field string, value interface{}) Filter {
+	return compare(field, "eq", value)
+}
+
+// Matches the items whose `field` doesn't equal `value`.
+func Ne(field string, value interface{}) Filter {
+	return compare(field, "ne", value)
+}
+
+// Matches the items whose `field` is less than `value`.
+func Lt(field string, value interface{}) Filter {
+	return compare(field, "lt", value)
+}
+
+// Matches the items whose `field` is less than or equal to `value`.
+func Le(field string, value interface{}) Filter {
+	return compare(field, "le", value)
+}
+
+// Matches the items whose `field` is greater than `value`.
+func Gt(field string, value interface{}) Filter {
+	return compare(field, "gt", value)
+}
+
+// Matches the items whose `field` is greater than or equal to `value`.
+func Ge(field string, value interface{}) Filter {
+	return compare(field, "ge", value)
+}
+
+// Matches the items whose `field` starts with `value`.
+func StartsWith(field, value string) Filter {
+	return Filter(fmt.Sprintf("startswith(%s,%s)", field, literal(value)))
+}
+
+// Matches the items whose `field` contains `value`.
+func Contains(field, value string) Filter {
+	return Filter(fmt.Sprintf("contains(%s,%s)", field, literal(value)))
+}
+
+// Matches the items with a `collection` (e.g. `categories`) holding `value`.
+func Has(collection string, value interface{}) Filter {
+	return Filter(fmt.Sprintf("%s/any(v:v eq %s)", collection, literal(value)))
+}
+
+// Matches the items all of the `filters` match. The empty ones are skipped.
+func And(filters ...Filter) Filter {
+	return join("and", filters)
+}
+
+// Matches the items any of the `filters` matches. The empty ones are
+// skipped.
+func Or(filters ...Filter) Filter {
+	return join("or", filters)
+}
+
+// Matches the items the `filter` doesn't match.
+func Not(filter Filter) Filter {
+	return Filter(fmt.Sprintf("not (%s)", filter))
+}
+
+// Encodes the query options as the query string of a request, starting
+// with `?`. The size of the pages is always set.
+func (q Query) String() string {
+	options := []string{}
+
+	if q.Filter != "" {
+		options = append(options, "$filter="+queryEscape(string(q.Filter)))
+	}
+
+	if len(q.OrderBy) > 0 {
+		fields := []string{}
+		for _, o := range q.OrderBy {
+			if o.Descending {
+				fields = append(fields, o.Field+" desc")
+				continue
+			}
+
+			fields = append(fields, o.Field)
+		}
+
+		options = append(options, "$orderby="+queryEscape(strings.Join(fields, ",")))
+	}
+
+	if len(q.Select) > 0 {
+		options = append(options, "$select="+queryEscape(strings.Join(q.Select, ",")))
+	}
+
+	options = append(options, "$top="+strconv.Itoa(q.pageSize()))
+
+	if q.Count {
+		options = append(options, "$count=true")
+	}
+
+	return "?" + strings.Join(options, "&")
+}
+
+// Returns the number of items asked for in a single page.
+func (q Query) pageSize() int {
+	if q.Top <= 0 || q.Top > maxPageSize {
+		return maxPageSize
+	}
+
+	return q.Top
+}
+
+// Tells whether enough items were retrieved for the query (so the rest of
+// the pages aren't needed).
+func (q Query) done(retrieved int) bool {
+	return q.Top > 0 && retrieved >= q.Top
+}
+
+func compare(field, operator string, value interface{}) Filter {
+	return Filter(fmt.Sprintf("%s %s %s", field, operator, literal(value)))
+}
+
+func join(operator string, filters []Filter) Filter {
+	parts := []string{}
+	for _, f := range filters {
+		if f != "" {
+			parts = append(parts, string(f))
+		}
+	}
+
+	if len(parts) == 1 {
+		return Filter(parts[0])
+	}
+
+	for i, part := range parts {
+		parts[i] = "(" + part + ")"
+	}
+
+	return Filter(strings.Join(parts, " "+operator+" "))
+}
+
+// Formats a value as an OData literal, e.g. `'it''s'` for the string `it's`.
+func literal(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "'" + strings.ReplaceAll(v, "'", "''") + "'"
+	case time.Time:
+		return v.UTC().Format(filterDateTimeLayout)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// Escapes a value of the query string, with the spaces as `%20` (as the
+// API doesn't take them as `+`).
+func queryEscape(value string) string {
+	return strings.ReplaceAll(url.QueryEscape(value), "+", "%20")
+}
@@ -0,0 +1,64 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package todoapi
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFilterBuilders(test *testing.T) {
+	due := time.Date(2021, 5, 3, 0, 0, 0, 0, time.UTC)
+
+	cases := map[Filter]string{
+		Eq("status", "completed"):                "status eq 'completed'",
+		Ne("title", "it's"):                      "title ne 'it''s'",
+		Lt("dueDateTime/dateTime", due):          "dueDateTime/dateTime lt 2021-05-03T00:00:00Z",
+		Ge("importance", nil):                    "importance ge null",
+		StartsWith("title", "Buy"):               "startswith(title,'Buy')",
+		Has("categories", "Work"):                "categories/any(v:v eq 'Work')",
+		And(Eq("isReminderOn", true), ""):        "isReminderOn eq true",
+		Or(Eq("a", 1), Not(Contains("b", "x"))):  "(a eq 1) or (not (contains(b,'x')))",
+		And(Gt("a", 1), Le("b", 2), Eq("c", "")): "(a gt 1) and (b le 2) and (c eq '')",
+	}
+
+	for filter, expected := range cases {
+		if string(filter) != expected {
+			test.Errorf("\nExpected the filter to be\n%s\nbut was\n%s", expected, filter)
+		}
+	}
+}
+
+func TestQueryString(test *testing.T) {
+	if q := (Query{}).String(); q != "?$top=100" {
+		test.Errorf("\nExpected only the page size\nbut was\n%s", q)
+	}
+
+	query := Query{
+		Filter:  Ne("status", "completed"),
+		OrderBy: []Order{Desc("importance"), Asc("title")},
+		Select:  []string{"id", "title"},
+		Top:     5,
+		Count:   true,
+	}
+
+	expected := "?$filter=status%20ne%20%27completed%27&$orderby=importance%20desc%2Ctitle" +
+		"&$select=id%2Ctitle&$top=5&$count=true"
+
+	if q := query.String(); q != expected {
+		test.Errorf("\nExpected the query to be\n%s\nbut was\n%s", expected, q)
+	}
+}
@@ -0,0 +1,417 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package todoapi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The recurrence pattern types supported by the API.
+const (
+	RecurrenceDaily           string = "daily"
+	RecurrenceWeekly          string = "weekly"
+	RecurrenceAbsoluteMonthly string = "absoluteMonthly"
+	RecurrenceRelativeMonthly string = "relativeMonthly"
+	RecurrenceAbsoluteYearly  string = "absoluteYearly"
+	RecurrenceRelativeYearly  string = "relativeYearly"
+)
+
+// The recurrence range types supported by the API.
+const (
+	RangeNoEnd    string = "noEnd"
+	RangeEndDate  string = "endDate"
+	RangeNumbered string = "numbered"
+)
+
+// The format of the dates used in a recurrence range.
+const RecurrenceDateLayout string = "2006-01-02"
+
+// Holds the recurrence of a task. It mirrors the `patternedRecurrence`
+// resource of the API.
+type PatternedRecurrence struct {
+	Pattern RecurrencePattern `json:"pattern"`
+	Range   RecurrenceRange   `json:"range"`
+}
+
+// Describes how often a task repeats.
+type RecurrencePattern struct {
+	Type           string   `json:"type"`
+	Interval       int      `json:"interval"`
+	DaysOfWeek     []string `json:"daysOfWeek,omitempty"`
+	DayOfMonth     int      `json:"dayOfMonth,omitempty"`
+	Month          int      `json:"month,omitempty"`
+	Index          string   `json:"index,omitempty"`
+	FirstDayOfWeek string   `json:"firstDayOfWeek,omitempty"`
+}
+
+// Describes for how long a task repeats.
+type RecurrenceRange struct {
+	Type                string `json:"type"`
+	StartDate           string `json:"startDate,omitempty"`
+	EndDate             string `json:"endDate,omitempty"`
+	NumberOfOccurrences int    `json:"numberOfOccurrences,omitempty"`
+	RecurrenceTimeZone  string `json:"recurrenceTimeZone,omitempty"`
+}
+
+// Maps the short week day names accepted in the shorthand notation to the
+// ones the API expects.
+var weekDays map[string]string = map[string]string{
+	"sun": "sunday",
+	"mon": "monday",
+	"tue": "tuesday",
+	"wed": "wednesday",
+	"thu": "thursday",
+	"fri": "friday",
+	"sat": "saturday",
+}
+
+// Maps the accepted spellings of a week index to the ones the API expects.
+var weekIndexes map[string]string = map[string]string{
+	"1st":    "first",
+	"first":  "first",
+	"2nd":    "second",
+	"second": "second",
+	"3rd":    "third",
+	"third":  "third",
+	"4th":    "fourth",
+	"fourth": "fourth",
+	"last":   "last",
+}
+
+// Parses the shorthand notation of a recurrence into a PatternedRecurrence.
+// The notation is `TYPE[:SPEC][;every=N][;until=DATE|;count=N]`, e.g.
+// `daily`, `weekdays`, `weekly:mon,wed`, `monthly:15`, `monthly:last-fri`,
+// `yearly:12-25` or `yearly:first-mon-sep;count=5`.
+func ParseRecurrence(shorthand string) (*PatternedRecurrence, error) {
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(shorthand)), ";")
+	kind, spec := splitOnce(parts[0], ":")
+
+	r := &PatternedRecurrence{
+		Pattern: RecurrencePattern{Interval: 1},
+		Range:   RecurrenceRange{Type: RangeNoEnd},
+	}
+
+	var err error
+	switch kind {
+	case "daily":
+		r.Pattern.Type = RecurrenceDaily
+	case "weekdays":
+		r.Pattern.Type = RecurrenceWeekly
+		r.Pattern.DaysOfWeek = []string{
+			"monday", "tuesday", "wednesday", "thursday", "friday",
+		}
+	case "weekly":
+		r.Pattern.Type = RecurrenceWeekly
+		r.Pattern.DaysOfWeek, err = parseWeekDays(spec)
+	case "monthly":
+		err = parseMonthlySpec(spec, &r.Pattern)
+	case "yearly":
+		err = parseYearlySpec(spec, &r.Pattern)
+	default:
+		err = fmt.Errorf("Unknown recurrence type %q", kind)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, modifier := range parts[1:] {
+		if err = applyRecurrenceModifier(modifier, r); err != nil {
+			return nil, err
+		}
+	}
+
+	return r, r.Validate()
+}
+
+// Validates that the recurrence holds all the values the API needs for its
+// pattern and range types.
+func (r *PatternedRecurrence) Validate() error {
+	p := r.Pattern
+
+	if p.Interval < 1 {
+		return fmt.Errorf("Recurrence interval must be positive, got %d", p.Interval)
+	}
+
+	switch p.Type {
+	case RecurrenceDaily:
+	case RecurrenceWeekly:
+		if len(p.DaysOfWeek) == 0 {
+			return fmt.Errorf("Weekly recurrence needs at least one day of week")
+		}
+	case RecurrenceAbsoluteMonthly:
+		if p.DayOfMonth < 1 || p.DayOfMonth > 31 {
+			return fmt.Errorf("Invalid day of month %d", p.DayOfMonth)
+		}
+	case RecurrenceRelativeMonthly:
+		if len(p.DaysOfWeek) == 0 || p.Index == "" {
+			return fmt.Errorf("Relative monthly recurrence needs a day of week and an index")
+		}
+	case RecurrenceAbsoluteYearly:
+		if p.Month < 1 || p.Month > 12 || p.DayOfMonth < 1 || p.DayOfMonth > 31 {
+			return fmt.Errorf("Absolute yearly recurrence needs a valid month and day of month")
+		}
+	case RecurrenceRelativeYearly:
+		if p.Month < 1 || p.Month > 12 || len(p.DaysOfWeek) == 0 || p.Index == "" {
+			return fmt.Errorf("Relative yearly recurrence needs a month, a day of week and an index")
+		}
+	default:
+		return fmt.Errorf("Unknown recurrence type %q", p.Type)
+	}
+
+	switch r.Range.Type {
+	case RangeNoEnd:
+	case RangeEndDate:
+		if _, err := time.Parse(RecurrenceDateLayout, r.Range.EndDate); err != nil {
+			return fmt.Errorf("Invalid recurrence end date %q", r.Range.EndDate)
+		}
+	case RangeNumbered:
+		if r.Range.NumberOfOccurrences < 1 {
+			return fmt.Errorf("Recurrence needs a positive number of occurrences")
+		}
+	default:
+		return fmt.Errorf("Unknown recurrence range type %q", r.Range.Type)
+	}
+
+	return nil
+}
+
+// Describes the recurrence in a human readable way, e.g.
+// `every 2 weeks on monday, wednesday until 2021-12-31`.
+func (r *PatternedRecurrence) String() string {
+	p := r.Pattern
+	var rule string
+
+	switch p.Type {
+	case RecurrenceDaily:
+		rule = every(p.Interval, "day")
+	case RecurrenceWeekly:
+		rule = fmt.Sprintf(
+			"%s on %s",
+			every(p.Interval, "week"),
+			strings.Join(p.DaysOfWeek, ", "),
+		)
+	case RecurrenceAbsoluteMonthly:
+		rule = fmt.Sprintf("%s on day %d", every(p.Interval, "month"), p.DayOfMonth)
+	case RecurrenceRelativeMonthly:
+		rule = fmt.Sprintf(
+			"%s on the %s %s",
+			every(p.Interval, "month"),
+			p.Index,
+			strings.Join(p.DaysOfWeek, ", "),
+		)
+	case RecurrenceAbsoluteYearly:
+		rule = fmt.Sprintf(
+			"%s on %s %d",
+			every(p.Interval, "year"),
+			time.Month(p.Month),
+			p.DayOfMonth,
+		)
+	case RecurrenceRelativeYearly:
+		rule = fmt.Sprintf(
+			"%s on the %s %s of %s",
+			every(p.Interval, "year"),
+			p.Index,
+			strings.Join(p.DaysOfWeek, ", "),
+			time.Month(p.Month),
+		)
+	default:
+		rule = p.Type
+	}
+
+	switch r.Range.Type {
+	case RangeEndDate:
+		rule += " until " + r.Range.EndDate
+	case RangeNumbered:
+		rule += fmt.Sprintf(", %d times", r.Range.NumberOfOccurrences)
+	}
+
+	return rule
+}
+
+// Parses the `monthly:SPEC` part of the shorthand. A number stands for a day
+// of the month, while `INDEX-DAY` (e.g. `last-fri`) for a relative one.
+func parseMonthlySpec(spec string, p *RecurrencePattern) error {
+	if day, err := strconv.Atoi(spec); err == nil {
+		p.Type = RecurrenceAbsoluteMonthly
+		p.DayOfMonth = day
+		return nil
+	}
+
+	index, day := splitOnce(spec, "-")
+	p.Type = RecurrenceRelativeMonthly
+
+	return setRelativeDay(index, day, p)
+}
+
+// Parses the `yearly:SPEC` part of the shorthand. It is either `MM-DD`
+// (e.g. `12-25`) or `INDEX-DAY-MONTH` (e.g. `first-mon-sep`).
+func parseYearlySpec(spec string, p *RecurrencePattern) error {
+	fields := strings.Split(spec, "-")
+
+	switch len(fields) {
+	case 2:
+		month, monthErr := strconv.Atoi(fields[0])
+		day, dayErr := strconv.Atoi(fields[1])
+		if monthErr != nil || dayErr != nil {
+			return fmt.Errorf("Invalid yearly recurrence %q, expected MM-DD", spec)
+		}
+
+		p.Type = RecurrenceAbsoluteYearly
+		p.Month = month
+		p.DayOfMonth = day
+
+		return nil
+	case 3:
+		month, err := parseMonth(fields[2])
+		if err != nil {
+			return err
+		}
+
+		p.Type = RecurrenceRelativeYearly
+		p.Month = month
+
+		return setRelativeDay(fields[0], fields[1], p)
+	}
+
+	return fmt.Errorf(
+		"Invalid yearly recurrence %q, expected MM-DD or INDEX-DAY-MONTH",
+		spec,
+	)
+}
+
+// Sets the index and the day of week of a relative recurrence pattern.
+func setRelativeDay(index, day string, p *RecurrencePattern) error {
+	idx, ok := weekIndexes[index]
+	if !ok {
+		return fmt.Errorf("Unknown week index %q", index)
+	}
+
+	days, err := parseWeekDays(day)
+	if err != nil {
+		return err
+	}
+
+	p.Index = idx
+	p.DaysOfWeek = days
+
+	return nil
+}
+
+// Applies a `key=value` modifier of the shorthand notation to the recurrence.
+func applyRecurrenceModifier(modifier string, r *PatternedRecurrence) error {
+	key, value := splitOnce(modifier, "=")
+
+	switch key {
+	case "every":
+		interval, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("Invalid recurrence interval %q", value)
+		}
+		r.Pattern.Interval = interval
+	case "until":
+		r.Range.Type = RangeEndDate
+		r.Range.EndDate = value
+	case "count":
+		count, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("Invalid number of occurrences %q", value)
+		}
+		r.Range.Type = RangeNumbered
+		r.Range.NumberOfOccurrences = count
+	default:
+		return fmt.Errorf("Unknown recurrence option %q", key)
+	}
+
+	return nil
+}
+
+// Converts a comma separated list of (short or full) week day names to the
+// full names the API expects.
+func ParseWeekDays(days string) ([]string, error) {
+	return parseWeekDays(strings.ToLower(days))
+}
+
+// The internal implementation of ParseWeekDays, expecting lower case input.
+func parseWeekDays(days string) ([]string, error) {
+	result := []string{}
+
+	for _, d := range strings.Split(days, ",") {
+		d = strings.TrimSpace(d)
+		if len(d) < 3 {
+			return nil, fmt.Errorf("Unknown day of week %q", d)
+		}
+
+		full, ok := weekDays[d[:3]]
+		if !ok || !strings.HasPrefix(full, d) {
+			return nil, fmt.Errorf("Unknown day of week %q", d)
+		}
+
+		result = append(result, full)
+	}
+
+	return result, nil
+}
+
+// Converts a month given either by number or by (short) name to its number.
+func parseMonth(month string) (int, error) {
+	if m, err := strconv.Atoi(month); err == nil {
+		return m, nil
+	}
+
+	for m := time.January; m <= time.December; m++ {
+		name := strings.ToLower(m.String())
+		if len(month) >= 3 && strings.HasPrefix(name, month) {
+			return int(m), nil
+		}
+	}
+
+	return 0, fmt.Errorf("Unknown month %q", month)
+}
+
+// Converts a week index given in any of its accepted spellings to the one
+// the API expects.
+func ParseWeekIndex(index string) (string, error) {
+	idx, ok := weekIndexes[strings.ToLower(index)]
+	if !ok {
+		return "", fmt.Errorf("Unknown week index %q", index)
+	}
+
+	return idx, nil
+}
+
+// Formats `every N units` taking care of the singular case.
+func every(interval int, unit string) string {
+	if interval <= 1 {
+		return "every " + unit
+	}
+
+	return fmt.Sprintf("every %d %ss", interval, unit)
+}
+
+// Splits a string on the first occurrence of `sep`.
+func splitOnce(s, sep string) (string, string) {
+	parts := strings.SplitN(s, sep, 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+
+	return parts[0], parts[1]
+}
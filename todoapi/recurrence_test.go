@@ -0,0 +1,126 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package todoapi
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseRecurrenceWeekly(test *testing.T) {
+	r, err := ParseRecurrence("weekly:mon,wed")
+
+	if err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	if r.Pattern.Type != RecurrenceWeekly {
+		test.Errorf("\nExpected type to be:\n%s\nbut was\n%s", RecurrenceWeekly, r.Pattern.Type)
+	}
+
+	expectedDays := []string{"monday", "wednesday"}
+	if !reflect.DeepEqual(r.Pattern.DaysOfWeek, expectedDays) {
+		test.Errorf("\nExpected days to be:\n%v\nbut was\n%v", expectedDays, r.Pattern.DaysOfWeek)
+	}
+
+	if r.Range.Type != RangeNoEnd {
+		test.Errorf("\nExpected range to be:\n%s\nbut was\n%s", RangeNoEnd, r.Range.Type)
+	}
+}
+
+func TestParseRecurrenceAbsoluteMonthly(test *testing.T) {
+	r, err := ParseRecurrence("monthly:15")
+
+	if err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	if r.Pattern.Type != RecurrenceAbsoluteMonthly || r.Pattern.DayOfMonth != 15 {
+		test.Errorf("\nExpected absolute monthly on day 15\nbut was\n%+v", r.Pattern)
+	}
+}
+
+func TestParseRecurrenceRelativeYearlyWithModifiers(test *testing.T) {
+	r, err := ParseRecurrence("yearly:first-mon-sep;every=2;count=5")
+
+	if err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	expected := PatternedRecurrence{
+		Pattern: RecurrencePattern{
+			Type:       RecurrenceRelativeYearly,
+			Interval:   2,
+			DaysOfWeek: []string{"monday"},
+			Month:      9,
+			Index:      "first",
+		},
+		Range: RecurrenceRange{Type: RangeNumbered, NumberOfOccurrences: 5},
+	}
+
+	if !reflect.DeepEqual(*r, expected) {
+		test.Errorf("\nExpected recurrence to be:\n%+v\nbut was\n%+v", expected, *r)
+	}
+}
+
+func TestParseRecurrenceUntil(test *testing.T) {
+	r, err := ParseRecurrence("monthly:last-fri;until=2021-12-31")
+
+	if err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	if r.Range.Type != RangeEndDate || r.Range.EndDate != "2021-12-31" {
+		test.Errorf("\nExpected to end on 2021-12-31\nbut was\n%+v", r.Range)
+	}
+
+	if r.Pattern.Index != "last" {
+		test.Errorf("\nExpected index to be:\nlast\nbut was\n%s", r.Pattern.Index)
+	}
+}
+
+func TestParseRecurrenceFailure(test *testing.T) {
+	for _, in := range []string{
+		"hourly",
+		"weekly:someday",
+		"monthly:42",
+		"monthly:fifth-mon",
+		"yearly:13-01",
+		"daily;until=tomorrow",
+		"daily;count=0",
+		"daily;often=yes",
+	} {
+		if _, err := ParseRecurrence(in); err == nil {
+			test.Errorf("\nExpected %q to return error\nbut it was\nnil", in)
+		}
+	}
+}
+
+func TestRecurrenceString(test *testing.T) {
+	r, _ := ParseRecurrence("weekly:mon,wed;every=2;until=2021-12-31")
+	expected := "every 2 weeks on monday, wednesday until 2021-12-31"
+
+	if r.String() != expected {
+		test.Errorf("\nExpected rule to be:\n%s\nbut was\n%s", expected, r.String())
+	}
+
+	r, _ = ParseRecurrence("yearly:last-fri-nov;count=3")
+	expected = "every year on the last friday of November, 3 times"
+
+	if r.String() != expected {
+		test.Errorf("\nExpected rule to be:\n%s\nbut was\n%s", expected, r.String())
+	}
+}
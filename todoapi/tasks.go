@@ -0,0 +1,308 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package todoapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// The layout the API uses for the `dateTime` part of a `dateTimeTimeZone`.
+const DateTimeLayout string = "2006-01-02T15:04:05.0000000"
+
+// The path (relative to a list) of the tasks' endpoints.
+const tasksPath string = "/tasks/"
+
+type TaskItem struct {
+	Id                   string               `json:"id,omitempty"`
+	Title                string               `json:"title,omitempty"`
+	Status               string               `json:"status,omitempty"`
+	Importance           string               `json:"importance,omitempty"`
+	Body                 *ItemBody            `json:"body,omitempty"`
+	Categories           []string             `json:"categories,omitempty"`
+	DueDateTime          *DateTimeTimeZone    `json:"dueDateTime,omitempty"`
+	IsReminderOn         bool                 `json:"isReminderOn,omitempty"`
+	ReminderDateTime     *DateTimeTimeZone    `json:"reminderDateTime,omitempty"`
+	Recurrence           *PatternedRecurrence `json:"recurrence,omitempty"`
+	CompletedDateTime    *DateTimeTimeZone    `json:"completedDateTime,omitempty"`
+	CreatedDateTime      string               `json:"createdDateTime,omitempty"`
+	LastModifiedDateTime string               `json:"lastModifiedDateTime,omitempty"`
+}
+
+type ItemBody struct {
+	Content     string `json:"content"`
+	ContentType string `json:"contentType"`
+}
+
+type DateTimeTimeZone struct {
+	DateTime string `json:"dateTime"`
+	TimeZone string `json:"timeZone"`
+}
+
+type TasksResponse struct {
+	Context  string     `json:"@odata.context"`
+	NextLink string     `json:"@odata.nextLink,omitempty"`
+	Count    int        `json:"@odata.count,omitempty"`
+	Tasks    []TaskItem `json:"value"`
+}
+
+// Builds a DateTimeTimeZone out of a time, keeping its wall-clock date and
+// time along with its location. The unnamed locations (e.g. the local one
+// when `TZ` isn't set) are sent as the system's time zone or, failing that,
+// as the `Etc/GMT` zone of their offset. Only the times with an offset no
+// zone has (e.g. +05:45 without a name) are converted to UTC.
+func NewDateTimeTimeZone(t time.Time) *DateTimeTimeZone {
+	zone := zoneName(t)
+	if zone == "" {
+		t = t.UTC()
+		zone = "UTC"
+	}
+
+	return &DateTimeTimeZone{
+		DateTime: t.Format(DateTimeLayout),
+		TimeZone: zone,
+	}
+}
+
+// Returns the name of the time zone of a time the API understands, empty
+// when there's none with its offset.
+func zoneName(t time.Time) string {
+	name := t.Location().String()
+	if name != "Local" && name != "" {
+		if _, err := time.LoadLocation(name); err == nil {
+			return name
+		}
+	}
+
+	_, offset := t.Zone()
+	for _, candidate := range systemZoneNames() {
+		loc, err := time.LoadLocation(candidate)
+		if err != nil {
+			continue
+		}
+
+		if _, o := t.In(loc).Zone(); o == offset {
+			return candidate
+		}
+	}
+
+	switch {
+	case offset == 0:
+		return "UTC"
+	case offset%3600 == 0:
+		// The signs of the Etc/GMT zones are inverted, e.g. Etc/GMT-3 is UTC+3.
+		return fmt.Sprintf("Etc/GMT%+d", -offset/3600)
+	}
+
+	return ""
+}
+
+// Returns the names the system's time zone may go by: the one in `TZ` and
+// the one `/etc/localtime` links to. Swapped in the tests.
+var systemZoneNames func() []string = func() []string {
+	names := []string{}
+
+	if tz := strings.TrimPrefix(os.Getenv("TZ"), ":"); tz != "" {
+		names = append(names, tz)
+	}
+
+	if link, err := os.Readlink("/etc/localtime"); err == nil {
+		if i := strings.Index(link, "zoneinfo/"); i != -1 {
+			names = append(names, link[i+len("zoneinfo/"):])
+		}
+	}
+
+	return names
+}
+
+// Converts the DateTimeTimeZone to a time. Time zones unknown to the system
+// (e.g. the Windows ones the API sometimes returns) are treated as UTC.
+func (d *DateTimeTimeZone) Time() (time.Time, error) {
+	loc, err := time.LoadLocation(d.TimeZone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	value := d.DateTime
+	if i := strings.Index(value, "."); i != -1 {
+		value = value[:i]
+	}
+
+	return time.ParseInLocation("2006-01-02T15:04:05", value, loc)
+}
+
+// Retrieves the collection of `TaskItem`s in a list.
+func (ta *TodoApi) TasksIndex(ctx context.Context, listId string) (*[]TaskItem, error) {
+	return retrieveTasks(ctx, ta.token, listId)
+}
+
+// Retrieves the `TaskItem`s in a list matching a Query, along with their
+// number when the query asks for it.
+func (ta *TodoApi) TasksQuery(ctx context.Context, listId string, query Query) (*TasksResponse, error) {
+	return queryTasks(ctx, ta.token, listId, query)
+}
+
+// Retrieves a single `TaskItem` from a list.
+func (ta *TodoApi) TasksShow(ctx context.Context, listId, taskId string) (*TaskItem, error) {
+	return retrieveTask(ctx, ta.token, listId, taskId)
+}
+
+// Creates a TaskItem in a list.
+func (ta *TodoApi) TasksCreate(ctx context.Context, listId string, task *TaskItem) (*TaskItem, error) {
+	return createATask(ctx, ta.token, listId, task)
+}
+
+// Updates a TaskItem, changing only the attributes that are set in `task`.
+func (ta *TodoApi) TasksUpdate(ctx context.Context, listId, taskId string, task *TaskItem) (*TaskItem, error) {
+	return updateTask(ctx, ta.token, listId, taskId, task)
+}
+
+// Deletes a TaskItem from a list.
+func (ta *TodoApi) TasksDelete(ctx context.Context, listId, taskId string) error {
+	return deleteTask(ctx, ta.token, listId, taskId)
+}
+
+// The function that is responsible for building the HTTP requests and
+// handling the responses of the 'List tasks' API endpoint. It follows the
+// `@odata.nextLink`s until all the pages are retrieved.
+func retrieveTasks(ctx context.Context, token, listId string) (*[]TaskItem, error) {
+	tasksResponse, err := queryTasks(ctx, token, listId, Query{})
+	if err != nil {
+		return nil, err
+	}
+
+	return &tasksResponse.Tasks, nil
+}
+
+// Does the same as `retrieveTasks` with a Query, stopping once the `Top`
+// tasks are retrieved.
+func queryTasks(ctx context.Context, token, listId string, query Query) (*TasksResponse, error) {
+	result := TasksResponse{Tasks: []TaskItem{}}
+	url := listsIndexEndpoint + listId + tasksPath + query.String()
+
+	for url != "" {
+		body, err := sendApiRequest(ctx, "GET", url, token, nil, 200)
+		if err != nil {
+			return nil, err
+		}
+
+		page := TasksResponse{}
+		if err = json.Unmarshal(body, &page); err != nil {
+			return nil, err
+		}
+
+		if result.Context == "" {
+			result.Context, result.Count = page.Context, page.Count
+		}
+
+		result.Tasks = append(result.Tasks, page.Tasks...)
+		url = page.NextLink
+
+		if query.done(len(result.Tasks)) {
+			result.Tasks = result.Tasks[:query.Top]
+			break
+		}
+	}
+
+	return &result, nil
+}
+
+// The function that is responsible for building the HTTP request and handling
+// the response of the 'Get a task' API endpoint.
+func retrieveTask(ctx context.Context, token, listId, taskId string) (*TaskItem, error) {
+	body, err := sendApiRequest(
+		ctx,
+		"GET",
+		listsIndexEndpoint+listId+tasksPath+taskId,
+		token,
+		nil,
+		200,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return unmarshalTask(body)
+}
+
+// The function that is responsible for building the HTTP request and handling
+// the response of the 'Create a task' API endpoint.
+func createATask(ctx context.Context, token, listId string, task *TaskItem) (*TaskItem, error) {
+	body, err := sendApiRequest(
+		ctx,
+		"POST",
+		listsIndexEndpoint+listId+tasksPath,
+		token,
+		task,
+		201,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return unmarshalTask(body)
+}
+
+// The function that is responsible for building the HTTP request and handling
+// the response of the 'Update a task' API endpoint.
+func updateTask(ctx context.Context, token, listId, taskId string, task *TaskItem) (*TaskItem, error) {
+	body, err := sendApiRequest(
+		ctx,
+		"PATCH",
+		listsIndexEndpoint+listId+tasksPath+taskId,
+		token,
+		task,
+		200,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return unmarshalTask(body)
+}
+
+// The function that is responsible for building the HTTP request and handling
+// the response of the 'Delete a task' API endpoint.
+func deleteTask(ctx context.Context, token, listId, taskId string) error {
+	_, err := sendApiRequest(
+		ctx,
+		"DELETE",
+		listsIndexEndpoint+listId+tasksPath+taskId,
+		token,
+		nil,
+		204,
+	)
+
+	return err
+}
+
+// Unmarshals the body of a response holding a single task.
+func unmarshalTask(body []byte) (*TaskItem, error) {
+	task := TaskItem{}
+	if err := json.Unmarshal(body, &task); err != nil {
+		return nil, err
+	}
+
+	return &task, nil
+}
@@ -0,0 +1,208 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package todoapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	httpService "github.com/betasve/mstd/ext/http/httptest"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+const taskResponse1 string = `{
+  "@odata.etag": "W/\"xzyPKP0BiUGgld+lMKXwbQAAgdhkVw==\"",
+  "id": "t1",
+  "title": "Task Title 1",
+  "status": "notStarted",
+  "importance": "high",
+  "dueDateTime": {
+    "dateTime": "2021-05-03T00:00:00.0000000",
+    "timeZone": "UTC"
+  },
+  "recurrence": {
+    "pattern": {
+      "type": "weekly",
+      "interval": 1,
+      "daysOfWeek": ["monday", "wednesday"],
+      "firstDayOfWeek": "sunday"
+    },
+    "range": {
+      "type": "noEnd",
+      "startDate": "2021-05-03",
+      "endDate": "0001-01-01"
+    }
+  }
+}`
+
+const taskResponse2 string = `{
+  "id": "t2",
+  "title": "Task Title 2",
+  "status": "completed",
+  "importance": "normal"
+}`
+
+func TestTasksIndexFollowsNextLink(test *testing.T) {
+	httpService.NewRequestStubFn = http.NewRequest
+	api := TodoApi{}
+	api.SetToken("token")
+
+	pages := map[string]string{
+		"graph.microsoft.com": fmt.Sprintf(
+			`{ "@odata.nextLink": "https://next/page", "value": [%s] }`,
+			taskResponse1,
+		),
+		"next": fmt.Sprintf(`{ "value": [%s] }`, taskResponse2),
+	}
+
+	httpService.MockFn = func(req *http.Request) (*http.Response, error) {
+		res := &http.Response{StatusCode: 200}
+		res.Body = ioutil.NopCloser(strings.NewReader(pages[req.URL.Host]))
+		return res, nil
+	}
+
+	tasks, err := api.TasksIndex(context.Background(), "list-id")
+
+	if err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	if len(*tasks) != 2 {
+		test.Fatalf("\nExpected a list of 2:\nbut got\n%d", len(*tasks))
+	}
+
+	task := (*tasks)[0]
+	if task.Recurrence == nil || task.Recurrence.Pattern.Type != RecurrenceWeekly {
+		test.Errorf("\nExpected a weekly recurrence\nbut got\n%+v", task.Recurrence)
+	}
+
+	if (*tasks)[1].Title != "Task Title 2" {
+		test.Errorf("\nExpected second task title:\nTask Title 2\nbut got\n%s", (*tasks)[1].Title)
+	}
+}
+
+func TestTasksCreateSendsRecurrence(test *testing.T) {
+	httpService.NewRequestStubFn = http.NewRequest
+	api := TodoApi{}
+	api.SetToken("token")
+
+	var sent TaskItem
+	var method, path string
+
+	httpService.MockFn = func(req *http.Request) (*http.Response, error) {
+		method = req.Method
+		path = req.URL.Path
+		body, _ := ioutil.ReadAll(req.Body)
+		_ = json.Unmarshal(body, &sent)
+
+		res := &http.Response{StatusCode: 201}
+		res.Body = ioutil.NopCloser(strings.NewReader(taskResponse1))
+		return res, nil
+	}
+
+	recurrence, _ := ParseRecurrence("weekly:mon,wed")
+	task, err := api.TasksCreate(
+		context.Background(),
+		"list-id",
+		&TaskItem{Title: "Task Title 1", Recurrence: recurrence},
+	)
+
+	if err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	if method != "POST" || path != "/v1.0/me/todo/lists/list-id/tasks/" {
+		test.Errorf("\nExpected POST to the tasks endpoint\nbut was\n%s %s", method, path)
+	}
+
+	if sent.Recurrence == nil || len(sent.Recurrence.Pattern.DaysOfWeek) != 2 {
+		test.Errorf("\nExpected the recurrence to be sent\nbut it was\n%+v", sent.Recurrence)
+	}
+
+	if task.Id != "t1" {
+		test.Errorf("\nExpected id to be:\nt1\nbut was\n%s", task.Id)
+	}
+}
+
+func TestUpdateTaskFailureWithWrongCode(test *testing.T) {
+	httpService.NewRequestStubFn = http.NewRequest
+	stubHttp(404, `{"error": {"code": "NotFound"}}`)
+
+	_, err := updateTask(context.Background(), "token", "list-id", "task-id", &TaskItem{Title: "new"})
+
+	if err == nil {
+		test.Error("\nExpected to return error\nbut it was\nnil")
+	}
+}
+
+func TestDateTimeTimeZoneTime(test *testing.T) {
+	d := DateTimeTimeZone{
+		DateTime: "2021-05-03T17:30:00.0000000",
+		TimeZone: "Europe/Sofia",
+	}
+
+	result, err := d.Time()
+	if err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	expected := time.Date(2021, 5, 3, 14, 30, 0, 0, time.UTC)
+	if !result.Equal(expected) {
+		test.Errorf("\nExpected time to be:\n%s\nbut was\n%s", expected, result)
+	}
+}
+
+func TestNewDateTimeTimeZoneKeepsTheLocalWallClock(test *testing.T) {
+	local, names := time.Local, systemZoneNames
+	time.Local = time.FixedZone("", 3*60*60)
+	systemZoneNames = func() []string { return nil }
+	defer func() { time.Local, systemZoneNames = local, names }()
+
+	due := time.Date(2021, 5, 3, 0, 0, 0, 0, time.Local)
+	result := NewDateTimeTimeZone(due)
+
+	if result.DateTime != "2021-05-03T00:00:00.0000000" || result.TimeZone != "Etc/GMT-3" {
+		test.Fatalf("\nExpected the date to be kept in a named zone\nbut was\n%+v", result)
+	}
+
+	if parsed, err := result.Time(); err != nil || !parsed.Equal(due) {
+		test.Errorf("\nExpected the time to be\n%s\nbut was\n%s (%v)", due, parsed, err)
+	}
+}
+
+func TestNewDateTimeTimeZoneNamedZones(test *testing.T) {
+	names := systemZoneNames
+	systemZoneNames = func() []string { return nil }
+	defer func() { systemZoneNames = names }()
+
+	sofia, _ := time.LoadLocation("Europe/Sofia")
+	cases := map[*time.Location]string{
+		time.UTC:                          "UTC",
+		sofia:                             "Europe/Sofia",
+		time.FixedZone("", -5*60*60):      "Etc/GMT+5",
+		time.FixedZone("", 5*60*60+45*60): "UTC",
+	}
+
+	for loc, expected := range cases {
+		if zone := NewDateTimeTimeZone(time.Date(2021, 5, 3, 9, 0, 0, 0, loc)).TimeZone; zone != expected {
+			test.Errorf("\nExpected the zone of %s to be\n%s\nbut was\n%s", loc, expected, zone)
+		}
+	}
+}
@@ -16,7 +16,11 @@ limitations under the License.
 package todoapitest
 
 import (
+	"context"
+	"encoding/json"
 	api "github.com/betasve/mstd/todoapi"
+	"strconv"
+	"strings"
 )
 
 type TodoApiMock struct {
@@ -27,6 +31,10 @@ var ListsIndexMockFn = func() (*[]api.ListsItem, error) {
 	return &[]api.ListsItem{}, nil
 }
 
+var ListsQueryMockFn = func(q api.Query) (*api.ListsResponse, error) {
+	return &api.ListsResponse{}, nil
+}
+
 var ListsCreateMockFn = func(n string) (*api.ListsItem, error) {
 	return &api.ListsItem{}, nil
 }
@@ -35,18 +43,133 @@ var ListsUpdateMockFn = func(i, n string) (*api.ListsItem, error) {
 	return &api.ListsItem{}, nil
 }
 
-func (ta *TodoApiMock) ListsIndex() (*[]api.ListsItem, error) {
+var TasksIndexMockFn = func(l string) (*[]api.TaskItem, error) {
+	return &[]api.TaskItem{}, nil
+}
+
+var TasksQueryMockFn = func(l string, q api.Query) (*api.TasksResponse, error) {
+	return &api.TasksResponse{}, nil
+}
+
+var TasksShowMockFn = func(l, i string) (*api.TaskItem, error) {
+	return &api.TaskItem{}, nil
+}
+
+var TasksCreateMockFn = func(l string, t *api.TaskItem) (*api.TaskItem, error) {
+	return t, nil
+}
+
+var TasksUpdateMockFn = func(l, i string, t *api.TaskItem) (*api.TaskItem, error) {
+	return t, nil
+}
+
+var TasksDeleteMockFn = func(l, i string) error {
+	return nil
+}
+
+var ChecklistItemsIndexMockFn = func(l, t string) (*[]api.ChecklistItem, error) {
+	return &[]api.ChecklistItem{}, nil
+}
+
+var ChecklistItemsCreateMockFn = func(l, t string, c *api.ChecklistItem) (*api.ChecklistItem, error) {
+	return c, nil
+}
+
+var LinkedResourcesIndexMockFn = func(l, t string) (*[]api.LinkedResource, error) {
+	return &[]api.LinkedResource{}, nil
+}
+
+var LinkedResourcesCreateMockFn = func(l, t string, r *api.LinkedResource) (*api.LinkedResource, error) {
+	return r, nil
+}
+
+// By default the task requests of a batch go to the mocks of the single
+// requests, e.g. a task created in a batch goes to TasksCreateMockFn.
+var BatchMockFn = func(r []api.BatchRequest) (map[string]*api.BatchResponse, error) {
+	responses := map[string]*api.BatchResponse{}
+	for _, request := range r {
+		responses[request.Id] = mockBatchRequest(request)
+	}
+
+	return responses, nil
+}
+
+var ListsDeltaMockFn = func() (*api.ListsDelta, error) {
+	return &api.ListsDelta{}, nil
+}
+
+var TasksDeltaMockFn = func(l string) (*api.TasksDelta, error) {
+	return &api.TasksDelta{}, nil
+}
+
+func (ta *TodoApiMock) ListsIndex(ctx context.Context) (*[]api.ListsItem, error) {
 	return ListsIndexMockFn()
 }
 
-func (ta *TodoApiMock) ListsCreate(name string) (*api.ListsItem, error) {
+func (ta *TodoApiMock) ListsQuery(ctx context.Context, query api.Query) (*api.ListsResponse, error) {
+	return ListsQueryMockFn(query)
+}
+
+func (ta *TodoApiMock) ListsCreate(ctx context.Context, name string) (*api.ListsItem, error) {
 	return ListsCreateMockFn(name)
 }
 
-func (ta *TodoApiMock) ListsUpdate(id, name string) (*api.ListsItem, error) {
+func (ta *TodoApiMock) ListsUpdate(ctx context.Context, id, name string) (*api.ListsItem, error) {
 	return ListsUpdateMockFn(id, name)
 }
 
+func (ta *TodoApiMock) TasksIndex(ctx context.Context, listId string) (*[]api.TaskItem, error) {
+	return TasksIndexMockFn(listId)
+}
+
+func (ta *TodoApiMock) TasksQuery(ctx context.Context, listId string, query api.Query) (*api.TasksResponse, error) {
+	return TasksQueryMockFn(listId, query)
+}
+
+func (ta *TodoApiMock) TasksShow(ctx context.Context, listId, taskId string) (*api.TaskItem, error) {
+	return TasksShowMockFn(listId, taskId)
+}
+
+func (ta *TodoApiMock) TasksCreate(ctx context.Context, listId string, task *api.TaskItem) (*api.TaskItem, error) {
+	return TasksCreateMockFn(listId, task)
+}
+
+func (ta *TodoApiMock) TasksUpdate(ctx context.Context, listId, taskId string, task *api.TaskItem) (*api.TaskItem, error) {
+	return TasksUpdateMockFn(listId, taskId, task)
+}
+
+func (ta *TodoApiMock) TasksDelete(ctx context.Context, listId, taskId string) error {
+	return TasksDeleteMockFn(listId, taskId)
+}
+
+func (ta *TodoApiMock) ChecklistItemsIndex(ctx context.Context, listId, taskId string) (*[]api.ChecklistItem, error) {
+	return ChecklistItemsIndexMockFn(listId, taskId)
+}
+
+func (ta *TodoApiMock) ChecklistItemsCreate(ctx context.Context, listId, taskId string, item *api.ChecklistItem) (*api.ChecklistItem, error) {
+	return ChecklistItemsCreateMockFn(listId, taskId, item)
+}
+
+func (ta *TodoApiMock) LinkedResourcesIndex(ctx context.Context, listId, taskId string) (*[]api.LinkedResource, error) {
+	return LinkedResourcesIndexMockFn(listId, taskId)
+}
+
+func (ta *TodoApiMock) LinkedResourcesCreate(ctx context.Context, listId, taskId string, resource *api.LinkedResource) (*api.LinkedResource, error) {
+	return LinkedResourcesCreateMockFn(listId, taskId, resource)
+}
+
+func (ta *TodoApiMock) Batch(ctx context.Context, requests []api.BatchRequest) (map[string]*api.BatchResponse, error) {
+	return BatchMockFn(requests)
+}
+
+func (ta *TodoApiMock) ListsDelta(ctx context.Context) (*api.ListsDelta, error) {
+	return ListsDeltaMockFn()
+}
+
+func (ta *TodoApiMock) TasksDelta(ctx context.Context, listId string) (*api.TasksDelta, error) {
+	return TasksDeltaMockFn(listId)
+}
+
 func (ta *TodoApiMock) SetToken(token string) {
 	ta.token = token
 }
@@ -54,3 +177,36 @@ func (ta *TodoApiMock) SetToken(token string) {
 func (ta *TodoApiMock) Token() string {
 	return ta.token
 }
+
+// Runs a request of a batch through the mock of the matching single request.
+func mockBatchRequest(request api.BatchRequest) *api.BatchResponse {
+	parts := strings.Split(strings.Trim(request.Url, "/"), "/")
+	if len(parts) < 5 || parts[4] != "tasks" || (request.Method != "POST" && len(parts) < 6) {
+		return &api.BatchResponse{Id: request.Id, Status: 404}
+	}
+
+	listId := parts[3]
+	task, _ := request.Body.(*api.TaskItem)
+
+	var result interface{}
+	var err error
+	status := 200
+
+	switch request.Method {
+	case "POST":
+		result, err = TasksCreateMockFn(listId, task)
+		status = 201
+	case "PATCH":
+		result, err = TasksUpdateMockFn(listId, parts[5], task)
+	case "DELETE":
+		err = TasksDeleteMockFn(listId, parts[5])
+		status = 204
+	}
+
+	if err != nil {
+		return &api.BatchResponse{Id: request.Id, Status: 400, Body: []byte(strconv.Quote(err.Error()))}
+	}
+
+	body, _ := json.Marshal(result)
+	return &api.BatchResponse{Id: request.Id, Status: status, Body: body}
+}
@@ -0,0 +1,248 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The `todotxt` package reads and writes tasks in the todo.txt format
+// (http://todotxt.org): one task per line, optionally marked completed with
+// `x`, with a `(A)` like priority, creation and completion dates, `+project`
+// and `@context` tags and `key:value` extensions (e.g. `due:2021-05-03`).
+package todotxt
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// The layout of the dates in todo.txt.
+const DateLayout string = "2006-01-02"
+
+// The extension holding the due date of a task.
+const DueKey string = "due"
+
+var priorityPattern *regexp.Regexp = regexp.MustCompile(`^\(([A-Z])\)$`)
+
+// An extension is `key:value`, the key starting with a letter (so a time
+// like `10:30` stays in the title).
+var extensionPattern *regexp.Regexp = regexp.MustCompile(`^([A-Za-z][^:\s/]*):([^:\s/][^:\s]*)$`)
+
+// A task of a todo.txt file. The dates are zero when missing.
+type Task struct {
+	Completed      bool
+	Priority       string
+	CompletionDate time.Time
+	CreationDate   time.Time
+	Title          string
+	Projects       []string
+	Contexts       []string
+	Extensions     map[string]string
+	// The order the extensions appeared in, to write them back in it.
+	extensionKeys []string
+}
+
+// Reads all the tasks of a todo.txt file, skipping the empty lines.
+func Parse(r io.Reader) ([]Task, error) {
+	tasks := []Task{}
+	scanner := bufio.NewScanner(r)
+	number := 0
+
+	for scanner.Scan() {
+		number++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		task, err := ParseLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("Line %d: %s", number, err)
+		}
+
+		tasks = append(tasks, task)
+	}
+
+	return tasks, scanner.Err()
+}
+
+// Reads a single task. The tags (projects, contexts and extensions) are
+// taken out of the title.
+func ParseLine(line string) (Task, error) {
+	task := Task{Extensions: map[string]string{}}
+	words := strings.Fields(line)
+
+	if len(words) > 0 && words[0] == "x" {
+		task.Completed = true
+		words = words[1:]
+
+		// A completed task can only have a creation date after a completion one.
+		if date, ok := parseDate(words); ok {
+			task.CompletionDate = date
+			words = words[1:]
+
+			if date, ok := parseDate(words); ok {
+				task.CreationDate = date
+				words = words[1:]
+			}
+		}
+	}
+
+	if len(words) > 0 && priorityPattern.MatchString(words[0]) {
+		task.Priority = words[0][1:2]
+		words = words[1:]
+	}
+
+	if date, ok := parseDate(words); ok && !task.Completed {
+		task.CreationDate = date
+		words = words[1:]
+	}
+
+	title := []string{}
+	for _, w := range words {
+		switch {
+		case len(w) > 1 && w[0] == '+':
+			task.Projects = append(task.Projects, w[1:])
+		case len(w) > 1 && w[0] == '@':
+			task.Contexts = append(task.Contexts, w[1:])
+		case extensionPattern.MatchString(w):
+			match := extensionPattern.FindStringSubmatch(w)
+			task.SetExtension(match[1], match[2])
+		default:
+			title = append(title, w)
+		}
+	}
+
+	task.Title = strings.Join(title, " ")
+	if task.Title == "" {
+		return task, fmt.Errorf("Task without a title: %q", line)
+	}
+
+	if due, ok := task.Extensions[DueKey]; ok {
+		if _, err := time.Parse(DateLayout, due); err != nil {
+			return task, fmt.Errorf("Invalid due date %q", due)
+		}
+	}
+
+	return task, nil
+}
+
+// Sets a `key:value` extension.
+func (t *Task) SetExtension(key, value string) {
+	if t.Extensions == nil {
+		t.Extensions = map[string]string{}
+	}
+
+	if _, ok := t.Extensions[key]; !ok {
+		t.extensionKeys = append(t.extensionKeys, key)
+	}
+
+	t.Extensions[key] = value
+}
+
+// Returns the due date of the task, if it has one.
+func (t Task) Due() (time.Time, bool) {
+	due, err := time.Parse(DateLayout, t.Extensions[DueKey])
+
+	return due, err == nil
+}
+
+// Formats the task as a todo.txt line.
+func (t Task) String() string {
+	parts := []string{}
+
+	if t.Completed {
+		parts = append(parts, "x")
+
+		if !t.CompletionDate.IsZero() {
+			parts = append(parts, t.CompletionDate.Format(DateLayout))
+
+			if !t.CreationDate.IsZero() {
+				parts = append(parts, t.CreationDate.Format(DateLayout))
+			}
+		}
+	}
+
+	if t.Priority != "" {
+		parts = append(parts, "("+t.Priority+")")
+	}
+
+	if !t.Completed && !t.CreationDate.IsZero() {
+		parts = append(parts, t.CreationDate.Format(DateLayout))
+	}
+
+	parts = append(parts, strings.Join(strings.Fields(t.Title), " "))
+
+	for _, p := range t.Projects {
+		parts = append(parts, "+"+Tag(p))
+	}
+
+	for _, c := range t.Contexts {
+		parts = append(parts, "@"+Tag(c))
+	}
+
+	keys := append([]string{}, t.extensionKeys...)
+	for k := range t.Extensions {
+		if !contains(keys, k) {
+			keys = append(keys, k)
+		}
+	}
+
+	for _, k := range keys {
+		parts = append(parts, k+":"+t.Extensions[k])
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// Writes the tasks, one per line.
+func Write(w io.Writer, tasks []Task) error {
+	for _, t := range tasks {
+		if _, err := fmt.Fprintln(w, t.String()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Turns a name into a (project or context) tag, joining its words with
+// dashes as tags can't have spaces.
+func Tag(name string) string {
+	return strings.Join(strings.Fields(name), "-")
+}
+
+// Parses the first word as a date, if it is one.
+func parseDate(words []string) (time.Time, bool) {
+	if len(words) == 0 {
+		return time.Time{}, false
+	}
+
+	date, err := time.Parse(DateLayout, words[0])
+
+	return date, err == nil
+}
+
+// Checks if a word is in a list.
+func contains(words []string, word string) bool {
+	for _, w := range words {
+		if w == word {
+			return true
+		}
+	}
+
+	return false
+}
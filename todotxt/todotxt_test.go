@@ -0,0 +1,105 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package todotxt
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseLine(test *testing.T) {
+	task, err := ParseLine("(A) 2021-05-01 Call mom +Family @phone due:2021-05-03 rec:1w")
+	if err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	expected := Task{
+		Priority:      "A",
+		CreationDate:  time.Date(2021, 5, 1, 0, 0, 0, 0, time.UTC),
+		Title:         "Call mom",
+		Projects:      []string{"Family"},
+		Contexts:      []string{"phone"},
+		Extensions:    map[string]string{"due": "2021-05-03", "rec": "1w"},
+		extensionKeys: []string{"due", "rec"},
+	}
+
+	if !reflect.DeepEqual(task, expected) {
+		test.Errorf("\nExpected task to be:\n%+v\nbut was\n%+v", expected, task)
+	}
+}
+
+func TestParseCompleted(test *testing.T) {
+	task, err := ParseLine("x 2021-05-04 2021-05-01 Pay rent http://bank.example")
+	if err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	if !task.Completed ||
+		task.CompletionDate.Day() != 4 ||
+		task.CreationDate.Day() != 1 ||
+		task.Title != "Pay rent http://bank.example" {
+		test.Errorf("\nExpected a completed task with both dates\nbut was\n%+v", task)
+	}
+}
+
+func TestParseTimesInTheTitle(test *testing.T) {
+	task, err := ParseLine("Call mom at 10:30 or 18:00:15 t:2021-05-02")
+	if err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	if task.Title != "Call mom at 10:30 or 18:00:15" ||
+		!reflect.DeepEqual(task.Extensions, map[string]string{"t": "2021-05-02"}) {
+		test.Errorf("\nExpected the times to stay in the title\nbut was\n%+v", task)
+	}
+}
+
+func TestParseFailures(test *testing.T) {
+	for _, line := range []string{"(A) +Family @phone", "Call due:someday"} {
+		if _, err := ParseLine(line); err == nil {
+			test.Errorf("\nExpected %q to return error\nbut it was\nnil", line)
+		}
+	}
+
+	_, err := Parse(strings.NewReader("Call mom\n\n(B)\n"))
+	if err == nil || !strings.HasPrefix(err.Error(), "Line 3") {
+		test.Errorf("\nExpected an error on line 3\nbut got\n%v", err)
+	}
+}
+
+func TestStringRoundTrip(test *testing.T) {
+	for _, line := range []string{
+		"(B) 2021-05-01 Call mom +Family @phone due:2021-05-03",
+		"x 2021-05-04 2021-05-01 (A) Pay rent +Home",
+		"Plain task",
+	} {
+		task, err := ParseLine(line)
+		if err != nil {
+			test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+		}
+
+		if task.String() != line {
+			test.Errorf("\nExpected task to be written as:\n%s\nbut was\n%s", line, task.String())
+		}
+	}
+
+	task := Task{Title: "Read", Projects: []string{"Reading List"}}
+	if task.String() != "Read +Reading-List" {
+		test.Errorf("\nExpected the project to be a tag\nbut was\n%s", task.String())
+	}
+}
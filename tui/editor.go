@@ -0,0 +1,115 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tui
+
+import (
+	"unicode"
+)
+
+// Edits a single line of text, like the prompt of a shell: it supports
+// moving the cursor, deleting characters and words, and clearing the line.
+type LineEditor struct {
+	text   []rune
+	cursor int
+}
+
+// Creates a LineEditor holding `text`, with the cursor at its end.
+func NewLineEditor(text string) *LineEditor {
+	e := &LineEditor{}
+	e.SetText(text)
+
+	return e
+}
+
+// Returns the text being edited.
+func (e *LineEditor) Text() string {
+	return string(e.text)
+}
+
+// Returns the position of the cursor (in characters).
+func (e *LineEditor) Cursor() int {
+	return e.cursor
+}
+
+// Replaces the text, moving the cursor to its end.
+func (e *LineEditor) SetText(text string) {
+	e.text = []rune(text)
+	e.cursor = len(e.text)
+}
+
+// Applies an editing key. It returns `false` for the keys that don't edit
+// the line (e.g. Enter), leaving them to the caller.
+func (e *LineEditor) HandleKey(k Key) bool {
+	switch k.Kind {
+	case KeyRune:
+		e.insert(k.Rune)
+	case KeyBackspace:
+		if e.cursor > 0 {
+			e.text = append(e.text[:e.cursor-1], e.text[e.cursor:]...)
+			e.cursor--
+		}
+	case KeyDelete:
+		if e.cursor < len(e.text) {
+			e.text = append(e.text[:e.cursor], e.text[e.cursor+1:]...)
+		}
+	case KeyLeft:
+		if e.cursor > 0 {
+			e.cursor--
+		}
+	case KeyRight:
+		if e.cursor < len(e.text) {
+			e.cursor++
+		}
+	case KeyHome, KeyCtrlA:
+		e.cursor = 0
+	case KeyEnd, KeyCtrlE:
+		e.cursor = len(e.text)
+	case KeyCtrlK:
+		e.text = e.text[:e.cursor]
+	case KeyCtrlU:
+		e.text = e.text[e.cursor:]
+		e.cursor = 0
+	case KeyCtrlW:
+		e.deleteWord()
+	default:
+		return false
+	}
+
+	return true
+}
+
+// Inserts a character at the cursor.
+func (e *LineEditor) insert(r rune) {
+	e.text = append(e.text, 0)
+	copy(e.text[e.cursor+1:], e.text[e.cursor:])
+	e.text[e.cursor] = r
+	e.cursor++
+}
+
+// Deletes the word before the cursor (and the spaces after it).
+func (e *LineEditor) deleteWord() {
+	start := e.cursor
+	for start > 0 && unicode.IsSpace(e.text[start-1]) {
+		start--
+	}
+	for start > 0 && !unicode.IsSpace(e.text[start-1]) {
+		start--
+	}
+
+	e.text = append(e.text[:start], e.text[e.cursor:]...)
+	e.cursor = start
+}
@@ -0,0 +1,74 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package tui
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+func TestLineEditor(test *testing.T) {
+	e := NewLineEditor("buy milk")
+
+	for _, k := range []Key{
+		{Kind: KeyCtrlW},
+		{Kind: KeyRune, Rune: 'b'},
+		{Kind: KeyHome},
+		{Kind: KeyDelete},
+		{Kind: KeyRune, Rune: 'g'},
+		{Kind: KeyEnd},
+		{Kind: KeyRune, Rune: 'x'},
+		{Kind: KeyBackspace},
+		{Kind: KeyRune, Rune: 'e'},
+		{Kind: KeyRune, Rune: 'e'},
+		{Kind: KeyRune, Rune: 'r'},
+	} {
+		e.HandleKey(k)
+	}
+
+	if e.Text() != "guy beer" {
+		test.Errorf("\nExpected text to be:\nguy beer\nbut was\n%s", e.Text())
+	}
+
+	if e.HandleKey(Key{Kind: KeyEnter}) {
+		test.Error("\nExpected enter not to be handled\nbut it was")
+	}
+}
+
+func TestReadKey(test *testing.T) {
+	in := bufio.NewReader(strings.NewReader("a\r\x7f\x1b[A\x1b[3~\x1b[1;5C\x03"))
+	expected := []Key{
+		{Kind: KeyRune, Rune: 'a'},
+		{Kind: KeyEnter},
+		{Kind: KeyBackspace},
+		{Kind: KeyUp},
+		{Kind: KeyDelete},
+		{Kind: KeyUnknown},
+		{Kind: KeyCtrlC},
+	}
+
+	for _, e := range expected {
+		k, err := ReadKey(in)
+		if err != nil {
+			test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+		}
+
+		if k != e {
+			test.Errorf("\nExpected key to be:\n%+v\nbut was\n%+v", e, k)
+		}
+	}
+}
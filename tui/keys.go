@@ -0,0 +1,152 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tui
+
+import (
+	"bufio"
+	"unicode"
+)
+
+// The kinds of keys that are told apart. A `KeyRune` carries the character
+// typed in `Key.Rune`.
+const (
+	KeyRune int = iota
+	KeyEnter
+	KeyEscape
+	KeyBackspace
+	KeyDelete
+	KeyTab
+	KeyUp
+	KeyDown
+	KeyLeft
+	KeyRight
+	KeyHome
+	KeyEnd
+	KeyCtrlA
+	KeyCtrlC
+	KeyCtrlD
+	KeyCtrlE
+	KeyCtrlK
+	KeyCtrlU
+	KeyCtrlW
+	KeyUnknown
+)
+
+// A key pressed in the terminal.
+type Key struct {
+	Kind int
+	Rune rune
+}
+
+// The keys sent as single control characters.
+var controlKeys map[rune]int = map[rune]int{
+	1:   KeyCtrlA,
+	3:   KeyCtrlC,
+	4:   KeyCtrlD,
+	5:   KeyCtrlE,
+	8:   KeyBackspace,
+	9:   KeyTab,
+	10:  KeyEnter,
+	11:  KeyCtrlK,
+	13:  KeyEnter,
+	21:  KeyCtrlU,
+	23:  KeyCtrlW,
+	127: KeyBackspace,
+}
+
+// The keys sent as (the final character of) escape sequences, e.g. `ESC [ A`
+// for the up arrow.
+var sequenceKeys map[rune]int = map[rune]int{
+	'A': KeyUp,
+	'B': KeyDown,
+	'C': KeyRight,
+	'D': KeyLeft,
+	'H': KeyHome,
+	'F': KeyEnd,
+}
+
+// The keys sent as `ESC [ N ~` sequences.
+var tildeKeys map[rune]int = map[rune]int{
+	'1': KeyHome,
+	'3': KeyDelete,
+	'4': KeyEnd,
+	'7': KeyHome,
+	'8': KeyEnd,
+}
+
+// Reads a single key from a terminal in raw mode. An escape character with
+// nothing following it (in the same read) is the escape key itself.
+func ReadKey(in *bufio.Reader) (Key, error) {
+	r, _, err := in.ReadRune()
+	if err != nil {
+		return Key{}, err
+	}
+
+	if r == 27 {
+		if in.Buffered() == 0 {
+			return Key{Kind: KeyEscape}, nil
+		}
+
+		return readSequence(in)
+	}
+
+	if kind, ok := controlKeys[r]; ok {
+		return Key{Kind: kind}, nil
+	}
+
+	if !unicode.IsPrint(r) {
+		return Key{Kind: KeyUnknown}, nil
+	}
+
+	return Key{Kind: KeyRune, Rune: r}, nil
+}
+
+// Reads the rest of an escape sequence (after the escape character).
+func readSequence(in *bufio.Reader) (Key, error) {
+	prefix, _, err := in.ReadRune()
+	if err != nil {
+		return Key{}, err
+	}
+
+	if prefix != '[' && prefix != 'O' {
+		return Key{Kind: KeyUnknown}, nil
+	}
+
+	r, _, err := in.ReadRune()
+	if err != nil {
+		return Key{}, err
+	}
+
+	if kind, ok := sequenceKeys[r]; ok {
+		return Key{Kind: kind}, nil
+	}
+
+	kind, ok := tildeKeys[r]
+
+	// Skip the rest of the sequence (its parameters and the final character).
+	for unicode.IsDigit(r) || r == ';' {
+		if r, _, err = in.ReadRune(); err != nil {
+			return Key{}, err
+		}
+	}
+
+	if ok && r == '~' {
+		return Key{Kind: kind}, nil
+	}
+
+	return Key{Kind: KeyUnknown}, nil
+}
@@ -0,0 +1,735 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The `tui` package is a keyboard driven, full screen terminal UI for
+// triaging the lists and tasks of the account. It works through a Backend,
+// so the app provides its own (cached, logged in) way of reaching the API.
+// Its line editor and key reading are shared with the other interactive
+// parts of the app.
+package tui
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"github.com/betasve/mstd/ext/term"
+	api "github.com/betasve/mstd/todoapi"
+	"io"
+	"os"
+	"strings"
+)
+
+// The operations the UI performs on the lists and the tasks.
+type Backend interface {
+	Lists() ([]api.ListsItem, error)
+	CreateList(name string) (*api.ListsItem, error)
+	RenameList(listId, name string) (*api.ListsItem, error)
+	Tasks(listId string) ([]api.TaskItem, error)
+	CreateTask(listId, title string) (*api.TaskItem, error)
+	UpdateTask(listId, taskId string, changes *api.TaskItem) (*api.TaskItem, error)
+	DeleteTask(listId, taskId string) error
+	MoveTask(fromListId, taskId, toListId string) (*api.TaskItem, error)
+}
+
+// The panes of the screen that can have the focus.
+const (
+	paneLists int = iota
+	paneTasks
+)
+
+// The width of the lists pane (including its border).
+const listsPaneWidth int = 28
+
+// The terminal escape sequences used to draw the screen.
+const (
+	altScreenOn  string = "\033[?1049h"
+	altScreenOff string = "\033[?1049l"
+	hideCursor   string = "\033[?25l"
+	showCursor   string = "\033[?25h"
+	clearScreen  string = "\033[H\033[2J"
+	reverse      string = "\033[7m"
+	bold         string = "\033[1m"
+	reset        string = "\033[0m"
+)
+
+// The keys of the UI, shown at the bottom of the screen.
+const shortHelp string = "a add  r rename  x complete  s star  m move  d delete  n notes  ? help  q quit"
+
+// The detailed help, shown instead of the tasks when toggled with `?`.
+var longHelp []string = []string{
+	"Navigation",
+	"  j / k, up / down     select the next / previous item",
+	"  tab, h / l           switch between the lists and the tasks",
+	"  enter                open the tasks of the selected list",
+	"  g                    reload the lists and the tasks",
+	"",
+	"Lists pane",
+	"  a                    add a list",
+	"  r                    rename the selected list",
+	"",
+	"Tasks pane",
+	"  a                    add a task",
+	"  r                    rename the selected task",
+	"  x, space             mark the selected task completed (or not)",
+	"  s                    star (mark important) the selected task, or unstar it",
+	"  m                    move the selected task to another list",
+	"  d                    delete the selected task",
+	"  n                    edit the notes of the selected task",
+	"",
+	"In a prompt, enter submits and esc cancels.",
+	"q, ctrl+c              quit",
+}
+
+// A question asked in the status line. `submit` is called with the answer.
+type prompt struct {
+	label  string
+	editor *LineEditor
+	submit func(answer string) error
+}
+
+// Holds the state of the UI.
+type UI struct {
+	backend Backend
+	lists   []api.ListsItem
+	tasks   []api.TaskItem
+	list    int
+	task    int
+	focus   int
+	prompt  *prompt
+	status  string
+	help    bool
+	quit    bool
+	width   int
+	height  int
+}
+
+// Creates a UI working with `backend`. It's empty until loaded.
+func New(backend Backend) *UI {
+	return &UI{backend: backend, width: 80, height: 24}
+}
+
+// Runs the UI in the terminal until the user quits it. The terminal is put
+// in raw mode (and the alternate screen) for the duration of the UI.
+func Run(backend Backend) error {
+	fd := int(os.Stdin.Fd())
+	if !term.Client.IsTerminal(fd) {
+		return errors.New("The UI needs an interactive terminal")
+	}
+
+	state, err := term.Client.MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = term.Client.Restore(fd, state) }()
+
+	fmt.Fprint(os.Stdout, altScreenOn+hideCursor)
+	defer fmt.Fprint(os.Stdout, showCursor+altScreenOff)
+
+	ui := New(backend)
+	if err := ui.Load(); err != nil {
+		ui.fail(err)
+	}
+
+	in := bufio.NewReader(os.Stdin)
+
+	for !ui.quit {
+		if width, height, err := term.Client.GetSize(fd); err == nil {
+			ui.SetSize(width, height)
+		}
+
+		fmt.Fprint(os.Stdout, ui.Render())
+
+		key, err := ReadKey(in)
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		ui.HandleKey(key)
+	}
+
+	return nil
+}
+
+// Loads the lists and the tasks of the selected one.
+func (u *UI) Load() error {
+	lists, err := u.backend.Lists()
+	if err != nil {
+		return err
+	}
+
+	u.lists = lists
+	u.list = clamp(u.list, len(u.lists))
+
+	return u.loadTasks()
+}
+
+// Sets the size of the screen (in characters).
+func (u *UI) SetSize(width, height int) {
+	u.width = width
+	u.height = height
+}
+
+// Checks if the user asked to quit.
+func (u *UI) Done() bool {
+	return u.quit
+}
+
+// Returns the message shown in the status line.
+func (u *UI) Status() string {
+	return u.status
+}
+
+// Acts on a key pressed by the user.
+func (u *UI) HandleKey(k Key) {
+	if u.prompt != nil {
+		u.handlePromptKey(k)
+		return
+	}
+
+	u.status = ""
+
+	switch {
+	case k.Kind == KeyCtrlC || isRune(k, 'q'):
+		u.quit = true
+	case k.Kind == KeyTab:
+		u.focus = 1 - u.focus
+	case k.Kind == KeyLeft || isRune(k, 'h'):
+		u.focus = paneLists
+	case k.Kind == KeyRight || isRune(k, 'l') || (k.Kind == KeyEnter && u.focus == paneLists):
+		u.focus = paneTasks
+	case k.Kind == KeyUp || isRune(k, 'k'):
+		u.moveSelection(-1)
+	case k.Kind == KeyDown || isRune(k, 'j'):
+		u.moveSelection(1)
+	case isRune(k, '?'):
+		u.help = !u.help
+	case isRune(k, 'g'):
+		if err := u.Load(); err != nil {
+			u.fail(err)
+		}
+	case isRune(k, 'a'):
+		u.add()
+	case isRune(k, 'r'):
+		u.rename()
+	case isRune(k, 'x') || isRune(k, ' '):
+		u.toggleCompleted()
+	case isRune(k, 's'):
+		u.toggleStar()
+	case isRune(k, 'm'):
+		u.moveTask()
+	case isRune(k, 'd'):
+		u.deleteTask()
+	case isRune(k, 'n'):
+		u.editNotes()
+	}
+}
+
+// Passes a key to the prompt: enter submits it, escape cancels it and the
+// rest edit the answer.
+func (u *UI) handlePromptKey(k Key) {
+	switch k.Kind {
+	case KeyEnter:
+		p := u.prompt
+		u.prompt = nil
+
+		if err := p.submit(strings.TrimSpace(p.editor.Text())); err != nil {
+			u.fail(err)
+		}
+	case KeyEscape, KeyCtrlC:
+		u.prompt = nil
+		u.status = "Cancelled"
+	default:
+		p := u.prompt
+		p.editor.HandleKey(k)
+	}
+}
+
+// Asks the user a question in the status line, pre-filling the answer with
+// `answer`.
+func (u *UI) ask(label, answer string, submit func(answer string) error) {
+	u.prompt = &prompt{label: label, editor: NewLineEditor(answer), submit: submit}
+}
+
+// Moves the selection in the focused pane, loading the tasks of the newly
+// selected list.
+func (u *UI) moveSelection(by int) {
+	if u.focus == paneTasks {
+		u.task = clamp(u.task+by, len(u.tasks))
+		return
+	}
+
+	selected := clamp(u.list+by, len(u.lists))
+	if selected == u.list {
+		return
+	}
+
+	u.list = selected
+	u.task = 0
+
+	if err := u.loadTasks(); err != nil {
+		u.fail(err)
+	}
+}
+
+// Loads the tasks of the selected list.
+func (u *UI) loadTasks() error {
+	list, ok := u.selectedList()
+	if !ok {
+		u.tasks = nil
+		return nil
+	}
+
+	tasks, err := u.backend.Tasks(list.Id)
+	if err != nil {
+		u.tasks = nil
+		return err
+	}
+
+	u.tasks = tasks
+	u.task = clamp(u.task, len(u.tasks))
+
+	return nil
+}
+
+// Adds a list or a task, depending on the focused pane.
+func (u *UI) add() {
+	if u.focus == paneLists {
+		u.ask("New list: ", "", func(name string) error {
+			if name == "" {
+				return nil
+			}
+
+			list, err := u.backend.CreateList(name)
+			if err != nil {
+				return err
+			}
+
+			u.lists = append(u.lists, *list)
+			u.list = len(u.lists) - 1
+			u.status = fmt.Sprintf("Created list %q", list.Name)
+
+			return u.loadTasks()
+		})
+
+		return
+	}
+
+	list, ok := u.selectedList()
+	if !ok {
+		return
+	}
+
+	u.ask("New task: ", "", func(title string) error {
+		if title == "" {
+			return nil
+		}
+
+		task, err := u.backend.CreateTask(list.Id, title)
+		if err != nil {
+			return err
+		}
+
+		u.tasks = append(u.tasks, *task)
+		u.task = len(u.tasks) - 1
+		u.status = fmt.Sprintf("Created task %q", task.Title)
+
+		return nil
+	})
+}
+
+// Renames the selected list or task, depending on the focused pane.
+func (u *UI) rename() {
+	list, ok := u.selectedList()
+	if !ok {
+		return
+	}
+
+	if u.focus == paneLists {
+		u.ask("Rename list: ", list.Name, func(name string) error {
+			if name == "" || name == list.Name {
+				return nil
+			}
+
+			renamed, err := u.backend.RenameList(list.Id, name)
+			if err != nil {
+				return err
+			}
+
+			u.lists[u.list] = *renamed
+			u.status = fmt.Sprintf("Renamed list to %q", renamed.Name)
+
+			return nil
+		})
+
+		return
+	}
+
+	task, ok := u.selectedTask()
+	if !ok {
+		return
+	}
+
+	u.ask("Rename task: ", task.Title, func(title string) error {
+		if title == "" || title == task.Title {
+			return nil
+		}
+
+		return u.updateTask(&api.TaskItem{Title: title}, "Renamed task")
+	})
+}
+
+// Marks the selected task completed, or not started when it already is.
+func (u *UI) toggleCompleted() {
+	task, ok := u.selectedTask()
+	if !ok || u.focus != paneTasks {
+		return
+	}
+
+	changes := &api.TaskItem{Status: "completed"}
+	if task.Status == "completed" {
+		changes.Status = "notStarted"
+	}
+
+	if err := u.updateTask(changes, "Marked task "+changes.Status); err != nil {
+		u.fail(err)
+	}
+}
+
+// Stars (sets a high importance to) the selected task, or unstars it when
+// it already is.
+func (u *UI) toggleStar() {
+	task, ok := u.selectedTask()
+	if !ok || u.focus != paneTasks {
+		return
+	}
+
+	changes := &api.TaskItem{Importance: "high"}
+	message := "Starred task"
+	if task.Importance == "high" {
+		changes.Importance = "normal"
+		message = "Unstarred task"
+	}
+
+	if err := u.updateTask(changes, message); err != nil {
+		u.fail(err)
+	}
+}
+
+// Moves the selected task to another list, asked for by its name.
+func (u *UI) moveTask() {
+	task, ok := u.selectedTask()
+	if !ok || u.focus != paneTasks {
+		return
+	}
+
+	from := u.lists[u.list]
+
+	u.ask(fmt.Sprintf("Move %q to list: ", task.Title), "", func(name string) error {
+		if name == "" {
+			return nil
+		}
+
+		to, ok := u.findList(name)
+		if !ok {
+			return fmt.Errorf("List %q not found", name)
+		}
+
+		if to.Id == from.Id {
+			return nil
+		}
+
+		if _, err := u.backend.MoveTask(from.Id, task.Id, to.Id); err != nil {
+			return err
+		}
+
+		u.removeSelectedTask()
+		u.status = fmt.Sprintf("Moved task to %q", to.Name)
+
+		return nil
+	})
+}
+
+// Deletes the selected task, once the user confirms it.
+func (u *UI) deleteTask() {
+	task, ok := u.selectedTask()
+	if !ok || u.focus != paneTasks {
+		return
+	}
+
+	list := u.lists[u.list]
+
+	u.ask(fmt.Sprintf("Delete %q? (y/N) ", task.Title), "", func(answer string) error {
+		if !strings.EqualFold(answer, "y") && !strings.EqualFold(answer, "yes") {
+			u.status = "Not deleted"
+			return nil
+		}
+
+		if err := u.backend.DeleteTask(list.Id, task.Id); err != nil {
+			return err
+		}
+
+		u.removeSelectedTask()
+		u.status = fmt.Sprintf("Deleted task %q", task.Title)
+
+		return nil
+	})
+}
+
+// Edits the notes (body) of the selected task.
+func (u *UI) editNotes() {
+	task, ok := u.selectedTask()
+	if !ok || u.focus != paneTasks {
+		return
+	}
+
+	notes := ""
+	if task.Body != nil {
+		notes = task.Body.Content
+	}
+
+	u.ask("Notes: ", notes, func(content string) error {
+		if content == notes {
+			return nil
+		}
+
+		body := &api.ItemBody{Content: content, ContentType: "text"}
+
+		return u.updateTask(&api.TaskItem{Body: body}, "Updated the notes")
+	})
+}
+
+// Sends the changes of the selected task to the backend and keeps the
+// updated task.
+func (u *UI) updateTask(changes *api.TaskItem, message string) error {
+	list, _ := u.selectedList()
+	task, _ := u.selectedTask()
+
+	updated, err := u.backend.UpdateTask(list.Id, task.Id, changes)
+	if err != nil {
+		return err
+	}
+
+	u.tasks[u.task] = *updated
+	u.status = message
+
+	return nil
+}
+
+// Removes the selected task from the screen.
+func (u *UI) removeSelectedTask() {
+	u.tasks = append(u.tasks[:u.task], u.tasks[u.task+1:]...)
+	u.task = clamp(u.task, len(u.tasks))
+}
+
+// Returns the selected list, if there's one.
+func (u *UI) selectedList() (api.ListsItem, bool) {
+	if u.list >= len(u.lists) {
+		return api.ListsItem{}, false
+	}
+
+	return u.lists[u.list], true
+}
+
+// Returns the selected task, if there's one.
+func (u *UI) selectedTask() (api.TaskItem, bool) {
+	if u.task >= len(u.tasks) {
+		return api.TaskItem{}, false
+	}
+
+	return u.tasks[u.task], true
+}
+
+// Finds a list by its id or its (case insensitive) name.
+func (u *UI) findList(name string) (api.ListsItem, bool) {
+	for _, l := range u.lists {
+		if l.Id == name || strings.EqualFold(l.Name, name) {
+			return l, true
+		}
+	}
+
+	return api.ListsItem{}, false
+}
+
+// Shows an error in the status line.
+func (u *UI) fail(err error) {
+	u.status = "Error: " + strings.Join(strings.Fields(err.Error()), " ")
+}
+
+// Draws the whole screen: a title line, the panes, the status (or prompt)
+// line and the keys.
+func (u *UI) Render() string {
+	rows := u.height - 3
+	if rows < 1 {
+		rows = 1
+	}
+
+	tasksWidth := u.width - listsPaneWidth
+	if tasksWidth < 0 {
+		tasksWidth = 0
+	}
+
+	screen := &strings.Builder{}
+	screen.WriteString(clearScreen)
+
+	title := " mstd"
+	if list, ok := u.selectedList(); ok {
+		title += " - " + list.Name
+	}
+	screen.WriteString(reverse + pad(title, u.width) + reset + "\r\n")
+
+	var right []string
+	if u.help {
+		right = longHelp
+	} else {
+		right = u.taskLines()
+	}
+
+	left := u.listLines()
+	listsOffset := scrollOffset(u.list, rows)
+	tasksOffset := scrollOffset(u.task, rows)
+	if u.help {
+		tasksOffset = 0
+	}
+
+	for i := 0; i < rows; i++ {
+		screen.WriteString(u.cell(left, listsOffset+i, u.list, paneLists, listsPaneWidth-1))
+		screen.WriteString("│")
+		screen.WriteString(u.cell(right, tasksOffset+i, u.task, paneTasks, tasksWidth))
+		screen.WriteString("\r\n")
+	}
+
+	if u.prompt != nil {
+		screen.WriteString(pad(u.prompt.label+u.prompt.editor.Text(), u.width))
+	} else {
+		screen.WriteString(pad(u.status, u.width))
+	}
+
+	screen.WriteString("\r\n" + bold + pad(shortHelp, u.width) + reset)
+
+	return screen.String()
+}
+
+// Returns the line of a pane at `index`, padded to `width` and highlighted
+// when it's the selected one.
+func (u *UI) cell(lines []string, index, selected, pane, width int) string {
+	if index >= len(lines) {
+		return pad("", width)
+	}
+
+	text := pad(lines[index], width)
+
+	if index != selected || (pane == paneTasks && u.help) {
+		return text
+	}
+
+	if u.focus == pane {
+		return reverse + text + reset
+	}
+
+	return bold + text + reset
+}
+
+// Returns the lines of the lists pane.
+func (u *UI) listLines() []string {
+	lines := []string{}
+	for _, l := range u.lists {
+		lines = append(lines, " "+l.Name)
+	}
+
+	return lines
+}
+
+// Returns the lines of the tasks pane, e.g. `[x] * Title (due 2021-05-03)`.
+func (u *UI) taskLines() []string {
+	lines := []string{}
+	for _, t := range u.tasks {
+		lines = append(lines, TaskLine(t))
+	}
+
+	if len(lines) == 0 && len(u.lists) > 0 {
+		lines = append(lines, " No tasks, press `a` to add one")
+	}
+
+	return lines
+}
+
+// Formats a task as a single line, marking if it's completed and starred
+// and showing its due date.
+func TaskLine(t api.TaskItem) string {
+	line := " [ ] "
+	if t.Status == "completed" {
+		line = " [x] "
+	}
+
+	if t.Importance == "high" {
+		line += "* "
+	} else {
+		line += "  "
+	}
+
+	line += t.Title
+
+	if t.DueDateTime != nil {
+		if due, err := t.DueDateTime.Time(); err == nil {
+			line += " (due " + due.Format("2006-01-02") + ")"
+		}
+	}
+
+	return line
+}
+
+// Checks if a key is a specific character.
+func isRune(k Key, r rune) bool {
+	return k.Kind == KeyRune && k.Rune == r
+}
+
+// Keeps an index within a collection of `length` items.
+func clamp(index, length int) int {
+	if index >= length {
+		index = length - 1
+	}
+
+	if index < 0 {
+		return 0
+	}
+
+	return index
+}
+
+// Returns the first line to show so the `selected` one is on screen.
+func scrollOffset(selected, rows int) int {
+	if selected < rows {
+		return 0
+	}
+
+	return selected - rows + 1
+}
+
+// Cuts or pads a text with spaces to exactly `width` characters.
+func pad(text string, width int) string {
+	runes := []rune(text)
+
+	if len(runes) > width {
+		return string(runes[:width])
+	}
+
+	return text + strings.Repeat(" ", width-len(runes))
+}
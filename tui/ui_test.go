@@ -0,0 +1,197 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package tui
+
+import (
+	"errors"
+	api "github.com/betasve/mstd/todoapi"
+	"strings"
+	"testing"
+)
+
+type fakeBackend struct {
+	lists []api.ListsItem
+	tasks map[string][]api.TaskItem
+}
+
+func newFakeBackend() *fakeBackend {
+	return &fakeBackend{
+		lists: []api.ListsItem{{Id: "l1", Name: "Inbox"}, {Id: "l2", Name: "Work"}},
+		tasks: map[string][]api.TaskItem{
+			"l1": {{Id: "t1", Title: "milk", Status: "notStarted", Importance: "normal"}},
+			"l2": {},
+		},
+	}
+}
+
+func (f *fakeBackend) Lists() ([]api.ListsItem, error) {
+	return f.lists, nil
+}
+
+func (f *fakeBackend) CreateList(name string) (*api.ListsItem, error) {
+	list := api.ListsItem{Id: "new-list", Name: name}
+	f.lists = append(f.lists, list)
+	return &list, nil
+}
+
+func (f *fakeBackend) RenameList(listId, name string) (*api.ListsItem, error) {
+	return &api.ListsItem{Id: listId, Name: name}, nil
+}
+
+func (f *fakeBackend) Tasks(listId string) ([]api.TaskItem, error) {
+	return append([]api.TaskItem{}, f.tasks[listId]...), nil
+}
+
+func (f *fakeBackend) CreateTask(listId, title string) (*api.TaskItem, error) {
+	task := api.TaskItem{Id: "new-task", Title: title, Status: "notStarted"}
+	f.tasks[listId] = append(f.tasks[listId], task)
+	return &task, nil
+}
+
+func (f *fakeBackend) UpdateTask(listId, taskId string, changes *api.TaskItem) (*api.TaskItem, error) {
+	for i, t := range f.tasks[listId] {
+		if t.Id != taskId {
+			continue
+		}
+
+		if changes.Title != "" {
+			t.Title = changes.Title
+		}
+		if changes.Status != "" {
+			t.Status = changes.Status
+		}
+		if changes.Importance != "" {
+			t.Importance = changes.Importance
+		}
+		if changes.Body != nil {
+			t.Body = changes.Body
+		}
+
+		f.tasks[listId][i] = t
+		return &t, nil
+	}
+
+	return nil, errors.New("Unsuccessful request to To Do API:\n404\nnot found")
+}
+
+func (f *fakeBackend) DeleteTask(listId, taskId string) error {
+	f.tasks[listId] = []api.TaskItem{}
+	return nil
+}
+
+func (f *fakeBackend) MoveTask(fromListId, taskId, toListId string) (*api.TaskItem, error) {
+	task := f.tasks[fromListId][0]
+	f.tasks[fromListId] = []api.TaskItem{}
+	f.tasks[toListId] = append(f.tasks[toListId], task)
+	return &task, nil
+}
+
+func typeKeys(ui *UI, keys ...interface{}) {
+	for _, k := range keys {
+		switch k := k.(type) {
+		case string:
+			for _, r := range k {
+				ui.HandleKey(Key{Kind: KeyRune, Rune: r})
+			}
+		case int:
+			ui.HandleKey(Key{Kind: k})
+		}
+	}
+}
+
+func newLoadedUI(test *testing.T) (*UI, *fakeBackend) {
+	backend := newFakeBackend()
+	ui := New(backend)
+
+	if err := ui.Load(); err != nil {
+		test.Fatalf("\nExpected error to be:\nnil\nbut was\n%s", err)
+	}
+
+	return ui, backend
+}
+
+func TestUITaskOperations(test *testing.T) {
+	ui, backend := newLoadedUI(test)
+
+	typeKeys(ui, KeyTab, "x", "s")
+	task := backend.tasks["l1"][0]
+
+	if task.Status != "completed" || task.Importance != "high" {
+		test.Errorf("\nExpected the task to be completed and starred\nbut was\n%+v", task)
+	}
+
+	typeKeys(ui, "n", "buy oat milk", KeyEnter)
+	if task = backend.tasks["l1"][0]; task.Body == nil || task.Body.Content != "buy oat milk" {
+		test.Errorf("\nExpected the notes to be:\nbuy oat milk\nbut were\n%+v", task.Body)
+	}
+
+	typeKeys(ui, "r", KeyCtrlU, "oat milk", KeyEnter)
+	if title := backend.tasks["l1"][0].Title; title != "oat milk" {
+		test.Errorf("\nExpected the title to be:\noat milk\nbut was\n%s", title)
+	}
+
+	typeKeys(ui, "a", "bread", KeyEnter)
+	if len(ui.tasks) != 2 || ui.tasks[1].Title != "bread" {
+		test.Errorf("\nExpected a new task to be added\nbut got\n%+v", ui.tasks)
+	}
+
+	if !strings.Contains(ui.Render(), "[ ]   bread") {
+		test.Errorf("\nExpected the screen to show the new task\nbut it was\n%s", ui.Render())
+	}
+}
+
+func TestUIMoveAndDelete(test *testing.T) {
+	ui, backend := newLoadedUI(test)
+
+	typeKeys(ui, KeyTab, "m", "nowhere", KeyEnter)
+	if !strings.Contains(ui.Status(), `List "nowhere" not found`) {
+		test.Errorf("\nExpected an error for an unknown list\nbut got\n%s", ui.Status())
+	}
+
+	typeKeys(ui, "m", "work", KeyEnter)
+	if len(backend.tasks["l2"]) != 1 || len(ui.tasks) != 0 {
+		test.Errorf("\nExpected the task to be moved\nbut got\n%+v", backend.tasks)
+	}
+
+	typeKeys(ui, "h", "j", KeyEnter)
+	if ui.list != 1 || len(ui.tasks) != 1 {
+		test.Fatalf("\nExpected the tasks of the second list\nbut got\n%+v", ui.tasks)
+	}
+
+	typeKeys(ui, "d", "n", KeyEnter)
+	if len(backend.tasks["l2"]) != 1 {
+		test.Error("\nExpected the task not to be deleted without confirmation\nbut it was")
+	}
+
+	typeKeys(ui, "d", "y", KeyEnter)
+	if len(backend.tasks["l2"]) != 0 || len(ui.tasks) != 0 {
+		test.Errorf("\nExpected the task to be deleted\nbut got\n%+v", backend.tasks["l2"])
+	}
+}
+
+func TestUIPromptCancelAndQuit(test *testing.T) {
+	ui, backend := newLoadedUI(test)
+
+	typeKeys(ui, "a", "Errands", KeyEscape)
+	if len(backend.lists) != 2 || ui.Status() != "Cancelled" {
+		test.Errorf("\nExpected the prompt to be cancelled\nbut got\n%s", ui.Status())
+	}
+
+	typeKeys(ui, "q")
+	if !ui.Done() {
+		test.Error("\nExpected the UI to be done\nbut it was not")
+	}
+}